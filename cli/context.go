@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alpkeskin/gotoon"
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/search"
+	"github.com/yoanbernabeu/grepai/stats"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+var (
+	contextMaxTokens int
+	contextLimit     int
+	contextPath      string
+	contextJSON      bool
+	contextTOON      bool
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context <query>",
+	Short: "Build a token-budgeted context bundle for a query",
+	Long: `Build a context bundle for a query that fits a token budget.
+
+Semantic search hits are combined with their enclosing symbols, the symbols'
+top callers and callees from the trace index and their RPG feature paths.
+Hits are ranked by score, hits within a symbol already in the bundle are
+dropped, and hits that don't fit the budget keep only their location.
+
+Examples:
+  grepai context "how sessions are refreshed"
+  grepai context "error handling" --max-tokens 2000 --path internal/
+  grepai context "database access" --toon`,
+	Args: cobra.ExactArgs(1),
+	RunE: runContext,
+}
+
+func init() {
+	contextCmd.Flags().IntVar(&contextMaxTokens, "max-tokens", search.DefaultContextTokens, "Approximate token budget of the bundle")
+	contextCmd.Flags().IntVarP(&contextLimit, "limit", "n", 20, "Number of search hits considered")
+	contextCmd.Flags().StringVar(&contextPath, "path", "", "Path prefix to filter search hits")
+	contextCmd.Flags().BoolVarP(&contextJSON, "json", "j", false, "Output the bundle in JSON format (for AI agents)")
+	contextCmd.Flags().BoolVarP(&contextTOON, "toon", "t", false, "Output the bundle in TOON format (token-efficient for AI agents)")
+	contextCmd.MarkFlagsMutuallyExclusive("json", "toon")
+
+	rootCmd.AddCommand(contextCmd)
+}
+
+func runContext(cmd *cobra.Command, args []string) error {
+	query := args[0]
+	ctx := context.Background()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	searcher, closeSearcher, err := openQuerySearcher(ctx, cfg, projectRoot, "")
+	if err != nil {
+		return err
+	}
+	defer closeSearcher()
+
+	normalizedPath, err := search.NormalizeProjectPathPrefix(contextPath, projectRoot)
+	if err != nil {
+		return fmt.Errorf("invalid --path value: %w", err)
+	}
+	opts := search.ContextOptions{MaxTokens: contextMaxTokens, Limit: contextLimit, PathPrefix: normalizedPath}
+
+	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
+	if err := symbolStore.Load(ctx); err != nil {
+		log.Printf("Warning: symbol index unavailable, callers and callees will not be listed: %v", err)
+	} else {
+		defer symbolStore.Close()
+		opts.Symbols = symbolStore
+	}
+	if cfg.RPG.Enabled {
+		rpgStore := rpg.NewGOBRPGStore(config.GetRPGIndexPath(projectRoot))
+		if err := rpgStore.Load(ctx); err == nil {
+			defer rpgStore.Close()
+			opts.FeaturePath = rpg.NewQueryEngine(rpgStore.GetGraph()).FeaturePathAt
+		}
+	}
+
+	bundle, err := search.BuildContext(ctx, searcher, query, opts)
+	if err != nil {
+		if contextJSON {
+			return outputSearchErrorJSON(err)
+		}
+		if contextTOON {
+			return outputSearchErrorTOON(err)
+		}
+		return err
+	}
+
+	var outputStr string
+	switch {
+	case contextJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(bundle)
+		outputStr = buf.String()
+	case contextTOON:
+		outputStr, err = gotoon.Encode(bundle)
+		outputStr += "\n"
+	default:
+		outputStr = formatContextText(bundle)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode context: %w", err)
+	}
+	fmt.Print(outputStr)
+	recordSearchStats(projectRoot, stats.Context, outputModeFromFlags(contextJSON, contextTOON, false), len(bundle.Items), outputStr)
+	return nil
+}
+
+// formatContextText renders a context bundle for terminals.
+func formatContextText(bundle *search.ContextBundle) string {
+	var buf strings.Builder
+	if len(bundle.Items) == 0 {
+		buf.WriteString("No results found.\n")
+		return buf.String()
+	}
+
+	fmt.Fprintf(&buf, "Context for: %q (%d items, ~%d/%d tokens", bundle.Query, len(bundle.Items), bundle.Tokens, bundle.MaxTokens)
+	if bundle.Omitted > 0 {
+		fmt.Fprintf(&buf, ", %d omitted", bundle.Omitted)
+	}
+	buf.WriteString(")\n\n")
+
+	for i, item := range bundle.Items {
+		fmt.Fprintf(&buf, "─── Item %d (score: %.4f) ───\n", i+1, item.Score)
+		fmt.Fprintf(&buf, "File: %s:%d-%d\n", item.FilePath, item.StartLine, item.EndLine)
+		if item.FeaturePath != "" {
+			fmt.Fprintf(&buf, "Feature: %s\n", item.FeaturePath)
+		}
+		if item.Symbol != "" {
+			fmt.Fprintf(&buf, "Symbol: %s\n", item.Symbol)
+		}
+		for _, caller := range item.Callers {
+			fmt.Fprintf(&buf, "  ← %s at %s:%d\n", caller.Name, caller.File, caller.Line)
+		}
+		for _, callee := range item.Callees {
+			fmt.Fprintf(&buf, "  → %s at %s:%d\n", callee.Name, callee.File, callee.Line)
+		}
+		buf.WriteString("\n")
+
+		if item.Content != "" {
+			lineNum := item.StartLine
+			for _, line := range strings.Split(item.Content, "\n") {
+				fmt.Fprintf(&buf, "%4d │ %s\n", lineNum, line)
+				lineNum++
+			}
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alpkeskin/gotoon"
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/search"
+	"github.com/yoanbernabeu/grepai/stats"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+var (
+	diffSearchBase    string
+	diffSearchHead    string
+	diffSearchLimit   int
+	diffSearchJSON    bool
+	diffSearchTOON    bool
+	diffSearchCompact bool
+)
+
+var diffSearchCmd = &cobra.Command{
+	Use:   "diff-search <query>",
+	Short: "Search the changes between two git refs",
+	Long: `Search only the hunks changed between two git refs by meaning.
+
+The changed hunks of 'git diff base...head' are embedded on the fly and scored
+against the query; the index is not modified. When a symbol index exists,
+changed symbols overlapping the matching hunks are listed with their callers.
+
+Examples:
+  grepai diff-search "error handling"
+  grepai diff-search "authentication changes" --base main --head feature/login
+  grepai diff-search "database access" --json --compact`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiffSearch,
+}
+
+func init() {
+	diffSearchCmd.Flags().StringVar(&diffSearchBase, "base", "main", "Base ref of the comparison")
+	diffSearchCmd.Flags().StringVar(&diffSearchHead, "head", "HEAD", "Head ref of the comparison")
+	diffSearchCmd.Flags().IntVarP(&diffSearchLimit, "limit", "n", 10, "Maximum number of hunks to return")
+	diffSearchCmd.Flags().BoolVarP(&diffSearchJSON, "json", "j", false, "Output results in JSON format (for AI agents)")
+	diffSearchCmd.Flags().BoolVarP(&diffSearchTOON, "toon", "t", false, "Output results in TOON format (token-efficient for AI agents)")
+	diffSearchCmd.Flags().BoolVarP(&diffSearchCompact, "compact", "c", false, "Output minimal format without content (requires --json or --toon)")
+	diffSearchCmd.MarkFlagsMutuallyExclusive("json", "toon")
+
+	rootCmd.AddCommand(diffSearchCmd)
+}
+
+func runDiffSearch(cmd *cobra.Command, args []string) error {
+	query := args[0]
+	ctx := context.Background()
+
+	if diffSearchCompact && !diffSearchJSON && !diffSearchTOON {
+		return fmt.Errorf("--compact flag requires --json or --toon flag")
+	}
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	emb, err := embedder.NewFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize embedder: %w", err)
+	}
+	defer emb.Close()
+
+	opts, closeSymbols, err := diffSearchOptions(ctx, cfg, projectRoot)
+	if err != nil {
+		return err
+	}
+	defer closeSymbols()
+	opts.Base = diffSearchBase
+	opts.Head = diffSearchHead
+	opts.Limit = diffSearchLimit
+
+	result, err := search.DiffSearch(ctx, emb, projectRoot, query, opts)
+	if err != nil {
+		if diffSearchJSON {
+			return outputSearchErrorJSON(err)
+		}
+		if diffSearchTOON {
+			return outputSearchErrorTOON(err)
+		}
+		return fmt.Errorf("diff search failed: %w", err)
+	}
+
+	var outputStr string
+	switch {
+	case diffSearchJSON:
+		outputStr, err = captureDiffSearchJSON(result, diffSearchCompact)
+	case diffSearchTOON:
+		outputStr, err = captureDiffSearchTOON(result, diffSearchCompact)
+	default:
+		outputStr = formatDiffSearchText(result)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(outputStr)
+	recordSearchStats(projectRoot, stats.DiffSearch, outputModeFromFlags(diffSearchJSON, diffSearchTOON, diffSearchCompact), len(result.Chunks), outputStr)
+	return nil
+}
+
+// diffSearchOptions restricts a diff search to files the project indexes and
+// attaches the working tree's symbol index when one exists. The returned
+// function closes the symbol index.
+func diffSearchOptions(ctx context.Context, cfg *config.Config, projectRoot string) (search.DiffSearchOptions, func(), error) {
+	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, cfg.Ignore, cfg.ExternalGitignore)
+	if err != nil {
+		return search.DiffSearchOptions{}, nil, fmt.Errorf("failed to initialize ignore matcher: %w", err)
+	}
+	scanner := indexer.NewScanner(projectRoot, ignoreMatcher)
+	scanner.SetExtensions(indexer.ExtensionSet(cfg.Indexing.Extensions, cfg.Indexing.ExcludeExtensions))
+
+	opts := search.DiffSearchOptions{Include: scanner.Accepts, Secrets: cfg.Indexing.Secrets}
+	closeSymbols := func() {}
+
+	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
+	if err := symbolStore.Load(ctx); err != nil {
+		log.Printf("Warning: symbol index unavailable, changed symbols will not be listed: %v", err)
+		return opts, closeSymbols, nil
+	}
+	opts.Symbols = symbolStore
+	closeSymbols = func() { symbolStore.Close() }
+	return opts, closeSymbols, nil
+}
+
+// compactDiffResult returns a copy of result without hunk content.
+func compactDiffResult(result *search.DiffSearchResult) *search.DiffSearchResult {
+	compact := *result
+	compact.Chunks = make([]search.DiffChunk, len(result.Chunks))
+	for i, c := range result.Chunks {
+		c.Content = ""
+		compact.Chunks[i] = c
+	}
+	return &compact
+}
+
+// captureDiffSearchJSON returns the JSON-encoded diff search result.
+func captureDiffSearchJSON(result *search.DiffSearchResult, compact bool) (string, error) {
+	if compact {
+		result = compactDiffResult(result)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// captureDiffSearchTOON returns the TOON-encoded diff search result.
+func captureDiffSearchTOON(result *search.DiffSearchResult, compact bool) (string, error) {
+	if compact {
+		result = compactDiffResult(result)
+	}
+	output, err := gotoon.Encode(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode TOON: %w", err)
+	}
+	return output + "\n", nil
+}
+
+// formatDiffSearchText renders a diff search result for terminals.
+func formatDiffSearchText(result *search.DiffSearchResult) string {
+	var buf strings.Builder
+	if len(result.Chunks) == 0 {
+		fmt.Fprintf(&buf, "No changed hunks found between %s and %s.\n", result.Base, result.Head)
+		return buf.String()
+	}
+
+	fmt.Fprintf(&buf, "Found %d hunks for: %q in %s...%s (%d files changed)\n\n",
+		len(result.Chunks), result.Query, result.Base, result.Head, result.FilesChanged)
+
+	for i, chunk := range result.Chunks {
+		fmt.Fprintf(&buf, "─── Hunk %d (score: %.4f) ───\n", i+1, chunk.Score)
+		fmt.Fprintf(&buf, "File: %s:%d-%d (%s)\n", chunk.FilePath, chunk.StartLine, chunk.EndLine, chunk.Status)
+		if len(chunk.Symbols) > 0 {
+			fmt.Fprintf(&buf, "Symbols: %s\n", strings.Join(chunk.Symbols, ", "))
+		}
+		buf.WriteString("\n")
+
+		lines := strings.Split(chunk.Content, "\n")
+		for j := 0; j < len(lines) && j < 20; j++ {
+			fmt.Fprintf(&buf, "     │ %s\n", lines[j])
+		}
+		if len(lines) > 20 {
+			fmt.Fprintf(&buf, "     │ ... (%d more lines)\n", len(lines)-20)
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(result.ChangedSymbols) > 0 {
+		buf.WriteString("Changed symbols:\n")
+		for _, sym := range result.ChangedSymbols {
+			fmt.Fprintf(&buf, "  %s (%s:%d) - %d callers\n", sym.Name, sym.File, sym.Line, len(sym.Callers))
+			for _, caller := range sym.Callers {
+				fmt.Fprintf(&buf, "      ← %s at %s:%d\n", caller.Name, caller.File, caller.Line)
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+	"github.com/yoanbernabeu/grepai/watcher"
+)
+
+// reindexProjectInline reindexes paths of projectRoot ("." for the whole
+// project) the way a watcher does on a reindex request, for MCP servers of
+// projects no background watcher watches. onProgress is called before each
+// file. It returns the number of files reindexed.
+func reindexProjectInline(ctx context.Context, projectRoot string, paths []string, onProgress func(done, total int, path string)) (int, error) {
+	// Watchers without a control socket, such as a foreground 'grepai watch',
+	// write the same index
+	release, err := daemon.LockIndex(config.GetWatchLockPath(projectRoot))
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	emb, err := initializeEmbedder(ctx, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize embedder: %w", err)
+	}
+	defer emb.Close()
+
+	project, err := openProjectIndex(ctx, projectRoot, cfg, emb)
+	if err != nil {
+		return 0, err
+	}
+	defer project.Close()
+	st, scanner, symbolStore := project.store, project.scanner, project.symbolStore
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		targets, err := watchReindexTargets(scanner, projectRoot, path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to reindex %s: %w", path, err)
+		}
+		for _, file := range targets {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	done := 0
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if onProgress != nil {
+			onProgress(done, len(files), file)
+		}
+		invalidateIndexedFile(ctx, st, file)
+		// Without a watcher, files outside paths may have changed unseen:
+		// last_index_time is left as is
+		handleFileEvent(ctx, project.idx, scanner, project.extractor, symbolStore, project.rpgEncoder, st, project.tracedLanguages, projectRoot, cfg, nil, nil, nil,
+			watcher.FileEvent{Type: watcher.EventModify, Path: file}, nil, nil, project.processors)
+		done++
+	}
+
+	// Keep what was reindexed, even when cancelled
+	persistCtx := context.WithoutCancel(ctx)
+	if err := st.Persist(persistCtx); err != nil {
+		return done, fmt.Errorf("failed to persist index: %w", err)
+	}
+	if err := symbolStore.Persist(persistCtx); err != nil {
+		log.Printf("Warning: failed to persist symbol index for %s: %v", projectRoot, err)
+	}
+	if project.rpgStore != nil {
+		if err := project.rpgStore.Persist(persistCtx); err != nil {
+			log.Printf("Warning: failed to persist RPG graph for %s: %v", projectRoot, err)
+		}
+	}
+	saveScanReport(projectRoot, scanner)
+
+	return done, ctx.Err()
+}
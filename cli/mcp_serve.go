@@ -1,22 +1,33 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yoanbernabeu/grepai/config"
 	"github.com/yoanbernabeu/grepai/mcp"
 )
 
+const (
+	mcpTransportStdio  = "stdio"
+	mcpTransportHTTP   = "http"
+	defaultMCPHTTPAddr = "127.0.0.1:7777"
+	mcpAuthTokenEnv    = "GREPAI_MCP_TOKEN"
+)
+
 var mcpServeCmd = &cobra.Command{
 	Use:   "mcp-serve [project-path]",
 	Short: "Start grepai as an MCP server",
 	Long: `Start grepai as an MCP (Model Context Protocol) server.
 
 This allows AI agents to use grepai as a native tool through the MCP protocol.
-The server communicates via stdio and exposes the following tools:
+The server communicates via stdio by default and exposes the following tools:
 
   - grepai_search: Semantic code search with natural language (includes RPG context when enabled)
   - grepai_trace_callers: Find all functions that call a symbol
@@ -26,6 +37,7 @@ The server communicates via stdio and exposes the following tools:
   - grepai_refs_writers: Find property/state writers for a symbol name
   - grepai_refs_graph: Build a property usage graph (readers + writers)
   - grepai_index_status: Check index health and statistics (includes RPG stats when enabled)
+  - grepai_reindex: Reindex files or the whole project, through the background watcher when one runs
   - grepai_rpg_search: Search RPG graph nodes by feature semantics
   - grepai_rpg_fetch: Fetch hierarchy and edge context for a specific RPG node
   - grepai_rpg_explore: Traverse RPG graph neighborhoods with direction/depth filters
@@ -37,11 +49,29 @@ Arguments:
 Flags:
   --workspace   Workspace name. When set, serves using workspace config from
                 ~/.grepai/workspace.yaml without requiring local .grepai/.
+  --transport   "stdio" (default) or "http". HTTP serves many clients from one
+                process with the index kept loaded: streamable HTTP on /mcp
+                and legacy SSE on /sse.
+  --addr        Address to listen on with --transport http (default 127.0.0.1:7777).
+  --auth-token  Bearer token required from HTTP clients (default: $GREPAI_MCP_TOKEN).
+                Required to listen on a non-loopback address.
+  --allowed-origins
+                Browser origins allowed to call the HTTP server besides
+                loopback ones, e.g. https://app.example.com.
+  --tool-timeout
+                Time a tool call may take before it is cancelled (default 2m,
+                0 disables the limit). Clients can also cancel calls.
+  --tool-timeouts
+                Per-tool overrides, e.g. grepai_trace_graph=5m,grepai_search=30s.
 
 Configuration for Claude Code:
   claude mcp add grepai -- grepai mcp-serve
   claude mcp add grepai -- grepai mcp-serve --workspace myworkspace
 
+Shared HTTP server:
+  grepai mcp-serve --transport http --addr 127.0.0.1:7777
+  claude mcp add --transport http grepai http://127.0.0.1:7777/mcp
+
 Configuration for Cursor (.cursor/mcp.json):
   {
     "mcpServers": {
@@ -67,6 +97,12 @@ Configuration for Cursor with explicit path (recommended for Windows):
 
 func init() {
 	mcpServeCmd.Flags().String("workspace", "", "Workspace name for workspace-only mode (no local .grepai/ required)")
+	mcpServeCmd.Flags().String("transport", mcpTransportStdio, "Transport: stdio or http (streamable HTTP and legacy SSE)")
+	mcpServeCmd.Flags().String("addr", defaultMCPHTTPAddr, "Address to listen on with --transport http")
+	mcpServeCmd.Flags().String("auth-token", "", "Bearer token required from HTTP clients (default: $"+mcpAuthTokenEnv+")")
+	mcpServeCmd.Flags().StringSlice("allowed-origins", nil, "Browser origins allowed to call the HTTP server besides loopback ones")
+	mcpServeCmd.Flags().Duration("tool-timeout", mcp.DefaultToolTimeout, "Time a tool call may take before it is cancelled (0 disables the limit)")
+	mcpServeCmd.Flags().StringToString("tool-timeouts", nil, "Per-tool timeouts overriding --tool-timeout, e.g. grepai_trace_graph=5m")
 	rootCmd.AddCommand(mcpServeCmd)
 }
 
@@ -149,6 +185,16 @@ func resolveMCPTarget(explicitPath, workspaceName string) (string, string, error
 
 func runMCPServe(cmd *cobra.Command, args []string) error {
 	workspaceFlag, _ := cmd.Flags().GetString("workspace")
+	transport, _ := cmd.Flags().GetString("transport")
+	if transport != mcpTransportStdio && transport != mcpTransportHTTP {
+		return fmt.Errorf("invalid --transport %q: must be %s or %s", transport, mcpTransportStdio, mcpTransportHTTP)
+	}
+
+	toolTimeout, _ := cmd.Flags().GetDuration("tool-timeout")
+	toolTimeouts, err := parseToolTimeouts(cmd)
+	if err != nil {
+		return err
+	}
 
 	var explicitPath string
 	if len(args) > 0 {
@@ -169,6 +215,35 @@ func runMCPServe(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create MCP server: %w", err)
 	}
-
+	srv.SetQueryClientFinder(findWatchQueryClient)
+	srv.SetReindexer(findWatchControlSocket, reindexProjectInline)
+	srv.SetToolTimeouts(toolTimeout, toolTimeouts)
+
+	if transport == mcpTransportHTTP {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("auth-token")
+		if token == "" {
+			token = os.Getenv(mcpAuthTokenEnv)
+		}
+		allowedOrigins, _ := cmd.Flags().GetStringSlice("allowed-origins")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return srv.ListenAndServeHTTP(ctx, mcp.HTTPOptions{Addr: addr, AuthToken: token, AllowedOrigins: allowedOrigins})
+	}
 	return srv.Serve()
 }
+
+// parseToolTimeouts parses the --tool-timeouts flag into timeouts by tool
+// name.
+func parseToolTimeouts(cmd *cobra.Command) (map[string]time.Duration, error) {
+	raw, _ := cmd.Flags().GetStringToString("tool-timeouts")
+	timeouts := make(map[string]time.Duration, len(raw))
+	for tool, value := range raw {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid --tool-timeouts value %q for %s: must be a duration such as 30s or 5m", value, tool)
+		}
+		timeouts[tool] = timeout
+	}
+	return timeouts, nil
+}
@@ -142,8 +142,8 @@ func runRefs(symbolName string, readers bool) (refsResult, error) {
 			return refsResult{}, fmt.Errorf("failed to find project root: %w", err)
 		}
 
-		symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
-		if err := symbolStore.Load(ctx); err != nil {
+		symbolStore, err := openSymbolStore(ctx, projectRoot, config.GetSymbolIndexPath(projectRoot))
+		if err != nil {
 			return refsResult{}, fmt.Errorf("failed to load symbol index: %w", err)
 		}
 		defer symbolStore.Close()
@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/yoanbernabeu/grepai/config"
 	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/rpg"
 	"github.com/yoanbernabeu/grepai/search"
 	"github.com/yoanbernabeu/grepai/stats"
@@ -27,6 +28,7 @@ var (
 	searchWorkspace string
 	searchProjects  []string
 	searchPath      string
+	searchRef       string
 )
 
 // SearchResultJSON is a lightweight struct for JSON output (excludes vector, hash, updated_at)
@@ -71,6 +73,7 @@ func init() {
 	searchCmd.Flags().StringVar(&searchWorkspace, "workspace", "", "Workspace name for cross-project search")
 	searchCmd.Flags().StringArrayVar(&searchProjects, "project", nil, "Project name(s) to search (requires --workspace, can be repeated)")
 	searchCmd.Flags().StringVar(&searchPath, "path", "", "Path prefix to filter search results")
+	searchCmd.Flags().StringVar(&searchRef, "ref", "", "Search a git ref snapshot created with 'grepai snapshot create' instead of the working tree")
 	searchCmd.MarkFlagsMutuallyExclusive("json", "toon")
 }
 
@@ -206,6 +209,10 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--project flag requires --workspace flag")
 	}
 
+	if searchRef != "" && searchWorkspace != "" {
+		return fmt.Errorf("--ref cannot be combined with --workspace")
+	}
+
 	// Workspace mode
 	if searchWorkspace != "" {
 		return runWorkspaceSearch(ctx, query, searchProjects, searchPath)
@@ -223,46 +230,13 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Initialize embedder
-	emb, err := embedder.NewFromConfig(cfg)
+	// Reuse the indexes loaded by the background watcher when it serves
+	// queries; snapshots are always read directly
+	searcher, closeSearcher, err := openQuerySearcher(ctx, cfg, projectRoot, searchRef)
 	if err != nil {
-		return fmt.Errorf("failed to initialize embedder: %w", err)
-	}
-	defer emb.Close()
-
-	// Initialize store
-	var st store.VectorStore
-	switch cfg.Store.Backend {
-	case "gob":
-		indexPath := config.GetIndexPath(projectRoot)
-		gobStore := store.NewGOBStore(indexPath)
-		if err := gobStore.Load(ctx); err != nil {
-			return fmt.Errorf("failed to load index: %w", err)
-		}
-		st = gobStore
-	case "postgres":
-		var err error
-		st, err = store.NewPostgresStore(ctx, cfg.Store.Postgres.DSN, projectRoot, cfg.Embedder.GetDimensions())
-		if err != nil {
-			return fmt.Errorf("failed to connect to postgres: %w", err)
-		}
-	case "qdrant":
-		collectionName := cfg.Store.Qdrant.Collection
-		if collectionName == "" {
-			collectionName = store.SanitizeCollectionName(projectRoot)
-		}
-		var err error
-		st, err = store.NewQdrantStore(ctx, cfg.Store.Qdrant.Endpoint, cfg.Store.Qdrant.Port, cfg.Store.Qdrant.UseTLS, collectionName, cfg.Store.Qdrant.APIKey, cfg.Embedder.GetDimensions())
-		if err != nil {
-			return fmt.Errorf("failed to connect to qdrant: %w", err)
-		}
-	default:
-		return fmt.Errorf("unknown storage backend: %s", cfg.Store.Backend)
+		return err
 	}
-	defer st.Close()
-
-	// Create searcher with boost config
-	searcher := search.NewSearcher(st, emb, cfg.Search)
+	defer closeSearcher()
 
 	normalizedPath, err := search.NormalizeProjectPathPrefix(searchPath, projectRoot)
 	if err != nil {
@@ -281,8 +255,11 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("search failed: %w", err)
 	}
 
-	// Enrich results with RPG context
-	enrichments := enrichWithRPG(projectRoot, cfg, results)
+	// Enrich results with RPG context (the RPG graph describes the working tree only)
+	enrichments := make([]rpgEnrichment, len(results))
+	if searchRef == "" {
+		enrichments = enrichWithRPG(projectRoot, cfg, results)
+	}
 
 	// JSON output mode
 	if searchJSON {
@@ -326,7 +303,11 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	// Display results (plain text — build output string for token estimation)
 	var buf strings.Builder
-	fmt.Fprintf(&buf, "Found %d results for: %q\n\n", len(results), query)
+	if searchRef != "" {
+		fmt.Fprintf(&buf, "Found %d results for: %q at %s\n\n", len(results), query, searchRef)
+	} else {
+		fmt.Fprintf(&buf, "Found %d results for: %q\n\n", len(results), query)
+	}
 
 	for i, result := range results {
 		fmt.Fprintf(&buf, "─── Result %d (score: %.4f) ───\n", i+1, result.Score)
@@ -529,10 +510,113 @@ func SearchJSON(projectRoot string, query string, limit int) ([]store.SearchResu
 
 	// Create searcher with boost config
 	searcher := search.NewSearcher(st, emb, cfg.Search)
+	applyFilePenalties(searcher, config.GetScanReportPath(projectRoot), cfg)
 
 	return searcher.Search(ctx, query, limit, "")
 }
 
+// openSearcher loads the index of projectRoot, or of the snapshot of ref
+// when set, and returns a searcher over it with a function releasing it.
+func openSearcher(ctx context.Context, cfg *config.Config, projectRoot, ref string) (*search.Searcher, func(), error) {
+	// Initialize embedder
+	emb, err := embedder.NewFromConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize embedder: %w", err)
+	}
+
+	// Initialize store
+	var st store.VectorStore
+	reportPath := config.GetScanReportPath(projectRoot)
+	if ref != "" {
+		snapshot, err := loadSnapshot(projectRoot, ref)
+		if err != nil {
+			emb.Close()
+			return nil, nil, err
+		}
+		st, err = initializeSnapshotStore(ctx, cfg, projectRoot, snapshot.Name)
+		if err != nil {
+			emb.Close()
+			return nil, nil, err
+		}
+		reportPath = config.GetSnapshotScanReportPath(projectRoot, snapshot.Name)
+	} else {
+		switch cfg.Store.Backend {
+		case "gob":
+			indexPath := config.GetIndexPath(projectRoot)
+			gobStore := store.NewGOBStore(indexPath)
+			if err := gobStore.Load(ctx); err != nil {
+				emb.Close()
+				return nil, nil, fmt.Errorf("failed to load index: %w", err)
+			}
+			st = gobStore
+		case "postgres":
+			var err error
+			st, err = store.NewPostgresStore(ctx, cfg.Store.Postgres.DSN, projectRoot, cfg.Embedder.GetDimensions())
+			if err != nil {
+				emb.Close()
+				return nil, nil, fmt.Errorf("failed to connect to postgres: %w", err)
+			}
+		case "qdrant":
+			collectionName := cfg.Store.Qdrant.Collection
+			if collectionName == "" {
+				collectionName = store.SanitizeCollectionName(projectRoot)
+			}
+			var err error
+			st, err = store.NewQdrantStore(ctx, cfg.Store.Qdrant.Endpoint, cfg.Store.Qdrant.Port, cfg.Store.Qdrant.UseTLS, collectionName, cfg.Store.Qdrant.APIKey, cfg.Embedder.GetDimensions())
+			if err != nil {
+				emb.Close()
+				return nil, nil, fmt.Errorf("failed to connect to qdrant: %w", err)
+			}
+		default:
+			emb.Close()
+			return nil, nil, fmt.Errorf("unknown storage backend: %s", cfg.Store.Backend)
+		}
+	}
+
+	// Create searcher with boost config
+	searcher := search.NewSearcher(st, emb, cfg.Search)
+	applyFilePenalties(searcher, reportPath, cfg)
+	return searcher, func() {
+		st.Close()
+		emb.Close()
+	}, nil
+}
+
+// applyFilePenalties ranks files indexed with the "penalize" policy lower,
+// using the scan report at reportPath.
+func applyFilePenalties(searcher *search.Searcher, reportPath string, cfg *config.Config) {
+	report, err := indexer.LoadScanReport(reportPath)
+	if err != nil {
+		return
+	}
+	searcher.SetFilePenalties(report.Penalized, cfg.Indexing.PenaltyFactor)
+}
+
+// applyWorkspaceFilePenalties ranks the "penalize" policy files of every
+// workspace project lower, each with its own project's penalty factor.
+// Workspace indexes store paths as "<workspace>/<project>/<path>", so the
+// project-relative paths of the scan reports are prefixed to match.
+func applyWorkspaceFilePenalties(searcher *search.Searcher, ws *config.Workspace) {
+	for _, project := range ws.Projects {
+		report, err := indexer.LoadScanReport(config.GetScanReportPath(project.Path))
+		if err != nil || len(report.Penalized) == 0 {
+			continue
+		}
+		factor := float32(config.DefaultPenaltyFactor)
+		if config.Exists(project.Path) {
+			if cfg, err := config.Load(project.Path); err == nil {
+				factor = cfg.Indexing.PenaltyFactor
+			}
+		}
+		prefix := ws.Name + "/" + project.Name + "/"
+		files := make(map[string]string, len(report.Penalized))
+		for path, reason := range report.Penalized {
+			files[prefix+path] = reason
+		}
+		searcher.AddFilePenalties(files, factor)
+	}
+}
+
 func init() {
 	// Ensure the search command is registered
 	_ = os.Getenv("GREPAI_DEBUG")
@@ -601,6 +685,7 @@ func runWorkspaceSearch(ctx context.Context, query string, projects []string, pa
 		Boost:  config.DefaultConfig().Search.Boost,
 	}
 	searcher := search.NewSearcher(st, emb, searchCfg)
+	applyWorkspaceFilePenalties(searcher, ws)
 
 	// Construct full path prefix for database query
 	// Database stores paths as: workspaceName/projectName/relativePath
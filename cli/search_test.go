@@ -2,12 +2,17 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/alpkeskin/gotoon"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/search"
 	"github.com/yoanbernabeu/grepai/store"
 )
 
@@ -444,3 +449,46 @@ func TestFindFileNode_PrefersExactPath(t *testing.T) {
 		t.Fatalf("expected exact path b.go, got %s", fileNode.Path)
 	}
 }
+
+func TestApplyWorkspaceFilePenalties(t *testing.T) {
+	backend := t.TempDir()
+	report := indexer.NewScanReport()
+	report.Penalized["api/api.pb.go"] = "generated"
+	if err := indexer.SaveScanReport(config.GetScanReportPath(backend), report); err != nil {
+		t.Fatalf("failed to save scan report: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Indexing.PenaltyFactor = 0.1
+	if err := cfg.Save(backend); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	ws := &config.Workspace{
+		Name: "acme",
+		Projects: []config.ProjectEntry{
+			{Name: "backend", Path: backend},
+			{Name: "frontend", Path: t.TempDir()},
+		},
+	}
+
+	st := store.NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	vector := []float32{0.1, 0.2, 0.3}
+	if err := st.SaveChunks(context.Background(), []store.Chunk{
+		{ID: "1", FilePath: "acme/backend/api/api.pb.go", Vector: vector},
+		{ID: "2", FilePath: "acme/frontend/api/api.pb.go", Vector: vector},
+	}); err != nil {
+		t.Fatalf("failed to save chunks: %v", err)
+	}
+	searcher := search.NewSearcher(st, &noOpEmbedder{}, config.SearchConfig{})
+	applyWorkspaceFilePenalties(searcher, ws)
+
+	results, err := searcher.Search(context.Background(), "api", 2, "")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Chunk.FilePath != "acme/frontend/api/api.pb.go" {
+		t.Fatalf("expected penalized workspace file ranked last, got %+v", results)
+	}
+	if results[1].Score > 0.11 {
+		t.Errorf("expected the backend penalty factor 0.1 applied, got score %f", results[1].Score)
+	}
+}
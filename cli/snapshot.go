@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+var snapshotName string
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Index git refs as named snapshots",
+	Long: `Index the code at a git ref (tag, branch or commit) without checking it out.
+
+Each snapshot is stored separately from the live index and can be queried
+with the --ref flag of search and trace. Embeddings are reused from the live
+index and from earlier runs wherever file content is unchanged.
+
+Examples:
+  grepai snapshot create v2.3.0
+  grepai search "token refresh" --ref v2.3.0
+  grepai trace callers "Login" --ref v2.3.0`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <ref>",
+	Short: "Index a git ref as a snapshot",
+	Long: `Index the files of a git ref by reading blobs from git (no checkout).
+
+Running the command again for the same ref updates the snapshot, embedding
+only the files whose content changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotCreate,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List indexed snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotList,
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringVar(&snapshotName, "name", "", "Snapshot name (default: derived from the ref)")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	ref := args[0]
+	ctx := context.Background()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	commit, err := git.ResolveRef(projectRoot, ref)
+	if err != nil {
+		return err
+	}
+
+	name := config.SnapshotName(ref)
+	if snapshotName != "" {
+		name = config.SnapshotName(snapshotName)
+	}
+	if err := checkSnapshotName(projectRoot, name, ref); err != nil {
+		return err
+	}
+
+	emb, err := initializeEmbedder(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer emb.Close()
+
+	st, err := initializeSnapshotStore(ctx, cfg, projectRoot, name)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, cfg.Ignore, cfg.ExternalGitignore)
+	if err != nil {
+		return fmt.Errorf("failed to initialize ignore matcher: %w", err)
+	}
+
+	rs := indexer.NewRefScanner(projectRoot, commit, ignoreMatcher)
+	rs.SetExtensions(indexer.ExtensionSet(cfg.Indexing.Extensions, cfg.Indexing.ExcludeExtensions))
+	rs.SetFilePolicy(filePolicyFromConfig(cfg))
+	defer rs.Close()
+
+	chunker := indexer.NewChunker(cfg.Chunking.Size, cfg.Chunking.Overlap)
+	processorRegistry := buildFrameworkRegistry(cfg)
+	idx := indexer.NewIndexer(projectRoot, st, emb, chunker, nil, time.Time{}, processorRegistry)
+	idx.SetExtractors(buildExtractorRegistry(cfg))
+
+	// Reuse embeddings of the live index for content unchanged since the ref.
+	if mainStore, err := initializeStore(ctx, cfg, projectRoot); err != nil {
+		log.Printf("Warning: live index unavailable, embeddings will not be reused: %v", err)
+	} else {
+		defer mainStore.Close()
+		if cache, ok := mainStore.(store.EmbeddingCache); ok {
+			idx.SetEmbeddingCache(cache)
+		}
+	}
+
+	fmt.Printf("Indexing %s (%s) as snapshot %q...\n", ref, shortCommit(commit.Hash), name)
+	stats, err := idx.IndexRef(ctx, rs,
+		func(info indexer.ProgressInfo) {
+			printProgress(info.Current, info.Total, info.CurrentFile)
+		},
+		printBatchProgress,
+	)
+	watchProgressOutput.clear()
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("snapshot indexing failed: %w", err)
+	}
+
+	if err := st.Persist(ctx); err != nil {
+		return fmt.Errorf("failed to persist snapshot index: %w", err)
+	}
+
+	symbolCount, err := indexSnapshotSymbols(ctx, cfg, projectRoot, name, rs, stats.ScannedFiles)
+	if err != nil {
+		log.Printf("Warning: failed to build snapshot symbol index: %v", err)
+	}
+
+	if err := indexer.SaveScanReport(config.GetSnapshotScanReportPath(projectRoot, name), rs.Report()); err != nil {
+		log.Printf("Warning: failed to save snapshot scan report: %v", err)
+	}
+
+	manifest := &indexer.SnapshotManifest{
+		Name:      name,
+		Ref:       ref,
+		Commit:    commit.Hash,
+		Files:     len(stats.ScannedFiles),
+		IndexedAt: time.Now(),
+	}
+	if err := indexer.SaveSnapshotManifest(config.GetSnapshotManifestPath(projectRoot, name), manifest); err != nil {
+		return err
+	}
+
+	fmt.Printf("Snapshot %q ready: %d files indexed, %d chunks created, %d files removed, %d skipped, %d symbols (took %s)\n",
+		name, stats.FilesIndexed, stats.ChunksCreated, stats.FilesRemoved, stats.FilesSkipped, symbolCount, stats.Duration.Round(time.Millisecond))
+	return nil
+}
+
+// indexSnapshotSymbols builds the snapshot's symbol index used by trace --ref.
+func indexSnapshotSymbols(ctx context.Context, cfg *config.Config, projectRoot, name string, rs *indexer.RefScanner, files []indexer.FileMeta) (int, error) {
+	symbolStore := trace.NewGOBSymbolStore(config.GetSnapshotSymbolIndexPath(projectRoot, name))
+	if err := symbolStore.Load(ctx); err != nil {
+		return 0, err
+	}
+	defer symbolStore.Close()
+
+	tracedLanguages := cfg.Trace.TracedLanguages()
+
+	extractor := trace.NewRegexExtractor()
+	processorRegistry := buildFrameworkRegistry(cfg)
+	present := make(map[string]bool, len(files))
+	symbolCount := 0
+	for _, file := range files {
+		present[file.Path] = true
+		if !isTracedLanguage(strings.ToLower(filepath.Ext(file.Path)), tracedLanguages) {
+			continue
+		}
+
+		fileInfo, err := rs.ScanFile(file.Path)
+		if err != nil {
+			log.Printf("Warning: failed to read %s for symbols: %v", file.Path, err)
+			continue
+		}
+		if fileInfo == nil {
+			continue
+		}
+		if existingHash, ok := symbolStore.GetFileContentHash(fileInfo.Path); ok && existingHash == fileInfo.Hash {
+			continue
+		}
+
+		symbols, refs, err := extractSymbolsWithFramework(ctx, extractor, fileInfo.Path, fileInfo.Content, processorRegistry)
+		if err != nil {
+			log.Printf("Warning: failed to extract symbols from %s: %v", fileInfo.Path, err)
+			continue
+		}
+		if err := symbolStore.SaveFileWithContentHash(ctx, fileInfo.Path, fileInfo.Hash, symbols, refs); err != nil {
+			log.Printf("Warning: failed to save symbols for %s: %v", fileInfo.Path, err)
+		}
+		symbolCount += len(symbols)
+	}
+
+	// Drop symbols of files that no longer exist at the ref.
+	for _, path := range symbolStore.ListFiles() {
+		if present[path] {
+			continue
+		}
+		if err := symbolStore.DeleteFile(ctx, path); err != nil {
+			log.Printf("Warning: failed to remove symbols for %s: %v", path, err)
+		}
+	}
+
+	return symbolCount, symbolStore.Persist(ctx)
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+
+	manifests, err := indexer.ListSnapshots(config.GetSnapshotsDir(projectRoot), config.SnapshotFileName)
+	if err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		fmt.Println("No snapshots. Create one with: grepai snapshot create <ref>")
+		return nil
+	}
+
+	for _, m := range manifests {
+		fmt.Printf("%-20s %-20s %s  %d files  indexed %s\n",
+			m.Name, m.Ref, shortCommit(m.Commit), m.Files, m.IndexedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// loadSnapshot returns the manifest of the snapshot for ref, which may be the
+// ref it was created from or its snapshot name.
+func loadSnapshot(projectRoot, ref string) (*indexer.SnapshotManifest, error) {
+	manifest, err := indexer.FindSnapshot(config.GetSnapshotsDir(projectRoot), config.SnapshotFileName, ref, config.SnapshotName(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("ref %q is not indexed; run 'grepai snapshot create %s' first", ref, ref)
+		}
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// checkSnapshotName rejects a snapshot name already taken by another ref, such
+// as "release/1.0" and "release_1.0" which derive the same name, so that one
+// snapshot does not overwrite the other.
+func checkSnapshotName(projectRoot, name, ref string) error {
+	existing, err := indexer.LoadSnapshotManifest(config.GetSnapshotManifestPath(projectRoot, name))
+	if err != nil || existing.Ref == ref {
+		return nil
+	}
+	return fmt.Errorf("snapshot name %q is already used by ref %q; choose another with --name", name, existing.Ref)
+}
+
+// initializeSnapshotStore opens the vector store of a named snapshot. It uses
+// the configured backend with a snapshot-specific index file, project ID or
+// collection.
+func initializeSnapshotStore(ctx context.Context, cfg *config.Config, projectRoot, name string) (store.VectorStore, error) {
+	switch cfg.Store.Backend {
+	case "gob":
+		gobStore := store.NewGOBStore(config.GetSnapshotIndexPath(projectRoot, name))
+		if err := gobStore.Load(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load snapshot index: %w", err)
+		}
+		return gobStore, nil
+	case "postgres":
+		return store.NewPostgresStore(ctx, cfg.Store.Postgres.DSN, store.SnapshotID(projectRoot, name), cfg.Embedder.GetDimensions())
+	case "qdrant":
+		collectionName := cfg.Store.Qdrant.Collection
+		if collectionName == "" {
+			collectionName = store.SanitizeCollectionName(projectRoot)
+		}
+		return store.NewQdrantStore(ctx, cfg.Store.Qdrant.Endpoint, cfg.Store.Qdrant.Port, cfg.Store.Qdrant.UseTLS, store.SnapshotID(collectionName, name), cfg.Store.Qdrant.APIKey, cfg.Embedder.GetDimensions())
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Store.Backend)
+	}
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
+)
+
+func TestLoadSnapshot(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	if _, err := loadSnapshot(projectRoot, "v1.0.0"); err == nil || !strings.Contains(err.Error(), "grepai snapshot create v1.0.0") {
+		t.Fatalf("expected hint to create the snapshot, got %v", err)
+	}
+
+	name := config.SnapshotName("release/1.0")
+	manifest := &indexer.SnapshotManifest{Name: name, Ref: "release/1.0", Commit: "abc"}
+	if err := indexer.SaveSnapshotManifest(config.GetSnapshotManifestPath(projectRoot, name), manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the original ref and the derived name resolve to the snapshot.
+	for _, ref := range []string{"release/1.0", "release_1.0"} {
+		got, err := loadSnapshot(projectRoot, ref)
+		if err != nil || got.Name != name {
+			t.Errorf("loadSnapshot(%q) = %+v, %v", ref, got, err)
+		}
+	}
+
+	// A snapshot created with a custom name is found by its ref.
+	custom := &indexer.SnapshotManifest{Name: "stable", Ref: "main", Commit: "def"}
+	if err := indexer.SaveSnapshotManifest(config.GetSnapshotManifestPath(projectRoot, "stable"), custom); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"main", "stable"} {
+		got, err := loadSnapshot(projectRoot, ref)
+		if err != nil || got.Name != "stable" {
+			t.Errorf("loadSnapshot(%q) = %+v, %v", ref, got, err)
+		}
+	}
+
+	traceRef = "release/1.0"
+	defer func() { traceRef = "" }()
+	path, err := traceSymbolIndexPath(projectRoot)
+	if err != nil || path != config.GetSnapshotSymbolIndexPath(projectRoot, name) {
+		t.Errorf("traceSymbolIndexPath() = %q, %v", path, err)
+	}
+}
+
+func TestCheckSnapshotName(t *testing.T) {
+	projectRoot := t.TempDir()
+	name := config.SnapshotName("release/1.0")
+	manifest := &indexer.SnapshotManifest{Name: name, Ref: "release/1.0", Commit: "abc"}
+	if err := indexer.SaveSnapshotManifest(config.GetSnapshotManifestPath(projectRoot, name), manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkSnapshotName(projectRoot, name, "release/1.0"); err != nil {
+		t.Errorf("expected the same ref to update its snapshot, got %v", err)
+	}
+	if err := checkSnapshotName(projectRoot, "other", "release_1.0"); err != nil {
+		t.Errorf("expected a free name to be accepted, got %v", err)
+	}
+	err := checkSnapshotName(projectRoot, name, "release_1.0")
+	if err == nil || !strings.Contains(err.Error(), `already used by ref "release/1.0"`) {
+		t.Errorf("expected a name collision error, got %v", err)
+	}
+}
@@ -129,7 +129,7 @@ func outputStatsHuman(summary stats.Summary, entries []stats.Entry, provider str
 	// Command breakdown
 	content += "\n"
 	cmdLine := "By command:  "
-	for _, k := range []string{"search", "trace-callers", "trace-callees", "trace-graph"} {
+	for _, k := range []string{"search", "trace-callers", "trace-callees", "trace-graph", "diff-search", "context"} {
 		if v := summary.ByCommandType[k]; v > 0 {
 			cmdLine += fmt.Sprintf("%s %d · ", k, v)
 		}
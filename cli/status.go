@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbletea"
@@ -14,6 +15,7 @@ import (
 	"github.com/yoanbernabeu/grepai/config"
 	"github.com/yoanbernabeu/grepai/daemon"
 	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/stats"
 	"github.com/yoanbernabeu/grepai/store"
 )
@@ -55,6 +57,8 @@ type model struct {
 	height          int
 	watchRunning    bool
 	watchPID        int
+	watchControl    *daemon.WatchStatus
+	watchProject    *daemon.ProjectStatus
 	watchLogDir     string
 	watchLogFile    string
 	worktreeID      string
@@ -62,6 +66,7 @@ type model struct {
 	savingsSummary  *stats.Summary
 	savingsDays     []stats.DaySummary
 	savingsSelected int
+	scanReport      *indexer.ScanReport
 }
 
 func init() {
@@ -220,15 +225,44 @@ func (m model) viewStats() string {
 		sb.WriteString(fmt.Sprintf("%s\n", m.stats.LastUpdated.Format("2006-01-02 15:04:05")))
 	}
 
+	if skipped := len(m.scanReport.Skipped); skipped > 0 {
+		sb.WriteString(normalStyle.Render("Files skipped:    "))
+		sb.WriteString(fmt.Sprintf("%d (%s)\n", skipped, indexer.FormatCounts(m.scanReport.SkipCounts())))
+	}
+	if penalized := len(m.scanReport.Penalized); penalized > 0 {
+		sb.WriteString(normalStyle.Render("Files penalized:  "))
+		sb.WriteString(fmt.Sprintf("%d (%s)\n", penalized, indexer.FormatCounts(m.scanReport.PenaltyCounts())))
+	}
+	if len(m.scanReport.Secrets) > 0 {
+		sb.WriteString(normalStyle.Render("Secrets found:    "))
+		sb.WriteString(formatSecretsSummary(m.scanReport) + "\n")
+	}
+	if failed := len(m.scanReport.Failed); failed > 0 {
+		sb.WriteString(normalStyle.Render("Files failed:     "))
+		sb.WriteString(fmt.Sprintf("%d\n", failed))
+	}
+
 	sb.WriteString(normalStyle.Render("Provider:         "))
 	sb.WriteString(fmt.Sprintf("%s (%s)\n", m.cfg.Embedder.Provider, m.cfg.Embedder.Model))
 
 	sb.WriteString(normalStyle.Render("Watcher status:   "))
 	if m.watchRunning {
-		sb.WriteString(fmt.Sprintf("running (PID %d)\n", m.watchPID))
+		state := "running"
+		if m.watchControl != nil && m.watchControl.Paused {
+			state = "paused"
+		}
+		sb.WriteString(fmt.Sprintf("%s (PID %d)\n", state, m.watchPID))
 	} else {
 		sb.WriteString("not running\n")
 	}
+	if m.watchRunning && m.watchProject != nil {
+		sb.WriteString(normalStyle.Render("Watcher queue:    "))
+		sb.WriteString(fmt.Sprintf("%d pending\n", m.watchProject.QueueDepth))
+		if m.watchProject.LastEvent != "" {
+			sb.WriteString(normalStyle.Render("Last event:       "))
+			sb.WriteString(fmt.Sprintf("%s (%s)\n", m.watchProject.LastEvent, m.watchProject.LastEventAt.Format("2006-01-02 15:04:05")))
+		}
+	}
 	sb.WriteString(normalStyle.Render("Watcher logs:     "))
 	if m.watchLogFile == "" {
 		sb.WriteString("N/A\n")
@@ -495,11 +529,17 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		savingsDays = stats.HistoryByDay(entries)
 	}
 
+	// Load skip/penalty reasons recorded by the watcher (non-fatal)
+	scanReport, err := indexer.LoadScanReport(config.GetScanReportPath(projectRoot))
+	if err != nil {
+		scanReport = indexer.NewScanReport()
+	}
+
 	watchStatus := resolveWatcherRuntimeStatus(projectRoot)
 	useUI := shouldUseStatusUI(isInteractiveTerminal(), statusNoUI)
 
 	if !useUI {
-		fmt.Print(renderStatusSummary(cfg, indexStats, watchStatus))
+		fmt.Print(renderStatusSummary(cfg, indexStats, watchStatus, scanReport))
 		return nil
 	}
 
@@ -517,11 +557,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		files:          files,
 		watchRunning:   watchStatus.running,
 		watchPID:       watchStatus.pid,
+		watchControl:   watchStatus.control,
+		watchProject:   watchStatus.project,
 		watchLogDir:    watchStatus.logDir,
 		watchLogFile:   watchStatus.logFile,
 		worktreeID:     watchStatus.worktreeID,
 		savingsSummary: savingsSummary,
 		savingsDays:    savingsDays,
+		scanReport:     scanReport,
 	}
 
 	// Run TUI
@@ -559,6 +602,8 @@ type watcherRuntimeStatus struct {
 	logDir     string
 	logFile    string
 	worktreeID string
+	control    *daemon.WatchStatus   // Reported by the control socket, if any
+	project    *daemon.ProjectStatus // The current project's entry in control
 }
 
 func resolveWatcherRuntimeStatus(projectRoot string) watcherRuntimeStatus {
@@ -587,21 +632,32 @@ func resolveWatcherRuntimeStatus(projectRoot string) watcherRuntimeStatus {
 		if worktreeID != "" {
 			pid, _ := daemon.GetRunningWorktreePID(logDir, worktreeID)
 			logFile := daemon.GetWorktreeLogFile(logDir, worktreeID)
+			socketPath := daemon.GetWorktreeControlSocketPath(logDir, worktreeID)
 			if pid == 0 {
 				legacyPID, _ := daemon.GetRunningPID(logDir)
 				if legacyPID > 0 {
 					pid = legacyPID
 					logFile = filepath.Join(logDir, "grepai-watch.log")
+					socketPath = daemon.GetControlSocketPath(logDir)
 				}
 			}
 			status.pid = pid
 			status.running = pid > 0
 			status.logFile = logFile
+			if status.running {
+				status.control = queryWatchControlStatus(socketPath)
+			}
 		} else {
 			pid, _ := daemon.GetRunningPID(logDir)
 			status.pid = pid
 			status.running = pid > 0
 			status.logFile = filepath.Join(logDir, "grepai-watch.log")
+			if status.running {
+				status.control = queryWatchControlStatus(daemon.GetControlSocketPath(logDir))
+			}
+		}
+		if status.control != nil {
+			status.project = status.control.Project(canonicalPath(projectRoot))
 		}
 		if status.running || idx == len(logDirs)-1 {
 			return status
@@ -611,6 +667,16 @@ func resolveWatcherRuntimeStatus(projectRoot string) watcherRuntimeStatus {
 	return status
 }
 
+// queryWatchControlStatus asks a background watcher for its status. It
+// returns nil for watchers without a control socket.
+func queryWatchControlStatus(socketPath string) *daemon.WatchStatus {
+	resp, err := daemon.SendControl(socketPath, daemon.ControlRequest{Command: daemon.ControlStatus})
+	if err != nil {
+		return nil
+	}
+	return resp.Status
+}
+
 func resolveWatcherCandidateLogDirs(projectRoot string) ([]string, error) {
 	defaultLogDir, err := daemon.GetDefaultLogDir()
 	if err != nil {
@@ -632,7 +698,7 @@ func resolveWatcherCandidateLogDirs(projectRoot string) ([]string, error) {
 	return logDirs, nil
 }
 
-func renderStatusSummary(cfg *config.Config, stats *store.IndexStats, watch watcherRuntimeStatus) string {
+func renderStatusSummary(cfg *config.Config, stats *store.IndexStats, watch watcherRuntimeStatus, report *indexer.ScanReport) string {
 	var sb strings.Builder
 	sb.WriteString("grepai index status\n")
 	sb.WriteString(fmt.Sprintf("Files indexed: %d\n", stats.TotalFiles))
@@ -643,9 +709,32 @@ func renderStatusSummary(cfg *config.Config, stats *store.IndexStats, watch watc
 	} else {
 		sb.WriteString(fmt.Sprintf("Last updated: %s\n", stats.LastUpdated.Format("2006-01-02 15:04:05")))
 	}
+	if report != nil && len(report.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("Files skipped: %d (%s)\n", len(report.Skipped), indexer.FormatCounts(report.SkipCounts())))
+	}
+	if report != nil && len(report.Penalized) > 0 {
+		sb.WriteString(fmt.Sprintf("Files penalized: %d (%s)\n", len(report.Penalized), indexer.FormatCounts(report.PenaltyCounts())))
+	}
+	if report != nil && len(report.Secrets) > 0 {
+		sb.WriteString(fmt.Sprintf("Secrets found: %s\n", formatSecretsSummary(report)))
+		sb.WriteString(formatSecretLocations(report, maxSecretFilesListed))
+	}
+	if report != nil && len(report.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("Files failed: %d\n", len(report.Failed)))
+	}
 	sb.WriteString(fmt.Sprintf("Provider: %s (%s)\n", cfg.Embedder.Provider, cfg.Embedder.Model))
 	if watch.running {
-		sb.WriteString(fmt.Sprintf("Watcher: running (PID %d)\n", watch.pid))
+		state := "running"
+		if watch.control != nil && watch.control.Paused {
+			state = "paused"
+		}
+		sb.WriteString(fmt.Sprintf("Watcher: %s (PID %d)\n", state, watch.pid))
+		if watch.project != nil {
+			sb.WriteString(fmt.Sprintf("Watcher queue: %d pending\n", watch.project.QueueDepth))
+			if watch.project.LastEvent != "" {
+				sb.WriteString(fmt.Sprintf("Watcher last event: %s (%s)\n", watch.project.LastEvent, watch.project.LastEventAt.Format("2006-01-02 15:04:05")))
+			}
+		}
 	} else {
 		sb.WriteString("Watcher: not running\n")
 	}
@@ -673,3 +762,42 @@ func loadStatusFiles(
 	})
 	return files, nil
 }
+
+// maxSecretFilesListed bounds the files listed under "Secrets found".
+const maxSecretFilesListed = 10
+
+// formatSecretsSummary renders the credentials of a scan report as
+// "3 in 2 files (assignment: 2, jwt: 1)".
+func formatSecretsSummary(report *indexer.ScanReport) string {
+	total := 0
+	for _, findings := range report.Secrets {
+		total += len(findings)
+	}
+	return fmt.Sprintf("%d in %d files (%s)", total, len(report.Secrets), indexer.FormatCounts(report.SecretCounts()))
+}
+
+// formatSecretLocations lists the files and lines where credentials were
+// found, never the credentials themselves.
+func formatSecretLocations(report *indexer.ScanReport, limit int) string {
+	paths := make([]string, 0, len(report.Secrets))
+	for path := range report.Secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for i, path := range paths {
+		if i == limit {
+			sb.WriteString(fmt.Sprintf("  ... and %d more files\n", len(paths)-limit))
+			break
+		}
+		lines := make([]string, len(report.Secrets[path]))
+		kinds := make(map[string]int)
+		for j, f := range report.Secrets[path] {
+			lines[j] = strconv.Itoa(f.Line)
+			kinds[f.Kind]++
+		}
+		sb.WriteString(fmt.Sprintf("  %s: line %s (%s)\n", path, strings.Join(lines, ", "), indexer.FormatCounts(kinds)))
+	}
+	return sb.String()
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/alpkeskin/gotoon"
 	"github.com/spf13/cobra"
 	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
 	"github.com/yoanbernabeu/grepai/embedder"
 	"github.com/yoanbernabeu/grepai/rpg"
 	gstats "github.com/yoanbernabeu/grepai/stats"
@@ -80,6 +81,7 @@ var (
 	traceUI        bool
 	traceWorkspace string
 	traceProject   string
+	traceRef       string
 )
 
 var runTraceActionCardUIRunner = runTraceActionCardUI
@@ -147,6 +149,8 @@ func init() {
 		cmd.MarkFlagsMutuallyExclusive("toon", "ui")
 		cmd.Flags().StringVar(&traceWorkspace, "workspace", "", "Workspace name for cross-project trace")
 		cmd.Flags().StringVar(&traceProject, "project", "", "Project name within workspace (requires --workspace)")
+		cmd.Flags().StringVar(&traceRef, "ref", "", "Trace a git ref snapshot created with 'grepai snapshot create' instead of the working tree")
+		cmd.MarkFlagsMutuallyExclusive("ref", "workspace")
 	}
 	traceGraphCmd.Flags().IntVarP(&traceDepth, "depth", "d", 2, "Maximum depth for graph traversal")
 
@@ -157,6 +161,42 @@ func init() {
 	rootCmd.AddCommand(traceCmd)
 }
 
+// traceSymbolIndexPath returns the symbol index to trace: the working tree's,
+// or the snapshot's when --ref is set.
+func traceSymbolIndexPath(projectRoot string) (string, error) {
+	if traceRef == "" {
+		return config.GetSymbolIndexPath(projectRoot), nil
+	}
+	snapshot, err := loadSnapshot(projectRoot, traceRef)
+	if err != nil {
+		return "", err
+	}
+	return config.GetSnapshotSymbolIndexPath(projectRoot, snapshot.Name), nil
+}
+
+// openSymbolStore loads the symbol index at indexPath. The working tree's
+// index is served by the background watcher when it answers queries, and
+// loaded directly once a query to it fails.
+func openSymbolStore(ctx context.Context, projectRoot, indexPath string) (trace.SymbolStore, error) {
+	if indexPath == config.GetSymbolIndexPath(projectRoot) {
+		if client := findWatchQueryClient(projectRoot); client != nil {
+			return daemon.NewFallbackSymbolStore(client.SymbolStore(), func(ctx context.Context) (trace.SymbolStore, error) {
+				return loadSymbolStore(ctx, indexPath)
+			}), nil
+		}
+	}
+	return loadSymbolStore(ctx, indexPath)
+}
+
+// loadSymbolStore loads the symbol index at indexPath.
+func loadSymbolStore(ctx context.Context, indexPath string) (trace.SymbolStore, error) {
+	symbolStore := trace.NewGOBSymbolStore(indexPath)
+	if err := symbolStore.Load(ctx); err != nil {
+		return nil, err
+	}
+	return symbolStore, nil
+}
+
 func runTraceCallers(cmd *cobra.Command, args []string) error {
 	symbolName := args[0]
 	ctx := context.Background()
@@ -225,8 +265,12 @@ func runTraceCallers(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize symbol store
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := traceSymbolIndexPath(projectRoot)
+	if err != nil {
+		return err
+	}
+	symbolStore, err := openSymbolStore(ctx, projectRoot, symbolIndexPath)
+	if err != nil {
 		if traceUI {
 			return showTraceActionCardUIError(
 				fmt.Errorf("failed to load symbol index: %w", err),
@@ -312,7 +356,7 @@ func runTraceCallers(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		log.Printf("Warning: failed to load config for RPG enrichment: %v", err)
 	}
-	if cfg != nil {
+	if cfg != nil && traceRef == "" {
 		enrichTraceWithRPG(projectRoot, cfg, &result)
 	}
 
@@ -384,8 +428,12 @@ func runTraceCallees(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := traceSymbolIndexPath(projectRoot)
+	if err != nil {
+		return err
+	}
+	symbolStore, err := openSymbolStore(ctx, projectRoot, symbolIndexPath)
+	if err != nil {
 		if traceUI {
 			return showTraceActionCardUIError(
 				fmt.Errorf("failed to load symbol index: %w", err),
@@ -461,7 +509,7 @@ func runTraceCallees(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		log.Printf("Warning: failed to load config for RPG enrichment: %v", err)
 	}
-	if cfg != nil {
+	if cfg != nil && traceRef == "" {
 		enrichTraceWithRPG(projectRoot, cfg, &result)
 	}
 
@@ -526,8 +574,12 @@ func runTraceGraph(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := traceSymbolIndexPath(projectRoot)
+	if err != nil {
+		return err
+	}
+	symbolStore, err := openSymbolStore(ctx, projectRoot, symbolIndexPath)
+	if err != nil {
 		if traceUI {
 			return showTraceActionCardUIError(
 				fmt.Errorf("failed to load symbol index: %w", err),
@@ -570,7 +622,7 @@ func runTraceGraph(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		log.Printf("Warning: failed to load config for RPG enrichment: %v", err)
 	}
-	if cfg != nil {
+	if cfg != nil && traceRef == "" {
 		enrichTraceWithRPG(projectRoot, cfg, &result)
 	}
 
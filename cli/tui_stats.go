@@ -122,7 +122,7 @@ func (m statsUIModel) viewSummary() string {
 	// Command breakdown
 	sb.WriteString("\n")
 	cmdParts := []string{}
-	for _, k := range []string{"search", "trace-callers", "trace-callees", "trace-graph"} {
+	for _, k := range []string{"search", "trace-callers", "trace-callees", "trace-graph", "diff-search", "context"} {
 		if v := m.summary.ByCommandType[k]; v > 0 {
 			cmdParts = append(cmdParts, fmt.Sprintf("%s %d", k, v))
 		}
@@ -16,6 +16,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/hooks"
 	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/watcher"
 )
@@ -137,6 +138,12 @@ type watchUIModel struct {
 	snapshots     map[string]watchStatsDelta
 	snapshotDrift map[string]watchStatsDelta
 
+	// Watcher event pipeline
+	queueDepth      map[string]int
+	eventsCoalesced int
+	overflows       int
+	rescans         int
+
 	totalEvents int
 	lastSuccess time.Time
 
@@ -184,6 +191,7 @@ func newWatchUIModel(cancel context.CancelFunc) watchUIModel {
 		progress:      newProgressModel(theme),
 		snapshots:     make(map[string]watchStatsDelta),
 		snapshotDrift: make(map[string]watchStatsDelta),
+		queueDepth:    make(map[string]int),
 	}
 }
 
@@ -308,7 +316,9 @@ func (m watchUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.currentActivity = activity
 
 	case watchUIStatsMsg:
-		if msg.delta.Snapshot {
+		if msg.delta.Queue {
+			m.applyQueueStats(msg.projectRoot, msg.delta)
+		} else if msg.delta.Snapshot {
 			m.applySnapshotStats(msg.projectRoot, msg.delta)
 		} else {
 			m.applyIncrementalStats(msg.projectRoot, msg.delta)
@@ -373,6 +383,23 @@ func (m *watchUIModel) applyStatsDelta(delta watchStatsDelta) {
 	m.symbolCount += delta.SymbolsFound - delta.SymbolsLost
 }
 
+// applyQueueStats records watcher pipeline counters, which are not part of
+// index snapshots.
+func (m *watchUIModel) applyQueueStats(projectRoot string, delta watchStatsDelta) {
+	m.queueDepth[projectRoot] = delta.QueueDepth
+	m.eventsCoalesced += delta.EventsCoalesced
+	m.overflows += delta.Overflows
+	m.rescans += delta.Rescans
+}
+
+func (m watchUIModel) totalQueueDepth() int {
+	total := 0
+	for _, depth := range m.queueDepth {
+		total += depth
+	}
+	return total
+}
+
 func (m *watchUIModel) applyIncrementalStats(projectRoot string, delta watchStatsDelta) {
 	m.applyStatsDelta(delta)
 
@@ -864,6 +891,10 @@ func (m watchUIModel) renderHealthPanel(width, height int) string {
 		m.theme.text.Render(fmt.Sprintf("Indexed files: %d", m.filesIndexed)),
 		m.theme.text.Render(fmt.Sprintf("Chunks created: %d", m.chunksCreated)),
 		m.theme.text.Render(fmt.Sprintf("Symbols: %d", m.symbolCount)),
+		m.theme.text.Render(fmt.Sprintf("Queue: %d pending · %d coalesced", m.totalQueueDepth(), m.eventsCoalesced)),
+	}
+	if m.overflows > 0 || m.rescans > 0 {
+		lines = append(lines, m.theme.text.Render(fmt.Sprintf("Overflows: %d · Rescans: %d", m.overflows, m.rescans)))
 	}
 
 	if m.err != nil {
@@ -1114,6 +1145,15 @@ func runWatchUIWorker(ctx context.Context, p *tea.Program) (err error) {
 
 	watchCtx, watchCancel := context.WithCancel(ctx)
 	defer watchCancel()
+	watchMetrics, stopMetrics := serveWatchMetrics(cfg)
+	defer stopMetrics()
+	hookObserver := func(projectRoot string, res hooks.Result) {
+		level := "ok"
+		if res.Err != nil {
+			level = "warn"
+		}
+		sendWatchUILedger(p, projectRoot, level, formatWatchHookResult(res))
+	}
 
 	emb, err := initializeEmbedder(watchCtx, cfg)
 	if err != nil {
@@ -1138,6 +1178,7 @@ func runWatchUIWorker(ctx context.Context, p *tea.Program) (err error) {
 		projectRoot,
 		emb,
 		withWatchSupervisorBackgroundChild(true),
+		withWatchSupervisorServices(watchServices{metrics: watchMetrics, hookObserver: hookObserver}),
 		withWatchSupervisorInitialLinkedWorktrees(initialLinked),
 		withWatchSupervisorScopeObserver(func(totalProjects int) {
 			p.Send(watchUIScopeMsg{totalProjects: totalProjects})
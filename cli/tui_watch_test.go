@@ -8,6 +8,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/store"
 )
 
@@ -172,6 +174,29 @@ func TestWatchUIModelSnapshotRebaseAvoidsDoubleCount(t *testing.T) {
 	}
 }
 
+func TestWatchUIModelQueueStatsDoNotTouchIndexStats(t *testing.T) {
+	m := newWatchUIModel(nil)
+
+	for _, msg := range []watchUIStatsMsg{
+		{projectRoot: "/tmp/a", delta: watchStatsDelta{Queue: true, QueueDepth: 7, EventsCoalesced: 3}},
+		{projectRoot: "/tmp/b", delta: watchStatsDelta{Queue: true, QueueDepth: 2, Overflows: 1, Rescans: 2}},
+		{projectRoot: "/tmp/a", delta: watchStatsDelta{Queue: true, QueueDepth: 0, EventsCoalesced: 1}},
+	} {
+		next, _ := m.Update(msg)
+		m = next.(watchUIModel)
+	}
+
+	if got := m.totalQueueDepth(); got != 2 {
+		t.Errorf("queue depth = %d, want 2", got)
+	}
+	if m.eventsCoalesced != 4 || m.overflows != 1 || m.rescans != 2 {
+		t.Errorf("coalesced=%d overflows=%d rescans=%d, want 4/1/2", m.eventsCoalesced, m.overflows, m.rescans)
+	}
+	if m.filesIndexed != 0 || m.chunksCreated != 0 || len(m.snapshotDrift) != 0 {
+		t.Errorf("queue stats leaked into index stats: files=%d chunks=%d drift=%v", m.filesIndexed, m.chunksCreated, m.snapshotDrift)
+	}
+}
+
 func TestWatchUIModelSessionFocusCyclesWithTab(t *testing.T) {
 	m := newWatchUIModel(nil)
 
@@ -420,7 +445,7 @@ func TestRenderStatusSummaryIncludesWatcherInfo(t *testing.T) {
 		logFile: "/tmp/grepai-watch.log",
 	}
 
-	out := renderStatusSummary(cfg, stats, watch)
+	out := renderStatusSummary(cfg, stats, watch, nil)
 	if !strings.Contains(out, "Files indexed: 12") {
 		t.Fatalf("summary missing files count: %q", out)
 	}
@@ -432,6 +457,37 @@ func TestRenderStatusSummaryIncludesWatcherInfo(t *testing.T) {
 	}
 }
 
+func TestRenderStatusSummaryIncludesSkipReasons(t *testing.T) {
+	report := indexer.NewScanReport()
+	report.Skipped["api.pb.go"] = indexer.ReasonGenerated
+	report.Skipped["gen/models.go"] = indexer.ReasonGenerated
+	report.Skipped["big.json"] = indexer.ReasonTooLarge
+	report.Penalized["third_party/lib.go"] = indexer.ReasonVendored
+
+	out := renderStatusSummary(config.DefaultConfig(), &store.IndexStats{}, watcherRuntimeStatus{}, report)
+	if !strings.Contains(out, "Files skipped: 3 (generated: 2, too large: 1)") {
+		t.Fatalf("summary missing skip reasons: %q", out)
+	}
+	if !strings.Contains(out, "Files penalized: 1 (vendored: 1)") {
+		t.Fatalf("summary missing penalized files: %q", out)
+	}
+}
+
+func TestRenderStatusSummaryIncludesSecrets(t *testing.T) {
+	report := indexer.NewScanReport()
+	report.Secrets = map[string][]indexer.SecretFinding{
+		".env": {{Kind: "assignment", Line: 2}, {Kind: "assignment", Line: 5}},
+	}
+
+	out := renderStatusSummary(config.DefaultConfig(), &store.IndexStats{}, watcherRuntimeStatus{}, report)
+	if !strings.Contains(out, "Secrets found: 2 in 1 files (assignment: 2)") {
+		t.Fatalf("summary missing secrets: %q", out)
+	}
+	if !strings.Contains(out, "  .env: line 2, 5 (assignment: 2)") {
+		t.Fatalf("summary missing secret locations: %q", out)
+	}
+}
+
 func TestWatchUILogLevel(t *testing.T) {
 	tests := []struct {
 		line string
@@ -468,3 +524,23 @@ func TestWatchUILogSourceResolver(t *testing.T) {
 		t.Fatalf("resolve(fallback) = %q, want %q", got, watchUILogSystem)
 	}
 }
+
+func TestRenderStatusSummaryReportsCurrentProjectQueue(t *testing.T) {
+	watch := watcherRuntimeStatus{
+		running: true,
+		pid:     999,
+		control: &daemon.WatchStatus{Projects: []daemon.ProjectStatus{
+			{Root: "/repo", QueueDepth: 3, LastEvent: "MODIFY a.go"},
+			{Root: "/repo/.worktrees/feature", QueueDepth: 40, LastEvent: "MODIFY b.go"},
+		}},
+	}
+	watch.project = watch.control.Project("/repo")
+
+	out := renderStatusSummary(config.DefaultConfig(), &store.IndexStats{}, watch, nil)
+	if !strings.Contains(out, "Watcher queue: 3 pending") {
+		t.Fatalf("expected the current project's queue only, got %q", out)
+	}
+	if !strings.Contains(out, "Watcher last event: MODIFY a.go") {
+		t.Fatalf("expected the current project's last event, got %q", out)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -22,7 +23,9 @@ import (
 	"github.com/yoanbernabeu/grepai/embedder"
 	"github.com/yoanbernabeu/grepai/framework"
 	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/hooks"
 	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/internal/logging"
 	"github.com/yoanbernabeu/grepai/rpg"
 	"github.com/yoanbernabeu/grepai/store"
 	"github.com/yoanbernabeu/grepai/trace"
@@ -37,6 +40,19 @@ var (
 	watchStop       bool
 	watchWorkspace  string
 	watchNoUI       bool
+	watchPause      bool
+	watchResume     bool
+	watchReindex    string
+	watchReload     bool
+	watchInstallSvc bool
+	watchRemoveSvc  bool
+	watchLogFormat  string
+	watchLogLevel   string
+	watchLogMaxSize int
+	watchLogMaxKeep int
+	watchLogs       bool
+	watchLogsFollow bool
+	watchLogsLines  int
 )
 
 var (
@@ -73,16 +89,27 @@ Background mode:
   grepai watch --status                  Check if background watcher is running
   grepai watch --stop                    Stop the background watcher
 
+Controlling the background watcher:
+  grepai watch --pause                   Stop processing changes (they are queued)
+  grepai watch --resume                  Process queued and new changes again
+  grepai watch --reindex src/api         Re-index a file or directory, even if unchanged
+  grepai watch --reload                  Reload .grepai/config.yaml and restart watching
+
+Running as a user service (systemd on Linux, launchd on macOS):
+  grepai watch --install-service         Start at login and restart on failure
+  grepai watch --uninstall-service       Stop and remove the service
+
 Default log directories:
   Linux:   ~/.local/state/grepai/logs/grepai-watch.log (or $XDG_STATE_HOME)
   macOS:   ~/Library/Logs/grepai/grepai-watch.log
   Windows: %LOCALAPPDATA%\grepai\logs\grepai-watch.log
 
 Log management:
-  Logs are not rotated automatically. To prevent disk usage growth, periodically
-  truncate or archive the log file:
-    echo "" > ~/Library/Logs/grepai/grepai-watch.log  (macOS example)
-  Or set up logrotate (Linux) / Newsyslog (macOS) for automatic rotation.`,
+  grepai watch --logs                    Show the last 50 lines of the watcher log
+  grepai watch --logs -f                 Keep printing new lines
+  grepai watch --background --log-format json --log-level debug
+  Background watchers rotate their log file at --log-max-size MB (default 10)
+  and keep --log-max-files rotated files (default 5).`,
 	RunE: runWatch,
 }
 
@@ -93,9 +120,26 @@ func init() {
 	watchCmd.Flags().BoolVar(&watchStop, "stop", false, "Stop the background watcher")
 	watchCmd.Flags().StringVar(&watchWorkspace, "workspace", "", "Workspace name for multi-project mode")
 	watchCmd.Flags().BoolVar(&watchNoUI, "no-ui", false, "Disable interactive UI in foreground mode")
+	watchCmd.Flags().BoolVar(&watchPause, "pause", false, "Pause event processing in the background watcher")
+	watchCmd.Flags().BoolVar(&watchResume, "resume", false, "Resume event processing in the background watcher")
+	watchCmd.Flags().StringVar(&watchReindex, "reindex", "", "Re-index a file or directory in the background watcher")
+	watchCmd.Flags().BoolVar(&watchReload, "reload", false, "Reload configuration in the background watcher")
+	watchCmd.Flags().BoolVar(&watchInstallSvc, "install-service", false, "Install and start the watcher as a user service (systemd or launchd)")
+	watchCmd.Flags().BoolVar(&watchRemoveSvc, "uninstall-service", false, "Stop and remove the watcher user service")
+	watchCmd.Flags().StringVar(&watchLogFormat, "log-format", logging.FormatText, "Log format of the background watcher: text or json")
+	watchCmd.Flags().StringVar(&watchLogLevel, "log-level", defaultWatchLogLevel, "Minimum log level of the background watcher: debug, info, warn or error")
+	watchCmd.Flags().IntVar(&watchLogMaxSize, "log-max-size", defaultWatchLogMaxSizeMB, "Rotate the log file when it reaches this size in MB (0 disables rotation)")
+	watchCmd.Flags().IntVar(&watchLogMaxKeep, "log-max-files", defaultWatchLogMaxFiles, "Number of rotated log files to keep")
+	watchCmd.Flags().BoolVar(&watchLogs, "logs", false, "Show the background watcher logs")
+	watchCmd.Flags().BoolVarP(&watchLogsFollow, "follow", "f", false, "Keep printing new log lines (with --logs)")
+	watchCmd.Flags().IntVar(&watchLogsLines, "lines", defaultWatchLogsLines, "Number of log lines to show (with --logs)")
 }
 
 func runWatch(cmd *cobra.Command, args []string) error {
+	if err := validateWatchLogFlags(); err != nil {
+		return err
+	}
+
 	// Validate mutually exclusive flags
 	activeFlags := 0
 	if watchBackground {
@@ -107,8 +151,24 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	if watchStop {
 		activeFlags++
 	}
+	controlReq, hasControlReq, err := watchControlRequestFromFlags()
+	if err != nil {
+		return err
+	}
+	if hasControlReq {
+		activeFlags++
+	}
+	if watchInstallSvc {
+		activeFlags++
+	}
+	if watchRemoveSvc {
+		activeFlags++
+	}
+	if watchLogs {
+		activeFlags++
+	}
 	if activeFlags > 1 {
-		return fmt.Errorf("flags --background, --status, and --stop are mutually exclusive")
+		return fmt.Errorf("flags --background, --status, --stop, --pause, --resume, --reindex, --reload, --install-service, --uninstall-service and --logs are mutually exclusive")
 	}
 
 	// Determine log directory
@@ -120,9 +180,15 @@ func runWatch(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to get default log directory: %w", err)
 		}
 	}
+	if absLogDir, err := filepath.Abs(logDir); err == nil {
+		logDir = absLogDir
+	}
 
 	// Workspace mode
 	if watchWorkspace != "" {
+		if hasControlReq && controlReq.Command == daemon.ControlReload {
+			return fmt.Errorf("--%s is not supported with --workspace; restart the watcher instead", controlReq.Command)
+		}
 		return runWorkspaceWatch(logDir)
 	}
 
@@ -143,6 +209,28 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		return showWatchStatus(logDir, worktreeID)
 	}
 
+	// Handle --logs flag
+	if watchLogs {
+		return showWatchLogs(resolveWatchLogFile(logDir, worktreeID))
+	}
+
+	// Handle --pause, --resume, --reindex and --reload
+	if hasControlReq {
+		return runWatchControlCommand(logDir, worktreeID, controlReq)
+	}
+
+	// Handle --install-service and --uninstall-service
+	if watchInstallSvc {
+		return installProjectWatchService(logDir, worktreeID)
+	}
+	if watchRemoveSvc {
+		projectRoot, err := config.FindProjectRoot()
+		if err != nil {
+			return err
+		}
+		return uninstallWatchService(daemon.ProjectServiceName(projectRoot))
+	}
+
 	// Handle --stop flag
 	if watchStop {
 		projectRoot, rootErr := config.FindProjectRoot()
@@ -245,6 +333,11 @@ func showWatchStatus(logDir, worktreeID string) error {
 		fmt.Printf("Worktree ID: %s\n", worktreeID)
 	}
 
+	// Watchers started by older versions have no control socket
+	if resp, err := sendWatchControl(logDir, worktreeID, daemon.ControlRequest{Command: daemon.ControlStatus}); err == nil && resp.Status != nil {
+		printWatchControlStatus(resp.Status)
+	}
+
 	return nil
 }
 
@@ -291,8 +384,11 @@ func stopWatchDaemon(logDir, worktreeID string) (bool, error) {
 	}
 
 	fmt.Printf("Stopping background watcher (PID %d)...\n", pid)
-	if err := daemon.StopProcess(pid); err != nil {
-		return false, fmt.Errorf("failed to stop process: %w", err)
+	if _, err := sendWatchControl(logDir, worktreeID, daemon.ControlRequest{Command: daemon.ControlStop}); err != nil {
+		// No control socket: fall back to a signal
+		if err := daemon.StopProcess(pid); err != nil {
+			return false, fmt.Errorf("failed to stop process: %w", err)
+		}
 	}
 
 	// Wait for process to stop with timeout
@@ -362,6 +458,7 @@ func startBackgroundWatch(logDir, worktreeID string) error {
 	if watchLogDir != "" {
 		args = append(args, "--log-dir", watchLogDir)
 	}
+	args = append(args, watchLogArgs()...)
 
 	// Spawn background process
 	var childPID int
@@ -597,7 +694,7 @@ func (m *rpgRealtimeManager) Snapshot() (dirtyFiles int, dirtyPersist bool, last
 	return len(m.dirtyFiles), m.dirtyPersist, m.lastDerivedRun, m.lastPersistRun
 }
 
-func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolStore trace.SymbolStore, rpgEncoder *rpg.RPGEncoder, rpgStore rpg.RPGStore, watchCfg config.WatchConfig, manager *rpgRealtimeManager) {
+func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolStore trace.SymbolStore, rpgEncoder *rpg.RPGEncoder, rpgStore rpg.RPGStore, watchCfg config.WatchConfig, manager *rpgRealtimeManager, hookRunner *hooks.Runner, watchMetrics *watchMetrics) {
 	if manager == nil || rpgEncoder == nil || rpgStore == nil || symbolStore == nil {
 		return
 	}
@@ -617,7 +714,7 @@ func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolSto
 
 			case <-reconcileTicker.C:
 				manager.ScheduleFullReconcile()
-				log.Printf("rpg_full_reconcile_triggered=true project=%s reason=periodic", projectLabel)
+				slog.Info("RPG full reconcile triggered", "project", projectLabel, "reason", "periodic")
 
 			case <-derivedTicker.C:
 				changedFiles, full := manager.NextDerivedBatch(watchCfg.RPGMaxDirtyFilesPerBatch)
@@ -639,29 +736,36 @@ func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolSto
 					failures := manager.MarkDerivedFailure(changedFiles, full)
 					if failures >= rpgDerivedFailureThreshold {
 						manager.ScheduleFullReconcile()
-						log.Printf("rpg_full_reconcile_triggered=true project=%s reason=retry_threshold failures=%d", projectLabel, failures)
+						slog.Info("RPG full reconcile triggered", "project", projectLabel, "reason", "retry_threshold", "failures", failures)
 					}
+					fireWatchHookError(hookRunner, "", err)
 					dirtyCount, _, _, _ := manager.Snapshot()
-					log.Printf("Warning: rpg_derived_refresh_ms=%d project=%s mode=%s changed_files=%d rpg_dirty_files_count=%d err=%v",
-						time.Since(start).Milliseconds(),
-						projectLabel,
-						mode,
-						len(changedFiles),
-						dirtyCount,
-						err,
+					slog.Warn("RPG derived edge refresh failed",
+						"project", projectLabel,
+						"mode", mode,
+						"rpg_derived_refresh_ms", time.Since(start).Milliseconds(),
+						"changed_files", len(changedFiles),
+						"rpg_dirty_files_count", dirtyCount,
+						"err", err,
 					)
 					continue
 				}
 
+				if watchMetrics != nil {
+					watchMetrics.rpgRefresh.Observe(time.Since(start).Seconds(), canonicalPath(projectLabel), mode)
+				}
 				manager.MarkDerivedSuccess()
 				manager.MarkPersistDirty()
+				if full {
+					hookRunner.Fire(hooks.Payload{Event: hooks.EventRPGReconciled, Mode: mode, DurationMs: time.Since(start).Milliseconds()})
+				}
 				dirtyCount, _, _, _ := manager.Snapshot()
-				log.Printf("rpg_derived_refresh_ms=%d project=%s mode=%s changed_files=%d rpg_dirty_files_count=%d",
-					time.Since(start).Milliseconds(),
-					projectLabel,
-					mode,
-					len(changedFiles),
-					dirtyCount,
+				slog.Info("RPG derived edges refreshed",
+					"project", projectLabel,
+					"mode", mode,
+					"rpg_derived_refresh_ms", time.Since(start).Milliseconds(),
+					"changed_files", len(changedFiles),
+					"rpg_dirty_files_count", dirtyCount,
 				)
 
 			case <-persistTicker.C:
@@ -671,9 +775,12 @@ func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolSto
 
 				start := time.Now()
 				if err := rpgStore.Persist(ctx); err != nil {
-					log.Printf("Warning: rpg_persist_ms=%d project=%s err=%v", time.Since(start).Milliseconds(), projectLabel, err)
+					slog.Warn("RPG persist failed", "project", projectLabel, "rpg_persist_ms", time.Since(start).Milliseconds(), "err", err)
 					continue
 				}
+				if watchMetrics != nil {
+					watchMetrics.rpgPersist.Observe(time.Since(start).Seconds(), canonicalPath(projectLabel))
+				}
 				manager.MarkPersisted()
 
 				_, _, lastDerived, _ := manager.Snapshot()
@@ -681,7 +788,7 @@ func startRPGRealtimeWorkers(ctx context.Context, projectLabel string, symbolSto
 				if !lastDerived.IsZero() {
 					lagMs = time.Since(lastDerived).Milliseconds()
 				}
-				log.Printf("rpg_persist_ms=%d project=%s persist_lag_ms=%d", time.Since(start).Milliseconds(), projectLabel, lagMs)
+				slog.Debug("RPG persisted", "project", projectLabel, "rpg_persist_ms", time.Since(start).Milliseconds(), "persist_lag_ms", lagMs)
 			}
 		}
 	}()
@@ -742,7 +849,7 @@ func runWatchLoop(ctx context.Context, st store.VectorStore, symbolStore *trace.
 			}
 
 		case event := <-w.Events():
-			handleFileEvent(ctx, idx, scanner, extractor, symbolStore, nil, nil, tracedLanguages, projectRoot, cfg, &lastConfigWrite, nil, event, nil, nil, processors...)
+			handleFileEvent(ctx, idx, scanner, extractor, symbolStore, nil, nil, tracedLanguages, projectRoot, cfg, &lastConfigWrite, nil, nil, event, nil, nil, processors...)
 		}
 	}
 }
@@ -789,15 +896,33 @@ func runInitialScan(ctx context.Context, idx *indexer.Indexer, scanner *indexer.
 	}
 
 	if err != nil {
+		if ctx.Err() != nil {
+			// Interrupted (Ctrl-C, SIGTERM or stop file): saved files were
+			// checkpointed and the next start resumes from there.
+			if !isBackgroundChild {
+				fmt.Println("Indexing interrupted; progress saved, it will resume on next start.")
+			} else {
+				log.Println("Indexing interrupted; progress saved, it will resume on next start.")
+			}
+			return nil, ctx.Err()
+		}
 		return nil, fmt.Errorf("initial indexing failed: %w", err)
 	}
 
 	if !isBackgroundChild {
-		fmt.Printf("Initial scan complete: %d files indexed, %d chunks created, %d files removed, %d skipped (took %s)\n",
-			stats.FilesIndexed, stats.ChunksCreated, stats.FilesRemoved, stats.FilesSkipped, stats.Duration.Round(time.Millisecond))
+		fmt.Printf("Initial scan complete: %d files indexed, %d chunks created, %d files removed, %d moved, %d skipped (took %s)\n",
+			stats.FilesIndexed, stats.ChunksCreated, stats.FilesRemoved, stats.FilesMoved, stats.FilesSkipped, stats.Duration.Round(time.Millisecond))
 	} else {
-		log.Printf("Initial scan complete: %d files indexed, %d chunks created, %d files removed, %d skipped (took %s)",
-			stats.FilesIndexed, stats.ChunksCreated, stats.FilesRemoved, stats.FilesSkipped, stats.Duration.Round(time.Millisecond))
+		log.Printf("Initial scan complete: %d files indexed, %d chunks created, %d files removed, %d moved, %d skipped (took %s)",
+			stats.FilesIndexed, stats.ChunksCreated, stats.FilesRemoved, stats.FilesMoved, stats.FilesSkipped, stats.Duration.Round(time.Millisecond))
+	}
+
+	// Moved files keep their symbols; the content hash check below then
+	// skips re-extracting them.
+	for _, move := range stats.MovedFiles {
+		if err := symbolStore.MoveFile(ctx, move.From, move.To); err != nil {
+			log.Printf("Warning: failed to move symbols of %s to %s: %v", move.From, move.To, err)
+		}
 	}
 
 	// Index symbols for traced languages
@@ -926,6 +1051,35 @@ func canonicalPath(path string) string {
 	return filepath.Clean(path)
 }
 
+// newRPGEncoder returns the RPG encoder of a project, extracting features
+// as configured by cfg.RPG.
+func newRPGEncoder(cfg *config.Config, rpgStore rpg.RPGStore, projectRoot string) *rpg.RPGEncoder {
+	var featureExtractor rpg.FeatureExtractor
+	switch cfg.RPG.FeatureMode {
+	case "llm", "hybrid":
+		if cfg.RPG.LLMEndpoint == "" || cfg.RPG.LLMModel == "" {
+			log.Printf("Warning: RPG feature_mode=%q but llm_endpoint or llm_model is empty for %s, falling back to local extractor", cfg.RPG.FeatureMode, projectRoot)
+			featureExtractor = rpg.NewLocalExtractor()
+		} else {
+			featureExtractor = rpg.NewLLMExtractor(rpg.LLMExtractorConfig{
+				Provider: cfg.RPG.LLMProvider,
+				Model:    cfg.RPG.LLMModel,
+				Endpoint: cfg.RPG.LLMEndpoint,
+				APIKey:   cfg.RPG.LLMAPIKey,
+				Timeout:  time.Duration(cfg.RPG.LLMTimeoutMs) * time.Millisecond,
+			})
+		}
+	default:
+		featureExtractor = rpg.NewLocalExtractor()
+	}
+
+	return rpg.NewRPGEncoder(rpgStore, featureExtractor, projectRoot, rpg.RPGEncoderConfig{
+		DriftThreshold:       cfg.RPG.DriftThreshold,
+		MaxTraversalDepth:    cfg.RPG.MaxTraversalDepth,
+		FeatureGroupStrategy: cfg.RPG.FeatureGroupStrategy,
+	})
+}
+
 func buildFrameworkRegistry(cfg *config.Config) *framework.ProcessorRegistry {
 	regCfg := framework.RegistryConfig{
 		Enabled:      cfg.Framework.Enabled,
@@ -946,6 +1100,133 @@ func buildFrameworkRegistry(cfg *config.Config) *framework.ProcessorRegistry {
 	)
 }
 
+func buildExtractorRegistry(cfg *config.Config) *indexer.ExtractorRegistry {
+	disabled := make(map[string]bool, len(cfg.Indexing.DisabledExtractors))
+	for _, name := range cfg.Indexing.DisabledExtractors {
+		disabled[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	var extractors []indexer.ContentExtractor
+	for _, e := range indexer.DefaultExtractors() {
+		if !disabled[e.Name()] {
+			extractors = append(extractors, e)
+		}
+	}
+	return indexer.NewExtractorRegistry(extractors...)
+}
+
+// projectIndex is what indexes the files of a project: its stores, scanner,
+// indexer, symbol extractor and, when RPG is enabled, RPG encoder.
+type projectIndex struct {
+	store           store.VectorStore
+	ignore          *indexer.IgnoreMatcher
+	extensions      map[string]bool
+	scanner         *indexer.Scanner
+	processors      *framework.ProcessorRegistry
+	idx             *indexer.Indexer
+	symbolStore     *trace.GOBSymbolStore
+	extractor       *trace.RegexExtractor
+	rpgStore        rpg.RPGStore
+	rpgEncoder      *rpg.RPGEncoder
+	tracedLanguages []string
+}
+
+// openProjectIndex opens the indexes of projectRoot configured by cfg and
+// sets up what updates them. Close releases them.
+func openProjectIndex(ctx context.Context, projectRoot string, cfg *config.Config, emb embedder.Embedder) (*projectIndex, error) {
+	st, err := initializeStore(ctx, cfg, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, cfg.Ignore, cfg.ExternalGitignore)
+	if err != nil {
+		_ = st.Close()
+		return nil, fmt.Errorf("failed to initialize ignore matcher: %w", err)
+	}
+
+	p := &projectIndex{
+		store:           st,
+		ignore:          ignoreMatcher,
+		extensions:      indexer.ExtensionSet(cfg.Indexing.Extensions, cfg.Indexing.ExcludeExtensions),
+		processors:      buildFrameworkRegistry(cfg),
+		extractor:       trace.NewRegexExtractor(),
+		tracedLanguages: cfg.Trace.TracedLanguages(),
+	}
+
+	p.scanner = indexer.NewScanner(projectRoot, ignoreMatcher)
+	p.scanner.SetExtensions(p.extensions)
+	p.scanner.SetFilePolicy(filePolicyFromConfig(cfg))
+	restoreScanFindings(projectRoot, p.scanner)
+
+	chunker := indexer.NewChunker(cfg.Chunking.Size, cfg.Chunking.Overlap)
+	p.idx = indexer.NewIndexer(projectRoot, st, emb, chunker, p.scanner, cfg.Watch.LastIndexTime, p.processors)
+	p.idx.SetExtractors(buildExtractorRegistry(cfg))
+	p.idx.SetCheckpoint(checkpointFromConfig(cfg, projectRoot))
+
+	p.symbolStore = trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
+	if err := p.symbolStore.Load(ctx); err != nil {
+		log.Printf("Warning: failed to load symbol index for %s: %v", projectRoot, err)
+	}
+
+	if cfg.RPG.Enabled {
+		p.rpgStore = rpg.NewGOBRPGStore(config.GetRPGIndexPath(projectRoot))
+		if err := p.rpgStore.Load(ctx); err != nil {
+			log.Printf("Warning: failed to load RPG index for %s: %v", projectRoot, err)
+		}
+		p.rpgEncoder = newRPGEncoder(cfg, p.rpgStore, projectRoot)
+	}
+	return p, nil
+}
+
+// Close closes the stores of the project.
+func (p *projectIndex) Close() {
+	if p.rpgStore != nil {
+		_ = p.rpgStore.Close()
+	}
+	_ = p.symbolStore.Close()
+	_ = p.store.Close()
+}
+
+func filePolicyFromConfig(cfg *config.Config) indexer.FilePolicyConfig {
+	return indexer.FilePolicyConfig{
+		MaxFileSize: int64(cfg.Indexing.MaxFileSizeKB) * 1024,
+		LargeFiles:  cfg.Indexing.LargeFiles,
+		Generated:   cfg.Indexing.Generated,
+		Vendored:    cfg.Indexing.Vendored,
+		Secrets:     cfg.Indexing.Secrets,
+	}
+}
+
+// checkpointFromConfig returns the checkpoint settings of the initial scan.
+func checkpointFromConfig(cfg *config.Config, projectRoot string) indexer.CheckpointConfig {
+	return indexer.CheckpointConfig{
+		JournalPath: config.GetIndexJournalPath(projectRoot),
+		Files:       cfg.Indexing.CheckpointFiles,
+		Interval:    time.Duration(cfg.Indexing.CheckpointIntervalSec) * time.Second,
+	}
+}
+
+// restoreScanFindings gives scanner the credentials found by the previous
+// run, which unchanged files keep since they are not read again, and the
+// files it failed to index, which are retried.
+func restoreScanFindings(projectRoot string, scanner *indexer.Scanner) {
+	report, err := indexer.LoadScanReport(config.GetScanReportPath(projectRoot))
+	if err != nil {
+		log.Printf("Warning: failed to load scan report for %s: %v", projectRoot, err)
+		return
+	}
+	scanner.SetSecretFindings(report.Secrets)
+	scanner.SetIndexFailures(report.Failed)
+}
+
+// saveScanReport persists skipped/penalized/failed files for `grepai status` and search.
+func saveScanReport(projectRoot string, scanner *indexer.Scanner) {
+	if err := indexer.SaveScanReport(config.GetScanReportPath(projectRoot), scanner.Report()); err != nil {
+		log.Printf("Warning: failed to save scan report for %s: %v", projectRoot, err)
+	}
+}
+
 // watchProject runs the full watch lifecycle for a single project.
 // The embedder is shared across all projects to avoid duplicate connections.
 // If onReady is non-nil, it is called once after initial indexing and watcher start.
@@ -961,10 +1242,10 @@ const (
 )
 
 func watchProject(ctx context.Context, projectRoot string, emb embedder.Embedder, isBackgroundChild bool, onReady func()) error {
-	return watchProjectWithEventObserver(ctx, projectRoot, emb, isBackgroundChild, onReady, nil, nil, nil, nil, nil, nil)
+	return watchProjectWithEventObserver(ctx, projectRoot, emb, isBackgroundChild, watchServices{}, onReady, nil, nil, nil, nil, nil, nil)
 }
 
-func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb embedder.Embedder, isBackgroundChild bool, onReady func(), onEvent watchEventObserver, onScan func(current, total int, file string), onEmbed func(info indexer.BatchProgressInfo), onRPG func(step string, current, total int), onActivity watchActivityObserver, onStats watchStatsObserver) error {
+func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb embedder.Embedder, isBackgroundChild bool, services watchServices, onReady func(), onEvent watchEventObserver, onScan func(current, total int, file string), onEmbed func(info indexer.BatchProgressInfo), onRPG func(step string, current, total int), onActivity watchActivityObserver, onStats watchStatsObserver) error {
 	// Load configuration
 	cfg, err := config.Load(projectRoot)
 	if err != nil {
@@ -973,88 +1254,46 @@ func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb
 
 	log.Printf("Watching project: %s (backend: %s)", projectRoot, cfg.Store.Backend)
 
-	// Initialize store
-	st, err := initializeStore(ctx, cfg, projectRoot)
+	// Refuses inline reindexes of MCP servers while the project is watched
+	releaseWatchLock, err := daemon.HoldWatchLock(config.GetWatchLockPath(projectRoot))
 	if err != nil {
 		return err
 	}
-	defer st.Close()
-
-	// Initialize ignore matcher
-	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, cfg.Ignore, cfg.ExternalGitignore)
-	if err != nil {
-		return fmt.Errorf("failed to initialize ignore matcher: %w", err)
-	}
-
-	// Initialize scanner
-	scanner := indexer.NewScanner(projectRoot, ignoreMatcher)
-
-	// Initialize chunker
-	chunker := indexer.NewChunker(cfg.Chunking.Size, cfg.Chunking.Overlap)
-	processorRegistry := buildFrameworkRegistry(cfg)
+	defer releaseWatchLock()
 
-	// Initialize indexer
-	idx := indexer.NewIndexer(projectRoot, st, emb, chunker, scanner, cfg.Watch.LastIndexTime, processorRegistry)
+	hookRunner := startWatchHooks(projectRoot, cfg.Watch.Hooks, services.hookObserver)
+	defer hookRunner.Close()
 
-	// Initialize symbol store and extractor
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
-		log.Printf("Warning: failed to load symbol index for %s: %v", projectRoot, err)
-	}
-	defer symbolStore.Close()
-
-	extractor := trace.NewRegexExtractor()
-
-	// Initialize RPG if enabled.
-	var rpgEncoder *rpg.RPGEncoder
-	var rpgStore rpg.RPGStore
-	if cfg.RPG.Enabled {
-		rpgStore = rpg.NewGOBRPGStore(config.GetRPGIndexPath(projectRoot))
-		if err := rpgStore.Load(ctx); err != nil {
-			log.Printf("Warning: failed to load RPG index for %s: %v", projectRoot, err)
-		}
-
-		var featureExtractor rpg.FeatureExtractor
-		switch cfg.RPG.FeatureMode {
-		case "llm", "hybrid":
-			if cfg.RPG.LLMEndpoint == "" || cfg.RPG.LLMModel == "" {
-				log.Printf("Warning: RPG feature_mode=%q but llm_endpoint or llm_model is empty, falling back to local extractor", cfg.RPG.FeatureMode)
-				featureExtractor = rpg.NewLocalExtractor()
-			} else {
-				featureExtractor = rpg.NewLLMExtractor(rpg.LLMExtractorConfig{
-					Provider: cfg.RPG.LLMProvider,
-					Model:    cfg.RPG.LLMModel,
-					Endpoint: cfg.RPG.LLMEndpoint,
-					APIKey:   cfg.RPG.LLMAPIKey,
-					Timeout:  time.Duration(cfg.RPG.LLMTimeoutMs) * time.Millisecond,
-				})
-			}
-		default:
-			featureExtractor = rpg.NewLocalExtractor()
-		}
-
-		rpgEncoder = rpg.NewRPGEncoder(rpgStore, featureExtractor, projectRoot, rpg.RPGEncoderConfig{
-			DriftThreshold:       cfg.RPG.DriftThreshold,
-			MaxTraversalDepth:    cfg.RPG.MaxTraversalDepth,
-			FeatureGroupStrategy: cfg.RPG.FeatureGroupStrategy,
-		})
+	// Open the stores and set up the scanner, indexer and extractors
+	project, err := openProjectIndex(ctx, projectRoot, cfg, emb)
+	if err != nil {
+		return err
 	}
+	defer project.Close()
+	st, scanner, idx := project.store, project.scanner, project.idx
+	symbolStore, extractor, processorRegistry := project.symbolStore, project.extractor, project.processors
+	rpgStore, rpgEncoder, tracedLanguages := project.rpgStore, project.rpgEncoder, project.tracedLanguages
 
-	if rpgStore != nil {
-		defer rpgStore.Close()
+	watchMetrics := services.metrics
+	if watchMetrics != nil {
+		onStats = watchMetrics.wrapStatsObserver(onStats)
 	}
 
-	tracedLanguages := cfg.Trace.EnabledLanguages
-	if len(tracedLanguages) == 0 {
-		tracedLanguages = []string{".go", ".js", ".ts", ".jsx", ".tsx", ".vue", ".py", ".php", ".lua", ".java", ".cs", ".fs", ".fsx", ".fsi"}
-	}
-	// In multi-worktree mode callers pass isBackgroundChild=true for non-interactive output.
 	// Run initial scan and build symbol index.
 	// In multi-worktree mode callers pass isBackgroundChild=true for non-interactive output.
 	stats, err := runInitialScan(ctx, idx, scanner, extractor, symbolStore, tracedLanguages, cfg.Watch.LastIndexTime, isBackgroundChild, onScan, onEmbed, processorRegistry)
 	if err != nil {
+		if ctx.Err() == nil {
+			fireWatchHookError(hookRunner, "", err)
+		}
 		return err
 	}
+	saveScanReport(projectRoot, scanner)
+	hookRunner.Fire(scanCompletePayload(stats))
+	if watchMetrics != nil {
+		watchMetrics.observeScan(projectRoot, stats)
+		defer watchMetrics.registerStore(projectRoot, st)()
+	}
 
 	if stats.FilesIndexed > 0 || stats.ChunksCreated > 0 {
 		cfg.Watch.LastIndexTime = time.Now()
@@ -1066,9 +1305,11 @@ func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb
 	if rpgEncoder != nil {
 		if err := rpgEncoder.BuildFull(ctx, symbolStore, st, onRPG); err != nil {
 			log.Printf("Warning: failed to build RPG graph for %s: %v", projectRoot, err)
+			fireWatchHookError(hookRunner, "", err)
 		} else {
 			rpgStats := rpgEncoder.Stats()
 			log.Printf("RPG graph built for %s: %d nodes, %d edges", projectRoot, rpgStats.TotalNodes, rpgStats.TotalEdges)
+			hookRunner.Fire(hooks.Payload{Event: hooks.EventRPGReconciled, Mode: "build"})
 		}
 	}
 
@@ -1084,11 +1325,15 @@ func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb
 	}
 
 	// Initialize watcher
-	w, err := watcher.NewWatcher(projectRoot, ignoreMatcher, cfg.Watch.DebounceMs)
+	w, err := watcher.NewWatcher(projectRoot, project.ignore, cfg.Watch.DebounceMs)
 	if err != nil {
 		return fmt.Errorf("failed to initialize watcher for %s: %w", projectRoot, err)
 	}
 	defer w.Close()
+	w.SetExtensions(project.extensions)
+	w.SetMode(watcher.Mode(cfg.Watch.Mode), time.Duration(cfg.Watch.PollIntervalMs)*time.Millisecond)
+	w.SetIncludePaths(cfg.Watch.IncludePaths, time.Duration(cfg.Watch.ReconcileIntervalSec)*time.Second)
+	enableMoveDetection(ctx, w, st)
 
 	if err := w.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start watcher for %s: %w", projectRoot, err)
@@ -1098,8 +1343,14 @@ func watchProjectWithEventObserver(ctx context.Context, projectRoot string, emb
 		onReady()
 	}
 
+	// Answer queries from the loaded indexes while the loop runs
+	if queries := services.queries; queries != nil {
+		services.querySearcher = newWatchQuerySearcher(st, emb, cfg, scanner.Report())
+		defer queries.Register(canonicalPath(projectRoot), services.querySearcher, symbolStore)()
+	}
+
 	// Run watch loop (responds to ctx.Done() for graceful shutdown)
-	return runProjectWatchLoop(ctx, st, symbolStore, w, idx, scanner, extractor, rpgEncoder, rpgStore, tracedLanguages, projectRoot, cfg, onEvent, onActivity, onStats, processorRegistry)
+	return runProjectWatchLoop(ctx, st, symbolStore, w, idx, scanner, extractor, rpgEncoder, rpgStore, tracedLanguages, projectRoot, cfg, services, hookRunner, onEvent, onActivity, onStats, processorRegistry)
 }
 
 func emitInitialStatsSnapshot(ctx context.Context, vectorStore store.VectorStore, symbolStore trace.SymbolStore, projectRoot string, onStats watchStatsObserver) {
@@ -1137,18 +1388,32 @@ func emitInitialStatsSnapshot(ctx context.Context, vectorStore store.VectorStore
 	}
 }
 
-func runProjectWatchLoop(ctx context.Context, st store.VectorStore, symbolStore *trace.GOBSymbolStore, w *watcher.Watcher, idx *indexer.Indexer, scanner *indexer.Scanner, extractor *trace.RegexExtractor, rpgEncoder *rpg.RPGEncoder, rpgStore rpg.RPGStore, tracedLanguages []string, projectRoot string, cfg *config.Config, onEvent watchEventObserver, onActivity watchActivityObserver, onStats watchStatsObserver, processors ...*framework.ProcessorRegistry) error {
+func runProjectWatchLoop(ctx context.Context, st store.VectorStore, symbolStore *trace.GOBSymbolStore, w *watcher.Watcher, idx *indexer.Indexer, scanner *indexer.Scanner, extractor *trace.RegexExtractor, rpgEncoder *rpg.RPGEncoder, rpgStore rpg.RPGStore, tracedLanguages []string, projectRoot string, cfg *config.Config, services watchServices, hookRunner *hooks.Runner, onEvent watchEventObserver, onActivity watchActivityObserver, onStats watchStatsObserver, processors ...*framework.ProcessorRegistry) error {
 	persistTicker := time.NewTicker(30 * time.Second)
 	defer persistTicker.Stop()
+	queueTicker := time.NewTicker(2 * time.Second)
+	defer queueTicker.Stop()
+	var lastQueueStats watcher.Stats
 
 	var lastConfigWrite time.Time
 	var rpgManager *rpgRealtimeManager
 	if rpgEncoder != nil && rpgStore != nil {
 		rpgManager = newRPGRealtimeManager(cfg.Watch.RPGMaxDirtyFilesPerBatch)
-		startRPGRealtimeWorkers(ctx, projectRoot, symbolStore, rpgEncoder, rpgStore, cfg.Watch, rpgManager)
+		startRPGRealtimeWorkers(ctx, projectRoot, symbolStore, rpgEncoder, rpgStore, cfg.Watch, rpgManager, hookRunner, services.metrics)
 	}
 
+	control := services.control
+	reindexCh, detach := control.attach(projectRoot)
+	defer detach()
+
 	for {
+		// While paused, events wait in the watcher queue
+		events := w.Events()
+		paused, pauseChanged := control.pauseState()
+		if paused {
+			events = nil
+		}
+
 		select {
 		case <-ctx.Done():
 			if err := st.Persist(ctx); err != nil {
@@ -1176,16 +1441,66 @@ func runProjectWatchLoop(ctx context.Context, st store.VectorStore, symbolStore
 					log.Printf("Warning: failed to persist RPG graph for %s: %v", projectRoot, err)
 				}
 			}
+			// Files indexed since may have been penalized
+			services.querySearcher.refresh(scanner.Report())
 
-		case event := <-w.Events():
+		case <-queueTicker.C:
+			current := w.Stats()
+			if onStats != nil && current != lastQueueStats {
+				onStats(projectRoot, watchQueueStatsDelta(lastQueueStats, current))
+			}
+			lastQueueStats = current
+
+		case <-pauseChanged:
+
+		case relPath := <-reindexCh:
+			files, err := watchReindexTargets(scanner, projectRoot, relPath)
+			if err != nil {
+				log.Printf("Failed to reindex %s: %v", relPath, err)
+				control.reindexDone(projectRoot)
+				continue
+			}
+			log.Printf("Reindexing %d file(s) under %s", len(files), relPath)
+			for _, file := range files {
+				if ctx.Err() != nil {
+					break
+				}
+				invalidateIndexedFile(ctx, st, file)
+				handleFileEvent(ctx, idx, scanner, extractor, symbolStore, rpgEncoder, st, tracedLanguages, projectRoot, cfg, &lastConfigWrite, rpgManager, hookRunner,
+					watcher.FileEvent{Type: watcher.EventModify, Path: file}, onActivity, onStats, processors...)
+			}
+			// Requested reindexes are persisted right away so that readers
+			// of the index files, such as MCP tools, see them once done.
+			if err := st.Persist(ctx); err != nil {
+				log.Printf("Warning: failed to persist index for %s: %v", projectRoot, err)
+			}
+			if err := symbolStore.Persist(ctx); err != nil {
+				log.Printf("Warning: failed to persist symbol index for %s: %v", projectRoot, err)
+			}
+			saveScanReport(projectRoot, scanner)
+			services.querySearcher.refresh(scanner.Report())
+			control.reindexDone(projectRoot)
+
+		case event := <-events:
 			if onEvent != nil {
 				onEvent(projectRoot, event)
 			}
-			handleFileEvent(ctx, idx, scanner, extractor, symbolStore, rpgEncoder, st, tracedLanguages, projectRoot, cfg, &lastConfigWrite, rpgManager, event, onActivity, onStats, processors...)
+			handleFileEvent(ctx, idx, scanner, extractor, symbolStore, rpgEncoder, st, tracedLanguages, projectRoot, cfg, &lastConfigWrite, rpgManager, hookRunner, event, onActivity, onStats, processors...)
 		}
 	}
 }
 
+// watchQueueStatsDelta converts watcher pipeline counters into a stats delta.
+func watchQueueStatsDelta(prev, current watcher.Stats) watchStatsDelta {
+	return watchStatsDelta{
+		Queue:           true,
+		QueueDepth:      current.Queued,
+		EventsCoalesced: int(current.Coalesced - prev.Coalesced),
+		Overflows:       int(current.Overflows - prev.Overflows),
+		Rescans:         int(current.Rescans - prev.Rescans),
+	}
+}
+
 type watchProjectRunner func(ctx context.Context, projectRoot string, emb embedder.Embedder, isBackgroundChild bool, onReady func()) error
 
 func startProjectWatch(g *errgroup.Group, gCtx context.Context, projectRoot string, emb embedder.Embedder, makeOnReady func() func(), startFn watchProjectRunner) {
@@ -1224,8 +1539,43 @@ type watchSupervisorSessionRunner func(
 
 type watchInitialReadySelector func(mainRoot, projectRoot string) bool
 
+// watchServices are the daemon services and metrics the watch sessions of a
+// supervisor report to. Nil fields are disabled.
+type watchServices struct {
+	control      *watchControl
+	queries      *daemon.QueryServer
+	metrics      *watchMetrics
+	hookObserver watchHookObserver
+
+	// querySearcher answers the queries of the current session's project.
+	querySearcher *watchQuerySearcher
+}
+
+// runSession is the default watchSupervisorSessionRunner: it watches
+// projectRoot, reporting to s.
+func (s watchServices) runSession(
+	ctx context.Context,
+	projectRoot string,
+	emb embedder.Embedder,
+	isBackgroundChild bool,
+	onReady func(),
+	onEvent watchSessionEventObserver,
+	onScan func(current, total int, file string),
+	onEmbed func(info indexer.BatchProgressInfo),
+	onRPG func(step string, current, total int),
+	onActivity watchActivityObserver,
+	onStats watchStatsObserver,
+) error {
+	var observer watchEventObserver
+	if onEvent != nil {
+		observer = watchEventObserver(onEvent)
+	}
+	return watchProjectWithEventObserver(ctx, projectRoot, emb, isBackgroundChild, s, onReady, observer, onScan, onEmbed, onRPG, onActivity, onStats)
+}
+
 type dynamicWatchSupervisorConfig struct {
 	isBackgroundChild     bool
+	services              watchServices
 	initialLinkedWorktree []string
 	discoverWorktrees     func(projectRoot string) []string
 	sessionRunner         watchSupervisorSessionRunner
@@ -1241,6 +1591,7 @@ type dynamicWatchSupervisorConfig struct {
 	initialReadySelector  watchInitialReadySelector
 	reconcileInterval     time.Duration
 	retryBackoff          func(attempt int) time.Duration
+	restartSignal         <-chan struct{}
 }
 
 type dynamicWatchSupervisorOption func(*dynamicWatchSupervisorConfig)
@@ -1261,6 +1612,12 @@ func withWatchSupervisorInitialLinkedWorktrees(linked []string) dynamicWatchSupe
 	}
 }
 
+func withWatchSupervisorServices(services watchServices) dynamicWatchSupervisorOption {
+	return func(cfg *dynamicWatchSupervisorConfig) {
+		cfg.services = services
+	}
+}
+
 func withWatchSupervisorDiscoverWorktrees(discover func(projectRoot string) []string) dynamicWatchSupervisorOption {
 	return func(cfg *dynamicWatchSupervisorConfig) {
 		cfg.discoverWorktrees = discover
@@ -1341,6 +1698,12 @@ func withWatchSupervisorStatsObserver(observer watchStatsObserver) dynamicWatchS
 	}
 }
 
+func withWatchSupervisorRestartSignal(restart <-chan struct{}) dynamicWatchSupervisorOption {
+	return func(cfg *dynamicWatchSupervisorConfig) {
+		cfg.restartSignal = restart
+	}
+}
+
 func withWatchSupervisorRetryBackoff(backoff func(attempt int) time.Duration) dynamicWatchSupervisorOption {
 	return func(cfg *dynamicWatchSupervisorConfig) {
 		cfg.retryBackoff = backoff
@@ -1350,25 +1713,6 @@ func withWatchSupervisorRetryBackoff(backoff func(attempt int) time.Duration) dy
 func newDynamicWatchSupervisorConfig() dynamicWatchSupervisorConfig {
 	return dynamicWatchSupervisorConfig{
 		discoverWorktrees: discoverWorktreesForWatch,
-		sessionRunner: func(
-			ctx context.Context,
-			projectRoot string,
-			emb embedder.Embedder,
-			isBackgroundChild bool,
-			onReady func(),
-			onEvent watchSessionEventObserver,
-			onScan func(current, total int, file string),
-			onEmbed func(info indexer.BatchProgressInfo),
-			onRPG func(step string, current, total int),
-			onActivity watchActivityObserver,
-			onStats watchStatsObserver,
-		) error {
-			var observer watchEventObserver
-			if onEvent != nil {
-				observer = watchEventObserver(onEvent)
-			}
-			return watchProjectWithEventObserver(ctx, projectRoot, emb, isBackgroundChild, onReady, observer, onScan, onEmbed, onRPG, onActivity, onStats)
-		},
 		reconcileInterval: worktreeReconcileInterval,
 		retryBackoff:      computeWatchSessionRetryBackoff,
 	}
@@ -1406,6 +1750,14 @@ type watchStatsDelta struct {
 	SymbolsFound  int
 	SymbolsLost   int
 	Snapshot      bool
+
+	// Watcher event pipeline, reported with Queue set. QueueDepth is a
+	// gauge, the other counters are deltas.
+	Queue           bool
+	QueueDepth      int
+	EventsCoalesced int
+	Overflows       int
+	Rescans         int
 }
 
 type watchActivityObserver func(state, file string)
@@ -1420,25 +1772,7 @@ func runDynamicWatchSupervisor(ctx context.Context, mainRoot string, emb embedde
 		cfg.discoverWorktrees = discoverWorktreesForWatch
 	}
 	if cfg.sessionRunner == nil {
-		cfg.sessionRunner = func(
-			ctx context.Context,
-			projectRoot string,
-			emb embedder.Embedder,
-			isBackgroundChild bool,
-			onReady func(),
-			onEvent watchSessionEventObserver,
-			onScan func(current, total int, file string),
-			onEmbed func(info indexer.BatchProgressInfo),
-			onRPG func(step string, current, total int),
-			onActivity watchActivityObserver,
-			onStats watchStatsObserver,
-		) error {
-			var observer watchEventObserver
-			if onEvent != nil {
-				observer = watchEventObserver(onEvent)
-			}
-			return watchProjectWithEventObserver(ctx, projectRoot, emb, isBackgroundChild, onReady, observer, onScan, onEmbed, onRPG, onActivity, onStats)
-		}
+		cfg.sessionRunner = cfg.services.runSession
 	}
 	if cfg.reconcileInterval <= 0 {
 		cfg.reconcileInterval = worktreeReconcileInterval
@@ -1645,6 +1979,13 @@ func runDynamicWatchSupervisor(ctx context.Context, mainRoot string, emb embedde
 		case <-supervisorCtx.Done():
 			shutdownSessions("context canceled")
 			return nil
+		case <-cfg.restartSignal:
+			// Restart every session so that it reloads its configuration
+			for root, handle := range managed {
+				emitLifecycle(root, "restarting", "config reload")
+				handle.markedClose = true
+				handle.cancel()
+			}
 		case <-reconcileTicker.C:
 			nextDesired := buildWatchDesiredProjects(mainRoot, cfg.discoverWorktrees(mainRoot))
 			applyDesired(nextDesired)
@@ -1791,11 +2132,12 @@ func runWatchForeground() error {
 	var logDir string
 	var worktreeID string
 	if isBackgroundChild {
-		// Configure structured logging with timestamps for daemon mode
-		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-		log.SetPrefix("[grepai-watch] ")
+		restoreLogging, err := setupBackgroundWatchLogging()
+		if err != nil {
+			return fmt.Errorf("failed to set up logging: %w", err)
+		}
+		defer restoreLogging()
 
-		var err error
 		logDir = watchLogDir
 		if logDir == "" {
 			logDir, err = daemon.GetDefaultLogDir()
@@ -1862,14 +2204,17 @@ func runWatchForeground() error {
 			fmt.Println("RPG: disabled")
 		}
 	} else {
-		log.Printf("Starting grepai watch in %s", projectRoot)
-		log.Printf("Provider: %s (%s)", cfg.Embedder.Provider, cfg.Embedder.Model)
-		log.Printf("Backend: %s", cfg.Store.Backend)
+		attrs := []any{
+			"project", projectRoot,
+			"provider", cfg.Embedder.Provider,
+			"model", cfg.Embedder.Model,
+			"backend", cfg.Store.Backend,
+			"rpg", cfg.RPG.Enabled,
+		}
 		if cfg.RPG.Enabled {
-			log.Printf("RPG: enabled (feature_mode: %s, llm: %s/%s)", cfg.RPG.FeatureMode, cfg.RPG.LLMProvider, cfg.RPG.LLMModel)
-		} else {
-			log.Printf("RPG: disabled")
+			attrs = append(attrs, "rpg_feature_mode", cfg.RPG.FeatureMode, "rpg_llm", cfg.RPG.LLMProvider+"/"+cfg.RPG.LLMModel)
 		}
+		slog.Info("Starting grepai watch", attrs...)
 	}
 
 	var restoreLogs func()
@@ -1965,6 +2310,43 @@ func runWatchForeground() error {
 		})
 	}
 
+	// Serve the control socket used by 'grepai watch --status/--stop/--pause'
+	var services watchServices
+	if isBackgroundChild {
+		control := newWatchControl(canonicalPath(projectRoot), watchCancel)
+		socketPath := daemon.GetControlSocketPath(logDir)
+		if worktreeID != "" {
+			socketPath = daemon.GetWorktreeControlSocketPath(logDir, worktreeID)
+		}
+		server, err := daemon.ServeControl(socketPath, control)
+		if err != nil {
+			log.Printf("Warning: control socket unavailable: %v", err)
+		} else {
+			defer server.Close()
+			services.control = control
+		}
+	}
+
+	// Serve search, trace and refs queries from the loaded indexes
+	if isBackgroundChild && cfg.Watch.ServeQueries {
+		socketPath := daemon.GetQuerySocketPath(logDir)
+		if worktreeID != "" {
+			socketPath = daemon.GetWorktreeQuerySocketPath(logDir, worktreeID)
+		}
+		queries, err := daemon.ServeQueries(socketPath)
+		if err != nil {
+			log.Printf("Warning: query socket unavailable: %v", err)
+		} else {
+			defer queries.Close()
+			services.queries = queries
+		}
+	}
+
+	// Export indexing, queue and store metrics over HTTP
+	watchMetrics, stopMetrics := serveWatchMetrics(cfg)
+	defer stopMetrics()
+	services.metrics = watchMetrics
+
 	printLifecycle := func(project, state, note string) {
 		level := strings.ToUpper(state)
 		message := fmt.Sprintf("[%s] %s", level, project)
@@ -1978,11 +2360,10 @@ func runWatchForeground() error {
 		fmt.Println(message)
 	}
 
-	return runDynamicWatchSupervisor(
-		watchCtx,
-		projectRoot,
-		emb,
+	control := services.control
+	supervisorOpts := []dynamicWatchSupervisorOption{
 		withWatchSupervisorBackgroundChild(isBackgroundChild),
+		withWatchSupervisorServices(services),
 		withWatchSupervisorInitialLinkedWorktrees(linkedWorktrees),
 		withWatchSupervisorInitialReadySelector(func(mainRoot, currentRoot string) bool {
 			if !isBackgroundChild {
@@ -1994,12 +2375,24 @@ func runWatchForeground() error {
 			initialReadyObserver(initialTotalProjects)
 		}),
 		withWatchSupervisorLifecycleObserver(func(projectRoot, state, note string) {
+			if control != nil {
+				control.observeLifecycle(projectRoot, state, note)
+			}
 			switch state {
-			case "queued", "starting", "running", "retrying", "error", "removed", "stopped":
+			case "queued", "starting", "running", "restarting", "retrying", "error", "removed", "stopped":
 				printLifecycle(projectRoot, state, note)
 			}
 		}),
-	)
+	}
+	if control != nil {
+		supervisorOpts = append(supervisorOpts,
+			withWatchSupervisorEventObserver(control.observeEvent),
+			withWatchSupervisorStatsObserver(control.observeStats),
+			withWatchSupervisorRestartSignal(control.reload),
+		)
+	}
+
+	return runDynamicWatchSupervisor(watchCtx, projectRoot, emb, supervisorOpts...)
 }
 
 func extractSymbolsWithFramework(ctx context.Context, extractor trace.SymbolExtractor, filePath, source string, processors ...*framework.ProcessorRegistry) ([]trace.Symbol, []trace.Reference, error) {
@@ -2046,7 +2439,33 @@ func extractSymbolsWithFramework(ctx context.Context, extractor trace.SymbolExtr
 	return symbols, refs, nil
 }
 
-func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer.Scanner, extractor *trace.RegexExtractor, symbolStore *trace.GOBSymbolStore, rpgEncoder *rpg.RPGEncoder, vectorStore store.VectorStore, enabledLanguages []string, projectRoot string, cfg *config.Config, lastConfigWrite *time.Time, rpgManager *rpgRealtimeManager, event watcher.FileEvent, onActivity watchActivityObserver, onStats watchStatsObserver, processors ...*framework.ProcessorRegistry) {
+// handleFileEvent indexes the file changed by event. lastConfigWrite throttles
+// the updates of last_index_time; nil leaves it unchanged, for callers that
+// index files without watching the others, which may have changed unseen.
+func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer.Scanner, extractor *trace.RegexExtractor, symbolStore *trace.GOBSymbolStore, rpgEncoder *rpg.RPGEncoder, vectorStore store.VectorStore, enabledLanguages []string, projectRoot string, cfg *config.Config, lastConfigWrite *time.Time, rpgManager *rpgRealtimeManager, hookRunner *hooks.Runner, event watcher.FileEvent, onActivity watchActivityObserver, onStats watchStatsObserver, processors ...*framework.ProcessorRegistry) {
+	if event.Type == watcher.EventMove {
+		if onActivity != nil {
+			onActivity("moving", event.Path)
+		}
+		if moveIndexedFile(ctx, idx, scanner, symbolStore, rpgEncoder, rpgManager, projectRoot, event) {
+			if onActivity != nil {
+				onActivity("steady", "")
+			}
+			return
+		}
+
+		// The file cannot be moved in place: drop the old path unless it
+		// reappeared, then index the new path like any other change.
+		if _, err := os.Stat(filepath.Join(projectRoot, event.OldPath)); os.IsNotExist(err) {
+			handleFileEvent(ctx, idx, scanner, extractor, symbolStore, rpgEncoder, vectorStore, enabledLanguages, projectRoot, cfg, lastConfigWrite, rpgManager, hookRunner,
+				watcher.FileEvent{Type: watcher.EventDelete, Path: event.OldPath}, onActivity, onStats, processors...)
+		}
+		event = watcher.FileEvent{Type: watcher.EventCreate, Path: event.Path}
+		if _, err := os.Stat(filepath.Join(projectRoot, event.Path)); os.IsNotExist(err) {
+			event.Type = watcher.EventDelete
+		}
+	}
+
 	if onActivity != nil {
 		op := "processing"
 		if event.Type == watcher.EventDelete {
@@ -2078,29 +2497,44 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 		start := time.Now()
 		fileInfo, err := scanner.ScanFile(event.Path)
 		if err != nil {
-			log.Printf("Failed to scan %s: %v", event.Path, err)
+			slog.Error("Failed to scan file", "path", event.Path, "err", err)
+			fireWatchHookError(hookRunner, event.Path, err)
 			return
 		}
 		if fileInfo == nil {
-			return // File was skipped (binary, too large, etc.)
+			// File was skipped (binary, too large, generated, etc.). Drop any
+			// chunks from before it became unindexable.
+			if fileExisted {
+				if err := idx.RemoveFile(ctx, event.Path); err != nil {
+					slog.Error("Failed to remove skipped file", "path", event.Path, "err", err)
+					fireWatchHookError(hookRunner, event.Path, err)
+				} else {
+					slog.Info("Removed file from index (now skipped)", "path", event.Path)
+					hookRunner.Fire(hooks.Payload{Event: hooks.EventFileRemoved, Path: event.Path, Chunks: oldChunkCount})
+				}
+				saveScanReport(projectRoot, scanner)
+			}
+			return
 		}
 
 		needsReindex, err := idx.NeedsReindex(ctx, fileInfo.Path, fileInfo.Hash)
 		if err != nil {
-			log.Printf("Failed to check reindex status for %s: %v", event.Path, err)
+			slog.Error("Failed to check reindex status", "path", event.Path, "err", err)
 			return
 		}
 		if !needsReindex {
-			log.Printf("Skipped unchanged %s", event.Path)
+			slog.Info("Skipped unchanged file", "path", event.Path)
 			return
 		}
 
 		chunks, err := idx.IndexFile(ctx, *fileInfo)
 		if err != nil {
-			log.Printf("Failed to index %s: %v", event.Path, err)
+			slog.Error("Failed to index file", "path", event.Path, "err", err)
+			fireWatchHookError(hookRunner, event.Path, err)
 			return
 		}
-		log.Printf("Indexed %s (%d chunks)", event.Path, chunks)
+		slog.Info("Indexed file", "path", event.Path, "chunks", chunks, "duration_ms", time.Since(start).Milliseconds())
+		hookRunner.Fire(hooks.Payload{Event: hooks.EventFileIndexed, Path: event.Path, Chunks: chunks, DurationMs: time.Since(start).Milliseconds()})
 
 		// Report stats (files/chunks)
 		if onStats != nil {
@@ -2116,11 +2550,12 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 
 		// Update last_index_time with throttling (only write if 30 seconds have passed)
 		now := time.Now()
-		if now.Sub(*lastConfigWrite) >= configWriteThrottle {
+		if lastConfigWrite != nil && now.Sub(*lastConfigWrite) >= configWriteThrottle {
 			cfg.Watch.LastIndexTime = now
 			if err := cfg.Save(projectRoot); err != nil {
 				log.Printf("Warning: failed to save config: %v", err)
 			}
+			saveScanReport(projectRoot, scanner)
 			*lastConfigWrite = now
 		}
 
@@ -2129,11 +2564,11 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 		if isTracedLanguage(ext, enabledLanguages) {
 			symbols, refs, err := extractSymbolsWithFramework(ctx, extractor, fileInfo.Path, fileInfo.Content, processors...)
 			if err != nil {
-				log.Printf("Failed to extract symbols from %s: %v", event.Path, err)
+				slog.Error("Failed to extract symbols", "path", event.Path, "err", err)
 			} else if err := symbolStore.SaveFileWithContentHash(ctx, fileInfo.Path, fileInfo.Hash, symbols, refs); err != nil {
-				log.Printf("Failed to save symbols for %s: %v", event.Path, err)
+				slog.Error("Failed to save symbols", "path", event.Path, "err", err)
 			} else {
-				log.Printf("Extracted %d symbols from %s", len(symbols), event.Path)
+				slog.Info("Extracted symbols", "path", event.Path, "symbols", len(symbols))
 
 				if onStats != nil {
 					onStats(projectRoot, watchStatsDelta{
@@ -2149,23 +2584,23 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 						eventType = "modify"
 					}
 					if err := rpgEncoder.HandleFileEvent(ctx, eventType, fileInfo.Path, symbols); err != nil {
-						log.Printf("Warning: failed to update RPG for %s: %v", event.Path, err)
+						slog.Warn("Failed to update RPG", "path", event.Path, "err", err)
 					}
 					if vectorStore != nil {
 						if chunks, err := vectorStore.GetChunksForFile(ctx, fileInfo.Path); err == nil {
 							if err := rpgEncoder.LinkChunksForFile(ctx, fileInfo.Path, chunks); err != nil {
-								log.Printf("Warning: failed to link RPG chunks for %s: %v", event.Path, err)
+								slog.Warn("Failed to link RPG chunks", "path", event.Path, "err", err)
 							}
 						}
 					}
 					if rpgManager != nil {
 						rpgManager.MarkFileDirty(fileInfo.Path)
 						dirtyCount, _, _, _ := rpgManager.Snapshot()
-						log.Printf("rpg_event_applied_ms=%d file=%s event=%s rpg_dirty_files_count=%d",
-							time.Since(start).Milliseconds(),
-							fileInfo.Path,
-							event.Type.String(),
-							dirtyCount,
+						slog.Debug("RPG event applied",
+							"path", fileInfo.Path,
+							"event", event.Type.String(),
+							"rpg_event_applied_ms", time.Since(start).Milliseconds(),
+							"rpg_dirty_files_count", dirtyCount,
 						)
 					}
 				}
@@ -2175,12 +2610,13 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 	case watcher.EventDelete, watcher.EventRename:
 		start := time.Now()
 		if err := idx.RemoveFile(ctx, event.Path); err != nil {
-			log.Printf("Failed to remove %s from index: %v", event.Path, err)
+			slog.Error("Failed to remove file from index", "path", event.Path, "err", err)
+			fireWatchHookError(hookRunner, event.Path, err)
 			return
 		}
 		// Also remove from symbol index
 		if err := symbolStore.DeleteFile(ctx, event.Path); err != nil {
-			log.Printf("Failed to remove symbols for %s: %v", event.Path, err)
+			slog.Error("Failed to remove symbols", "path", event.Path, "err", err)
 		}
 
 		if onStats != nil {
@@ -2193,20 +2629,75 @@ func handleFileEvent(ctx context.Context, idx *indexer.Indexer, scanner *indexer
 
 		if rpgEncoder != nil {
 			if err := rpgEncoder.HandleFileEvent(ctx, "delete", event.Path, nil); err != nil {
-				log.Printf("Warning: failed to update RPG for deleted %s: %v", event.Path, err)
+				slog.Warn("Failed to update RPG for deleted file", "path", event.Path, "err", err)
 			} else if rpgManager != nil {
 				rpgManager.MarkFileDirty(event.Path)
 				dirtyCount, _, _, _ := rpgManager.Snapshot()
-				log.Printf("rpg_event_applied_ms=%d file=%s event=%s rpg_dirty_files_count=%d",
-					time.Since(start).Milliseconds(),
-					event.Path,
-					event.Type.String(),
-					dirtyCount,
+				slog.Debug("RPG event applied",
+					"path", event.Path,
+					"event", event.Type.String(),
+					"rpg_event_applied_ms", time.Since(start).Milliseconds(),
+					"rpg_dirty_files_count", dirtyCount,
 				)
 			}
 		}
-		log.Printf("Removed %s from index", event.Path)
+		slog.Info("Removed file from index", "path", event.Path)
+		hookRunner.Fire(hooks.Payload{Event: hooks.EventFileRemoved, Path: event.Path, Chunks: oldChunkCount, DurationMs: time.Since(start).Milliseconds()})
+	}
+}
+
+// moveIndexedFile moves the chunks, symbols and RPG nodes of a renamed file
+// to its new path without re-embedding. It reports false when the file must
+// be indexed instead: its content changed, the old path exists again, or the
+// store cannot move files.
+func moveIndexedFile(ctx context.Context, idx *indexer.Indexer, scanner *indexer.Scanner, symbolStore *trace.GOBSymbolStore, rpgEncoder *rpg.RPGEncoder, rpgManager *rpgRealtimeManager, projectRoot string, event watcher.FileEvent) bool {
+	if _, err := os.Stat(filepath.Join(projectRoot, event.OldPath)); err == nil {
+		return false
+	}
+	fileInfo, err := scanner.ScanFile(event.Path)
+	if err != nil || fileInfo == nil {
+		return false
+	}
+
+	moved, err := idx.MoveFile(ctx, event.OldPath, *fileInfo)
+	if err != nil {
+		slog.Error("Failed to move file", "from", event.OldPath, "to", event.Path, "err", err)
+		return false
+	}
+	if !moved {
+		return false
+	}
+
+	if symbolStore != nil {
+		if err := symbolStore.MoveFile(ctx, event.OldPath, event.Path); err != nil {
+			slog.Error("Failed to move symbols", "from", event.OldPath, "to", event.Path, "err", err)
+		}
 	}
+	if rpgEncoder != nil {
+		if err := rpgEncoder.HandleFileMove(ctx, event.OldPath, event.Path); err != nil {
+			slog.Warn("Failed to move RPG nodes", "from", event.OldPath, "to", event.Path, "err", err)
+		} else if rpgManager != nil {
+			rpgManager.MarkFileDirty(event.Path)
+		}
+	}
+
+	slog.Info("Moved file", "from", event.OldPath, "to", event.Path)
+	return true
+}
+
+// enableMoveDetection lets the watcher pair deletes and creates of files
+// with unchanged content into moves, using the hashes recorded in the index.
+func enableMoveDetection(ctx context.Context, w *watcher.Watcher, vectorStore store.VectorStore) {
+	if _, ok := vectorStore.(store.FileMover); !ok {
+		return
+	}
+	w.SetMoveDetection(func(relPath string) (string, bool) {
+		doc, err := vectorStore.GetDocument(ctx, relPath)
+		if err != nil || doc == nil {
+			return "", false
+		}
+		return doc.Hash, doc.Hash != ""
+	})
 }
 
 // isTracedLanguage checks if a file extension is in the enabled languages list.
@@ -2367,6 +2858,28 @@ func runWorkspaceWatch(logDir string) error {
 		return stopWorkspaceWatchDaemon(logDir, watchWorkspace)
 	}
 
+	// Handle --logs flag
+	if watchLogs {
+		return showWatchLogs(daemon.GetWorkspaceLogFile(logDir, ws.Name))
+	}
+
+	// Handle --pause, --resume and --reindex
+	controlReq, hasControlReq, err := watchControlRequestFromFlags()
+	if err != nil {
+		return err
+	}
+	if hasControlReq {
+		return sendWatchControlCommand(daemon.GetWorkspaceControlSocketPath(logDir, ws.Name), logDir, "grepai watch --workspace "+ws.Name, controlReq)
+	}
+
+	// Handle --install-service and --uninstall-service
+	if watchInstallSvc {
+		return installWorkspaceWatchService(logDir, ws)
+	}
+	if watchRemoveSvc {
+		return uninstallWatchService(daemon.WorkspaceServiceName(ws.Name))
+	}
+
 	// Handle --background flag
 	if watchBackground {
 		return startBackgroundWorkspaceWatch(logDir, ws)
@@ -2406,6 +2919,11 @@ func showWorkspaceWatchStatus(logDir string, ws *config.Workspace) error {
 		fmt.Printf("  - %s: %s\n", p.Name, p.Path)
 	}
 
+	// Watchers started by older versions have no control socket
+	if status := queryWatchControlStatus(daemon.GetWorkspaceControlSocketPath(logDir, ws.Name)); status != nil {
+		printWatchControlStatus(status)
+	}
+
 	return nil
 }
 
@@ -2421,8 +2939,11 @@ func stopWorkspaceWatchDaemon(logDir, workspaceName string) error {
 	}
 
 	fmt.Printf("Stopping workspace watcher %s (PID %d)...\n", workspaceName, pid)
-	if err := daemon.StopProcess(pid); err != nil {
-		return fmt.Errorf("failed to stop process: %w", err)
+	if _, err := daemon.SendControl(daemon.GetWorkspaceControlSocketPath(logDir, workspaceName), daemon.ControlRequest{Command: daemon.ControlStop}); err != nil {
+		// No control socket: fall back to a signal
+		if err := daemon.StopProcess(pid); err != nil {
+			return fmt.Errorf("failed to stop process: %w", err)
+		}
 	}
 
 	// Wait for process to stop
@@ -2464,6 +2985,7 @@ func startBackgroundWorkspaceWatch(logDir string, ws *config.Workspace) error {
 	if watchLogDir != "" {
 		extraArgs = append(extraArgs, "--log-dir", watchLogDir)
 	}
+	extraArgs = append(extraArgs, watchLogArgs()...)
 
 	// Spawn background process
 	childPID, exitCh, err := daemon.SpawnWorkspaceBackground(logDir, ws.Name, extraArgs)
@@ -2507,8 +3029,11 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 
 	// If running in background, write PID file
 	if isBackgroundChild {
-		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-		log.SetPrefix(fmt.Sprintf("[grepai-workspace-%s] ", ws.Name))
+		restoreLogging, err := setupBackgroundWatchLogging()
+		if err != nil {
+			return fmt.Errorf("failed to set up logging: %w", err)
+		}
+		defer restoreLogging()
 
 		if err := daemon.WriteWorkspacePIDFile(logDir, ws.Name); err != nil {
 			return fmt.Errorf("failed to write PID file: %w", err)
@@ -2526,10 +3051,13 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 		fmt.Printf("Embedder: %s (%s)\n", ws.Embedder.Provider, ws.Embedder.Model)
 		fmt.Printf("Projects: %d\n", len(ws.Projects))
 	} else {
-		log.Printf("Starting workspace watcher: %s", ws.Name)
-		log.Printf("Backend: %s", ws.Store.Backend)
-		log.Printf("Embedder: %s (%s)", ws.Embedder.Provider, ws.Embedder.Model)
-		log.Printf("Projects: %d", len(ws.Projects))
+		slog.Info("Starting workspace watcher",
+			"workspace", ws.Name,
+			"backend", ws.Store.Backend,
+			"provider", ws.Embedder.Provider,
+			"model", ws.Embedder.Model,
+			"projects", len(ws.Projects),
+		)
 	}
 
 	// Check all project paths exist
@@ -2557,23 +3085,69 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 	runtimes := make(map[string]*workspaceProjectRuntime, len(ws.Projects))
 	watchers := make([]*watcher.Watcher, 0, len(ws.Projects))
 
+	// Handle signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	wsStopCh := daemon.StopChannel()
+
+	// Cancelled by 'grepai watch --workspace --stop' through the control socket
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	defer watchCancel()
+
+	// Serve the control socket used by 'grepai watch --workspace --status/--stop/--pause'
+	var control *watchControl
+	if isBackgroundChild {
+		c := newWatchControl("", watchCancel)
+		server, err := daemon.ServeControl(daemon.GetWorkspaceControlSocketPath(logDir, ws.Name), c)
+		if err != nil {
+			log.Printf("Warning: control socket unavailable: %v", err)
+		} else {
+			defer server.Close()
+			control = c
+		}
+	}
+
+	// Interrupt the initial scans cleanly so that their checkpoints are kept.
+	initCtx, initCancel := context.WithCancel(watchCtx)
+	defer initCancel()
+	initDone := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+			initCancel()
+		case <-wsStopCh:
+			initCancel()
+		case <-initDone:
+		}
+	}()
+
 	for _, project := range ws.Projects {
+		if initCtx.Err() != nil {
+			break
+		}
 		if !isBackgroundChild {
 			fmt.Printf("\nIndexing project: %s (%s)\n", project.Name, project.Path)
 		} else {
 			log.Printf("Indexing project: %s (%s)", project.Name, project.Path)
 		}
 
-		runtime, w, rtErr := initializeWorkspaceRuntime(ctx, ws, project, emb, st, isBackgroundChild)
+		projectKey := canonicalPath(project.Path)
+		control.observeLifecycle(projectKey, "starting", "initial scan")
+		runtime, w, rtErr := initializeWorkspaceRuntime(initCtx, ws, project, emb, st, isBackgroundChild)
+		if rtErr != nil && initCtx.Err() != nil {
+			break
+		}
 		if rtErr != nil {
 			log.Printf("Warning: failed to initialize runtime for %s: %v", project.Name, rtErr)
+			control.observeLifecycle(projectKey, "error", rtErr.Error())
 			continue
 		}
 
-		projectKey := canonicalPath(project.Path)
+		control.observeLifecycle(projectKey, "running", "")
 		runtimes[projectKey] = runtime
 		watchers = append(watchers, w)
 	}
+	close(initDone)
 
 	defer func() {
 		for _, w := range watchers {
@@ -2590,9 +3164,23 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 					log.Printf("Warning: failed to close RPG store for %s: %v", runtime.project.Path, err)
 				}
 			}
+			runtime.hooks.Close()
+			runtime.releaseLock()
 		}
 	}()
 
+	if initCtx.Err() != nil {
+		if !isBackgroundChild {
+			fmt.Println("\nShutting down...")
+		} else {
+			log.Println("Shutting down...")
+		}
+		if err := st.Persist(ctx); err != nil {
+			log.Printf("Warning: failed to persist index on shutdown: %v", err)
+		}
+		return nil
+	}
+
 	// Write ready file
 	if isBackgroundChild {
 		if err := daemon.WriteWorkspaceReadyFile(logDir, ws.Name); err != nil {
@@ -2605,11 +3193,6 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 		}()
 	}
 
-	// Handle signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	wsStopCh := daemon.StopChannel()
-
 	if !isBackgroundChild {
 		fmt.Printf("\nWatching %d projects for changes... (Press Ctrl+C to stop)\n", len(runtimes))
 	} else {
@@ -2650,12 +3233,65 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 		}
 	}
 
+	// Collect reindex requests from the control socket
+	reindexChan := make(chan workspaceReindexRequest)
+	for projectKey := range runtimes {
+		projectKey := projectKey
+		requests, detach := control.attach(projectKey)
+		defer detach()
+		if requests == nil {
+			continue
+		}
+		go func() {
+			for {
+				select {
+				case relPath := <-requests:
+					select {
+					case reindexChan <- workspaceReindexRequest{projectKey: projectKey, relPath: relPath}:
+					case <-watchCtx.Done():
+						return
+					}
+				case <-watchCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
 	// Event loop
 	persistTicker := time.NewTicker(30 * time.Second)
 	defer persistTicker.Stop()
+	queueTicker := time.NewTicker(2 * time.Second)
+	defer queueTicker.Stop()
 
 	for {
+		// While paused, events wait in the watcher queues
+		events := (<-chan workspaceWatchEvent)(eventChan)
+		paused, pauseChanged := control.pauseState()
+		if paused {
+			events = nil
+		}
+
 		select {
+		case <-watchCtx.Done():
+			log.Println("Stop requested, shutting down...")
+			persistAndShutdown()
+			return nil
+
+		case <-pauseChanged:
+
+		case <-queueTicker.C:
+			for projectKey, runtime := range runtimes {
+				if runtime.watcher == nil {
+					continue
+				}
+				control.observeStats(projectKey, watchStatsDelta{Queue: true, QueueDepth: runtime.watcher.Stats().Queued})
+			}
+
+		case req := <-reindexChan:
+			reindexWorkspaceProject(ctx, st, runtimes[req.projectKey], req.relPath)
+			control.reindexDone(req.projectKey)
+
 		case <-sigChan:
 			if !isBackgroundChild {
 				fmt.Println("\nShutting down...")
@@ -2685,13 +3321,14 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 				}
 			}
 
-		case event := <-eventChan:
+		case event := <-events:
 			projectKey := canonicalPath(event.projectPath)
 			runtime := runtimes[projectKey]
 			if runtime == nil {
 				log.Printf("Warning: received event for unknown runtime: %s", event.projectPath)
 				continue
 			}
+			control.observeEvent(projectKey, event.event)
 			handleFileEvent(
 				ctx,
 				runtime.idx,
@@ -2705,6 +3342,7 @@ func runWorkspaceWatchForeground(logDir string, ws *config.Workspace) error {
 				runtime.cfg,
 				&runtime.lastConfigWrite,
 				runtime.manager,
+				runtime.hooks,
 				event.event,
 				nil,
 				nil,
@@ -2719,6 +3357,40 @@ type workspaceWatchEvent struct {
 	event       watcher.FileEvent
 }
 
+// workspaceReindexRequest is a reindex request of the control socket for a
+// workspace project, keyed by its canonical path.
+type workspaceReindexRequest struct {
+	projectKey string
+	relPath    string
+}
+
+// reindexWorkspaceProject re-indexes the files of a workspace project under
+// relPath ("." for the whole project) and persists the result.
+func reindexWorkspaceProject(ctx context.Context, st store.VectorStore, runtime *workspaceProjectRuntime, relPath string) {
+	projectRoot := runtime.project.Path
+	files, err := watchReindexTargets(runtime.scanner, projectRoot, relPath)
+	if err != nil {
+		log.Printf("Failed to reindex %s in %s: %v", relPath, runtime.project.Name, err)
+		return
+	}
+	log.Printf("Reindexing %d file(s) under %s in %s", len(files), relPath, runtime.project.Name)
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		invalidateIndexedFile(ctx, runtime.vectorStore, file)
+		handleFileEvent(ctx, runtime.idx, runtime.scanner, runtime.extractor, runtime.symbolStore, runtime.rpgEncoder, runtime.vectorStore, runtime.tracedLanguages, projectRoot, runtime.cfg, &runtime.lastConfigWrite, runtime.manager, runtime.hooks,
+			watcher.FileEvent{Type: watcher.EventModify, Path: file}, nil, nil, runtime.processor)
+	}
+	if err := st.Persist(ctx); err != nil {
+		log.Printf("Warning: failed to persist index: %v", err)
+	}
+	if err := runtime.symbolStore.Persist(ctx); err != nil {
+		log.Printf("Warning: failed to persist symbol index for %s: %v", runtime.project.Name, err)
+	}
+	saveScanReport(projectRoot, runtime.scanner)
+}
+
 type workspaceProjectRuntime struct {
 	project         config.ProjectEntry
 	cfg             *config.Config
@@ -2734,6 +3406,8 @@ type workspaceProjectRuntime struct {
 	lastConfigWrite time.Time
 	manager         *rpgRealtimeManager
 	watcher         *watcher.Watcher
+	hooks           *hooks.Runner
+	releaseLock     func() // Releases the watch lock of the project
 }
 
 func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, project config.ProjectEntry, emb embedder.Embedder, sharedStore store.VectorStore, isBackgroundChild bool) (*workspaceProjectRuntime, *watcher.Watcher, error) {
@@ -2752,7 +3426,16 @@ func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, proje
 		return nil, nil, fmt.Errorf("failed to initialize ignore matcher: %w", err)
 	}
 
+	releaseWatchLock, err := daemon.HoldWatchLock(config.GetWatchLockPath(project.Path))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	scanner := indexer.NewScanner(project.Path, ignoreMatcher)
+	extensions := indexer.ExtensionSet(projectCfg.Indexing.Extensions, projectCfg.Indexing.ExcludeExtensions)
+	scanner.SetExtensions(extensions)
+	scanner.SetFilePolicy(filePolicyFromConfig(projectCfg))
+	restoreScanFindings(project.Path, scanner)
 	chunker := indexer.NewChunker(projectCfg.Chunking.Size, projectCfg.Chunking.Overlap)
 	processorRegistry := buildFrameworkRegistry(projectCfg)
 	vectorStore := &projectPrefixStore{
@@ -2762,22 +3445,30 @@ func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, proje
 		projectPath:   project.Path,
 	}
 	idx := indexer.NewIndexer(project.Path, vectorStore, emb, chunker, scanner, projectCfg.Watch.LastIndexTime, processorRegistry)
+	idx.SetExtractors(buildExtractorRegistry(projectCfg))
+	idx.SetCheckpoint(checkpointFromConfig(projectCfg, project.Path))
 	extractor := trace.NewRegexExtractor()
 	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(project.Path))
 	if err := symbolStore.Load(ctx); err != nil {
 		log.Printf("Warning: failed to load symbol index for %s: %v", project.Path, err)
 	}
 
-	tracedLanguages := projectCfg.Trace.EnabledLanguages
-	if len(tracedLanguages) == 0 {
-		tracedLanguages = []string{".go", ".js", ".ts", ".jsx", ".tsx", ".vue", ".py", ".php", ".lua", ".java", ".cs", ".fs", ".fsx", ".fsi"}
-	}
+	tracedLanguages := projectCfg.Trace.TracedLanguages()
+
+	hookRunner := startWatchHooks(project.Path, projectCfg.Watch.Hooks, nil)
 
 	stats, err := runInitialScan(ctx, idx, scanner, extractor, symbolStore, tracedLanguages, projectCfg.Watch.LastIndexTime, isBackgroundChild, nil, nil, processorRegistry)
 	if err != nil {
+		if ctx.Err() == nil {
+			fireWatchHookError(hookRunner, "", err)
+		}
+		hookRunner.Close()
 		_ = symbolStore.Close()
+		releaseWatchLock()
 		return nil, nil, err
 	}
+	saveScanReport(project.Path, scanner)
+	hookRunner.Fire(scanCompletePayload(stats))
 	if stats.FilesIndexed > 0 || stats.ChunksCreated > 0 {
 		projectCfg.Watch.LastIndexTime = time.Now()
 		if err := projectCfg.Save(project.Path); err != nil {
@@ -2794,39 +3485,19 @@ func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, proje
 			log.Printf("Warning: failed to load RPG index for %s: %v", project.Path, err)
 		}
 
-		var featureExtractor rpg.FeatureExtractor
-		switch projectCfg.RPG.FeatureMode {
-		case "llm", "hybrid":
-			if projectCfg.RPG.LLMEndpoint == "" || projectCfg.RPG.LLMModel == "" {
-				log.Printf("Warning: RPG feature_mode=%q but llm_endpoint or llm_model is empty for %s, falling back to local extractor", projectCfg.RPG.FeatureMode, project.Path)
-				featureExtractor = rpg.NewLocalExtractor()
-			} else {
-				featureExtractor = rpg.NewLLMExtractor(rpg.LLMExtractorConfig{
-					Provider: projectCfg.RPG.LLMProvider,
-					Model:    projectCfg.RPG.LLMModel,
-					Endpoint: projectCfg.RPG.LLMEndpoint,
-					APIKey:   projectCfg.RPG.LLMAPIKey,
-					Timeout:  time.Duration(projectCfg.RPG.LLMTimeoutMs) * time.Millisecond,
-				})
-			}
-		default:
-			featureExtractor = rpg.NewLocalExtractor()
-		}
-
-		rpgEncoder = rpg.NewRPGEncoder(rpgStore, featureExtractor, project.Path, rpg.RPGEncoderConfig{
-			DriftThreshold:       projectCfg.RPG.DriftThreshold,
-			MaxTraversalDepth:    projectCfg.RPG.MaxTraversalDepth,
-			FeatureGroupStrategy: projectCfg.RPG.FeatureGroupStrategy,
-		})
+		rpgEncoder = newRPGEncoder(projectCfg, rpgStore, project.Path)
 		if err := rpgEncoder.BuildFull(ctx, symbolStore, vectorStore, nil); err != nil {
 			log.Printf("Warning: failed to build RPG graph for %s: %v", project.Path, err)
+			fireWatchHookError(hookRunner, "", err)
+		} else {
+			hookRunner.Fire(hooks.Payload{Event: hooks.EventRPGReconciled, Mode: "build"})
 		}
 		if err := rpgStore.Persist(ctx); err != nil {
 			log.Printf("Warning: failed to persist RPG graph for %s: %v", project.Path, err)
 		}
 
 		manager = newRPGRealtimeManager(projectCfg.Watch.RPGMaxDirtyFilesPerBatch)
-		startRPGRealtimeWorkers(ctx, fmt.Sprintf("workspace:%s/%s", ws.Name, project.Name), symbolStore, rpgEncoder, rpgStore, projectCfg.Watch, manager)
+		startRPGRealtimeWorkers(ctx, fmt.Sprintf("workspace:%s/%s", ws.Name, project.Name), symbolStore, rpgEncoder, rpgStore, projectCfg.Watch, manager, hookRunner, nil)
 	}
 
 	w, err := watcher.NewWatcher(project.Path, ignoreMatcher, projectCfg.Watch.DebounceMs)
@@ -2835,14 +3506,22 @@ func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, proje
 			_ = rpgStore.Close()
 		}
 		_ = symbolStore.Close()
+		hookRunner.Close()
+		releaseWatchLock()
 		return nil, nil, fmt.Errorf("failed to create watcher: %w", err)
 	}
+	w.SetExtensions(extensions)
+	w.SetMode(watcher.Mode(projectCfg.Watch.Mode), time.Duration(projectCfg.Watch.PollIntervalMs)*time.Millisecond)
+	w.SetIncludePaths(projectCfg.Watch.IncludePaths, time.Duration(projectCfg.Watch.ReconcileIntervalSec)*time.Second)
+	enableMoveDetection(ctx, w, vectorStore)
 	if err := w.Start(ctx); err != nil {
 		w.Close()
 		if rpgStore != nil {
 			_ = rpgStore.Close()
 		}
 		_ = symbolStore.Close()
+		hookRunner.Close()
+		releaseWatchLock()
 		return nil, nil, fmt.Errorf("failed to start watcher: %w", err)
 	}
 
@@ -2860,6 +3539,8 @@ func initializeWorkspaceRuntime(ctx context.Context, ws *config.Workspace, proje
 		tracedLanguages: tracedLanguages,
 		manager:         manager,
 		watcher:         w,
+		hooks:           hookRunner,
+		releaseLock:     releaseWatchLock,
 	}
 	return runtime, w, nil
 }
@@ -2929,6 +3610,15 @@ func (p *projectPrefixStore) DeleteByFile(ctx context.Context, filePath string)
 	return p.store.DeleteByFile(ctx, prefixedPath)
 }
 
+// MoveFile implements store.FileMover when the underlying store does.
+func (p *projectPrefixStore) MoveFile(ctx context.Context, oldPath, newPath string) error {
+	mover, ok := p.store.(store.FileMover)
+	if !ok {
+		return fmt.Errorf("store backend cannot move files")
+	}
+	return mover.MoveFile(ctx, p.getPrefix()+"/"+p.toRelSlash(oldPath), p.getPrefix()+"/"+p.toRelSlash(newPath))
+}
+
 func (p *projectPrefixStore) Search(ctx context.Context, queryVector []float32, limit int, opts store.SearchOptions) ([]store.SearchResult, error) {
 	return p.store.Search(ctx, queryVector, limit, opts)
 }
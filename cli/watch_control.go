@@ -0,0 +1,404 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/watcher"
+)
+
+// watchControlReindexBuffer bounds the reindex requests waiting for a
+// project's watch loop.
+const watchControlReindexBuffer = 64
+
+// watchControl serves the control socket of a background watcher. It tracks
+// per-project state from the supervisor observers and hands pause, reindex
+// and reload requests to the watch loops. The methods used by watch loops
+// accept a nil control, which never pauses or reindexes.
+type watchControl struct {
+	mainRoot  string // "" for workspace watchers, which cannot reload
+	startedAt time.Time
+	stop      func()
+	reload    chan struct{}
+
+	mu       sync.Mutex
+	paused   bool
+	changed  chan struct{} // Closed and replaced when paused changes
+	projects map[string]*watchControlProject
+}
+
+type watchControlProject struct {
+	status  daemon.ProjectStatus
+	reindex chan string // Project-relative paths; nil while no loop runs
+}
+
+func newWatchControl(mainRoot string, stop func()) *watchControl {
+	return &watchControl{
+		mainRoot:  mainRoot,
+		startedAt: time.Now(),
+		stop:      stop,
+		reload:    make(chan struct{}, 1),
+		changed:   make(chan struct{}),
+		projects:  make(map[string]*watchControlProject),
+	}
+}
+
+func (c *watchControl) project(root string) *watchControlProject {
+	p, ok := c.projects[root]
+	if !ok {
+		p = &watchControlProject{status: daemon.ProjectStatus{Root: root}}
+		c.projects[root] = p
+	}
+	return p
+}
+
+func (c *watchControl) observeLifecycle(projectRoot, state, note string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state == "removed" {
+		delete(c.projects, projectRoot)
+		return
+	}
+	p := c.project(projectRoot)
+	p.status.State = state
+	p.status.Note = note
+}
+
+func (c *watchControl) observeEvent(projectRoot string, event watcher.FileEvent) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.project(projectRoot)
+	p.status.LastEvent = event.Type.String() + " " + filepath.ToSlash(event.Path)
+	p.status.LastEventAt = time.Now()
+}
+
+func (c *watchControl) observeStats(projectRoot string, delta watchStatsDelta) {
+	if c == nil || !delta.Queue {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.project(projectRoot).status.QueueDepth = delta.QueueDepth
+}
+
+// attach registers the watch loop of a project and returns the channel its
+// reindex requests arrive on, and a function to call when the loop exits.
+func (c *watchControl) attach(projectRoot string) (<-chan string, func()) {
+	if c == nil {
+		return nil, func() {}
+	}
+	ch := make(chan string, watchControlReindexBuffer)
+	c.mu.Lock()
+	c.project(projectRoot).reindex = ch
+	c.mu.Unlock()
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if p, ok := c.projects[projectRoot]; ok && p.reindex == ch {
+			// Requests still queued are dropped with the channel
+			p.reindex = nil
+			p.status.PendingReindex = 0
+		}
+	}
+}
+
+// reindexDone records that the watch loop of a project finished one of its
+// reindex requests.
+func (c *watchControl) reindexDone(projectRoot string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.projects[projectRoot]; ok && p.status.PendingReindex > 0 {
+		p.status.PendingReindex--
+	}
+}
+
+// pauseState reports whether event processing is paused, and a channel
+// closed when that changes.
+func (c *watchControl) pauseState() (bool, <-chan struct{}) {
+	if c == nil {
+		return false, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused, c.changed
+}
+
+func (c *watchControl) setPaused(paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused == paused {
+		return
+	}
+	c.paused = paused
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Status implements daemon.ControlHandler.
+func (c *watchControl) Status() daemon.WatchStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := daemon.WatchStatus{
+		PID:       os.Getpid(),
+		Version:   version,
+		StartedAt: c.startedAt,
+		Paused:    c.paused,
+	}
+	for _, p := range c.projects {
+		status.Projects = append(status.Projects, p.status)
+	}
+	sort.Slice(status.Projects, func(i, j int) bool {
+		return status.Projects[i].Root < status.Projects[j].Root
+	})
+	return status
+}
+
+// Pause implements daemon.ControlHandler. File events keep being collected
+// and are processed on resume.
+func (c *watchControl) Pause() error {
+	c.setPaused(true)
+	return nil
+}
+
+// Resume implements daemon.ControlHandler.
+func (c *watchControl) Resume() error {
+	c.setPaused(false)
+	return nil
+}
+
+// Reindex implements daemon.ControlHandler. path must be absolute; it is
+// queued on the watch loop of the innermost project containing it.
+func (c *watchControl) Reindex(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("reindex path must be absolute, got %q", path)
+	}
+	path = canonicalPath(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var owner string
+	for root := range c.projects {
+		if (path == root || strings.HasPrefix(path, root+string(filepath.Separator))) && len(root) > len(owner) {
+			owner = root
+		}
+	}
+	if owner == "" {
+		return "", fmt.Errorf("%s is not inside a watched project", path)
+	}
+	p := c.projects[owner]
+	if p.reindex == nil {
+		return "", fmt.Errorf("project %s is not running (state: %s)", owner, p.status.State)
+	}
+
+	relPath, err := filepath.Rel(owner, path)
+	if err != nil {
+		return "", err
+	}
+	select {
+	case p.reindex <- relPath:
+		p.status.PendingReindex++
+	default:
+		return "", fmt.Errorf("too many pending reindex requests for %s", owner)
+	}
+	return fmt.Sprintf("Reindex of %s queued in %s", relPath, owner), nil
+}
+
+// ReloadConfig implements daemon.ControlHandler. The configuration is
+// validated before the watch sessions restart with it.
+func (c *watchControl) ReloadConfig() error {
+	if c.mainRoot == "" {
+		return errors.New("workspace watchers cannot reload their configuration; restart the watcher")
+	}
+	if _, err := config.Load(c.mainRoot); err != nil {
+		return err
+	}
+	select {
+	case c.reload <- struct{}{}:
+	default:
+		// A reload is already pending
+	}
+	return nil
+}
+
+// Stop implements daemon.ControlHandler.
+func (c *watchControl) Stop() error {
+	c.stop()
+	return nil
+}
+
+// watchReindexTargets lists the indexable files under relPath ("." for the
+// whole project).
+func watchReindexTargets(scanner *indexer.Scanner, projectRoot, relPath string) ([]string, error) {
+	info, err := os.Stat(filepath.Join(projectRoot, relPath))
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{relPath}, nil
+	}
+
+	files, _, err := scanner.ScanMetadata()
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, file := range files {
+		if relPath == "." || strings.HasPrefix(file.Path, relPath+string(filepath.Separator)) {
+			targets = append(targets, file.Path)
+		}
+	}
+	return targets, nil
+}
+
+// invalidateIndexedFile clears the recorded content hash of a file so that
+// the next event for it re-indexes it even if unchanged.
+func invalidateIndexedFile(ctx context.Context, st store.VectorStore, relPath string) {
+	doc, err := st.GetDocument(ctx, relPath)
+	if err != nil || doc == nil {
+		return
+	}
+	doc.Hash = ""
+	doc.Path = relPath // Stores that prefix paths return them prefixed
+	if err := st.SaveDocument(ctx, *doc); err != nil {
+		log.Printf("Warning: failed to invalidate %s: %v", relPath, err)
+	}
+}
+
+// watchControlRequestFromFlags builds the control request selected by the
+// --pause, --resume, --reindex and --reload flags.
+func watchControlRequestFromFlags() (daemon.ControlRequest, bool, error) {
+	var requests []daemon.ControlRequest
+	if watchPause {
+		requests = append(requests, daemon.ControlRequest{Command: daemon.ControlPause})
+	}
+	if watchResume {
+		requests = append(requests, daemon.ControlRequest{Command: daemon.ControlResume})
+	}
+	if watchReindex != "" {
+		absPath, err := filepath.Abs(watchReindex)
+		if err != nil {
+			return daemon.ControlRequest{}, false, fmt.Errorf("failed to resolve %s: %w", watchReindex, err)
+		}
+		requests = append(requests, daemon.ControlRequest{Command: daemon.ControlReindex, Path: absPath})
+	}
+	if watchReload {
+		requests = append(requests, daemon.ControlRequest{Command: daemon.ControlReload})
+	}
+	switch len(requests) {
+	case 0:
+		return daemon.ControlRequest{}, false, nil
+	case 1:
+		return requests[0], true, nil
+	default:
+		return daemon.ControlRequest{}, false, fmt.Errorf("flags --pause, --resume, --reindex and --reload are mutually exclusive")
+	}
+}
+
+// watchControlSocketPath returns the control socket of the background
+// watcher for a worktree, or of the main watcher.
+func watchControlSocketPath(logDir, worktreeID string) string {
+	if worktreeID != "" {
+		return daemon.GetWorktreeControlSocketPath(logDir, worktreeID)
+	}
+	return daemon.GetControlSocketPath(logDir)
+}
+
+// findWatchControlSocket returns the control socket of the background
+// watcher watching projectRoot, or "" when none does.
+func findWatchControlSocket(projectRoot string) string {
+	if projectRoot == "" {
+		return ""
+	}
+	logDirs, err := resolveWatcherCandidateLogDirs(projectRoot)
+	if err != nil {
+		return ""
+	}
+	return daemon.FindControlSocket(projectRoot, logDirs)
+}
+
+// sendWatchControl sends a request to the background watcher.
+func sendWatchControl(logDir, worktreeID string, req daemon.ControlRequest) (*daemon.ControlResponse, error) {
+	return daemon.SendControl(watchControlSocketPath(logDir, worktreeID), req)
+}
+
+func runWatchControlCommand(logDir, worktreeID string, req daemon.ControlRequest) error {
+	return sendWatchControlCommand(watchControlSocketPath(logDir, worktreeID), logDir, "grepai watch", req)
+}
+
+// sendWatchControlCommand sends req to the watcher serving socketPath and
+// prints the outcome. watchCmd is the command line that starts the watcher,
+// used in hints.
+func sendWatchControlCommand(socketPath, logDir, watchCmd string, req daemon.ControlRequest) error {
+	resp, err := daemon.SendControl(socketPath, req)
+	if errors.Is(err, daemon.ErrControlUnavailable) {
+		return fmt.Errorf("no background watcher is accepting commands in %s\nStart one with '%s --background' (watchers started by older versions must be restarted)", logDir, watchCmd)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", req.Command, err)
+	}
+
+	switch req.Command {
+	case daemon.ControlPause:
+		fmt.Printf("Background watcher paused; changes are queued until '%s --resume'\n", watchCmd)
+	case daemon.ControlResume:
+		fmt.Println("Background watcher resumed")
+	case daemon.ControlReindex:
+		fmt.Println(resp.Message)
+	case daemon.ControlReload:
+		fmt.Println("Configuration reloaded; watch sessions are restarting")
+	}
+	return nil
+}
+
+// printWatchControlStatus prints the details reported by a background
+// watcher's control socket.
+func printWatchControlStatus(status *daemon.WatchStatus) {
+	if status.Version != "" {
+		fmt.Printf("Version: %s\n", status.Version)
+	}
+	if !status.StartedAt.IsZero() {
+		fmt.Printf("Uptime: %s\n", time.Since(status.StartedAt).Round(time.Second))
+	}
+	if status.Paused {
+		fmt.Println("Processing: paused (use 'grepai watch --resume')")
+	} else {
+		fmt.Println("Processing: active")
+	}
+	if len(status.Projects) == 0 {
+		return
+	}
+	fmt.Println("Projects:")
+	for _, project := range status.Projects {
+		fmt.Printf("  %s\n", project.Root)
+		state := project.State
+		if project.Note != "" && project.Note != "steady" {
+			state += " (" + project.Note + ")"
+		}
+		fmt.Printf("    State: %s\n", state)
+		fmt.Printf("    Queue: %d pending\n", project.QueueDepth)
+		if project.LastEvent != "" {
+			fmt.Printf("    Last event: %s (%s ago)\n", project.LastEvent, time.Since(project.LastEventAt).Round(time.Second))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/watcher"
+)
+
+func TestWatchControl_PauseSignalsLoops(t *testing.T) {
+	c := newWatchControl(t.TempDir(), func() {})
+
+	paused, changed := c.pauseState()
+	if paused {
+		t.Fatal("expected control to start unpaused")
+	}
+	if err := c.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected pause to close the changed channel")
+	}
+	if paused, _ := c.pauseState(); !paused {
+		t.Fatal("expected control to be paused")
+	}
+	if !c.Status().Paused {
+		t.Fatal("expected status to report paused")
+	}
+
+	_, changed = c.pauseState()
+	if err := c.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected resume to close the changed channel")
+	}
+}
+
+func TestWatchControl_NilIsInert(t *testing.T) {
+	var c *watchControl
+	reindexCh, detach := c.attach("/repo")
+	defer detach()
+	if reindexCh != nil {
+		t.Fatal("expected nil reindex channel without control")
+	}
+	if paused, changed := c.pauseState(); paused || changed != nil {
+		t.Fatal("expected nil control to never pause")
+	}
+	c.observeLifecycle("/repo", "running", "")
+	c.observeEvent("/repo", watcher.FileEvent{Type: watcher.EventModify, Path: "a.go"})
+	c.observeStats("/repo", watchStatsDelta{Queue: true, QueueDepth: 1})
+}
+
+func TestWatchControl_ReindexRoutesToInnermostProject(t *testing.T) {
+	mainRoot := canonicalPath(t.TempDir())
+	worktreeRoot := filepath.Join(mainRoot, ".worktrees", "feature")
+	c := newWatchControl(mainRoot, func() {})
+
+	mainCh, detachMain := c.attach(mainRoot)
+	defer detachMain()
+	worktreeCh, detachWorktree := c.attach(worktreeRoot)
+
+	msg, err := c.Reindex(filepath.Join(worktreeRoot, "src", "main.go"))
+	if err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if !strings.Contains(msg, worktreeRoot) {
+		t.Fatalf("expected message to name the worktree, got %q", msg)
+	}
+	select {
+	case rel := <-worktreeCh:
+		if rel != filepath.Join("src", "main.go") {
+			t.Fatalf("unexpected relative path %q", rel)
+		}
+	default:
+		t.Fatal("expected request on the worktree loop")
+	}
+	select {
+	case rel := <-mainCh:
+		t.Fatalf("unexpected request on the main loop: %q", rel)
+	default:
+	}
+
+	if _, err := c.Reindex(mainRoot); err != nil {
+		t.Fatalf("Reindex of project root failed: %v", err)
+	}
+	if rel := <-mainCh; rel != "." {
+		t.Fatalf("expected project root to be reindexed as \".\", got %q", rel)
+	}
+
+	detachWorktree()
+	if _, err := c.Reindex(filepath.Join(worktreeRoot, "main.go")); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("expected not running error, got %v", err)
+	}
+	if _, err := c.Reindex(filepath.Join(filepath.Dir(mainRoot), "elsewhere")); err == nil || !strings.Contains(err.Error(), "not inside a watched project") {
+		t.Fatalf("expected not watched error, got %v", err)
+	}
+	if _, err := c.Reindex("relative/path"); err == nil {
+		t.Fatal("expected relative path to be rejected")
+	}
+}
+
+func TestWatchControl_CountsPendingReindexes(t *testing.T) {
+	root := canonicalPath(t.TempDir())
+	c := newWatchControl(root, func() {})
+	pending := func() int {
+		for _, p := range c.Status().Projects {
+			if p.Root == root {
+				return p.PendingReindex
+			}
+		}
+		return -1
+	}
+
+	ch, detach := c.attach(root)
+	for _, path := range []string{filepath.Join(root, "a.go"), filepath.Join(root, "b.go")} {
+		if _, err := c.Reindex(path); err != nil {
+			t.Fatalf("Reindex failed: %v", err)
+		}
+	}
+	if got := pending(); got != 2 {
+		t.Fatalf("expected 2 pending reindexes, got %d", got)
+	}
+
+	<-ch
+	c.reindexDone(root)
+	if got := pending(); got != 1 {
+		t.Fatalf("expected 1 pending reindex, got %d", got)
+	}
+
+	// Requests still queued are dropped with the loop
+	detach()
+	if got := pending(); got != 0 {
+		t.Fatalf("expected no pending reindex after detach, got %d", got)
+	}
+}
+
+func TestWatchControl_StatusFromObservers(t *testing.T) {
+	c := newWatchControl("/repo", func() {})
+
+	c.observeLifecycle("/repo", "running", "steady")
+	c.observeLifecycle("/repo/.worktrees/a", "retrying", "failed to load config")
+	c.observeEvent("/repo", watcher.FileEvent{Type: watcher.EventModify, Path: "main.go"})
+	c.observeStats("/repo", watchStatsDelta{Queue: true, QueueDepth: 7})
+
+	status := c.Status()
+	if len(status.Projects) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", status.Projects)
+	}
+	main := status.Projects[0]
+	if main.Root != "/repo" || main.State != "running" || main.QueueDepth != 7 {
+		t.Fatalf("unexpected main project status: %+v", main)
+	}
+	if !strings.HasSuffix(main.LastEvent, "main.go") || time.Since(main.LastEventAt) > time.Minute {
+		t.Fatalf("unexpected last event: %+v", main)
+	}
+	if status.Projects[1].State != "retrying" || status.Projects[1].Note != "failed to load config" {
+		t.Fatalf("unexpected worktree status: %+v", status.Projects[1])
+	}
+
+	c.observeLifecycle("/repo/.worktrees/a", "removed", "")
+	if got := len(c.Status().Projects); got != 1 {
+		t.Fatalf("expected removed project to be dropped, got %d projects", got)
+	}
+}
+
+func TestWatchControl_StopAndReload(t *testing.T) {
+	root := t.TempDir()
+	stopped := false
+	c := newWatchControl(root, func() { stopped = true })
+
+	if err := c.ReloadConfig(); err == nil {
+		t.Fatal("expected reload without a config to fail")
+	}
+	select {
+	case <-c.reload:
+		t.Fatal("expected no reload signal after a failed validation")
+	default:
+	}
+
+	if err := c.Stop(); err != nil || !stopped {
+		t.Fatalf("expected Stop to call the stop function, err=%v", err)
+	}
+}
+
+func TestWatchControl_WorkspaceCannotReload(t *testing.T) {
+	c := newWatchControl("", func() {})
+	err := c.ReloadConfig()
+	if err == nil || !strings.Contains(err.Error(), "restart the watcher") {
+		t.Fatalf("expected workspace reload to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/hooks"
+	"github.com/yoanbernabeu/grepai/indexer"
+)
+
+// watchHookObserver is notified of each hook run of a project.
+type watchHookObserver func(projectRoot string, res hooks.Result)
+
+// startWatchHooks starts the hook runner of a project. Runs are logged and
+// passed to observer, if any. It returns nil when no hooks are configured; a
+// nil runner fires nothing.
+func startWatchHooks(projectRoot string, cfg config.WatchHooksConfig, observer watchHookObserver) *hooks.Runner {
+	return hooks.New(cfg, projectRoot, func(res hooks.Result) {
+		if res.Err != nil {
+			slog.Warn("Hook failed", "hook", res.Hook, "event", string(res.Event), "project", res.Project, "duration_ms", res.Duration.Milliseconds(), "err", res.Err)
+		} else {
+			slog.Info("Hook ran", "hook", res.Hook, "event", string(res.Event), "project", res.Project, "duration_ms", res.Duration.Milliseconds())
+		}
+		if observer != nil {
+			observer(projectRoot, res)
+		}
+	})
+}
+
+// fireWatchHookError fires the error hooks of r for a failure on path, which
+// may be empty.
+func fireWatchHookError(r *hooks.Runner, path string, err error) {
+	r.Fire(hooks.Payload{Event: hooks.EventError, Path: path, Error: err.Error()})
+}
+
+// scanCompletePayload describes the end of an initial scan.
+func scanCompletePayload(stats *indexer.IndexStats) hooks.Payload {
+	return hooks.Payload{
+		Event:        hooks.EventScanComplete,
+		FilesIndexed: stats.FilesIndexed,
+		FilesRemoved: stats.FilesRemoved,
+		Chunks:       stats.ChunksCreated,
+		DurationMs:   stats.Duration.Milliseconds(),
+	}
+}
+
+// formatWatchHookResult describes a hook run for the watch UI activity log.
+func formatWatchHookResult(res hooks.Result) string {
+	if res.Err != nil {
+		return fmt.Sprintf("Hook %s (%s) failed: %v", res.Hook, res.Event, res.Err)
+	}
+	return fmt.Sprintf("Hook %s (%s) ran in %dms", res.Hook, res.Event, res.Duration.Milliseconds())
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/hooks"
+	"github.com/yoanbernabeu/grepai/indexer"
+)
+
+func TestStartWatchHooks_ReportsRunsToObserver(t *testing.T) {
+	var got hooks.Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewDecoder(req.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var observed []string
+	observer := func(projectRoot string, res hooks.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, projectRoot+": "+formatWatchHookResult(res))
+	}
+
+	projectRoot := t.TempDir()
+	runner := startWatchHooks(projectRoot, config.WatchHooksConfig{Actions: []config.WatchHook{
+		{Name: "notify", Events: []string{"scan_complete"}, URL: srv.URL},
+	}}, observer)
+	runner.Fire(scanCompletePayload(&indexer.IndexStats{FilesIndexed: 4, ChunksCreated: 9, Duration: 1500 * time.Millisecond}))
+	fireWatchHookError(runner, "a.go", context.DeadlineExceeded) // No error hook configured
+	runner.Close()
+
+	if got.Event != hooks.EventScanComplete || got.FilesIndexed != 4 || got.Chunks != 9 || got.DurationMs != 1500 || got.Project != projectRoot {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(observed) != 1 || !strings.HasPrefix(observed[0], projectRoot+": Hook notify (scan_complete) ran in") {
+		t.Fatalf("unexpected observed runs: %q", observed)
+	}
+}
+
+func TestStartWatchHooks_NoRunner(t *testing.T) {
+	if startWatchHooks(t.TempDir(), config.WatchHooksConfig{}, nil) != nil {
+		t.Fatal("expected no runner without hooks")
+	}
+	// Firing without a runner is a no-op
+	fireWatchHookError(nil, "a.go", context.Canceled)
+}
@@ -257,6 +257,7 @@ func TestHandleFileEvent_SkipsUnchangedFile(t *testing.T) {
 		cfg,
 		&lastWrite,
 		nil,
+		nil,
 		watcher.FileEvent{Type: watcher.EventModify, Path: "main.go"},
 		nil,
 		nil,
@@ -341,7 +342,7 @@ func TestHandleWorkspaceFileEvent_SkipsUnchangedFile(t *testing.T) {
 	cfg := config.DefaultConfig()
 	var lastConfigWrite time.Time
 
-	handleFileEvent(ctx, idx, scanner, extractor, nil, nil, wrappedStore, nil, projectPath, cfg, &lastConfigWrite, nil, watcher.FileEvent{
+	handleFileEvent(ctx, idx, scanner, extractor, nil, nil, wrappedStore, nil, projectPath, cfg, &lastConfigWrite, nil, nil, watcher.FileEvent{
 		Type: watcher.EventModify,
 		Path: "proj/main.go",
 	}, nil, nil)
@@ -411,6 +412,7 @@ func TestHandleFileEvent_IndexesChangedFileAndUpdatesSymbols(t *testing.T) {
 		cfg,
 		&lastWrite,
 		nil,
+		nil,
 		watcher.FileEvent{Type: watcher.EventModify, Path: "main.go"},
 		nil,
 		nil,
@@ -435,6 +437,36 @@ func TestHandleFileEvent_IndexesChangedFileAndUpdatesSymbols(t *testing.T) {
 	}
 }
 
+func TestHandleFileEvent_WithoutLastConfigWriteKeepsLastIndexTime(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectRoot, "main.go"), []byte("package main\n\nfunc real() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to create source file: %v", err)
+	}
+
+	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	emb := &countingEmbedder{}
+	scanner := indexer.NewScanner(projectRoot, ignoreMatcher)
+	vecStore := store.NewGOBStore(filepath.Join(projectRoot, "index.gob"))
+	idx := indexer.NewIndexer(projectRoot, vecStore, emb, indexer.NewChunker(512, 50), scanner, time.Time{})
+	symbolStore := trace.NewGOBSymbolStore(filepath.Join(projectRoot, "symbols.gob"))
+	defer symbolStore.Close()
+
+	cfg := config.DefaultConfig()
+	handleFileEvent(ctx, idx, scanner, trace.NewRegexExtractor(), symbolStore, nil, vecStore, []string{".go"}, projectRoot, cfg, nil, nil, nil,
+		watcher.FileEvent{Type: watcher.EventModify, Path: "main.go"}, nil, nil)
+
+	if emb.embedCalls == 0 && emb.embedBatchCalls == 0 {
+		t.Fatal("expected the file to be indexed")
+	}
+	if !cfg.Watch.LastIndexTime.IsZero() {
+		t.Fatalf("expected last index time to be left as is, got %v", cfg.Watch.LastIndexTime)
+	}
+}
+
 func TestHandleFileEvent_DeleteRemovesIndexAndSymbols(t *testing.T) {
 	ctx := context.Background()
 	projectRoot := t.TempDir()
@@ -486,6 +518,7 @@ func TestHandleFileEvent_DeleteRemovesIndexAndSymbols(t *testing.T) {
 		cfg,
 		&lastWrite,
 		nil,
+		nil,
 		watcher.FileEvent{Type: watcher.EventDelete, Path: "main.go"},
 		nil,
 		nil,
@@ -566,3 +599,71 @@ func TestEmitInitialStatsSnapshot_ReportsExistingTotals(t *testing.T) {
 		t.Fatal("expected snapshot delta to be marked as Snapshot")
 	}
 }
+
+func TestHandleFileEvent_MoveKeepsEmbeddingsAndSymbols(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+
+	content := "package main\n\nfunc real() {}\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, "old.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create source file: %v", err)
+	}
+
+	ignoreMatcher, err := indexer.NewIgnoreMatcher(projectRoot, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	emb := &countingEmbedder{}
+	scanner := indexer.NewScanner(projectRoot, ignoreMatcher)
+	vecStore := store.NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	idx := indexer.NewIndexer(projectRoot, vecStore, emb, indexer.NewChunker(512, 50), scanner, time.Time{})
+	symbolStore := trace.NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	defer symbolStore.Close()
+
+	cfg := config.DefaultConfig()
+	lastWrite := time.Time{}
+	handle := func(event watcher.FileEvent) {
+		handleFileEvent(ctx, idx, scanner, trace.NewRegexExtractor(), symbolStore, nil, vecStore,
+			[]string{".go"}, projectRoot, cfg, &lastWrite, nil, nil, event, nil, nil)
+	}
+
+	handle(watcher.FileEvent{Type: watcher.EventCreate, Path: "old.go"})
+	embedded := emb.embedCalls + emb.embedBatchCalls
+
+	if err := os.Rename(filepath.Join(projectRoot, "old.go"), filepath.Join(projectRoot, "new.go")); err != nil {
+		t.Fatal(err)
+	}
+	handle(watcher.FileEvent{Type: watcher.EventMove, Path: "new.go", OldPath: "old.go"})
+
+	if got := emb.embedCalls + emb.embedBatchCalls; got != embedded {
+		t.Errorf("expected move not to embed, got %d new calls", got-embedded)
+	}
+	if doc, _ := vecStore.GetDocument(ctx, "old.go"); doc != nil {
+		t.Error("expected old.go to be removed from the index")
+	}
+	if doc, _ := vecStore.GetDocument(ctx, "new.go"); doc == nil || len(doc.ChunkIDs) == 0 {
+		t.Fatalf("expected new.go to be indexed, got %+v", doc)
+	}
+	if syms, _ := symbolStore.LookupSymbol(ctx, "real"); len(syms) != 1 || syms[0].File != "new.go" {
+		t.Errorf("expected symbol to move to new.go, got %+v", syms)
+	}
+
+	// A move whose content changed meanwhile falls back to re-indexing.
+	if err := os.WriteFile(filepath.Join(projectRoot, "moved.go"), []byte(content+"\nfunc extra() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(projectRoot, "new.go")); err != nil {
+		t.Fatal(err)
+	}
+	handle(watcher.FileEvent{Type: watcher.EventMove, Path: "moved.go", OldPath: "new.go"})
+
+	if got := emb.embedCalls + emb.embedBatchCalls; got == embedded {
+		t.Error("expected changed content to be embedded")
+	}
+	if doc, _ := vecStore.GetDocument(ctx, "new.go"); doc != nil {
+		t.Error("expected new.go to be removed from the index")
+	}
+	if syms, _ := symbolStore.LookupSymbol(ctx, "extra"); len(syms) != 1 || syms[0].File != "moved.go" {
+		t.Errorf("expected moved.go to be re-extracted, got %+v", syms)
+	}
+}
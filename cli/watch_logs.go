@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+	"github.com/yoanbernabeu/grepai/internal/logging"
+)
+
+const (
+	defaultWatchLogLevel     = "info"
+	defaultWatchLogMaxSizeMB = 10
+	defaultWatchLogMaxFiles  = 5
+	defaultWatchLogsLines    = 50
+)
+
+func validateWatchLogFlags() error {
+	if err := logging.ValidateFormat(watchLogFormat); err != nil {
+		return err
+	}
+	if _, err := logging.ParseLevel(watchLogLevel); err != nil {
+		return err
+	}
+	if watchLogMaxSize < 0 {
+		return fmt.Errorf("--log-max-size must be >= 0")
+	}
+	if watchLogMaxKeep < 0 {
+		return fmt.Errorf("--log-max-files must be >= 0")
+	}
+	if (watchLogsFollow || watchLogsLines != defaultWatchLogsLines) && !watchLogs {
+		return fmt.Errorf("--follow and --lines require --logs")
+	}
+	return nil
+}
+
+// watchLogArgs returns the logging flags to pass on to a background watcher
+// or service.
+func watchLogArgs() []string {
+	var args []string
+	if watchLogFormat != logging.FormatText {
+		args = append(args, "--log-format", watchLogFormat)
+	}
+	if watchLogLevel != defaultWatchLogLevel {
+		args = append(args, "--log-level", watchLogLevel)
+	}
+	if watchLogMaxSize != defaultWatchLogMaxSizeMB {
+		args = append(args, "--log-max-size", strconv.Itoa(watchLogMaxSize))
+	}
+	if watchLogMaxKeep != defaultWatchLogMaxFiles {
+		args = append(args, "--log-max-files", strconv.Itoa(watchLogMaxKeep))
+	}
+	return args
+}
+
+// setupBackgroundWatchLogging switches a background watcher to structured
+// logging. The watcher writes to (and rotates) the log file named by
+// GREPAI_LOG_FILE, or to stderr when it runs under a service manager with a
+// journal.
+func setupBackgroundWatchLogging() (func(), error) {
+	var output io.Writer = os.Stderr
+	var rotating *logging.RotatingWriter
+	if path := os.Getenv(logging.FileEnv); path != "" {
+		var err error
+		rotating, err = logging.OpenRotating(path, int64(watchLogMaxSize)*1024*1024, watchLogMaxKeep)
+		if err != nil {
+			return nil, err
+		}
+		output = rotating
+	}
+
+	restore, err := logging.Setup(logging.Options{
+		Format: watchLogFormat,
+		Level:  watchLogLevel,
+		Output: output,
+	})
+	if err != nil {
+		if rotating != nil {
+			rotating.Close()
+		}
+		return nil, err
+	}
+	return func() {
+		restore()
+		if rotating != nil {
+			rotating.Close()
+		}
+	}, nil
+}
+
+// resolveWatchLogFile returns the log file of the watcher for the current
+// project or worktree. A worktree watched by the main watcher logs to the
+// main log file.
+func resolveWatchLogFile(logDir, worktreeID string) string {
+	logDirs := []string{logDir}
+	if watchLogDir == "" {
+		if projectRoot, err := config.FindProjectRoot(); err == nil {
+			if candidates, err := resolveWatcherCandidateLogDirs(projectRoot); err == nil && len(candidates) > 0 {
+				logDirs = candidates
+			}
+		}
+	}
+
+	var candidates []string
+	for _, dir := range logDirs {
+		if worktreeID != "" {
+			candidates = append(candidates, daemon.GetWorktreeLogFile(dir, worktreeID))
+		}
+		candidates = append(candidates, filepath.Join(dir, "grepai-watch.log"))
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// showWatchLogs prints the last lines of a watcher log file, pretty-printing
+// structured records, and keeps printing new lines with --follow.
+func showWatchLogs(logFile string) error {
+	lines, offset, err := logging.TailLines(logFile, watchLogsLines)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no log file at %s", logFile)
+		}
+		return fmt.Errorf("failed to read %s: %w", logFile, err)
+	}
+	for _, line := range lines {
+		fmt.Println(logging.FormatLine(line))
+	}
+	if !watchLogsFollow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return logging.Follow(ctx, logFile, offset, func(line string) {
+		fmt.Println(logging.FormatLine(line))
+	})
+}
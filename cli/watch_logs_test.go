@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/daemon"
+)
+
+func setWatchLogFlags(t *testing.T, format, level string, maxSize, maxFiles int) {
+	t.Helper()
+	oldFormat, oldLevel, oldSize, oldKeep := watchLogFormat, watchLogLevel, watchLogMaxSize, watchLogMaxKeep
+	t.Cleanup(func() {
+		watchLogFormat, watchLogLevel, watchLogMaxSize, watchLogMaxKeep = oldFormat, oldLevel, oldSize, oldKeep
+	})
+	watchLogFormat, watchLogLevel, watchLogMaxSize, watchLogMaxKeep = format, level, maxSize, maxFiles
+}
+
+func TestWatchLogArgs(t *testing.T) {
+	setWatchLogFlags(t, "text", defaultWatchLogLevel, defaultWatchLogMaxSizeMB, defaultWatchLogMaxFiles)
+	if args := watchLogArgs(); len(args) != 0 {
+		t.Fatalf("expected no args for defaults, got %q", args)
+	}
+
+	setWatchLogFlags(t, "json", "debug", 0, 2)
+	got := strings.Join(watchLogArgs(), " ")
+	want := "--log-format json --log-level debug --log-max-size 0 --log-max-files 2"
+	if got != want {
+		t.Fatalf("watchLogArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateWatchLogFlags(t *testing.T) {
+	setWatchLogFlags(t, "yaml", defaultWatchLogLevel, defaultWatchLogMaxSizeMB, defaultWatchLogMaxFiles)
+	if err := validateWatchLogFlags(); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	setWatchLogFlags(t, "json", "trace", defaultWatchLogMaxSizeMB, defaultWatchLogMaxFiles)
+	if err := validateWatchLogFlags(); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+	setWatchLogFlags(t, "json", "warn", -1, defaultWatchLogMaxFiles)
+	if err := validateWatchLogFlags(); err == nil {
+		t.Fatal("expected an error for a negative size")
+	}
+	setWatchLogFlags(t, "json", "warn", defaultWatchLogMaxSizeMB, defaultWatchLogMaxFiles)
+	if err := validateWatchLogFlags(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveWatchLogFile_FallsBackToMainLog(t *testing.T) {
+	logDir := t.TempDir()
+	oldLogDir := watchLogDir
+	watchLogDir = logDir
+	t.Cleanup(func() { watchLogDir = oldLogDir })
+
+	mainLog := filepath.Join(logDir, "grepai-watch.log")
+	if got := resolveWatchLogFile(logDir, "abc123"); got != daemon.GetWorktreeLogFile(logDir, "abc123") {
+		t.Fatalf("expected the worktree log when no log exists, got %s", got)
+	}
+	if err := os.WriteFile(mainLog, []byte("started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveWatchLogFile(logDir, "abc123"); got != mainLog {
+		t.Fatalf("expected the main log of the watcher covering the worktree, got %s", got)
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/metrics"
+	"github.com/yoanbernabeu/grepai/store"
+)
+
+// watchMetricsStatsTimeout bounds the GetStats calls made during a scrape.
+const watchMetricsStatsTimeout = 5 * time.Second
+
+// watchMetrics holds the per-project metrics of a watch session.
+type watchMetrics struct {
+	filesIndexed    *metrics.CounterVec
+	filesRemoved    *metrics.CounterVec
+	chunksIndexed   *metrics.CounterVec
+	chunksRemoved   *metrics.CounterVec
+	eventsCoalesced *metrics.CounterVec
+	overflows       *metrics.CounterVec
+	rescans         *metrics.CounterVec
+	queueDepth      *metrics.GaugeVec
+	rpgRefresh      *metrics.HistogramVec
+	rpgPersist      *metrics.HistogramVec
+
+	mu     sync.Mutex
+	stores map[string]store.VectorStore
+}
+
+func newWatchMetrics(reg *metrics.Registry) *watchMetrics {
+	m := &watchMetrics{
+		filesIndexed: reg.NewCounterVec("grepai_files_indexed_total",
+			"Files indexed, including the initial scan.", "project"),
+		filesRemoved: reg.NewCounterVec("grepai_files_removed_total",
+			"Files removed from the index.", "project"),
+		chunksIndexed: reg.NewCounterVec("grepai_chunks_indexed_total",
+			"Chunks embedded and stored, including the initial scan.", "project"),
+		chunksRemoved: reg.NewCounterVec("grepai_chunks_removed_total",
+			"Chunks removed from the index.", "project"),
+		eventsCoalesced: reg.NewCounterVec("grepai_watch_events_coalesced_total",
+			"File events merged into an already queued event.", "project"),
+		overflows: reg.NewCounterVec("grepai_watch_overflows_total",
+			"Watcher event queue overflows.", "project"),
+		rescans: reg.NewCounterVec("grepai_watch_rescans_total",
+			"Rescans triggered after an overflow or a reconcile.", "project"),
+		queueDepth: reg.NewGaugeVec("grepai_watch_queue_depth",
+			"File events waiting to be processed.", "project"),
+		rpgRefresh: reg.NewHistogramVec("grepai_rpg_derived_refresh_duration_seconds",
+			"Duration of RPG derived edge refreshes, by mode (incremental or full).",
+			metrics.DefaultBuckets, "project", "mode"),
+		rpgPersist: reg.NewHistogramVec("grepai_rpg_persist_duration_seconds",
+			"Duration of RPG graph persists.", metrics.DefaultBuckets, "project"),
+		stores: make(map[string]store.VectorStore),
+	}
+
+	labels := []string{"project"}
+	reg.NewGaugeFunc("grepai_store_files", "Files in the vector store.", labels, func() []metrics.Sample {
+		return m.storeSamples(func(s *store.IndexStats) float64 { return float64(s.TotalFiles) })
+	})
+	reg.NewGaugeFunc("grepai_store_chunks", "Chunks in the vector store.", labels, func() []metrics.Sample {
+		return m.storeSamples(func(s *store.IndexStats) float64 { return float64(s.TotalChunks) })
+	})
+	reg.NewGaugeFunc("grepai_store_size_bytes", "Size of the vector store as reported by the backend.", labels, func() []metrics.Sample {
+		return m.storeSamples(func(s *store.IndexStats) float64 { return float64(s.IndexSize) })
+	})
+	return m
+}
+
+// observeStats records the incremental stats of a watch loop. Snapshots
+// describe the whole index and are exported by the store gauges instead.
+func (m *watchMetrics) observeStats(projectRoot string, delta watchStatsDelta) {
+	if delta.Snapshot {
+		return
+	}
+	project := canonicalPath(projectRoot)
+	if delta.Queue {
+		m.queueDepth.Set(float64(delta.QueueDepth), project)
+		m.eventsCoalesced.Add(float64(delta.EventsCoalesced), project)
+		m.overflows.Add(float64(delta.Overflows), project)
+		m.rescans.Add(float64(delta.Rescans), project)
+		return
+	}
+	m.filesIndexed.Add(float64(delta.FilesIndexed), project)
+	m.filesRemoved.Add(float64(delta.FilesRemoved), project)
+	m.chunksIndexed.Add(float64(delta.ChunksCreated), project)
+	m.chunksRemoved.Add(float64(delta.ChunksRemoved), project)
+}
+
+// observeScan records the files and chunks indexed by an initial scan.
+func (m *watchMetrics) observeScan(projectRoot string, stats *indexer.IndexStats) {
+	if stats == nil {
+		return
+	}
+	project := canonicalPath(projectRoot)
+	m.filesIndexed.Add(float64(stats.FilesIndexed), project)
+	m.filesRemoved.Add(float64(stats.FilesRemoved), project)
+	m.chunksIndexed.Add(float64(stats.ChunksCreated), project)
+}
+
+// registerStore exports the size of st until the returned function is called.
+func (m *watchMetrics) registerStore(projectRoot string, st store.VectorStore) func() {
+	project := canonicalPath(projectRoot)
+	m.mu.Lock()
+	m.stores[project] = st
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		if m.stores[project] == st {
+			delete(m.stores, project)
+		}
+		m.mu.Unlock()
+		m.queueDepth.Delete(project)
+	}
+}
+
+func (m *watchMetrics) storeSamples(value func(*store.IndexStats) float64) []metrics.Sample {
+	m.mu.Lock()
+	stores := make(map[string]store.VectorStore, len(m.stores))
+	for project, st := range m.stores {
+		stores[project] = st
+	}
+	m.mu.Unlock()
+
+	samples := make([]metrics.Sample, 0, len(stores))
+	for project, st := range stores {
+		ctx, cancel := context.WithTimeout(context.Background(), watchMetricsStatsTimeout)
+		stats, err := st.GetStats(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to read store stats for metrics: %v", err)
+			continue
+		}
+		if stats == nil {
+			continue
+		}
+		samples = append(samples, metrics.Sample{LabelValues: []string{project}, Value: value(stats)})
+	}
+	return samples
+}
+
+var (
+	defaultWatchMetricsOnce sync.Once
+	defaultWatchMetrics     *watchMetrics
+)
+
+// serveWatchMetrics starts the metrics endpoint configured by
+// watch.metrics_addr and returns the metrics the watch sessions record, and a
+// function stopping the endpoint. Without an address the metrics are nil.
+func serveWatchMetrics(cfg *config.Config) (*watchMetrics, func()) {
+	if cfg == nil || cfg.Watch.MetricsAddr == "" {
+		return nil, func() {}
+	}
+	defaultWatchMetricsOnce.Do(func() {
+		defaultWatchMetrics = newWatchMetrics(metrics.Default)
+	})
+	server, err := metrics.Serve(cfg.Watch.MetricsAddr, metrics.Default)
+	if err != nil {
+		log.Printf("Warning: metrics endpoint unavailable: %v", err)
+		return nil, func() {}
+	}
+	slog.Info("Serving metrics", "addr", "http://"+server.Addr()+"/metrics")
+	return defaultWatchMetrics, func() { _ = server.Close() }
+}
+
+// wrapStatsObserver returns an observer recording stats in m before passing
+// them to next, which may be nil.
+func (m *watchMetrics) wrapStatsObserver(next watchStatsObserver) watchStatsObserver {
+	return func(projectRoot string, delta watchStatsDelta) {
+		m.observeStats(projectRoot, delta)
+		if next != nil {
+			next(projectRoot, delta)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/metrics"
+	"github.com/yoanbernabeu/grepai/store"
+)
+
+func TestWatchMetrics_Scrape(t *testing.T) {
+	reg := metrics.NewRegistry()
+	m := newWatchMetrics(reg)
+
+	projectRoot := t.TempDir()
+	st := store.NewGOBStore(filepath.Join(projectRoot, "index.gob"))
+	if err := st.SaveChunks(context.Background(), []store.Chunk{
+		{ID: "a.go_0", FilePath: "a.go", Content: "package a", Vector: []float32{1}},
+		{ID: "a.go_1", FilePath: "a.go", Content: "func A() {}", Vector: []float32{1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SaveDocument(context.Background(), store.Document{Path: "a.go", ChunkIDs: []string{"a.go_0", "a.go_1"}}); err != nil {
+		t.Fatal(err)
+	}
+	unregister := m.registerStore(projectRoot, st)
+
+	m.observeScan(projectRoot, &indexer.IndexStats{FilesIndexed: 3, ChunksCreated: 10})
+	m.observeStats(projectRoot, watchStatsDelta{FilesIndexed: 1, ChunksCreated: 2, ChunksRemoved: 1})
+	m.observeStats(projectRoot, watchStatsDelta{FilesIndexed: 100, Snapshot: true})
+	m.observeStats(projectRoot, watchStatsDelta{Queue: true, QueueDepth: 4, Overflows: 1})
+	m.rpgPersist.Observe(0.2, canonicalPath(projectRoot))
+
+	server, err := metrics.Serve("127.0.0.1:0", reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	scrape := func() string {
+		t.Helper()
+		resp, err := http.Get("http://" + server.Addr() + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	project := canonicalPath(projectRoot)
+	body := scrape()
+	for _, want := range []string{
+		fmt.Sprintf("grepai_files_indexed_total{project=%q} 4\n", project),
+		fmt.Sprintf("grepai_chunks_indexed_total{project=%q} 12\n", project),
+		fmt.Sprintf("grepai_chunks_removed_total{project=%q} 1\n", project),
+		fmt.Sprintf("grepai_watch_queue_depth{project=%q} 4\n", project),
+		fmt.Sprintf("grepai_watch_overflows_total{project=%q} 1\n", project),
+		fmt.Sprintf("grepai_store_files{project=%q} 1\n", project),
+		fmt.Sprintf("grepai_store_chunks{project=%q} 2\n", project),
+		fmt.Sprintf("grepai_rpg_persist_duration_seconds_count{project=%q} 1\n", project),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %q:\n%s", want, body)
+		}
+	}
+
+	unregister()
+	body = scrape()
+	if strings.Contains(body, "grepai_store_files{") || strings.Contains(body, "grepai_watch_queue_depth{") {
+		t.Fatalf("expected the store and queue gauges to be removed:\n%s", body)
+	}
+}
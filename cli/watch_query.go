@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/search"
+	"github.com/yoanbernabeu/grepai/store"
+)
+
+// findWatchQueryClient returns a client for the background watcher serving
+// queries for projectRoot, or nil when commands must load the indexes
+// themselves.
+func findWatchQueryClient(projectRoot string) *daemon.QueryClient {
+	if projectRoot == "" {
+		return nil
+	}
+	logDirs, err := resolveWatcherCandidateLogDirs(projectRoot)
+	if err != nil {
+		return nil
+	}
+	return daemon.FindQueryClient(projectRoot, logDirs)
+}
+
+// openQuerySearcher returns a searcher over the index of projectRoot, or of
+// the snapshot of ref, and a function releasing it. The working tree index
+// is searched through the background watcher when it serves queries.
+func openQuerySearcher(ctx context.Context, cfg *config.Config, projectRoot, ref string) (daemon.QuerySearcher, func(), error) {
+	if ref == "" {
+		if client := findWatchQueryClient(projectRoot); client != nil {
+			s := daemon.NewFallbackSearcher(client, func(ctx context.Context) (daemon.QuerySearcher, func(), error) {
+				return openSearcher(ctx, cfg, projectRoot, "")
+			})
+			return s, s.Close, nil
+		}
+	}
+	searcher, closeSearcher, err := openSearcher(ctx, cfg, projectRoot, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	return searcher, closeSearcher, nil
+}
+
+// watchQuerySearcher answers the queries for a watched project. The files
+// ranked lower change as the watcher indexes, so refresh swaps in a searcher
+// with the current penalties rather than changing the one queries may be
+// running on.
+type watchQuerySearcher struct {
+	st      store.VectorStore
+	emb     embedder.Embedder
+	cfg     *config.Config
+	current atomic.Pointer[search.Searcher]
+}
+
+func newWatchQuerySearcher(st store.VectorStore, emb embedder.Embedder, cfg *config.Config, report *indexer.ScanReport) *watchQuerySearcher {
+	s := &watchQuerySearcher{st: st, emb: emb, cfg: cfg}
+	s.refresh(report)
+	return s
+}
+
+func (s *watchQuerySearcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	return s.current.Load().Search(ctx, query, limit, pathPrefix)
+}
+
+// refresh ranks the files penalized in report lower, with the configured
+// penalty factor. It is a no-op on a nil searcher.
+func (s *watchQuerySearcher) refresh(report *indexer.ScanReport) {
+	if s == nil {
+		return
+	}
+	searcher := search.NewSearcher(s.st, s.emb, s.cfg.Search)
+	if report != nil {
+		searcher.SetFilePenalties(report.Penalized, s.cfg.Indexing.PenaltyFactor)
+	}
+	s.current.Store(searcher)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/store"
+)
+
+func TestWatchQuerySearcher_RefreshAppliesCurrentPenalties(t *testing.T) {
+	st := store.NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	vector := []float32{0.1, 0.2, 0.3}
+	if err := st.SaveChunks(context.Background(), []store.Chunk{
+		{ID: "1", FilePath: "api/api.pb.go", Vector: vector},
+		{ID: "2", FilePath: "api/handler.go", Vector: vector},
+	}); err != nil {
+		t.Fatalf("failed to save chunks: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Indexing.PenaltyFactor = 0.1
+
+	searcher := newWatchQuerySearcher(st, &noOpEmbedder{}, cfg, indexer.NewScanReport())
+	results, err := searcher.Search(context.Background(), "api", 2, "")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	for _, r := range results {
+		if r.Score < 0.5 {
+			t.Fatalf("expected no penalty before the file is penalized, got %+v", results)
+		}
+	}
+
+	report := indexer.NewScanReport()
+	report.Penalized["api/api.pb.go"] = "generated"
+	searcher.refresh(report)
+	results, err = searcher.Search(context.Background(), "api", 2, "")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Chunk.FilePath != "api/handler.go" || results[1].Score > 0.11 {
+		t.Errorf("expected the newly penalized file ranked last, got %+v", results)
+	}
+
+	// Sessions that serve no queries have no searcher to refresh
+	var none *watchQuerySearcher
+	none.refresh(report)
+}
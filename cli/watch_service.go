@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+)
+
+// watchServiceExecutable returns the path of the running grepai binary for
+// service definitions.
+func watchServiceExecutable() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	return executable, nil
+}
+
+// projectWatchServiceSpec describes the service running 'grepai watch' for a
+// project or linked worktree.
+func projectWatchServiceSpec(logDir, projectRoot, worktreeID string) (daemon.ServiceSpec, error) {
+	executable, err := watchServiceExecutable()
+	if err != nil {
+		return daemon.ServiceSpec{}, err
+	}
+	logFile := filepath.Join(logDir, "grepai-watch.log")
+	if worktreeID != "" {
+		logFile = daemon.GetWorktreeLogFile(logDir, worktreeID)
+	}
+	return daemon.ServiceSpec{
+		Name:        daemon.ProjectServiceName(projectRoot),
+		Description: fmt.Sprintf("grepai watch for %s", projectRoot),
+		Executable:  executable,
+		Args:        append([]string{"watch", "--no-ui", "--log-dir", logDir}, watchLogArgs()...),
+		WorkingDir:  projectRoot,
+		LogFile:     logFile,
+	}, nil
+}
+
+// workspaceWatchServiceSpec describes the service running 'grepai watch
+// --workspace' for a workspace.
+func workspaceWatchServiceSpec(logDir string, ws *config.Workspace) (daemon.ServiceSpec, error) {
+	executable, err := watchServiceExecutable()
+	if err != nil {
+		return daemon.ServiceSpec{}, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return daemon.ServiceSpec{}, err
+	}
+	return daemon.ServiceSpec{
+		Name:        daemon.WorkspaceServiceName(ws.Name),
+		Description: fmt.Sprintf("grepai watch for workspace %s", ws.Name),
+		Executable:  executable,
+		Args:        append([]string{"watch", "--workspace", ws.Name, "--no-ui", "--log-dir", logDir}, watchLogArgs()...),
+		WorkingDir:  homeDir,
+		LogFile:     daemon.GetWorkspaceLogFile(logDir, ws.Name),
+	}, nil
+}
+
+func installProjectWatchService(logDir, worktreeID string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	var pid int
+	if worktreeID != "" {
+		pid, err = daemon.GetRunningWorktreePID(logDir, worktreeID)
+	} else {
+		pid, err = daemon.GetRunningPID(logDir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check running status: %w", err)
+	}
+	if pid > 0 {
+		return fmt.Errorf("a background watcher is already running (PID %d); stop it with 'grepai watch --stop' before installing the service", pid)
+	}
+
+	spec, err := projectWatchServiceSpec(logDir, projectRoot, worktreeID)
+	if err != nil {
+		return err
+	}
+	if err := installWatchService(spec); err != nil {
+		return err
+	}
+	if err := saveWatchLogDirHint(projectRoot, logDir); err != nil {
+		fmt.Printf("Warning: failed to save log directory hint: %v\n", err)
+	}
+	return nil
+}
+
+func installWorkspaceWatchService(logDir string, ws *config.Workspace) error {
+	pid, err := daemon.GetRunningWorkspacePID(logDir, ws.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check running status: %w", err)
+	}
+	if pid > 0 {
+		return fmt.Errorf("workspace watcher %s is already running (PID %d); stop it with 'grepai watch --workspace %s --stop' before installing the service", ws.Name, pid, ws.Name)
+	}
+
+	spec, err := workspaceWatchServiceSpec(logDir, ws)
+	if err != nil {
+		return err
+	}
+	return installWatchService(spec)
+}
+
+func installWatchService(spec daemon.ServiceSpec) error {
+	install, err := daemon.InstallService(spec)
+	if err != nil {
+		if install != nil {
+			return fmt.Errorf("wrote %s but failed to enable it: %w", install.Path, err)
+		}
+		return fmt.Errorf("failed to install service: %w", err)
+	}
+
+	switch install.Manager {
+	case "systemd":
+		fmt.Printf("Installed systemd user service %s\n", spec.Name)
+		fmt.Printf("Unit: %s\n", install.Path)
+		fmt.Println("The watcher is running, starts at login and restarts on failure.")
+		fmt.Printf("\nLogs:    journalctl --user -u %s -f\n", spec.Name)
+		fmt.Printf("Status:  systemctl --user status %s\n", spec.Name)
+		fmt.Println("To keep it running after logout: loginctl enable-linger")
+	case "launchd":
+		fmt.Printf("Wrote launchd agent %s\n", install.Path)
+		fmt.Printf("\nLoad it with:  launchctl bootstrap gui/$(id -u) %s\n", install.Path)
+		fmt.Printf("Logs:          %s\n", spec.LogFile)
+	}
+	fmt.Println("Remove it with 'grepai watch --uninstall-service'")
+	return nil
+}
+
+func uninstallWatchService(name string) error {
+	path, err := daemon.UninstallService(name)
+	if err != nil {
+		return fmt.Errorf("failed to uninstall service: %w", err)
+	}
+	if path == "" {
+		fmt.Printf("No service %s is installed\n", name)
+		return nil
+	}
+	fmt.Printf("Removed service %s (%s)\n", name, path)
+	return nil
+}
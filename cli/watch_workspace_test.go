@@ -317,3 +317,39 @@ func TestPrintProgressAndBatchProgress_NoUIModeUsesNewlines(t *testing.T) {
 		t.Fatalf("expected inline progress output, got %q", out)
 	}
 }
+
+func TestRunWorkspaceWatchPausesThroughControlSocket(t *testing.T) {
+	ws := config.Workspace{
+		Name: "ws",
+		Store: config.StoreConfig{
+			Backend:  "postgres",
+			Postgres: config.PostgresConfig{DSN: "postgres://localhost/test"},
+		},
+		Embedder: config.EmbedderConfig{Provider: "ollama"},
+		Projects: []config.ProjectEntry{{Name: "p1", Path: t.TempDir()}},
+	}
+	setupWorkspaceHome(t, ws)
+	withWatchGlobals(t, ws.Name, false, false, false)
+	oldPause := watchPause
+	watchPause = true
+	t.Cleanup(func() { watchPause = oldPause })
+
+	logDir := t.TempDir()
+	if err := runWorkspaceWatch(logDir); err == nil || !strings.Contains(err.Error(), "grepai watch --workspace ws --background") {
+		t.Fatalf("expected a hint to start the workspace watcher, got %v", err)
+	}
+
+	control := newWatchControl("", func() {})
+	server, err := daemon.ServeControl(daemon.GetWorkspaceControlSocketPath(logDir, ws.Name), control)
+	if err != nil {
+		t.Fatalf("ServeControl() failed: %v", err)
+	}
+	defer server.Close()
+
+	if err := runWorkspaceWatch(logDir); err != nil {
+		t.Fatalf("runWorkspaceWatch() failed: %v", err)
+	}
+	if paused, _ := control.pauseState(); !paused {
+		t.Fatal("expected the workspace watcher to be paused")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/metrics"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +18,11 @@ const (
 	IndexFileName       = "index.gob"
 	SymbolIndexFileName = "symbols.gob"
 	RPGIndexFileName    = "rpg.gob"
+	ScanReportFileName  = "scan_report.json"
+	SnapshotsDirName    = "snapshots"
+	SnapshotFileName    = "snapshot.json"
+	IndexJournalName    = "index.journal"
+	WatchLockFileName   = "watch.lock"
 
 	DefaultEmbedderProvider         = "ollama"
 	DefaultOllamaEmbeddingModel     = "nomic-embed-text"
@@ -40,6 +46,21 @@ const (
 	DefaultQwen8BDimensions         = 4096
 	DefaultOpenAIParallelism        = 4
 
+	// Indexing defaults for large, generated and vendored files. Large files
+	// are skipped and generated and vendored files are indexed, as before the
+	// policies existed.
+	DefaultMaxFileSizeKB   = 1024
+	DefaultLargeFilePolicy = "skip"
+	DefaultFilePolicy      = "index"
+	DefaultPenaltyFactor   = 0.3
+
+	// DefaultSecretsPolicy redacts credentials before embedding and storage.
+	DefaultSecretsPolicy = "redact"
+
+	// Checkpoint defaults for resumable indexing.
+	DefaultCheckpointFiles       = 200
+	DefaultCheckpointIntervalSec = 30
+
 	DefaultPostgresDSN    = "postgres://localhost:5432/grepai"
 	DefaultQdrantEndpoint = "localhost"
 	DefaultQdrantPort     = 6334
@@ -56,6 +77,15 @@ const (
 	DefaultWatchRPGDerivedDebounceMs      = 300
 	DefaultWatchRPGFullReconcileIntervalS = 300
 	DefaultWatchRPGMaxDirtyFilesPerBatch  = 128
+
+	// Watch defaults for change detection.
+	DefaultWatchMode           = "auto"
+	DefaultWatchPollIntervalMs = 2000
+	DefaultWatchReconcileSec   = 60
+
+	// Watch hook defaults.
+	DefaultWatchHookTimeoutSec    = 30
+	DefaultWatchHookMaxConcurrent = 2
 )
 
 type Config struct {
@@ -63,16 +93,43 @@ type Config struct {
 	Embedder          EmbedderConfig  `yaml:"embedder"`
 	Store             StoreConfig     `yaml:"store"`
 	Chunking          ChunkingConfig  `yaml:"chunking"`
+	Indexing          IndexingConfig  `yaml:"indexing,omitempty"`
 	Framework         FrameworkConfig `yaml:"framework_processing"`
 	Watch             WatchConfig     `yaml:"watch"`
 	Search            SearchConfig    `yaml:"search"`
 	Trace             TraceConfig     `yaml:"trace"`
 	RPG               RPGConfig       `yaml:"rpg"`
 	Update            UpdateConfig    `yaml:"update"`
+	MCP               MCPConfig       `yaml:"mcp,omitempty"`
 	Ignore            []string        `yaml:"ignore"`
 	ExternalGitignore string          `yaml:"external_gitignore,omitempty"`
 }
 
+// MCPConfig is the access policy of mcp-serve. Path patterns use gitignore
+// syntax and are matched against paths relative to the project.
+type MCPConfig struct {
+	Tools      []string `yaml:"tools,omitempty"`       // Tools exposed to clients (default: all)
+	AllowPaths []string `yaml:"allow_paths,omitempty"` // Only results under these paths are returned (default: all)
+	DenyPaths  []string `yaml:"deny_paths,omitempty"`  // Results under these paths are never returned
+	Redaction  string   `yaml:"redaction,omitempty"`   // redact | off (default: redact)
+}
+
+// RedactsSecrets reports whether credentials are redacted from results.
+func (c MCPConfig) RedactsSecrets() bool {
+	return c.Redaction != "off"
+}
+
+// ValidateMCPConfig checks MCP policy values for validity.
+func ValidateMCPConfig(cfg MCPConfig) error {
+	switch cfg.Redaction {
+	case "", "redact", "off":
+		// valid
+	default:
+		return fmt.Errorf("mcp.redaction must be one of: redact, off; got %q", cfg.Redaction)
+	}
+	return nil
+}
+
 // UpdateConfig holds auto-update settings
 type UpdateConfig struct {
 	CheckOnStartup bool `yaml:"check_on_startup"` // Check for updates when running commands
@@ -205,6 +262,59 @@ type ChunkingConfig struct {
 	Overlap int `yaml:"overlap"`
 }
 
+// IndexingConfig controls which files are indexed and how structured formats
+// are converted to text before chunking.
+type IndexingConfig struct {
+	Extensions         []string `yaml:"extensions,omitempty"`          // Extra file extensions to index (e.g. ".tpl")
+	ExcludeExtensions  []string `yaml:"exclude_extensions,omitempty"`  // Built-in extensions to stop indexing
+	DisabledExtractors []string `yaml:"disabled_extractors,omitempty"` // notebook | openapi | markdown | rst
+	MaxFileSizeKB      int      `yaml:"max_file_size_kb,omitempty"`    // Files above this size follow LargeFiles
+	LargeFiles         string   `yaml:"large_files,omitempty"`         // skip | penalize | index
+	Generated          string   `yaml:"generated,omitempty"`           // skip | penalize | index
+	Vendored           string   `yaml:"vendored,omitempty"`            // skip | penalize | index
+	PenaltyFactor      float32  `yaml:"penalty_factor,omitempty"`      // Search score multiplier for penalized files
+	Secrets            string   `yaml:"secrets,omitempty"`             // redact | skip | index: files containing credentials
+	// Resumable indexing: the index is persisted after every CheckpointFiles
+	// files or CheckpointIntervalSec seconds, whichever comes first.
+	CheckpointFiles       int `yaml:"checkpoint_files,omitempty"`
+	CheckpointIntervalSec int `yaml:"checkpoint_interval_sec,omitempty"`
+}
+
+// ValidateIndexingConfig checks indexing configuration values for validity.
+func ValidateIndexingConfig(cfg IndexingConfig) error {
+	if cfg.MaxFileSizeKB < 1 {
+		return fmt.Errorf("indexing.max_file_size_kb must be >= 1, got %d", cfg.MaxFileSizeKB)
+	}
+	for key, policy := range map[string]string{
+		"large_files": cfg.LargeFiles,
+		"generated":   cfg.Generated,
+		"vendored":    cfg.Vendored,
+	} {
+		switch policy {
+		case "skip", "penalize", "index":
+			// valid
+		default:
+			return fmt.Errorf("indexing.%s must be one of: skip, penalize, index; got %q", key, policy)
+		}
+	}
+	switch cfg.Secrets {
+	case "redact", "skip", "index":
+		// valid
+	default:
+		return fmt.Errorf("indexing.secrets must be one of: redact, skip, index; got %q", cfg.Secrets)
+	}
+	if cfg.PenaltyFactor <= 0 || cfg.PenaltyFactor > 1 {
+		return fmt.Errorf("indexing.penalty_factor must be in (0, 1], got %.2f", cfg.PenaltyFactor)
+	}
+	if cfg.CheckpointFiles < 1 {
+		return fmt.Errorf("indexing.checkpoint_files must be >= 1, got %d", cfg.CheckpointFiles)
+	}
+	if cfg.CheckpointIntervalSec < 1 {
+		return fmt.Errorf("indexing.checkpoint_interval_sec must be >= 1, got %d", cfg.CheckpointIntervalSec)
+	}
+	return nil
+}
+
 func DefaultStoreForBackend(backend string) StoreConfig {
 	cfg := StoreConfig{Backend: backendOrDefault(backend)}
 	switch cfg.Backend {
@@ -278,12 +388,36 @@ func (c *FrameworkFeatureConfig) UnmarshalYAML(value *yaml.Node) error {
 }
 
 type WatchConfig struct {
-	DebounceMs                  int       `yaml:"debounce_ms"`
-	LastIndexTime               time.Time `yaml:"last_index_time,omitempty"`
-	RPGPersistIntervalMs        int       `yaml:"rpg_persist_interval_ms,omitempty"`
-	RPGDerivedDebounceMs        int       `yaml:"rpg_derived_debounce_ms,omitempty"`
-	RPGFullReconcileIntervalSec int       `yaml:"rpg_full_reconcile_interval_sec,omitempty"`
-	RPGMaxDirtyFilesPerBatch    int       `yaml:"rpg_max_dirty_files_per_batch,omitempty"`
+	DebounceMs                  int              `yaml:"debounce_ms"`
+	Mode                        string           `yaml:"mode,omitempty"`             // auto | fsnotify | poll
+	PollIntervalMs              int              `yaml:"poll_interval_ms,omitempty"` // Interval between polls in poll mode
+	IncludePaths                []string         `yaml:"include_paths,omitempty"`    // Sub-trees watched live; empty watches everything
+	ReconcileIntervalSec        int              `yaml:"reconcile_interval_sec,omitempty"`
+	ServeQueries                bool             `yaml:"serve_queries,omitempty"` // Background watcher answers search/trace/refs over a local socket
+	MetricsAddr                 string           `yaml:"metrics_addr,omitempty"`  // Loopback host:port serving Prometheus metrics; empty disables
+	Hooks                       WatchHooksConfig `yaml:"hooks,omitempty"`
+	LastIndexTime               time.Time        `yaml:"last_index_time,omitempty"`
+	RPGPersistIntervalMs        int              `yaml:"rpg_persist_interval_ms,omitempty"`
+	RPGDerivedDebounceMs        int              `yaml:"rpg_derived_debounce_ms,omitempty"`
+	RPGFullReconcileIntervalSec int              `yaml:"rpg_full_reconcile_interval_sec,omitempty"`
+	RPGMaxDirtyFilesPerBatch    int              `yaml:"rpg_max_dirty_files_per_batch,omitempty"`
+}
+
+// WatchHooksConfig configures the commands and webhooks fired on watch events.
+type WatchHooksConfig struct {
+	TimeoutSec    int         `yaml:"timeout_sec,omitempty"`    // Per-run timeout; 0 uses the default
+	MaxConcurrent int         `yaml:"max_concurrent,omitempty"` // Hooks running at once; 0 uses the default
+	Actions       []WatchHook `yaml:"actions,omitempty"`
+}
+
+// WatchHook is a command or webhook fired on a set of watch events. Commands
+// receive the event as JSON on stdin; webhooks receive it as a POST body.
+type WatchHook struct {
+	Name       string   `yaml:"name,omitempty"`
+	Events     []string `yaml:"events"` // scan_complete | file_indexed | file_removed | rpg_reconciled | error
+	Command    string   `yaml:"command,omitempty"`
+	URL        string   `yaml:"url,omitempty"`
+	TimeoutSec int      `yaml:"timeout_sec,omitempty"` // Overrides hooks.timeout_sec
 }
 
 type TraceConfig struct {
@@ -292,6 +426,19 @@ type TraceConfig struct {
 	ExcludePatterns  []string `yaml:"exclude_patterns"`  // Patterns to exclude
 }
 
+// DefaultTracedLanguages lists the file extensions whose symbols are indexed
+// when trace.enabled_languages is empty.
+var DefaultTracedLanguages = []string{".go", ".js", ".ts", ".jsx", ".tsx", ".vue", ".py", ".php", ".lua", ".java", ".cs", ".fs", ".fsx", ".fsi"}
+
+// TracedLanguages returns the enabled languages, or DefaultTracedLanguages
+// when none are configured.
+func (c TraceConfig) TracedLanguages() []string {
+	if len(c.EnabledLanguages) == 0 {
+		return DefaultTracedLanguages
+	}
+	return c.EnabledLanguages
+}
+
 type RPGConfig struct {
 	Enabled              bool    `yaml:"enabled"`
 	StorePath            string  `yaml:"store_path,omitempty"`
@@ -331,6 +478,32 @@ func ValidateRPGConfig(cfg RPGConfig) error {
 
 // ValidateWatchConfig checks watch configuration values for validity.
 func ValidateWatchConfig(cfg WatchConfig) error {
+	switch cfg.Mode {
+	case "auto", "fsnotify", "poll":
+		// valid
+	default:
+		return fmt.Errorf("watch.mode must be one of: auto, fsnotify, poll; got %q", cfg.Mode)
+	}
+	if cfg.PollIntervalMs < 100 {
+		return fmt.Errorf("watch.poll_interval_ms must be >= 100, got %d", cfg.PollIntervalMs)
+	}
+	for _, path := range cfg.IncludePaths {
+		clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+		if strings.TrimSpace(path) == "" || filepath.IsAbs(path) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("watch.include_paths entries must be relative paths inside the project, got %q", path)
+		}
+	}
+	if cfg.ReconcileIntervalSec < 10 {
+		return fmt.Errorf("watch.reconcile_interval_sec must be >= 10, got %d", cfg.ReconcileIntervalSec)
+	}
+	if cfg.MetricsAddr != "" {
+		if err := metrics.ValidateAddr(cfg.MetricsAddr); err != nil {
+			return fmt.Errorf("watch.metrics_addr: %w", err)
+		}
+	}
+	if err := validateWatchHooks(cfg.Hooks); err != nil {
+		return err
+	}
 	if cfg.RPGPersistIntervalMs < 200 {
 		return fmt.Errorf("watch.rpg_persist_interval_ms must be >= 200, got %d", cfg.RPGPersistIntervalMs)
 	}
@@ -346,6 +519,38 @@ func ValidateWatchConfig(cfg WatchConfig) error {
 	return nil
 }
 
+func validateWatchHooks(cfg WatchHooksConfig) error {
+	if cfg.TimeoutSec < 0 {
+		return fmt.Errorf("watch.hooks.timeout_sec must be >= 0, got %d", cfg.TimeoutSec)
+	}
+	if cfg.MaxConcurrent < 0 {
+		return fmt.Errorf("watch.hooks.max_concurrent must be >= 0, got %d", cfg.MaxConcurrent)
+	}
+	for i, hook := range cfg.Actions {
+		if (hook.Command == "") == (hook.URL == "") {
+			return fmt.Errorf("watch.hooks.actions[%d] must set exactly one of command or url", i)
+		}
+		if hook.URL != "" && !strings.HasPrefix(hook.URL, "http://") && !strings.HasPrefix(hook.URL, "https://") {
+			return fmt.Errorf("watch.hooks.actions[%d].url must be an http or https URL, got %q", i, hook.URL)
+		}
+		if hook.TimeoutSec < 0 {
+			return fmt.Errorf("watch.hooks.actions[%d].timeout_sec must be >= 0, got %d", i, hook.TimeoutSec)
+		}
+		if len(hook.Events) == 0 {
+			return fmt.Errorf("watch.hooks.actions[%d].events must not be empty", i)
+		}
+		for _, event := range hook.Events {
+			switch event {
+			case "scan_complete", "file_indexed", "file_removed", "rpg_reconciled", "error":
+				// valid
+			default:
+				return fmt.Errorf("watch.hooks.actions[%d].events must be among: scan_complete, file_indexed, file_removed, rpg_reconciled, error; got %q", i, event)
+			}
+		}
+	}
+	return nil
+}
+
 func DefaultConfig() *Config {
 	return &Config{
 		Version:  1,
@@ -355,6 +560,16 @@ func DefaultConfig() *Config {
 			Size:    512,
 			Overlap: 50,
 		},
+		Indexing: IndexingConfig{
+			MaxFileSizeKB:         DefaultMaxFileSizeKB,
+			LargeFiles:            DefaultLargeFilePolicy,
+			Generated:             DefaultFilePolicy,
+			Vendored:              DefaultFilePolicy,
+			PenaltyFactor:         DefaultPenaltyFactor,
+			Secrets:               DefaultSecretsPolicy,
+			CheckpointFiles:       DefaultCheckpointFiles,
+			CheckpointIntervalSec: DefaultCheckpointIntervalSec,
+		},
 		Framework: FrameworkConfig{
 			Enabled:  true,
 			Mode:     "auto",
@@ -368,6 +583,9 @@ func DefaultConfig() *Config {
 		},
 		Watch: WatchConfig{
 			DebounceMs:                  500,
+			Mode:                        DefaultWatchMode,
+			PollIntervalMs:              DefaultWatchPollIntervalMs,
+			ReconcileIntervalSec:        DefaultWatchReconcileSec,
 			RPGPersistIntervalMs:        DefaultWatchRPGPersistIntervalMs,
 			RPGDerivedDebounceMs:        DefaultWatchRPGDerivedDebounceMs,
 			RPGFullReconcileIntervalSec: DefaultWatchRPGFullReconcileIntervalS,
@@ -488,6 +706,63 @@ func GetRPGIndexPath(projectRoot string) string {
 	return filepath.Join(GetConfigDir(projectRoot), RPGIndexFileName)
 }
 
+func GetScanReportPath(projectRoot string) string {
+	return filepath.Join(GetConfigDir(projectRoot), ScanReportFileName)
+}
+
+func GetIndexJournalPath(projectRoot string) string {
+	return filepath.Join(GetConfigDir(projectRoot), IndexJournalName)
+}
+
+// GetWatchLockPath returns the lock file held by the watchers of a project.
+func GetWatchLockPath(projectRoot string) string {
+	return filepath.Join(GetConfigDir(projectRoot), WatchLockFileName)
+}
+
+// SnapshotName turns a git ref into a snapshot name usable as a directory,
+// collection suffix or project ID suffix (e.g. "release/2.3" -> "release_2.3").
+func SnapshotName(ref string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, ref)
+	if strings.Trim(name, ".") == "" {
+		name = strings.ReplaceAll(name, ".", "_")
+	}
+	return name
+}
+
+// GetSnapshotsDir returns the directory holding all git ref snapshots.
+func GetSnapshotsDir(projectRoot string) string {
+	return filepath.Join(GetConfigDir(projectRoot), SnapshotsDirName)
+}
+
+// GetSnapshotDir returns the directory of a named snapshot. Snapshot files use
+// the same names as the main index (index.gob, symbols.gob, scan_report.json).
+func GetSnapshotDir(projectRoot, name string) string {
+	return filepath.Join(GetSnapshotsDir(projectRoot), name)
+}
+
+func GetSnapshotManifestPath(projectRoot, name string) string {
+	return filepath.Join(GetSnapshotDir(projectRoot, name), SnapshotFileName)
+}
+
+func GetSnapshotIndexPath(projectRoot, name string) string {
+	return filepath.Join(GetSnapshotDir(projectRoot, name), IndexFileName)
+}
+
+func GetSnapshotSymbolIndexPath(projectRoot, name string) string {
+	return filepath.Join(GetSnapshotDir(projectRoot, name), SymbolIndexFileName)
+}
+
+func GetSnapshotScanReportPath(projectRoot, name string) string {
+	return filepath.Join(GetSnapshotDir(projectRoot, name), ScanReportFileName)
+}
+
 func Load(projectRoot string) (*Config, error) {
 	configPath := GetConfigPath(projectRoot)
 
@@ -509,6 +784,16 @@ func Load(projectRoot string) (*Config, error) {
 		return nil, fmt.Errorf("invalid watch configuration: %w", err)
 	}
 
+	// Validate indexing file policies
+	if err := ValidateIndexingConfig(cfg.Indexing); err != nil {
+		return nil, fmt.Errorf("invalid indexing configuration: %w", err)
+	}
+
+	// Validate MCP access policy
+	if err := ValidateMCPConfig(cfg.MCP); err != nil {
+		return nil, fmt.Errorf("invalid MCP configuration: %w", err)
+	}
+
 	// Validate RPG config when enabled
 	if cfg.RPG.Enabled {
 		if err := ValidateRPGConfig(cfg.RPG); err != nil {
@@ -553,6 +838,32 @@ func (c *Config) applyDefaults() {
 		c.Chunking.Overlap = defaults.Chunking.Overlap
 	}
 
+	// Indexing defaults
+	if c.Indexing.MaxFileSizeKB == 0 {
+		c.Indexing.MaxFileSizeKB = defaults.Indexing.MaxFileSizeKB
+	}
+	if c.Indexing.LargeFiles == "" {
+		c.Indexing.LargeFiles = defaults.Indexing.LargeFiles
+	}
+	if c.Indexing.Generated == "" {
+		c.Indexing.Generated = defaults.Indexing.Generated
+	}
+	if c.Indexing.Vendored == "" {
+		c.Indexing.Vendored = defaults.Indexing.Vendored
+	}
+	if c.Indexing.PenaltyFactor == 0 {
+		c.Indexing.PenaltyFactor = defaults.Indexing.PenaltyFactor
+	}
+	if c.Indexing.Secrets == "" {
+		c.Indexing.Secrets = defaults.Indexing.Secrets
+	}
+	if c.Indexing.CheckpointFiles == 0 {
+		c.Indexing.CheckpointFiles = defaults.Indexing.CheckpointFiles
+	}
+	if c.Indexing.CheckpointIntervalSec == 0 {
+		c.Indexing.CheckpointIntervalSec = defaults.Indexing.CheckpointIntervalSec
+	}
+
 	// Framework processing defaults
 	hasFrameworkConfig := c.Framework.isSet
 	if !hasFrameworkConfig {
@@ -585,6 +896,15 @@ func (c *Config) applyDefaults() {
 	if c.Watch.DebounceMs == 0 {
 		c.Watch.DebounceMs = defaults.Watch.DebounceMs
 	}
+	if c.Watch.Mode == "" {
+		c.Watch.Mode = defaults.Watch.Mode
+	}
+	if c.Watch.PollIntervalMs == 0 {
+		c.Watch.PollIntervalMs = defaults.Watch.PollIntervalMs
+	}
+	if c.Watch.ReconcileIntervalSec == 0 {
+		c.Watch.ReconcileIntervalSec = defaults.Watch.ReconcileIntervalSec
+	}
 	if c.Watch.RPGPersistIntervalMs == 0 {
 		c.Watch.RPGPersistIntervalMs = defaults.Watch.RPGPersistIntervalMs
 	}
@@ -60,6 +60,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Watch.DebounceMs != 500 {
 		t.Errorf("expected debounce 500ms, got %d", cfg.Watch.DebounceMs)
 	}
+	if cfg.Watch.Mode != DefaultWatchMode || cfg.Watch.PollIntervalMs != DefaultWatchPollIntervalMs {
+		t.Errorf("expected watch mode %q every %dms, got %q every %dms", DefaultWatchMode, DefaultWatchPollIntervalMs, cfg.Watch.Mode, cfg.Watch.PollIntervalMs)
+	}
 	if cfg.Watch.RPGPersistIntervalMs != DefaultWatchRPGPersistIntervalMs {
 		t.Errorf("expected watch.rpg_persist_interval_ms=%d, got %d", DefaultWatchRPGPersistIntervalMs, cfg.Watch.RPGPersistIntervalMs)
 	}
@@ -695,6 +698,10 @@ func TestValidateWatchConfig(t *testing.T) {
 		{
 			name: "valid config",
 			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				IncludePaths:                []string{"src", "services/api/"},
+				ReconcileIntervalSec:        60,
 				RPGPersistIntervalMs:        1000,
 				RPGDerivedDebounceMs:        300,
 				RPGFullReconcileIntervalSec: 300,
@@ -742,6 +749,143 @@ func TestValidateWatchConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown mode",
+			cfg: WatchConfig{
+				Mode:                        "inotify",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "poll interval too low",
+			cfg: WatchConfig{
+				Mode:                        "poll",
+				PollIntervalMs:              99,
+				ReconcileIntervalSec:        60,
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "include path outside project",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				IncludePaths:                []string{"src", "../shared"},
+				ReconcileIntervalSec:        60,
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "reconcile interval too low",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        9,
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "metrics on localhost",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				MetricsAddr:                 "127.0.0.1:9464",
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: false,
+		},
+		{
+			name: "metrics on all interfaces",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				MetricsAddr:                 ":9464",
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "hooks with command and webhook",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				Hooks:                       WatchHooksConfig{Actions: []WatchHook{{Events: []string{"scan_complete"}, Command: "make docs"}, {Events: []string{"error"}, URL: "https://example.com/hook"}}},
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: false,
+		},
+		{
+			name: "hook with command and url",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				Hooks:                       WatchHooksConfig{Actions: []WatchHook{{Events: []string{"error"}, Command: "true", URL: "https://example.com/hook"}}},
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "hook with unknown event",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				Hooks:                       WatchHooksConfig{Actions: []WatchHook{{Events: []string{"file_changed"}, Command: "true"}}},
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
+		{
+			name: "hook with non-http url",
+			cfg: WatchConfig{
+				Mode:                        "auto",
+				PollIntervalMs:              2000,
+				ReconcileIntervalSec:        60,
+				Hooks:                       WatchHooksConfig{Actions: []WatchHook{{Events: []string{"error"}, URL: "ftp://example.com"}}},
+				RPGPersistIntervalMs:        1000,
+				RPGDerivedDebounceMs:        300,
+				RPGFullReconcileIntervalSec: 300,
+				RPGMaxDirtyFilesPerBatch:    128,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -753,3 +897,102 @@ func TestValidateWatchConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyDefaults_IndexingFilePolicy(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults()
+
+	if cfg.Indexing.MaxFileSizeKB != DefaultMaxFileSizeKB {
+		t.Errorf("expected indexing.max_file_size_kb=%d, got %d", DefaultMaxFileSizeKB, cfg.Indexing.MaxFileSizeKB)
+	}
+	if cfg.Indexing.Generated != "index" || cfg.Indexing.Vendored != "index" {
+		t.Errorf("expected generated and vendored files indexed by default, got %+v", cfg.Indexing)
+	}
+	if cfg.Indexing.LargeFiles != "skip" {
+		t.Errorf("expected large files skipped by default, got %q", cfg.Indexing.LargeFiles)
+	}
+	if err := ValidateIndexingConfig(cfg.Indexing); err != nil {
+		t.Errorf("defaults should be valid: %v", err)
+	}
+}
+
+func TestValidateIndexingConfig(t *testing.T) {
+	valid := DefaultConfig().Indexing
+
+	tests := []struct {
+		name    string
+		mutate  func(c *IndexingConfig)
+		wantErr bool
+	}{
+		{"defaults", func(c *IndexingConfig) {}, false},
+		{"penalize generated", func(c *IndexingConfig) { c.Generated = "penalize" }, false},
+		{"index vendored", func(c *IndexingConfig) { c.Vendored = "index" }, false},
+		{"unknown policy", func(c *IndexingConfig) { c.LargeFiles = "drop" }, true},
+		{"skip secrets", func(c *IndexingConfig) { c.Secrets = "skip" }, false},
+		{"unknown secrets policy", func(c *IndexingConfig) { c.Secrets = "penalize" }, true},
+		{"zero max size", func(c *IndexingConfig) { c.MaxFileSizeKB = 0 }, true},
+		{"penalty above one", func(c *IndexingConfig) { c.PenaltyFactor = 1.5 }, true},
+		{"zero checkpoint files", func(c *IndexingConfig) { c.CheckpointFiles = 0 }, true},
+		{"negative checkpoint interval", func(c *IndexingConfig) { c.CheckpointIntervalSec = -1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.mutate(&cfg)
+			err := ValidateIndexingConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIndexingConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMCPConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        MCPConfig
+		wantErr    bool
+		wantRedact bool
+	}{
+		{"defaults", MCPConfig{}, false, true},
+		{"redact", MCPConfig{Redaction: "redact"}, false, true},
+		{"off", MCPConfig{Redaction: "off"}, false, false},
+		{"unknown redaction", MCPConfig{Redaction: "mask"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMCPConfig(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMCPConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.cfg.RedactsSecrets() != tt.wantRedact {
+				t.Errorf("RedactsSecrets() = %v, want %v", tt.cfg.RedactsSecrets(), tt.wantRedact)
+			}
+		})
+	}
+}
+
+func TestSnapshotName(t *testing.T) {
+	tests := map[string]string{
+		"v2.3.0":           "v2.3.0",
+		"release/2.3":      "release_2.3",
+		"HEAD~3":           "HEAD_3",
+		"..":               "__",
+		"feature/a b":      "feature_a_b",
+		"a1b2c3d4e5f6a7b8": "a1b2c3d4e5f6a7b8",
+	}
+	for ref, want := range tests {
+		if got := SnapshotName(ref); got != want {
+			t.Errorf("SnapshotName(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestTraceConfig_TracedLanguages(t *testing.T) {
+	if got := (TraceConfig{}).TracedLanguages(); len(got) != len(DefaultTracedLanguages) {
+		t.Errorf("expected default traced languages, got %v", got)
+	}
+	if got := (TraceConfig{EnabledLanguages: []string{".rs"}}).TracedLanguages(); len(got) != 1 || got[0] != ".rs" {
+		t.Errorf("expected configured languages, got %v", got)
+	}
+}
@@ -26,6 +26,7 @@ type Workspace struct {
 	Store    StoreConfig    `yaml:"store"`
 	Embedder EmbedderConfig `yaml:"embedder"`
 	Projects []ProjectEntry `yaml:"projects"`
+	MCP      MCPConfig      `yaml:"mcp,omitempty"` // Access policy of mcp-serve for this workspace
 }
 
 // ProjectEntry represents a single project within a workspace.
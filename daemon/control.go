@@ -0,0 +1,223 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	controlSocketName     = "grepai-watch.sock"
+	worktreeControlSuffix = ".sock"
+
+	// socketDialTimeout bounds connecting to a daemon that is not listening.
+	socketDialTimeout = 2 * time.Second
+	// controlRequestTimeout bounds a whole request/response exchange.
+	controlRequestTimeout = 10 * time.Second
+)
+
+// Control commands understood by a control server.
+const (
+	ControlStatus  = "status"
+	ControlPause   = "pause"
+	ControlResume  = "resume"
+	ControlReindex = "reindex"
+	ControlReload  = "reload"
+	ControlStop    = "stop"
+)
+
+// ErrControlUnavailable is returned by SendControl when no daemon serves the
+// socket: it is not running, or predates the control socket.
+var ErrControlUnavailable = errors.New("control socket unavailable")
+
+// ControlRequest is a command sent to a running watch daemon. Requests and
+// responses are exchanged as one JSON document per line.
+type ControlRequest struct {
+	Command string `json:"command"`
+	Path    string `json:"path,omitempty"` // Absolute path to reindex
+}
+
+// ControlResponse is the daemon's answer to a ControlRequest.
+type ControlResponse struct {
+	OK      bool         `json:"ok"`
+	Error   string       `json:"error,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Status  *WatchStatus `json:"status,omitempty"`
+}
+
+// WatchStatus describes a running watch daemon.
+type WatchStatus struct {
+	PID       int             `json:"pid"`
+	Version   string          `json:"version,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	Paused    bool            `json:"paused"`
+	Projects  []ProjectStatus `json:"projects"`
+}
+
+// ProjectStatus describes one project (main worktree or linked worktree)
+// watched by a daemon.
+type ProjectStatus struct {
+	Root        string    `json:"root"`
+	State       string    `json:"state"` // queued, starting, running, restarting, retrying, error, stopped
+	Note        string    `json:"note,omitempty"`
+	QueueDepth  int       `json:"queue_depth"`
+	LastEvent   string    `json:"last_event,omitempty"` // e.g. "MODIFY src/main.go"
+	LastEventAt time.Time `json:"last_event_at,omitempty"`
+	// PendingReindex counts the reindex requests queued or in progress
+	PendingReindex int `json:"pending_reindex,omitempty"`
+}
+
+// Project returns the status of the innermost project containing path, or
+// nil when the daemon watches none. path must be absolute and canonical.
+func (s *WatchStatus) Project(path string) *ProjectStatus {
+	var found *ProjectStatus
+	for i := range s.Projects {
+		root := s.Projects[i].Root
+		if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(root) > len(found.Root) {
+			found = &s.Projects[i]
+		}
+	}
+	return found
+}
+
+// ControlHandler executes control commands inside the daemon. Methods must
+// return quickly: long operations such as reindexing are queued.
+type ControlHandler interface {
+	Status() WatchStatus
+	Pause() error
+	Resume() error
+	Reindex(path string) (string, error)
+	ReloadConfig() error
+	Stop() error
+}
+
+// GetControlSocketPath returns the path of the control socket of the watch
+// daemon.
+func GetControlSocketPath(logDir string) string {
+	return filepath.Join(logDir, controlSocketName)
+}
+
+// GetWorktreeControlSocketPath returns the path of the control socket of a
+// worktree watch daemon.
+func GetWorktreeControlSocketPath(logDir, worktreeID string) string {
+	return filepath.Join(logDir, worktreePIDPrefix+worktreeID+worktreeControlSuffix)
+}
+
+// FindControlSocket returns the control socket of the running daemon
+// watching projectRoot, looking for control sockets in logDirs. It returns
+// "" when no running daemon watches the project.
+func FindControlSocket(projectRoot string, logDirs []string) string {
+	root := projectRoot
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = filepath.Clean(abs)
+	}
+
+	for _, logDir := range logDirs {
+		sockets, _ := filepath.Glob(filepath.Join(logDir, "grepai-*"+worktreeControlSuffix))
+		sort.Strings(sockets)
+		for _, socketPath := range sockets {
+			if strings.HasSuffix(socketPath, "query.sock") {
+				continue
+			}
+			if info, err := os.Stat(socketPath); err != nil || info.Mode()&os.ModeSocket == 0 {
+				continue
+			}
+			resp, err := SendControl(socketPath, ControlRequest{Command: ControlStatus})
+			if err == nil && resp.Status != nil && resp.Status.Project(root) != nil {
+				return socketPath
+			}
+		}
+	}
+	return ""
+}
+
+// ControlServer serves control requests on a Unix domain socket.
+type ControlServer struct {
+	*socketServer
+	handler ControlHandler
+}
+
+// ServeControl listens on socketPath and serves control requests with
+// handler until Close is called. A leftover socket from a crashed daemon is
+// replaced; the PID file lock guarantees a single daemon per socket.
+func ServeControl(socketPath string, handler ControlHandler) (*ControlServer, error) {
+	s := &ControlServer{handler: handler}
+	server, err := listenSocket(socketPath, controlRequestTimeout, s.handle)
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	s.socketServer = server
+	return s, nil
+}
+
+// Close stops accepting requests, waits for in-flight ones and removes the
+// socket.
+func (s *ControlServer) Close() error {
+	return s.close()
+}
+
+func (s *ControlServer) handle(line []byte) any {
+	var req ControlRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return ControlResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+	return s.dispatch(req)
+}
+
+func (s *ControlServer) dispatch(req ControlRequest) ControlResponse {
+	var err error
+	resp := ControlResponse{}
+	switch req.Command {
+	case ControlStatus:
+		status := s.handler.Status()
+		resp.Status = &status
+	case ControlPause:
+		err = s.handler.Pause()
+	case ControlResume:
+		err = s.handler.Resume()
+	case ControlReindex:
+		if req.Path == "" {
+			err = errors.New("reindex requires a path")
+			break
+		}
+		resp.Message, err = s.handler.Reindex(req.Path)
+	case ControlReload:
+		err = s.handler.ReloadConfig()
+	case ControlStop:
+		err = s.handler.Stop()
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.OK = true
+	return resp
+}
+
+// SendControl sends a request to the daemon listening on socketPath. It
+// returns ErrControlUnavailable when nothing listens there, and the daemon's
+// message as an error when the command fails.
+func SendControl(socketPath string, req ControlRequest) (*ControlResponse, error) {
+	var resp ControlResponse
+	if err := exchange(context.Background(), socketPath, controlRequestTimeout, ErrControlUnavailable, req, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
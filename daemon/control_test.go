@@ -0,0 +1,237 @@
+package daemon
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeControlHandler struct {
+	paused   bool
+	reindex  string
+	stopped  bool
+	projects []ProjectStatus // Defaults to a running /repo
+}
+
+func (h *fakeControlHandler) Status() WatchStatus {
+	projects := h.projects
+	if projects == nil {
+		projects = []ProjectStatus{{Root: "/repo", State: "running", QueueDepth: 3}}
+	}
+	return WatchStatus{PID: 42, Paused: h.paused, Projects: projects}
+}
+
+func (h *fakeControlHandler) Pause() error  { h.paused = true; return nil }
+func (h *fakeControlHandler) Resume() error { h.paused = false; return nil }
+
+func (h *fakeControlHandler) Reindex(path string) (string, error) {
+	if !strings.HasPrefix(path, "/repo") {
+		return "", errors.New("not watched")
+	}
+	h.reindex = path
+	return "queued " + path, nil
+}
+
+func (h *fakeControlHandler) ReloadConfig() error { return nil }
+func (h *fakeControlHandler) Stop() error         { h.stopped = true; return nil }
+
+func TestControlRoundTrip(t *testing.T) {
+	socketPath := GetControlSocketPath(t.TempDir())
+	handler := &fakeControlHandler{}
+	server, err := ServeControl(socketPath, handler)
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer server.Close()
+
+	if _, err := SendControl(socketPath, ControlRequest{Command: ControlPause}); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	resp, err := SendControl(socketPath, ControlRequest{Command: ControlStatus})
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if resp.Status == nil || !resp.Status.Paused || resp.Status.PID != 42 {
+		t.Fatalf("unexpected status: %+v", resp.Status)
+	}
+	if len(resp.Status.Projects) != 1 || resp.Status.Projects[0].QueueDepth != 3 {
+		t.Fatalf("unexpected projects: %+v", resp.Status.Projects)
+	}
+
+	resp, err = SendControl(socketPath, ControlRequest{Command: ControlReindex, Path: "/repo/main.go"})
+	if err != nil {
+		t.Fatalf("reindex failed: %v", err)
+	}
+	if resp.Message != "queued /repo/main.go" || handler.reindex != "/repo/main.go" {
+		t.Fatalf("unexpected reindex result: %q, %q", resp.Message, handler.reindex)
+	}
+
+	if _, err := SendControl(socketPath, ControlRequest{Command: ControlStop}); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if !handler.stopped {
+		t.Fatal("expected handler to be stopped")
+	}
+}
+
+func TestControlErrors(t *testing.T) {
+	socketPath := GetControlSocketPath(t.TempDir())
+	server, err := ServeControl(socketPath, &fakeControlHandler{})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		req  ControlRequest
+		want string
+	}{
+		{"unknown command", ControlRequest{Command: "explode"}, `unknown command "explode"`},
+		{"reindex without path", ControlRequest{Command: ControlReindex}, "reindex requires a path"},
+		{"handler error", ControlRequest{Command: ControlReindex, Path: "/elsewhere"}, "not watched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := SendControl(socketPath, tt.req)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected error %q, got %v", tt.want, err)
+			}
+			if resp == nil || resp.OK {
+				t.Fatalf("expected a failed response, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestControlUnavailable(t *testing.T) {
+	socketPath := GetControlSocketPath(t.TempDir())
+	if _, err := SendControl(socketPath, ControlRequest{Command: ControlStatus}); !errors.Is(err, ErrControlUnavailable) {
+		t.Fatalf("expected ErrControlUnavailable, got %v", err)
+	}
+
+	server, err := ServeControl(socketPath, &fakeControlHandler{})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := SendControl(socketPath, ControlRequest{Command: ControlStatus}); !errors.Is(err, ErrControlUnavailable) {
+		t.Fatalf("expected ErrControlUnavailable after Close, got %v", err)
+	}
+}
+
+func TestServeControlReplacesStaleSocket(t *testing.T) {
+	socketPath := GetWorktreeControlSocketPath(t.TempDir(), "abc123")
+	if filepath.Base(socketPath) != "grepai-worktree-abc123.sock" {
+		t.Fatalf("unexpected worktree socket name: %s", socketPath)
+	}
+
+	first, err := ServeControl(socketPath, &fakeControlHandler{})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	// Simulate a crashed daemon: the listener is gone, the socket file stays.
+	first.listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = first.listener.Close()
+	if _, err := os.Stat(socketPath); err != nil {
+		t.Fatalf("expected stale socket to remain: %v", err)
+	}
+	first.wg.Wait()
+
+	second, err := ServeControl(socketPath, &fakeControlHandler{})
+	if err != nil {
+		t.Fatalf("ServeControl over stale socket failed: %v", err)
+	}
+	defer second.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := SendControl(socketPath, ControlRequest{Command: ControlStatus})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("status failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for status")
+	}
+}
+
+func TestServeControlKeepsLiveSocket(t *testing.T) {
+	socketPath := GetControlSocketPath(t.TempDir())
+
+	first, err := ServeControl(socketPath, &fakeControlHandler{})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer first.Close()
+
+	if _, err := ServeControl(socketPath, &fakeControlHandler{}); !errors.Is(err, ErrSocketInUse) {
+		t.Fatalf("expected ErrSocketInUse for a live socket, got %v", err)
+	}
+	if _, err := SendControl(socketPath, ControlRequest{Command: ControlStatus}); err != nil {
+		t.Fatalf("expected the first daemon to keep its socket: %v", err)
+	}
+}
+
+func TestFindControlSocket(t *testing.T) {
+	logDir := t.TempDir()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if socketPath := FindControlSocket(root, []string{logDir}); socketPath != "" {
+		t.Fatalf("expected no socket, got %s", socketPath)
+	}
+
+	// A query socket is never taken for a control socket
+	queries, err := ServeQueries(GetQuerySocketPath(logDir))
+	if err != nil {
+		t.Fatalf("ServeQueries failed: %v", err)
+	}
+	defer queries.Close()
+
+	other, err := ServeControl(GetControlSocketPath(logDir), &fakeControlHandler{
+		projects: []ProjectStatus{{Root: filepath.Join(root, "other"), State: "running"}},
+	})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer other.Close()
+	if socketPath := FindControlSocket(root, []string{logDir}); socketPath != "" {
+		t.Fatalf("expected no socket for an unwatched project, got %s", socketPath)
+	}
+
+	worktreeSocket := GetWorktreeControlSocketPath(logDir, "abc123")
+	worktree, err := ServeControl(worktreeSocket, &fakeControlHandler{
+		projects: []ProjectStatus{{Root: root, State: "running", PendingReindex: 1}},
+	})
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer worktree.Close()
+
+	if socketPath := FindControlSocket(sub, []string{logDir}); socketPath != worktreeSocket {
+		t.Fatalf("expected %s, got %q", worktreeSocket, socketPath)
+	}
+	resp, err := SendControl(worktreeSocket, ControlRequest{Command: ControlStatus})
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if project := resp.Status.Project(sub); project == nil || project.PendingReindex != 1 {
+		t.Fatalf("unexpected project status: %+v", project)
+	}
+}
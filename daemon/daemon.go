@@ -55,6 +55,8 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/yoanbernabeu/grepai/internal/logging"
 )
 
 const (
@@ -261,6 +263,7 @@ func IsReady(logDir string) bool {
 //   - stdout/stderr redirected to logDir/grepai-watch.log
 //   - stdin set to nil (no input)
 //   - GREPAI_BACKGROUND=1 environment variable set
+//   - GREPAI_LOG_FILE set to the log file, which the child rotates
 //   - process group detachment (Unix only)
 //
 // Args should be the command-line arguments to pass to the child process
@@ -477,7 +480,7 @@ func spawnBackgroundWithLog(logDir, logPath string, args []string) (int, <-chan
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 	cmd.Stdin = nil
-	cmd.Env = append(os.Environ(), "GREPAI_BACKGROUND=1")
+	cmd.Env = append(os.Environ(), "GREPAI_BACKGROUND=1", logging.FileEnv+"="+logPath)
 	cmd.SysProcAttr = sysProcAttr()
 	liveness.configureCmd(cmd)
 
@@ -0,0 +1,270 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/metrics"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+const (
+	querySocketName     = "grepai-query.sock"
+	worktreeQuerySuffix = "-query.sock"
+
+	// queryRequestTimeout bounds a query, including embedding the search text.
+	queryRequestTimeout = 60 * time.Second
+)
+
+// Query operations understood by a query server.
+const (
+	QueryPing          = "ping"
+	QuerySearch        = "search"
+	QueryLookupSymbol  = "lookup_symbol"
+	QueryLookupCallers = "lookup_callers"
+	QueryLookupCallees = "lookup_callees"
+	QueryLookupReaders = "lookup_readers"
+	QueryLookupWriters = "lookup_writers"
+	QueryCallGraph     = "call_graph"
+	QueryFileSymbols   = "file_symbols"
+	QueryCallEdges     = "call_edges"
+	QueryFileIndexed   = "file_indexed"
+	QuerySymbolStats   = "symbol_stats"
+)
+
+var (
+	queryDuration = metrics.Default.NewHistogramVec(
+		"grepai_query_duration_seconds",
+		"Latency of queries served from the loaded indexes, by operation (search, lookup_symbol, ...).",
+		metrics.DefaultBuckets, "op")
+	queryErrors = metrics.Default.NewCounterVec(
+		"grepai_query_errors_total",
+		"Queries that returned an error, by operation.",
+		"op")
+)
+
+// ErrQueryUnavailable is returned when no daemon serves queries on a socket.
+var ErrQueryUnavailable = errors.New("query socket unavailable")
+
+// QueryRequest is a query sent to a watch daemon for one of its projects.
+type QueryRequest struct {
+	Op    string `json:"op"`
+	Root  string `json:"root"`            // Absolute project root
+	Query string `json:"query,omitempty"` // Search text or symbol name
+	Limit int    `json:"limit,omitempty"`
+	Path  string `json:"path,omitempty"` // Path prefix for search, file for symbol lookups
+	Depth int    `json:"depth,omitempty"`
+}
+
+// QueryResponse is the daemon's answer to a QueryRequest. Only the field
+// matching the operation is set.
+type QueryResponse struct {
+	Error      string               `json:"error,omitempty"`
+	Results    []store.SearchResult `json:"results,omitempty"`
+	Symbols    []trace.Symbol       `json:"symbols,omitempty"`
+	References []trace.Reference    `json:"references,omitempty"`
+	Graph      *trace.CallGraph     `json:"graph,omitempty"`
+	Edges      []trace.CallEdge     `json:"edges,omitempty"`
+	Stats      *trace.SymbolStats   `json:"stats,omitempty"`
+	Indexed    bool                 `json:"indexed,omitempty"`
+}
+
+// QuerySearcher runs semantic searches against a loaded index.
+type QuerySearcher interface {
+	Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error)
+}
+
+// GetQuerySocketPath returns the path of the query socket of the watch
+// daemon.
+func GetQuerySocketPath(logDir string) string {
+	return filepath.Join(logDir, querySocketName)
+}
+
+// GetWorktreeQuerySocketPath returns the path of the query socket of a
+// worktree watch daemon.
+func GetWorktreeQuerySocketPath(logDir, worktreeID string) string {
+	return filepath.Join(logDir, worktreePIDPrefix+worktreeID+worktreeQuerySuffix)
+}
+
+type queryProject struct {
+	searcher QuerySearcher
+	symbols  trace.SymbolStore
+}
+
+// QueryServer answers search and symbol queries from the indexes a watch
+// daemon keeps in memory, so that clients don't have to load them.
+type QueryServer struct {
+	*socketServer
+
+	mu       sync.RWMutex
+	projects map[string]queryProject
+}
+
+// ServeQueries listens on socketPath and serves queries for the projects
+// registered with Register until Close is called.
+func ServeQueries(socketPath string) (*QueryServer, error) {
+	s := &QueryServer{projects: make(map[string]queryProject)}
+	server, err := listenSocket(socketPath, queryRequestTimeout, s.handle)
+	if err != nil {
+		return nil, fmt.Errorf("query socket: %w", err)
+	}
+	s.socketServer = server
+	return s, nil
+}
+
+// Close stops accepting queries, waits for in-flight ones and removes the
+// socket.
+func (s *QueryServer) Close() error {
+	return s.close()
+}
+
+// Register serves queries for the project at root until the returned
+// function is called. root must be absolute and canonical.
+func (s *QueryServer) Register(root string, searcher QuerySearcher, symbols trace.SymbolStore) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	project := queryProject{searcher: searcher, symbols: symbols}
+	s.projects[root] = project
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if current, ok := s.projects[root]; ok && current == project {
+			delete(s.projects, root)
+		}
+	}
+}
+
+func (s *QueryServer) handle(line []byte) any {
+	var req QueryRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return QueryResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+
+	s.mu.RLock()
+	project, ok := s.projects[req.Root]
+	s.mu.RUnlock()
+	if !ok {
+		return QueryResponse{Error: fmt.Sprintf("project %s is not served", req.Root)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryRequestTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := project.dispatch(ctx, req)
+	queryDuration.Observe(time.Since(start).Seconds(), req.Op)
+	if err != nil {
+		queryErrors.Inc(req.Op)
+		return QueryResponse{Error: err.Error()}
+	}
+	return resp
+}
+
+func (p queryProject) dispatch(ctx context.Context, req QueryRequest) (QueryResponse, error) {
+	var resp QueryResponse
+	var err error
+	if req.Op != QueryPing && req.Op != QuerySearch && p.symbols == nil {
+		return resp, errors.New("symbol index is not loaded")
+	}
+	switch req.Op {
+	case QueryPing:
+	case QuerySearch:
+		resp.Results, err = p.searcher.Search(ctx, req.Query, req.Limit, req.Path)
+		// Vectors are large and useless to clients
+		for i := range resp.Results {
+			resp.Results[i].Chunk.Vector = nil
+		}
+	case QueryLookupSymbol:
+		resp.Symbols, err = p.symbols.LookupSymbol(ctx, req.Query)
+	case QueryLookupCallers:
+		resp.References, err = p.symbols.LookupCallers(ctx, req.Query)
+	case QueryLookupCallees:
+		resp.References, err = p.symbols.LookupCallees(ctx, req.Query, req.Path)
+	case QueryLookupReaders:
+		resp.References, err = p.symbols.LookupReaders(ctx, req.Query)
+	case QueryLookupWriters:
+		resp.References, err = p.symbols.LookupWriters(ctx, req.Query)
+	case QueryCallGraph:
+		resp.Graph, err = p.symbols.GetCallGraph(ctx, req.Query, req.Depth)
+	case QueryFileSymbols:
+		resp.Symbols, err = p.symbols.GetSymbolsForFile(ctx, req.Path)
+	case QueryCallEdges:
+		resp.Edges, err = p.symbols.GetCallEdges(ctx)
+	case QueryFileIndexed:
+		resp.Indexed = p.symbols.IsFileIndexed(req.Path)
+	case QuerySymbolStats:
+		resp.Stats, err = p.symbols.GetStats(ctx)
+	default:
+		err = fmt.Errorf("unknown query %q", req.Op)
+	}
+	return resp, err
+}
+
+// QueryClient sends queries for one project to a watch daemon.
+type QueryClient struct {
+	socketPath string
+	root       string
+}
+
+// FindQueryClient returns a client for the daemon serving queries for
+// projectRoot, looking for query sockets in logDirs. It returns nil when no
+// running daemon serves the project.
+func FindQueryClient(projectRoot string, logDirs []string) *QueryClient {
+	root := projectRoot
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = filepath.Clean(abs)
+	}
+
+	for _, logDir := range logDirs {
+		sockets, _ := filepath.Glob(filepath.Join(logDir, "grepai-*query.sock"))
+		sort.Strings(sockets)
+		for _, socketPath := range sockets {
+			if info, err := os.Stat(socketPath); err != nil || info.Mode()&os.ModeSocket == 0 {
+				continue
+			}
+			client := &QueryClient{socketPath: socketPath, root: root}
+			if _, err := client.send(context.Background(), QueryRequest{Op: QueryPing}); err == nil {
+				return client
+			}
+		}
+	}
+	return nil
+}
+
+func (c *QueryClient) send(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+	req.Root = c.root
+	var resp QueryResponse
+	if err := exchange(ctx, c.socketPath, queryRequestTimeout, ErrQueryUnavailable, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Search runs a semantic search in the daemon. Result chunks carry no
+// vectors.
+func (c *QueryClient) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	resp, err := c.send(ctx, QueryRequest{Op: QuerySearch, Query: query, Limit: limit, Path: pathPrefix})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SymbolStore returns a read-only symbol store backed by the daemon's symbol
+// index.
+func (c *QueryClient) SymbolStore() trace.SymbolStore {
+	return &remoteSymbolStore{client: c}
+}
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// FallbackSearcher searches through a watch daemon, and loads the index
+// itself once a daemon query fails, e.g. on a timeout or while the daemon
+// restarts.
+type FallbackSearcher struct {
+	client QuerySearcher
+	open   func(ctx context.Context) (QuerySearcher, func(), error)
+
+	mu         sync.Mutex
+	local      QuerySearcher
+	closeLocal func()
+}
+
+// NewFallbackSearcher returns a searcher querying client until it fails, and
+// the searcher returned by open from then on.
+func NewFallbackSearcher(client QuerySearcher, open func(ctx context.Context) (QuerySearcher, func(), error)) *FallbackSearcher {
+	return &FallbackSearcher{client: client, open: open}
+}
+
+// Search runs the search in the daemon, or in the local index once the
+// daemon failed to answer.
+func (s *FallbackSearcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	s.mu.Lock()
+	local := s.local
+	s.mu.Unlock()
+	if local == nil {
+		results, err := s.client.Search(ctx, query, limit, pathPrefix)
+		if err == nil || ctx.Err() != nil {
+			return results, err
+		}
+		slog.Debug("Watch daemon query failed, searching the index directly", "err", err)
+
+		if local, err = s.loadLocal(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return local.Search(ctx, query, limit, pathPrefix)
+}
+
+func (s *FallbackSearcher) loadLocal(ctx context.Context) (QuerySearcher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local == nil {
+		local, closeLocal, err := s.open(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.local, s.closeLocal = local, closeLocal
+	}
+	return s.local, nil
+}
+
+// Close releases the local index if it was loaded.
+func (s *FallbackSearcher) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closeLocal != nil {
+		s.closeLocal()
+		s.local, s.closeLocal = nil, nil
+	}
+}
+
+// fallbackSymbolStore reads a symbol index through a watch daemon, and loads
+// it itself once a daemon query fails.
+type fallbackSymbolStore struct {
+	client trace.SymbolStore
+	open   func(ctx context.Context) (trace.SymbolStore, error)
+
+	mu    sync.Mutex
+	local trace.SymbolStore
+}
+
+var _ trace.SymbolStore = (*fallbackSymbolStore)(nil)
+
+// NewFallbackSymbolStore returns a symbol store reading from client until a
+// query fails, and from the store returned by open from then on.
+func NewFallbackSymbolStore(client trace.SymbolStore, open func(ctx context.Context) (trace.SymbolStore, error)) trace.SymbolStore {
+	return &fallbackSymbolStore{client: client, open: open}
+}
+
+// withFallback runs call against the daemon, or against the local index
+// once the daemon failed to answer.
+func withFallback[T any](ctx context.Context, s *fallbackSymbolStore, call func(trace.SymbolStore) (T, error)) (T, error) {
+	s.mu.Lock()
+	local := s.local
+	s.mu.Unlock()
+	if local == nil {
+		result, err := call(s.client)
+		if err == nil || ctx.Err() != nil {
+			return result, err
+		}
+		slog.Debug("Watch daemon query failed, reading the symbol index directly", "err", err)
+
+		if local, err = s.loadLocal(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	return call(local)
+}
+
+func (s *fallbackSymbolStore) loadLocal(ctx context.Context) (trace.SymbolStore, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local == nil {
+		local, err := s.open(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.local = local
+	}
+	return s.local, nil
+}
+
+// current returns the store reads go to.
+func (s *fallbackSymbolStore) current() trace.SymbolStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local != nil {
+		return s.local
+	}
+	return s.client
+}
+
+func (s *fallbackSymbolStore) SaveFile(ctx context.Context, filePath string, symbols []trace.Symbol, refs []trace.Reference) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *fallbackSymbolStore) DeleteFile(ctx context.Context, filePath string) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *fallbackSymbolStore) IsFileIndexed(filePath string) bool {
+	return s.current().IsFileIndexed(filePath)
+}
+
+func (s *fallbackSymbolStore) LookupSymbol(ctx context.Context, name string) ([]trace.Symbol, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Symbol, error) {
+		return st.LookupSymbol(ctx, name)
+	})
+}
+
+func (s *fallbackSymbolStore) LookupCallers(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Reference, error) {
+		return st.LookupCallers(ctx, symbolName)
+	})
+}
+
+func (s *fallbackSymbolStore) LookupCallees(ctx context.Context, symbolName string, file string) ([]trace.Reference, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Reference, error) {
+		return st.LookupCallees(ctx, symbolName, file)
+	})
+}
+
+func (s *fallbackSymbolStore) LookupReaders(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Reference, error) {
+		return st.LookupReaders(ctx, symbolName)
+	})
+}
+
+func (s *fallbackSymbolStore) LookupWriters(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Reference, error) {
+		return st.LookupWriters(ctx, symbolName)
+	})
+}
+
+func (s *fallbackSymbolStore) GetCallGraph(ctx context.Context, symbolName string, depth int) (*trace.CallGraph, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) (*trace.CallGraph, error) {
+		return st.GetCallGraph(ctx, symbolName, depth)
+	})
+}
+
+// Load is a no-op: the local index is loaded on the first failed query.
+func (s *fallbackSymbolStore) Load(ctx context.Context) error {
+	return nil
+}
+
+func (s *fallbackSymbolStore) Persist(ctx context.Context) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *fallbackSymbolStore) GetSymbolsForFile(ctx context.Context, filePath string) ([]trace.Symbol, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.Symbol, error) {
+		return st.GetSymbolsForFile(ctx, filePath)
+	})
+}
+
+func (s *fallbackSymbolStore) GetCallEdges(ctx context.Context) ([]trace.CallEdge, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) ([]trace.CallEdge, error) {
+		return st.GetCallEdges(ctx)
+	})
+}
+
+// Close releases the local index if it was loaded.
+func (s *fallbackSymbolStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local == nil {
+		return nil
+	}
+	err := s.local.Close()
+	s.local = nil
+	return err
+}
+
+func (s *fallbackSymbolStore) GetStats(ctx context.Context) (*trace.SymbolStats, error) {
+	return withFallback(ctx, s, func(st trace.SymbolStore) (*trace.SymbolStats, error) {
+		return st.GetStats(ctx)
+	})
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+type countingQuerySearcher struct {
+	results []store.SearchResult
+	err     error
+	calls   int
+}
+
+func (f *countingQuerySearcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	f.calls++
+	return f.results, f.err
+}
+
+func TestFallbackSearcher_FallsBackOnDaemonError(t *testing.T) {
+	client := &countingQuerySearcher{err: errors.New("query socket unavailable: connection refused")}
+	local := &countingQuerySearcher{results: []store.SearchResult{{Chunk: store.Chunk{FilePath: "main.go"}}}}
+	closed := false
+	s := NewFallbackSearcher(client, func(ctx context.Context) (QuerySearcher, func(), error) {
+		return local, func() { closed = true }, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		results, err := s.Search(context.Background(), "main", 5, "")
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].Chunk.FilePath != "main.go" {
+			t.Fatalf("expected local results, got %+v", results)
+		}
+	}
+	if client.calls != 1 || local.calls != 2 {
+		t.Errorf("expected one daemon query then local searches, got daemon=%d local=%d", client.calls, local.calls)
+	}
+
+	s.Close()
+	if !closed {
+		t.Error("expected the local searcher to be released")
+	}
+}
+
+func TestFallbackSearcher_UsesDaemon(t *testing.T) {
+	client := &countingQuerySearcher{results: []store.SearchResult{{Chunk: store.Chunk{FilePath: "daemon.go"}}}}
+	s := NewFallbackSearcher(client, func(ctx context.Context) (QuerySearcher, func(), error) {
+		t.Fatal("index must not be loaded while the daemon answers")
+		return nil, nil, nil
+	})
+
+	results, err := s.Search(context.Background(), "main", 5, "")
+	if err != nil || len(results) != 1 || results[0].Chunk.FilePath != "daemon.go" {
+		t.Fatalf("expected daemon results, got %+v, %v", results, err)
+	}
+	s.Close()
+}
+
+func TestFallbackSymbolStore_FallsBackOnDaemonError(t *testing.T) {
+	// No daemon listens on the socket, as after it stopped
+	client := &QueryClient{socketPath: GetQuerySocketPath(t.TempDir()), root: t.TempDir()}
+	loads := 0
+	ss := NewFallbackSymbolStore(client.SymbolStore(), func(ctx context.Context) (trace.SymbolStore, error) {
+		loads++
+		return newTestSymbolStore(t), nil
+	})
+	defer ss.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		symbols, err := ss.LookupSymbol(ctx, "Handle")
+		if err != nil {
+			t.Fatalf("LookupSymbol failed: %v", err)
+		}
+		if len(symbols) != 1 || symbols[0].File != "api/handler.go" {
+			t.Fatalf("expected the symbol from the local index, got %+v", symbols)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected the local index to be loaded once, got %d", loads)
+	}
+	if !ss.IsFileIndexed("main.go") {
+		t.Error("expected the local index to answer once loaded")
+	}
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// errReadOnlySymbolStore is returned by the write methods of a remote
+// symbol store: only the daemon updates its index.
+var errReadOnlySymbolStore = errors.New("symbol index served by the watch daemon is read-only")
+
+// remoteSymbolStore implements trace.SymbolStore with queries to a daemon.
+type remoteSymbolStore struct {
+	client *QueryClient
+}
+
+var _ trace.SymbolStore = (*remoteSymbolStore)(nil)
+
+func (s *remoteSymbolStore) query(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+	return s.client.send(ctx, req)
+}
+
+func (s *remoteSymbolStore) SaveFile(ctx context.Context, filePath string, symbols []trace.Symbol, refs []trace.Reference) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *remoteSymbolStore) DeleteFile(ctx context.Context, filePath string) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *remoteSymbolStore) IsFileIndexed(filePath string) bool {
+	resp, err := s.client.send(context.Background(), QueryRequest{Op: QueryFileIndexed, Path: filePath})
+	return err == nil && resp.Indexed
+}
+
+func (s *remoteSymbolStore) LookupSymbol(ctx context.Context, name string) ([]trace.Symbol, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryLookupSymbol, Query: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Symbols, nil
+}
+
+func (s *remoteSymbolStore) LookupCallers(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryLookupCallers, Query: symbolName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.References, nil
+}
+
+func (s *remoteSymbolStore) LookupCallees(ctx context.Context, symbolName string, file string) ([]trace.Reference, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryLookupCallees, Query: symbolName, Path: file})
+	if err != nil {
+		return nil, err
+	}
+	return resp.References, nil
+}
+
+func (s *remoteSymbolStore) LookupReaders(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryLookupReaders, Query: symbolName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.References, nil
+}
+
+func (s *remoteSymbolStore) LookupWriters(ctx context.Context, symbolName string) ([]trace.Reference, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryLookupWriters, Query: symbolName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.References, nil
+}
+
+func (s *remoteSymbolStore) GetCallGraph(ctx context.Context, symbolName string, depth int) (*trace.CallGraph, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryCallGraph, Query: symbolName, Depth: depth})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Graph, nil
+}
+
+// Load is a no-op: the daemon keeps the index loaded.
+func (s *remoteSymbolStore) Load(ctx context.Context) error {
+	return nil
+}
+
+func (s *remoteSymbolStore) Persist(ctx context.Context) error {
+	return errReadOnlySymbolStore
+}
+
+func (s *remoteSymbolStore) GetSymbolsForFile(ctx context.Context, filePath string) ([]trace.Symbol, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryFileSymbols, Path: filePath})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Symbols, nil
+}
+
+func (s *remoteSymbolStore) GetCallEdges(ctx context.Context) ([]trace.CallEdge, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QueryCallEdges})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Edges, nil
+}
+
+// Close is a no-op: the daemon owns the index.
+func (s *remoteSymbolStore) Close() error {
+	return nil
+}
+
+func (s *remoteSymbolStore) GetStats(ctx context.Context) (*trace.SymbolStats, error) {
+	resp, err := s.query(ctx, QueryRequest{Op: QuerySymbolStats})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Stats, nil
+}
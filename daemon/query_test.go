@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+type fakeQuerySearcher struct {
+	lastQuery string
+	lastPath  string
+}
+
+func (s *fakeQuerySearcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	s.lastQuery = query
+	s.lastPath = pathPrefix
+	if query == "fail" {
+		return nil, errors.New("embedder unavailable")
+	}
+	return []store.SearchResult{
+		{Chunk: store.Chunk{FilePath: "api/handler.go", StartLine: 3, EndLine: 9, Content: "func Handle()", Vector: []float32{0.1, 0.2}}, Score: 0.9},
+	}[:limit], nil
+}
+
+func newTestSymbolStore(t *testing.T) *trace.GOBSymbolStore {
+	t.Helper()
+	ctx := context.Background()
+	ss := trace.NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	if err := ss.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	symbols := []trace.Symbol{
+		{Name: "Handle", Kind: trace.KindFunction, File: "api/handler.go", Line: 3, Language: "go"},
+		{Name: "main", Kind: trace.KindFunction, File: "main.go", Line: 1, Language: "go"},
+	}
+	refs := []trace.Reference{
+		{SymbolName: "Handle", File: "main.go", Line: 2, CallerName: "main", CallerFile: "main.go", CallerLine: 1},
+	}
+	if err := ss.SaveFile(ctx, "api/handler.go", symbols[:1], nil); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if err := ss.SaveFile(ctx, "main.go", symbols[1:], refs); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	return ss
+}
+
+func TestQueryServer_SearchAndSymbols(t *testing.T) {
+	logDir := t.TempDir()
+	root := t.TempDir()
+	server, err := ServeQueries(GetQuerySocketPath(logDir))
+	if err != nil {
+		t.Fatalf("ServeQueries failed: %v", err)
+	}
+	defer server.Close()
+
+	searcher := &fakeQuerySearcher{}
+	unregister := server.Register(root, searcher, newTestSymbolStore(t))
+	defer unregister()
+
+	client := FindQueryClient(root, []string{t.TempDir(), logDir})
+	if client == nil {
+		t.Fatal("expected a client for the registered project")
+	}
+	ctx := context.Background()
+
+	results, err := client.Search(ctx, "request handler", 1, "api/")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Chunk.FilePath != "api/handler.go" || results[0].Score != 0.9 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Chunk.Vector != nil {
+		t.Fatal("expected vectors to be stripped from results")
+	}
+	if searcher.lastQuery != "request handler" || searcher.lastPath != "api/" {
+		t.Fatalf("unexpected search arguments: %q, %q", searcher.lastQuery, searcher.lastPath)
+	}
+	if _, err := client.Search(ctx, "fail", 1, ""); err == nil || err.Error() != "embedder unavailable" {
+		t.Fatalf("expected searcher error, got %v", err)
+	}
+
+	ss := client.SymbolStore()
+	symbols, err := ss.LookupSymbol(ctx, "Handle")
+	if err != nil || len(symbols) != 1 || symbols[0].File != "api/handler.go" {
+		t.Fatalf("unexpected LookupSymbol result: %+v, %v", symbols, err)
+	}
+	callers, err := ss.LookupCallers(ctx, "Handle")
+	if err != nil || len(callers) != 1 || callers[0].CallerName != "main" {
+		t.Fatalf("unexpected LookupCallers result: %+v, %v", callers, err)
+	}
+	stats, err := ss.GetStats(ctx)
+	if err != nil || stats.TotalSymbols != 2 {
+		t.Fatalf("unexpected stats: %+v, %v", stats, err)
+	}
+	if !ss.IsFileIndexed("main.go") || ss.IsFileIndexed("missing.go") {
+		t.Fatal("unexpected IsFileIndexed results")
+	}
+	if err := ss.SaveFile(ctx, "x.go", nil, nil); !errors.Is(err, errReadOnlySymbolStore) {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+}
+
+func TestFindQueryClient_OnlyServedProjects(t *testing.T) {
+	logDir := t.TempDir()
+	root := t.TempDir()
+
+	if client := FindQueryClient(root, []string{logDir}); client != nil {
+		t.Fatal("expected no client without a query socket")
+	}
+
+	server, err := ServeQueries(GetWorktreeQuerySocketPath(logDir, "abc123"))
+	if err != nil {
+		t.Fatalf("ServeQueries failed: %v", err)
+	}
+	defer server.Close()
+
+	if client := FindQueryClient(root, []string{logDir}); client != nil {
+		t.Fatal("expected no client for an unregistered project")
+	}
+
+	unregister := server.Register(root, &fakeQuerySearcher{}, nil)
+	if client := FindQueryClient(root, []string{logDir}); client == nil {
+		t.Fatal("expected the worktree query socket to be found")
+	} else if _, err := client.SymbolStore().LookupSymbol(context.Background(), "x"); err == nil {
+		t.Fatal("expected symbol queries to fail without a symbol store")
+	}
+
+	unregister()
+	if client := FindQueryClient(root, []string{logDir}); client != nil {
+		t.Fatal("expected no client after the project is unregistered")
+	}
+}
+
+// blockingQuerySearcher answers only once released.
+type blockingQuerySearcher struct {
+	release chan struct{}
+}
+
+func (s *blockingQuerySearcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
+	<-s.release
+	return nil, nil
+}
+
+func TestQueryClient_SearchHonorsContext(t *testing.T) {
+	logDir := t.TempDir()
+	root := t.TempDir()
+	server, err := ServeQueries(GetQuerySocketPath(logDir))
+	if err != nil {
+		t.Fatalf("ServeQueries failed: %v", err)
+	}
+	defer server.Close()
+
+	searcher := &blockingQuerySearcher{release: make(chan struct{})}
+	defer close(searcher.release)
+	unregister := server.Register(root, searcher, nil)
+	defer unregister()
+
+	client := FindQueryClient(root, []string{logDir})
+	if client == nil {
+		t.Fatal("expected a client for the registered project")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Search(ctx, "slow", 1, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end the search, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := client.Search(ctx, "slow", 1, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation to end the search, got %v", err)
+	}
+}
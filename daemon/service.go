@@ -0,0 +1,288 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/yoanbernabeu/grepai/internal/logging"
+)
+
+// ErrServiceUnsupported is returned when the platform has no supported user
+// service manager.
+var ErrServiceUnsupported = errors.New("user services are only supported with systemd (Linux) and launchd (macOS)")
+
+// ServiceSpec describes a watch daemon run by the user's service manager
+// instead of being spawned with SpawnBackground.
+type ServiceSpec struct {
+	Name        string   // Unit name / launchd label suffix, e.g. grepai-watch-api-1a2b3c4d
+	Description string   // Human readable description
+	Executable  string   // Absolute path of the grepai binary
+	Args        []string // Arguments, e.g. watch --no-ui --log-dir <dir>
+	WorkingDir  string   // Project root (or any directory for workspaces)
+	LogFile     string   // Log file for service managers without a journal
+}
+
+// ServiceInstall reports where a service was installed.
+type ServiceInstall struct {
+	Path    string // Unit or plist file
+	Enabled bool   // Whether the service manager started it
+	Manager string // systemd or launchd
+}
+
+var serviceNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ProjectServiceName returns a stable service name for the watch daemon of a
+// project: the directory name and a hash of the full path.
+func ProjectServiceName(projectRoot string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(projectRoot)))
+	base := strings.Trim(serviceNameUnsafe.ReplaceAllString(filepath.Base(projectRoot), "-"), "-.")
+	if base == "" {
+		base = "project"
+	}
+	return "grepai-watch-" + base + "-" + hex.EncodeToString(sum[:4])
+}
+
+// WorkspaceServiceName returns the service name for the watch daemon of a
+// workspace.
+func WorkspaceServiceName(workspaceName string) string {
+	return "grepai-workspace-" + strings.Trim(serviceNameUnsafe.ReplaceAllString(workspaceName, "-"), "-.")
+}
+
+// SystemdUnitPath returns the path of the systemd user unit of a service:
+// $XDG_CONFIG_HOME/systemd/user or ~/.config/systemd/user.
+func SystemdUnitPath(name string) (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(base, "systemd", "user", name+".service"), nil
+}
+
+// LaunchdPlistPath returns the path of the launchd agent of a service in
+// ~/Library/LaunchAgents.
+func LaunchdPlistPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "Library", "LaunchAgents", LaunchdLabel(name)+".plist"), nil
+}
+
+// LaunchdLabel returns the launchd label of a service.
+func LaunchdLabel(name string) string {
+	return "com.grepai." + name
+}
+
+// RenderSystemdUnit renders a systemd user unit that restarts the daemon on
+// failure and sends its output to the journal.
+func RenderSystemdUnit(spec ServiceSpec) string {
+	execStart := make([]string, 0, len(spec.Args)+1)
+	for _, arg := range append([]string{spec.Executable}, spec.Args...) {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by 'grepai watch --install-service'; remove with --uninstall-service\n")
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", spec.Description)
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(spec.WorkingDir))
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(execStart, " "))
+	// Background mode: PID file, ready marker and control socket
+	b.WriteString("Environment=GREPAI_BACKGROUND=1\n")
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	// Leave time to persist the index on shutdown
+	b.WriteString("TimeoutStopSec=60\n")
+	b.WriteString("StandardOutput=journal\n")
+	b.WriteString("StandardError=journal\n")
+	fmt.Fprintf(&b, "SyslogIdentifier=%s\n\n", spec.Name)
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// RenderLaunchdPlist renders a launchd agent that starts the daemon at login
+// and restarts it when it exits with an error.
+func RenderLaunchdPlist(spec ServiceSpec) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<!-- Generated by 'grepai watch --install-service'; remove with --uninstall-service -->
+<plist version="1.0">
+<dict>
+`)
+	plistKey(&b, "Label", LaunchdLabel(spec.Name))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{spec.Executable}, spec.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	plistKey(&b, "WorkingDirectory", spec.WorkingDir)
+	b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	b.WriteString("\t\t<key>GREPAI_BACKGROUND</key>\n\t\t<string>1</string>\n")
+	fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", logging.FileEnv, xmlEscape(spec.LogFile))
+	b.WriteString("\t</dict>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>10</integer>\n")
+	plistKey(&b, "StandardOutPath", spec.LogFile)
+	plistKey(&b, "StandardErrorPath", spec.LogFile)
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func plistKey(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// systemdQuote quotes a word for ExecStart and WorkingDirectory, escaping
+// systemd's specifier and variable expansion.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	s = strings.ReplaceAll(s, "$", "$$")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// systemctl runs systemctl --user; replaced in tests.
+var systemctl = func(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// launchctl runs launchctl; replaced in tests.
+var launchctl = func(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// InstallService writes the service definition for the platform's user
+// service manager. With systemd the unit is enabled and started; with
+// launchd the agent is only written, to be loaded with launchctl.
+func InstallService(spec ServiceSpec) (*ServiceInstall, error) {
+	switch runtime.GOOS {
+	case "linux":
+		path, err := SystemdUnitPath(spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeServiceFile(path, RenderSystemdUnit(spec)); err != nil {
+			return nil, err
+		}
+		install := &ServiceInstall{Path: path, Manager: "systemd"}
+		if err := systemctl("daemon-reload"); err != nil {
+			return install, err
+		}
+		if err := systemctl("enable", "--now", spec.Name+".service"); err != nil {
+			return install, err
+		}
+		install.Enabled = true
+		return install, nil
+	case "darwin":
+		path, err := LaunchdPlistPath(spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(spec.LogFile), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		if err := writeServiceFile(path, RenderLaunchdPlist(spec)); err != nil {
+			return nil, err
+		}
+		return &ServiceInstall{Path: path, Manager: "launchd"}, nil
+	default:
+		return nil, ErrServiceUnsupported
+	}
+}
+
+// UninstallService stops and removes a service installed with
+// InstallService. It returns the removed file, or "" if none was installed.
+func UninstallService(name string) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		path, err := SystemdUnitPath(name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return "", nil
+		}
+		if err := systemctl("disable", "--now", name+".service"); err != nil {
+			return "", err
+		}
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return path, systemctl("daemon-reload")
+	case "darwin":
+		path, err := LaunchdPlistPath(name)
+		if err != nil {
+			return "", err
+		}
+		return uninstallLaunchdAgent(path, LaunchdLabel(name))
+	default:
+		return "", ErrServiceUnsupported
+	}
+}
+
+// uninstallLaunchdAgent unloads the agent with label, if loaded, then removes
+// its plist at path. launchd keeps running a loaded agent until logout once
+// only the plist is gone.
+func uninstallLaunchdAgent(path, label string) (string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+	target := fmt.Sprintf("gui/%d/%s", os.Getuid(), label)
+	if launchctl("print", target) == nil {
+		if err := launchctl("bootout", target); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func writeServiceFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func testServiceSpec() ServiceSpec {
+	return ServiceSpec{
+		Name:        ProjectServiceName("/home/me/My Project"),
+		Description: "grepai watch for /home/me/My Project",
+		Executable:  "/usr/local/bin/grepai",
+		Args:        []string{"watch", "--no-ui", "--log-dir", "/home/me/.local/state/grepai/logs"},
+		WorkingDir:  "/home/me/My Project",
+		LogFile:     "/home/me/.local/state/grepai/logs/grepai-watch.log",
+	}
+}
+
+func TestProjectServiceName(t *testing.T) {
+	name := ProjectServiceName("/home/me/My Project")
+	if !strings.HasPrefix(name, "grepai-watch-My-Project-") || len(name) != len("grepai-watch-My-Project-")+8 {
+		t.Fatalf("unexpected service name %q", name)
+	}
+	if name != ProjectServiceName("/home/me/My Project/") {
+		t.Fatal("expected the name to ignore trailing separators")
+	}
+	if name == ProjectServiceName("/srv/My Project") {
+		t.Fatal("expected projects with the same directory name to get different names")
+	}
+	if got := WorkspaceServiceName("acme corp"); got != "grepai-workspace-acme-corp" {
+		t.Fatalf("unexpected workspace service name %q", got)
+	}
+}
+
+func TestRenderSystemdUnit(t *testing.T) {
+	unit := RenderSystemdUnit(testServiceSpec())
+	for _, want := range []string{
+		`WorkingDirectory="/home/me/My Project"`,
+		"ExecStart=/usr/local/bin/grepai watch --no-ui --log-dir /home/me/.local/state/grepai/logs\n",
+		"Environment=GREPAI_BACKGROUND=1\n",
+		"Restart=on-failure\n",
+		"StandardOutput=journal\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":        "plain",
+		"with space":   `"with space"`,
+		`say "hi"`:     `"say \"hi\""`,
+		"100%":         "100%%",
+		"$HOME/x":      "$$HOME/x",
+		"":             `""`,
+		`C:\dir name`:  `"C:\\dir name"`,
+		"semi;colon":   `"semi;colon"`,
+		"tab\tinside":  "\"tab\tinside\"",
+		"quote'single": `"quote'single"`,
+	}
+	for in, want := range tests {
+		if got := systemdQuote(in); got != want {
+			t.Errorf("systemdQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenderLaunchdPlist(t *testing.T) {
+	spec := testServiceSpec()
+	spec.Args = append(spec.Args, "a&b")
+	plist := RenderLaunchdPlist(spec)
+	for _, want := range []string{
+		"<string>com.grepai." + spec.Name + "</string>",
+		"<string>/usr/local/bin/grepai</string>",
+		"<string>a&amp;b</string>",
+		"<key>GREPAI_BACKGROUND</key>",
+		"<key>GREPAI_LOG_FILE</key>\n\t\t<string>/home/me/.local/state/grepai/logs/grepai-watch.log</string>",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+		"<key>StandardOutPath</key>\n\t<string>/home/me/.local/state/grepai/logs/grepai-watch.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestInstallAndUninstallSystemdService(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("systemd services are only installed on Linux")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var calls []string
+	original := systemctl
+	systemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+	defer func() { systemctl = original }()
+
+	spec := testServiceSpec()
+	install, err := InstallService(spec)
+	if err != nil {
+		t.Fatalf("InstallService failed: %v", err)
+	}
+	if !install.Enabled || install.Manager != "systemd" {
+		t.Fatalf("unexpected install result: %+v", install)
+	}
+	data, err := os.ReadFile(install.Path)
+	if err != nil || string(data) != RenderSystemdUnit(spec) {
+		t.Fatalf("unexpected unit file content (err=%v)", err)
+	}
+
+	removed, err := UninstallService(spec.Name)
+	if err != nil || removed != install.Path {
+		t.Fatalf("UninstallService = %q, %v", removed, err)
+	}
+	if _, err := os.Stat(install.Path); !os.IsNotExist(err) {
+		t.Fatal("expected unit file to be removed")
+	}
+	want := []string{"daemon-reload", "enable --now " + spec.Name + ".service", "disable --now " + spec.Name + ".service", "daemon-reload"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected systemctl calls %q", calls)
+	}
+
+	if removed, err := UninstallService(spec.Name); err != nil || removed != "" {
+		t.Fatalf("expected uninstalling twice to be a no-op, got %q, %v", removed, err)
+	}
+}
+
+func TestUninstallLaunchdAgentBootsOutFirst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "com.grepai.test.plist")
+	if err := os.WriteFile(path, []byte(RenderLaunchdPlist(testServiceSpec())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	original := launchctl
+	launchctl = func(args ...string) error {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("launchctl %v ran after the plist was removed", args)
+		}
+		calls = append(calls, args[0])
+		return nil
+	}
+	defer func() { launchctl = original }()
+
+	removed, err := uninstallLaunchdAgent(path, "com.grepai.test")
+	if err != nil || removed != path {
+		t.Fatalf("uninstallLaunchdAgent = %q, %v", removed, err)
+	}
+	if strings.Join(calls, "|") != "print|bootout" {
+		t.Fatalf("unexpected launchctl calls %q", calls)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected plist to be removed")
+	}
+
+	if removed, err := uninstallLaunchdAgent(path, "com.grepai.test"); err != nil || removed != "" {
+		t.Fatalf("expected uninstalling twice to be a no-op, got %q, %v", removed, err)
+	}
+}
@@ -0,0 +1,168 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSocketInUse is returned when another daemon is listening on a socket.
+var ErrSocketInUse = errors.New("socket is in use by another daemon")
+
+// socketServer serves one JSON request per connection on a Unix domain
+// socket, answering with one JSON response line.
+type socketServer struct {
+	listener net.Listener
+	path     string
+	timeout  time.Duration
+	handle   func(line []byte) any
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// listenSocket listens on socketPath, replacing a leftover socket from a
+// crashed daemon, and serves connections with handle until close is called.
+func listenSocket(socketPath string, timeout time.Duration, handle func(line []byte) any) (*socketServer, error) {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	// A socket that still answers belongs to a live daemon; only one left
+	// behind by a crash may be replaced.
+	if conn, err := net.DialTimeout("unix", socketPath, socketDialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrSocketInUse, socketPath)
+	}
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket: %w", err)
+	}
+	// Only the owner may talk to the daemon
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	s := &socketServer{listener: listener, path: socketPath, timeout: timeout, handle: handle}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// close stops accepting requests, waits for in-flight ones and removes the
+// socket.
+func (s *socketServer) close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.listener.Close()
+		s.wg.Wait()
+		_ = os.Remove(s.path)
+	})
+	return err
+}
+
+func (s *socketServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Warning: accept on %s failed: %v", s.path, err)
+			}
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s *socketServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return
+	}
+	data, err := json.Marshal(s.handle(line))
+	if err != nil {
+		return
+	}
+	_, _ = conn.Write(append(data, '\n'))
+}
+
+// exchange sends req to the server listening on socketPath and decodes its
+// answer into resp. A failure to connect is wrapped in unavailable. The
+// exchange is bounded by timeout and by ctx: its deadline caps the
+// connection's, and cancelling it closes the connection.
+func exchange(ctx context.Context, socketPath string, timeout time.Duration, unavailable error, req, resp any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, socketDialTimeout)
+	conn, err := dialer.DialContext(dialCtx, "unix", socketPath)
+	cancel()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("%w: %v", unavailable, err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(timeout)
+	ctxDeadline, ok := ctx.Deadline()
+	ctxBound := ok && ctxDeadline.Before(deadline)
+	if ctxBound {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := sendAndReceive(conn, req, resp); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		// The connection may time out just before ctx notices its deadline
+		if ctxBound && errors.Is(err, os.ErrDeadlineExceeded) {
+			return context.DeadlineExceeded
+		}
+		return err
+	}
+	return nil
+}
+
+func sendAndReceive(conn net.Conn, req, resp any) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(line, resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
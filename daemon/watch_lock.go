@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yoanbernabeu/grepai/internal/fileutil"
+)
+
+// ErrIndexBusy is returned by LockIndex while a watcher watches the
+// project, whether or not it serves a control socket, or while another
+// reindex runs.
+var ErrIndexBusy = errors.New("a watcher or another reindex is indexing this project")
+
+// HoldWatchLock takes a shared lock on the watch lock file of a project
+// (config.GetWatchLockPath) for as long as a watcher watches it, waiting
+// for an inline reindex holding it to finish. Call release when the watcher
+// stops.
+func HoldWatchLock(lockPath string) (release func(), err error) {
+	f, err := openWatchLock(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileutil.FlockShared(f, false); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("watch lock: %w", err)
+	}
+	return func() {
+		_ = fileutil.Funlock(f)
+		_ = f.Close()
+	}, nil
+}
+
+// LockIndex takes the watch lock file of a project exclusively, for
+// reindexing it without a watcher. It returns ErrIndexBusy when a watcher
+// or another reindex holds the lock.
+func LockIndex(lockPath string) (release func(), err error) {
+	f, err := openWatchLock(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileutil.FlockExclusive(f, true); err != nil {
+		_ = f.Close()
+		return nil, ErrIndexBusy
+	}
+	return func() {
+		_ = fileutil.Funlock(f)
+		_ = f.Close()
+	}, nil
+}
+
+func openWatchLock(lockPath string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("watch lock: %w", err)
+	}
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("watch lock: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockIndex_RefusedWhileWatched(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), ".grepai", "watch.lock")
+
+	releaseWatch, err := HoldWatchLock(lockPath)
+	if err != nil {
+		t.Fatalf("HoldWatchLock failed: %v", err)
+	}
+	releaseOther, err := HoldWatchLock(lockPath)
+	if err != nil {
+		t.Fatalf("expected watchers to share the lock, got %v", err)
+	}
+	if _, err := LockIndex(lockPath); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("expected ErrIndexBusy while watched, got %v", err)
+	}
+	releaseWatch()
+	if _, err := LockIndex(lockPath); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("expected ErrIndexBusy while a watcher remains, got %v", err)
+	}
+	releaseOther()
+
+	release, err := LockIndex(lockPath)
+	if err != nil {
+		t.Fatalf("expected the lock once no watcher runs, got %v", err)
+	}
+	if _, err := LockIndex(lockPath); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("expected a second reindex to be refused, got %v", err)
+	}
+	release()
+}
@@ -16,6 +16,7 @@ const (
 	workspaceLogSuffix   = ".log"
 	workspaceReadyPrefix = "grepai-workspace-"
 	workspaceReadySuffix = ".ready"
+	workspaceSockSuffix  = ".sock"
 )
 
 // GetWorkspacePIDFile returns the path to the PID file for a workspace.
@@ -33,6 +34,12 @@ func GetWorkspaceReadyFile(logDir, workspaceName string) string {
 	return filepath.Join(logDir, workspaceReadyPrefix+workspaceName+workspaceReadySuffix)
 }
 
+// GetWorkspaceControlSocketPath returns the path of the control socket of a
+// workspace watch daemon.
+func GetWorkspaceControlSocketPath(logDir, workspaceName string) string {
+	return filepath.Join(logDir, workspacePIDPrefix+workspaceName+workspaceSockSuffix)
+}
+
 // WriteWorkspacePIDFile writes the current process ID to the workspace PID file.
 func WriteWorkspacePIDFile(logDir, workspaceName string) error {
 	if err := os.MkdirAll(logDir, 0755); err != nil {
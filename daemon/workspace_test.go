@@ -34,6 +34,14 @@ func TestWorkspacePIDFunctions(t *testing.T) {
 		}
 	})
 
+	t.Run("GetWorkspaceControlSocketPath", func(t *testing.T) {
+		socketPath := GetWorkspaceControlSocketPath(tmpDir, workspaceName)
+		expected := filepath.Join(tmpDir, "grepai-workspace-test-workspace.sock")
+		if socketPath != expected {
+			t.Errorf("expected %s, got %s", expected, socketPath)
+		}
+	})
+
 	// Test GetWorkspaceReadyFile
 	t.Run("GetWorkspaceReadyFile", func(t *testing.T) {
 		readyFile := GetWorkspaceReadyFile(tmpDir, workspaceName)
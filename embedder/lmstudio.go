@@ -109,7 +109,7 @@ func (e *LMStudioEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := e.client.Do(req)
+	resp, err := doEmbedRequest(e.client, req, "lmstudio")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request to LM Studio: %w", err)
 	}
@@ -0,0 +1,45 @@
+package embedder
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/metrics"
+)
+
+var (
+	embedRequestDuration = metrics.Default.NewHistogramVec(
+		"grepai_embedding_request_duration_seconds",
+		"Latency of embedding requests until the provider responds, by provider.",
+		metrics.DefaultBuckets, "provider")
+	embedRequests = metrics.Default.NewCounterVec(
+		"grepai_embedding_requests_total",
+		"Embedding requests by provider and HTTP status (\"error\" when no response was received).",
+		"provider", "status")
+	embedRetries = metrics.Default.NewCounterVec(
+		"grepai_embedding_retries_total",
+		"Embedding batches retried after a retryable error, by provider and HTTP status.",
+		"provider", "status")
+	rateLimitHits = metrics.Default.NewCounterVec(
+		"grepai_embedding_rate_limit_hits_total",
+		"429 responses seen by the adaptive rate limiter.")
+	parallelismChanges = metrics.Default.NewCounterVec(
+		"grepai_embedding_parallelism_changes_total",
+		"Parallelism changes of the adaptive rate limiter, by direction (reduced or restored).",
+		"direction")
+)
+
+// doEmbedRequest sends an embedding request and records its latency and
+// status for provider.
+func doEmbedRequest(client *http.Client, req *http.Request, provider string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	embedRequestDuration.Observe(time.Since(start).Seconds(), provider)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	embedRequests.Inc(provider, status)
+	return resp, err
+}
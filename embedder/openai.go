@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -177,7 +179,7 @@ func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.apiKey))
 
-	resp, err := e.client.Do(req)
+	resp, err := doEmbedRequest(e.client, req, "openai")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request to OpenAI: %w", err)
 	}
@@ -369,15 +371,25 @@ func (e *OpenAIEmbedder) embedBatchWithRetry(
 		if !e.retryPolicy.ShouldRetry(attempt) {
 			return nil, fmt.Errorf("batch %d failed after %d attempts: %w", batch.Index, attempt+1, err)
 		}
+		embedRetries.Inc("openai", strconv.Itoa(retryErr.StatusCode))
 
 		if progress != nil {
 			progress(batch.Index, totalBatches, int(completedChunks.Load()), totalChunks, true, attempt+1, retryErr.StatusCode)
 		}
 
+		delay := e.calculateRetryDelay(attempt, retryErr)
+		slog.Warn("Embedding batch failed, retrying",
+			"batch", batch.Index,
+			"attempt", attempt+1,
+			"status", retryErr.StatusCode,
+			"delay_ms", delay.Milliseconds(),
+			"err", retryErr,
+		)
+
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(e.calculateRetryDelay(attempt, retryErr)):
+		case <-time.After(delay):
 		}
 	}
 }
@@ -466,7 +478,7 @@ func (e *OpenAIEmbedder) embedBatchRequest(ctx context.Context, texts []string)
 		return nil, err
 	}
 
-	resp, err := e.client.Do(req)
+	resp, err := doEmbedRequest(e.client, req, "openai")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request to OpenAI: %w", err)
 	}
@@ -144,7 +144,7 @@ func (e *OpenRouterEmbedder) EmbedBatch(ctx context.Context, texts []string) ([]
 	req.Header.Set("HTTP-Referer", "grepai")
 	req.Header.Set("X-Title", "grepai")
 
-	resp, err := e.client.Do(req)
+	resp, err := doEmbedRequest(e.client, req, "openrouter")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request to OpenRouter: %w", err)
 	}
@@ -1,7 +1,7 @@
 package embedder
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"sync"
@@ -155,6 +155,7 @@ func (arl *AdaptiveRateLimiter) OnRateLimitHit() bool {
 
 	// Increment rate limit hits
 	hits := arl.rateLimitHits.Add(1)
+	rateLimitHits.Inc()
 
 	// Check if we should reduce parallelism
 	if int(hits) >= arl.reductionThreshold {
@@ -187,7 +188,8 @@ func (arl *AdaptiveRateLimiter) reduceParallelism() bool {
 	arl.rateLimitHits.Store(0)
 	arl.lastReductionTime = time.Now()
 
-	log.Printf("Rate limit: reducing parallelism from %d to %d due to consecutive 429 responses", current, newLevel)
+	parallelismChanges.Inc("reduced")
+	slog.Warn("Rate limit: reducing parallelism after consecutive 429 responses", "from", current, "to", newLevel)
 
 	return true
 }
@@ -232,7 +234,8 @@ func (arl *AdaptiveRateLimiter) restoreParallelism() bool {
 	arl.currentWorkers.Store(int32(newLevel)) //nolint:gosec // newLevel is always small positive (<=maxWorkers)
 	arl.successStreak.Store(0)
 
-	log.Printf("Rate limit: restoring parallelism from %d to %d after successful requests", current, newLevel)
+	parallelismChanges.Inc("restored")
+	slog.Info("Rate limit: restoring parallelism after successful requests", "from", current, "to", newLevel)
 
 	return true
 }
@@ -139,7 +139,7 @@ func (e *SyntheticEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.apiKey))
 
-	resp, err := e.client.Do(req)
+	resp, err := doEmbedRequest(e.client, req, "synthetic")
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request to Synthetic: %w", err)
 	}
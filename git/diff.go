@@ -0,0 +1,211 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File change statuses reported by Diff.
+const (
+	StatusAdded    = "added"
+	StatusDeleted  = "deleted"
+	StatusModified = "modified"
+	StatusRenamed  = "renamed"
+)
+
+// FileDiff is the set of changes made to one file.
+type FileDiff struct {
+	Path    string // Path at head (at base for deleted files)
+	OldPath string // Path at base, differs from Path for renames
+	Status  string
+	Binary  bool
+	Hunks   []Hunk
+}
+
+// Hunk is a single "@@ -a,b +c,d @@" block of a unified diff.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string   // Text after the closing "@@", usually the enclosing function
+	Lines    []string // Diff lines including their ' ', '+' or '-' prefix
+}
+
+// NewEnd returns the last head line touched by the hunk.
+func (h Hunk) NewEnd() int {
+	if h.NewLines == 0 {
+		return h.NewStart
+	}
+	return h.NewStart + h.NewLines - 1
+}
+
+// Diff returns the changes between the merge base of base and head, and head
+// (`git diff base...head`), as seen from path. Paths are relative to path and
+// files outside it are not included.
+func Diff(path, base, head string) ([]FileDiff, error) {
+	for _, ref := range []string{base, head} {
+		if ref == "" || strings.HasPrefix(ref, "-") {
+			return nil, fmt.Errorf("invalid git ref %q", ref)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "-c", "core.quotePath=false",
+		"diff", "--no-color", "--no-ext-diff", "--no-prefix", "--relative", "-M", "-U3",
+		base+"..."+head, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git diff %s...%s failed: %s", base, head, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to execute git command (is git installed?): %w", err)
+	}
+	return ParseDiff(output)
+}
+
+// ParseDiff parses `git diff --no-prefix` output.
+func ParseDiff(output []byte) ([]FileDiff, error) {
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *Hunk
+
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+		if file.Path == "" {
+			file.Path = file.OldPath
+		}
+		files = append(files, *file)
+		file = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			file = &FileDiff{Status: StatusModified}
+			continue
+		}
+		if file == nil {
+			continue
+		}
+
+		if hunk != nil {
+			if line != "" && strings.ContainsRune(" +-\\", rune(line[0])) {
+				if line[0] != '\\' { // "\ No newline at end of file"
+					hunk.Lines = append(hunk.Lines, line)
+				}
+				continue
+			}
+			if line == "" { // Context line of an empty line with trailing space trimmed
+				hunk.Lines = append(hunk.Lines, " ")
+				continue
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "@@ "):
+			if hunk != nil {
+				file.Hunks = append(file.Hunks, *hunk)
+			}
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			hunk = &h
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = StatusAdded
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = StatusDeleted
+		case strings.HasPrefix(line, "rename from "):
+			file.Status = StatusRenamed
+			file.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- "):
+			if p := strings.TrimPrefix(line, "--- "); p != "/dev/null" {
+				file.OldPath = p
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				file.Path = p
+			}
+		case strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+			if file.Path == "" && file.OldPath == "" {
+				file.Path = binaryDiffPath(line)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	flush()
+
+	return files, nil
+}
+
+// parseHunkHeader parses "@@ -oldStart[,oldLines] +newStart[,newLines] @@ section".
+func parseHunkHeader(line string) (Hunk, error) {
+	rest := strings.TrimPrefix(line, "@@ ")
+	ranges, section, ok := strings.Cut(rest, " @@")
+	if !ok {
+		return Hunk{}, fmt.Errorf("malformed hunk header: %q", line)
+	}
+	oldRange, newRange, ok := strings.Cut(ranges, " ")
+	if !ok || !strings.HasPrefix(oldRange, "-") || !strings.HasPrefix(newRange, "+") {
+		return Hunk{}, fmt.Errorf("malformed hunk header: %q", line)
+	}
+
+	h := Hunk{Section: strings.TrimSpace(section)}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(oldRange[1:]); err != nil {
+		return Hunk{}, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(newRange[1:]); err != nil {
+		return Hunk{}, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+// parseRange parses "start[,count]"; count defaults to 1.
+func parseRange(s string) (start, count int, err error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startStr); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// binaryDiffPath extracts the path from "Binary files a and b differ".
+func binaryDiffPath(line string) string {
+	paths := strings.TrimSuffix(strings.TrimPrefix(line, "Binary files "), " differ")
+	oldPath, newPath, _ := strings.Cut(paths, " and ")
+	if newPath != "/dev/null" {
+		return newPath
+	}
+	return oldPath
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	output := `diff --git main.go main.go
+index 1111111..2222222 100644
+--- main.go
++++ main.go
+@@ -1,4 +1,5 @@ package main
+ package main
+
+-func Old() {}
++func New() {}
++func Extra() {}
+
+@@ -10 +11,0 @@ func tail() {
+-	removed()
+\ No newline at end of file
+diff --git gone.go gone.go
+deleted file mode 100644
+index 3333333..0000000
+--- gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package gone
+-func Gone() {}
+diff --git old/name.go new/name.go
+similarity index 90%
+rename from old/name.go
+rename to new/name.go
+diff --git logo.png logo.png
+new file mode 100644
+index 0000000..4444444
+Binary files /dev/null and logo.png differ
+`
+	files, err := ParseDiff([]byte(output))
+	if err != nil {
+		t.Fatalf("ParseDiff failed: %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files, got %d: %+v", len(files), files)
+	}
+
+	main := files[0]
+	if main.Path != "main.go" || main.Status != StatusModified || len(main.Hunks) != 2 {
+		t.Fatalf("unexpected main.go diff %+v", main)
+	}
+	first := main.Hunks[0]
+	if first.OldStart != 1 || first.OldLines != 4 || first.NewStart != 1 || first.NewLines != 5 || first.Section != "package main" {
+		t.Errorf("unexpected first hunk header %+v", first)
+	}
+	if len(first.Lines) != 6 || first.Lines[2] != "-func Old() {}" || first.Lines[1] != " " {
+		t.Errorf("unexpected first hunk lines %q", first.Lines)
+	}
+	second := main.Hunks[1]
+	if second.OldLines != 1 || second.NewStart != 11 || second.NewLines != 0 || len(second.Lines) != 1 {
+		t.Errorf("unexpected second hunk %+v", second)
+	}
+
+	if files[1].Path != "gone.go" || files[1].Status != StatusDeleted {
+		t.Errorf("unexpected deleted file %+v", files[1])
+	}
+	if files[2].Path != "new/name.go" || files[2].OldPath != "old/name.go" || files[2].Status != StatusRenamed {
+		t.Errorf("unexpected renamed file %+v", files[2])
+	}
+	if files[3].Path != "logo.png" || !files[3].Binary || files[3].Status != StatusAdded {
+		t.Errorf("unexpected binary file %+v", files[3])
+	}
+}
+
+func TestParseHunkHeader_Malformed(t *testing.T) {
+	for _, line := range []string{"@@ -1,2 +1,2", "@@ 1,2 3,4 @@", "@@ -a +1 @@"} {
+		if _, err := parseHunkHeader(line); err == nil {
+			t.Errorf("parseHunkHeader(%q) expected error", line)
+		}
+	}
+}
+
+func TestDiff_MergeBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	setupGitRepo(t, repoPath)
+	gitRun(t, repoPath, "branch", "-M", "main")
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n\nfunc A() {}\n")
+	gitRun(t, repoPath, "add", ".")
+	gitRun(t, repoPath, "commit", "-m", "base")
+
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	write("a.go", "package a\n\nfunc A() { B() }\n")
+	write("b.go", "package a\n\nfunc B() {}\n")
+	gitRun(t, repoPath, "add", ".")
+	gitRun(t, repoPath, "commit", "-m", "feature")
+
+	// A later commit on main must not show up in main...feature.
+	gitRun(t, repoPath, "checkout", "main")
+	write("c.go", "package a\n")
+	gitRun(t, repoPath, "add", ".")
+	gitRun(t, repoPath, "commit", "-m", "main only")
+
+	files, err := Diff(repoPath, "main", "feature")
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	got := map[string]FileDiff{}
+	for _, f := range files {
+		got[f.Path] = f
+	}
+	if len(got) != 2 || got["a.go"].Status != StatusModified || got["b.go"].Status != StatusAdded {
+		t.Fatalf("unexpected diff %+v", files)
+	}
+	if h := got["a.go"].Hunks; len(h) != 1 || h[0].NewStart != 1 {
+		t.Errorf("unexpected a.go hunks %+v", h)
+	}
+
+	if _, err := Diff(repoPath, "--output=x", "feature"); err == nil {
+		t.Error("expected error for option-like ref")
+	}
+	if _, err := Diff(repoPath, "main", "does-not-exist"); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+}
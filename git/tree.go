@@ -0,0 +1,168 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit identifies a resolved commit.
+type Commit struct {
+	Hash string    // Full commit SHA
+	Time time.Time // Committer date
+}
+
+// TreeEntry is a blob listed by ListTree.
+type TreeEntry struct {
+	Path   string // Slash-separated path relative to the directory passed to ListTree
+	Object string // Blob SHA
+	Size   int64
+}
+
+// ResolveRef resolves ref (branch, tag or commit-ish) to a commit.
+func ResolveRef(path, ref string) (*Commit, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "log", "-1", "--format=%H %ct", ref+"^{commit}", "--")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("unknown git ref %q: %s", ref, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to execute git command (is git installed?): %w", err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected git log output for %q: %q", ref, string(output))
+	}
+	seconds, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit time for %q: %w", ref, err)
+	}
+	return &Commit{Hash: fields[0], Time: time.Unix(seconds, 0)}, nil
+}
+
+// ListTree lists the blobs of commit below path, recursively. Paths are
+// relative to path, which may be a subdirectory of the repository. Symlinks
+// and submodules are not returned.
+func ListTree(path, commit string) ([]TreeEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "ls-tree", "-r", "-l", "-z", commit)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git ls-tree failed: %w (stderr: %s)", err, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("failed to execute git command (is git installed?): %w", err)
+	}
+	return parseTree(output)
+}
+
+// parseTree parses `git ls-tree -r -l -z` output:
+// "<mode> SP <type> SP <object> SP+ <size> TAB <path> NUL".
+func parseTree(output []byte) ([]TreeEntry, error) {
+	var entries []TreeEntry
+	for _, record := range bytes.Split(output, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+		meta, path, ok := bytes.Cut(record, []byte{'\t'})
+		if !ok {
+			return nil, fmt.Errorf("malformed ls-tree entry: %q", record)
+		}
+		fields := strings.Fields(string(meta))
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed ls-tree entry: %q", record)
+		}
+		// Only regular files: skip symlinks (120000) and submodules (160000).
+		if fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob size in ls-tree entry %q: %w", record, err)
+		}
+		entries = append(entries, TreeEntry{
+			Path:   string(path),
+			Object: fields[2],
+			Size:   size,
+		})
+	}
+	return entries, nil
+}
+
+// BlobReader reads blob contents through a long-lived `git cat-file --batch`
+// process. It is not safe for concurrent use.
+type BlobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewBlobReader starts a blob reader for the repository containing path.
+func NewBlobReader(ctx context.Context, path string) (*BlobReader, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git cat-file stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git cat-file stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file (is git installed?): %w", err)
+	}
+	return &BlobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Read returns the content of the blob with the given object SHA.
+func (r *BlobReader) Read(object string) ([]byte, error) {
+	if _, err := io.WriteString(r.stdin, object+"\n"); err != nil {
+		return nil, fmt.Errorf("failed to request blob %s: %w", object, err)
+	}
+
+	header, err := r.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob header for %s: %w", object, err)
+	}
+	// "<object> <type> <size>\n" or "<object> missing\n"
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("blob %s not found", object)
+	}
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", object, fields[1])
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob size for %s: %w", object, err)
+	}
+
+	// Content is followed by a single LF.
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(r.stdout, content); err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", object, err)
+	}
+	return content[:size], nil
+}
+
+// Close stops the underlying git process.
+func (r *BlobReader) Close() error {
+	_ = r.stdin.Close()
+	return r.cmd.Wait()
+}
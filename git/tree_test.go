@@ -0,0 +1,118 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func TestTreeAtRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	setupGitRepo(t, repoPath)
+
+	files := map[string]string{
+		"README.md":       "top\n",
+		"app/main.go":     "package main\n",
+		"app/lib/util.go": "package lib\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun(t, repoPath, "add", ".")
+	gitRun(t, repoPath, "commit", "-m", "v1")
+	gitRun(t, repoPath, "tag", "v1.0.0")
+
+	// Change the working tree after tagging: reads must still see v1.0.0.
+	if err := os.WriteFile(filepath.Join(repoPath, "app", "main.go"), []byte("package changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	appDir := filepath.Join(repoPath, "app")
+	commit, err := ResolveRef(appDir, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ResolveRef failed: %v", err)
+	}
+	if len(commit.Hash) != 40 || commit.Time.IsZero() {
+		t.Fatalf("unexpected commit %+v", commit)
+	}
+
+	entries, err := ListTree(appDir, commit.Hash)
+	if err != nil {
+		t.Fatalf("ListTree failed: %v", err)
+	}
+	got := map[string]TreeEntry{}
+	for _, e := range entries {
+		got[e.Path] = e
+	}
+	if len(got) != 2 || got["main.go"].Object == "" || got["lib/util.go"].Size != int64(len("package lib\n")) {
+		t.Fatalf("expected entries relative to app/, got %+v", entries)
+	}
+
+	reader, err := NewBlobReader(context.Background(), appDir)
+	if err != nil {
+		t.Fatalf("NewBlobReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	for i := 0; i < 2; i++ { // the reader is reusable across requests
+		content, err := reader.Read(got["main.go"].Object)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if string(content) != "package main\n" {
+			t.Errorf("Read = %q, want committed content", content)
+		}
+	}
+	if _, err := reader.Read("0000000000000000000000000000000000000000"); err == nil {
+		t.Error("expected error for missing object")
+	}
+}
+
+func TestResolveRef_Invalid(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	setupGitRepo(t, repoPath)
+
+	for _, ref := range []string{"", "--output=x", "does-not-exist"} {
+		if _, err := ResolveRef(repoPath, ref); err == nil {
+			t.Errorf("ResolveRef(%q) expected error", ref)
+		}
+	}
+}
+
+func TestParseTree_SkipsSymlinksAndSubmodules(t *testing.T) {
+	output := "100644 blob aaaa     12\ta.go\x00" +
+		"120000 blob bbbb      4\tlink.go\x00" +
+		"160000 commit cccc       -\tsub\x00" +
+		"100755 blob dddd      7\tbin/run.sh\x00"
+	entries, err := parseTree([]byte(output))
+	if err != nil {
+		t.Fatalf("parseTree failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "a.go" || entries[0].Size != 12 || entries[1].Path != "bin/run.sh" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
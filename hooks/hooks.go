@@ -0,0 +1,333 @@
+// Package hooks runs the user commands and webhooks configured in
+// watch.hooks when the watcher indexes files, finishes a scan, reconciles the
+// RPG graph or hits an error.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+)
+
+// Event is the kind of watch event a hook is fired on.
+type Event string
+
+const (
+	EventScanComplete  Event = "scan_complete"
+	EventFileIndexed   Event = "file_indexed"
+	EventFileRemoved   Event = "file_removed"
+	EventRPGReconciled Event = "rpg_reconciled"
+	EventError         Event = "error"
+)
+
+// queueSize is the number of hook runs that may wait for a free slot before
+// new ones are dropped.
+const queueSize = 256
+
+// closeGrace is how long Close lets queued and running hooks finish before
+// cancelling them.
+const closeGrace = 5 * time.Second
+
+// maxOutput bounds the command output kept for error messages.
+const maxOutput = 4096
+
+// ErrQueueFull is reported when a hook is dropped because too many runs are
+// already waiting.
+var ErrQueueFull = errors.New("hook queue full, run dropped")
+
+// Payload is the JSON document a hook receives on stdin, or as the body of
+// the webhook request.
+type Payload struct {
+	Event        Event     `json:"event"`
+	Project      string    `json:"project"`
+	Time         time.Time `json:"time"`
+	Path         string    `json:"path,omitempty"`
+	Chunks       int       `json:"chunks,omitempty"`
+	FilesIndexed int       `json:"files_indexed,omitempty"`
+	FilesRemoved int       `json:"files_removed,omitempty"`
+	DurationMs   int64     `json:"duration_ms,omitempty"`
+	Mode         string    `json:"mode,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Result describes a finished, failed or dropped hook run.
+type Result struct {
+	Hook     string
+	Event    Event
+	Project  string
+	Duration time.Duration
+	Err      error
+}
+
+type job struct {
+	hook    config.WatchHook
+	payload []byte
+	p       Payload
+}
+
+// Runner fires the hooks of a project. A nil Runner fires nothing, so call
+// sites need not check whether hooks are configured.
+type Runner struct {
+	hooks    []config.WatchHook
+	timeout  time.Duration
+	project  string
+	onResult func(Result)
+	client   *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   chan job
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New starts a runner for the hooks of the project at projectRoot, where
+// commands run. onResult, which may be nil, is called after each run from a
+// worker goroutine. New returns nil when no hooks are configured.
+func New(cfg config.WatchHooksConfig, projectRoot string, onResult func(Result)) *Runner {
+	if len(cfg.Actions) == 0 {
+		return nil
+	}
+	timeout := time.Duration(cfg.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = config.DefaultWatchHookTimeoutSec * time.Second
+	}
+	workers := cfg.MaxConcurrent
+	if workers <= 0 {
+		workers = config.DefaultWatchHookMaxConcurrent
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Runner{
+		hooks:    cfg.Actions,
+		timeout:  timeout,
+		project:  projectRoot,
+		onResult: onResult,
+		client:   &http.Client{},
+		ctx:      ctx,
+		cancel:   cancel,
+		jobs:     make(chan job, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	return r
+}
+
+// Fire queues the hooks subscribed to p.Event, filling in the project and
+// time when unset. It never blocks: runs that do not fit in the queue are
+// dropped and reported with ErrQueueFull.
+func (r *Runner) Fire(p Payload) {
+	if r == nil {
+		return
+	}
+	if p.Project == "" {
+		p.Project = r.project
+	}
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+
+	var payload []byte
+	for _, hook := range r.hooks {
+		if !subscribed(hook, p.Event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(p); err != nil {
+				return
+			}
+		}
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return
+		}
+		select {
+		case r.jobs <- job{hook: hook, payload: payload, p: p}:
+			r.mu.Unlock()
+		default:
+			r.mu.Unlock()
+			r.report(Result{Hook: Name(hook), Event: p.Event, Project: p.Project, Err: ErrQueueFull})
+		}
+	}
+}
+
+// Close stops accepting events and waits for queued and running hooks,
+// cancelling those still running after a short grace period.
+func (r *Runner) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.jobs)
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(closeGrace):
+		r.cancel()
+		<-done
+	}
+	r.cancel()
+}
+
+func (r *Runner) work() {
+	defer r.wg.Done()
+	for j := range r.jobs {
+		if r.ctx.Err() != nil {
+			continue
+		}
+		timeout := r.timeout
+		if j.hook.TimeoutSec > 0 {
+			timeout = time.Duration(j.hook.TimeoutSec) * time.Second
+		}
+		ctx, cancel := context.WithTimeout(r.ctx, timeout)
+		start := time.Now()
+		var err error
+		if j.hook.URL != "" {
+			err = r.post(ctx, j.hook.URL, j.payload)
+		} else {
+			err = r.run(ctx, j.hook.Command, j.p, j.payload)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		r.report(Result{Hook: Name(j.hook), Event: j.p.Event, Project: j.p.Project, Duration: time.Since(start), Err: err})
+	}
+}
+
+func (r *Runner) report(res Result) {
+	if r.onResult != nil {
+		r.onResult(res)
+	}
+}
+
+// run executes command with the shell, passing the payload on stdin and the
+// event, project and path in GREPAI_HOOK_* variables.
+func (r *Runner) run(ctx context.Context, command string, p Payload, payload []byte) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = r.project
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"GREPAI_HOOK_EVENT="+string(p.Event),
+		"GREPAI_HOOK_PROJECT="+p.Project,
+		"GREPAI_HOOK_PATH="+p.Path,
+	)
+	var output limitedBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Don't wait forever for background processes holding the pipes
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%w: %s", err, lastLine(out))
+		}
+		return err
+	}
+	return nil
+}
+
+// post sends the payload to url as JSON. Non-2xx responses are errors.
+func (r *Runner) post(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grepai-hooks")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxOutput))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Name returns the name of hook, or its command or URL when it has none.
+func Name(hook config.WatchHook) string {
+	switch {
+	case hook.Name != "":
+		return hook.Name
+	case hook.URL != "":
+		return hook.URL
+	default:
+		return hook.Command
+	}
+}
+
+func subscribed(hook config.WatchHook, event Event) bool {
+	for _, e := range hook.Events {
+		if Event(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// limitedBuffer keeps the last maxOutput bytes written to it.
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxOutput {
+		b.buf = b.buf[len(b.buf)-maxOutput:]
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
@@ -0,0 +1,190 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+)
+
+type resultCollector struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (c *resultCollector) add(res Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, res)
+}
+
+func (c *resultCollector) all() []Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Result(nil), c.results...)
+}
+
+func TestNew_NoActions(t *testing.T) {
+	r := New(config.WatchHooksConfig{}, t.TempDir(), nil)
+	if r != nil {
+		t.Fatal("expected a nil runner without actions")
+	}
+	// A nil runner must be usable
+	r.Fire(Payload{Event: EventError})
+	r.Close()
+}
+
+func TestRunner_CommandReceivesPayload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	var results resultCollector
+	r := New(config.WatchHooksConfig{Actions: []config.WatchHook{
+		{Name: "record", Events: []string{"file_indexed"}, Command: `cat > payload.json; echo "$GREPAI_HOOK_EVENT $GREPAI_HOOK_PATH" > env.txt`},
+		{Name: "other", Events: []string{"scan_complete"}, Command: "touch other.txt"},
+	}}, dir, results.add)
+
+	r.Fire(Payload{Event: EventFileIndexed, Path: "main.go", Chunks: 3})
+	r.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "payload.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid payload %q: %v", data, err)
+	}
+	if got.Event != EventFileIndexed || got.Path != "main.go" || got.Chunks != 3 || got.Project != dir || got.Time.IsZero() {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+	env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(env)) != "file_indexed main.go" {
+		t.Fatalf("unexpected environment: %q", env)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); !os.IsNotExist(err) {
+		t.Fatal("hook fired for an event it is not subscribed to")
+	}
+
+	res := results.all()
+	if len(res) != 1 || res[0].Hook != "record" || res[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", res)
+	}
+}
+
+func TestRunner_CommandFailureAndTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	var results resultCollector
+	r := New(config.WatchHooksConfig{TimeoutSec: 1, Actions: []config.WatchHook{
+		{Name: "fail", Events: []string{"error"}, Command: "echo boom >&2; exit 3"},
+		{Name: "slow", Events: []string{"error"}, Command: "sleep 5"},
+	}}, t.TempDir(), results.add)
+
+	start := time.Now()
+	r.Fire(Payload{Event: EventError, Error: "test"})
+	r.Close()
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("timeout not enforced, took %s", elapsed)
+	}
+
+	byName := make(map[string]Result)
+	for _, res := range results.all() {
+		byName[res.Hook] = res
+	}
+	if err := byName["fail"].Err; err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the failure to carry the command output, got %v", err)
+	}
+	if err := byName["slow"].Err; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestRunner_Webhook(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(req.Body)
+		var p Payload
+		if err := json.Unmarshal(data, &p); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, p)
+		mu.Unlock()
+		if p.Event == EventError {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	var results resultCollector
+	r := New(config.WatchHooksConfig{Actions: []config.WatchHook{
+		{Events: []string{"scan_complete", "error"}, URL: srv.URL},
+	}}, "/project", results.add)
+	r.Fire(Payload{Event: EventScanComplete, FilesIndexed: 12})
+	r.Fire(Payload{Event: EventError, Error: "embedder unreachable"})
+	r.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0].Project != "/project" || bodies[0].FilesIndexed != 12 {
+		t.Fatalf("unexpected webhook bodies: %+v", bodies)
+	}
+	var failed int
+	for _, res := range results.all() {
+		if res.Hook != srv.URL {
+			t.Fatalf("expected the URL as hook name, got %q", res.Hook)
+		}
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("expected the 500 response to be reported as a failure, got %d failures", failed)
+	}
+}
+
+func TestRunner_DropsWhenQueueFull(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	var results resultCollector
+	r := New(config.WatchHooksConfig{MaxConcurrent: 1, Actions: []config.WatchHook{
+		{Name: "slow", Events: []string{"file_indexed"}, Command: "sleep 0.2"},
+	}}, t.TempDir(), results.add)
+	for i := 0; i < queueSize+10; i++ {
+		r.Fire(Payload{Event: EventFileIndexed})
+	}
+
+	var dropped int
+	for _, res := range results.all() {
+		if res.Err == ErrQueueFull {
+			dropped++
+		}
+	}
+	r.cancel() // Skip the queued runs
+	r.Close()
+	if dropped == 0 {
+		t.Fatal("expected runs to be dropped when the queue is full")
+	}
+}
@@ -0,0 +1,225 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/internal/fileutil"
+)
+
+// minCheckpointRoundBatches is the size of the first embedding round when a
+// checkpoint interval is set. Files completed by a round are saved before the
+// next round starts, so the interval is checked within a group. Later rounds
+// are sized from the measured embedding rate to last about one interval, so
+// parallel embedding only waits for a round to drain about once per checkpoint.
+const minCheckpointRoundBatches = 8
+
+// CheckpointConfig makes long indexing runs resumable. Files are indexed in
+// groups of Files; as files are saved the store is persisted once Files files
+// or Interval have passed since the last checkpoint. The files of the group in
+// flight are recorded in a journal at JournalPath until the group is saved.
+type CheckpointConfig struct {
+	JournalPath string
+	Files       int
+	Interval    time.Duration
+}
+
+// IndexJournal lists the files being indexed when a run was interrupted.
+type IndexJournal struct {
+	StartedAt time.Time      `json:"started_at"`
+	Files     []JournalEntry `json:"files"`
+}
+
+// JournalEntry is a file of an in-flight group. Hash is the content hash
+// being indexed; a saved document with the same hash was stored completely.
+type JournalEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// SetCheckpoint enables periodic checkpoints and the in-flight journal.
+func (idx *Indexer) SetCheckpoint(cfg CheckpointConfig) {
+	idx.checkpoint = cfg
+}
+
+// SaveIndexJournal writes the journal atomically.
+func SaveIndexJournal(path string, journal *IndexJournal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index journal: %w", err)
+	}
+	if err := fileutil.EnsureParentDir(path); err != nil {
+		return fmt.Errorf("failed to create index journal directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write index journal: %w", err)
+	}
+	return fileutil.ReplaceFileAtomically(tmpPath, path)
+}
+
+// LoadIndexJournal reads the journal left by an interrupted run. It returns
+// nil without error when the previous run completed.
+func LoadIndexJournal(path string) (*IndexJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read index journal: %w", err)
+	}
+	journal := &IndexJournal{}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse index journal: %w", err)
+	}
+	return journal, nil
+}
+
+// interruptedFiles returns the files left in flight by an interrupted run,
+// with the hash each was being indexed at. They stay in the journal until
+// the run completes, so that a second interruption does not lose them.
+func (idx *Indexer) interruptedFiles() map[string]string {
+	idx.resumed = nil
+	if idx.checkpoint.JournalPath == "" {
+		return nil
+	}
+	journal, err := LoadIndexJournal(idx.checkpoint.JournalPath)
+	if err != nil {
+		slog.Warn("Ignoring index journal", "err", err)
+		return nil
+	}
+	if journal == nil {
+		return nil
+	}
+
+	slog.Info("Resuming interrupted indexing",
+		"started_at", journal.StartedAt.Format(time.RFC3339),
+		"files_in_flight", len(journal.Files),
+	)
+	idx.resumed = journal
+	files := make(map[string]string, len(journal.Files))
+	for _, entry := range journal.Files {
+		files[entry.Path] = entry.Hash
+	}
+	return files
+}
+
+// beginGroup journals the files about to be indexed, along with the files
+// left in flight by an interrupted run.
+func (idx *Indexer) beginGroup(files []FileInfo) {
+	if idx.checkpoint.JournalPath == "" {
+		return
+	}
+	journal := &IndexJournal{StartedAt: time.Now(), Files: make([]JournalEntry, 0, len(files))}
+	inGroup := make(map[string]bool, len(files))
+	for _, f := range files {
+		journal.Files = append(journal.Files, JournalEntry{Path: f.Path, Hash: f.Hash})
+		inGroup[f.Path] = true
+	}
+	if idx.resumed != nil {
+		journal.StartedAt = idx.resumed.StartedAt
+		for _, entry := range idx.resumed.Files {
+			if !inGroup[entry.Path] {
+				journal.Files = append(journal.Files, entry)
+			}
+		}
+	}
+	if err := SaveIndexJournal(idx.checkpoint.JournalPath, journal); err != nil {
+		slog.Warn("Failed to save index journal", "err", err)
+	}
+}
+
+// maybeCheckpoint persists the store when enough files or time have passed
+// since the last checkpoint. force persists regardless, e.g. on interruption.
+func (idx *Indexer) maybeCheckpoint(ctx context.Context, indexed int, force bool) {
+	if idx.checkpoint.Files <= 0 && idx.checkpoint.Interval <= 0 {
+		return
+	}
+	idx.sinceCheckpoint += indexed
+	due := force ||
+		(idx.checkpoint.Files > 0 && idx.sinceCheckpoint >= idx.checkpoint.Files) ||
+		(idx.checkpoint.Interval > 0 && time.Since(idx.lastCheckpoint) >= idx.checkpoint.Interval)
+	if !due {
+		return
+	}
+
+	// Persist even when ctx was cancelled by Ctrl-C: that is when it matters most.
+	if err := idx.store.Persist(context.WithoutCancel(ctx)); err != nil {
+		slog.Warn("Failed to checkpoint index", "err", err)
+		return
+	}
+	idx.sinceCheckpoint = 0
+	idx.lastCheckpoint = time.Now()
+}
+
+// endGroups removes the journal once every group has been saved.
+func (idx *Indexer) endGroups() {
+	idx.resumed = nil
+	if idx.checkpoint.JournalPath == "" {
+		return
+	}
+	if err := os.Remove(idx.checkpoint.JournalPath); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove index journal", "err", err)
+	}
+}
+
+// nextRoundSize returns the number of batches expected to take about interval
+// at the rate of the last round, which embedded last batches in elapsed.
+func nextRoundSize(last int, elapsed, interval time.Duration, remaining int) int {
+	if elapsed <= 0 {
+		return remaining
+	}
+	size := float64(last) * float64(interval) / float64(elapsed)
+	if size >= float64(remaining) {
+		return remaining
+	}
+	return max(int(size), minCheckpointRoundBatches)
+}
+
+// renumberBatches returns a copy of batches indexed from 0, as EmbedBatches
+// expects of the batches it is given.
+func renumberBatches(batches []embedder.Batch) []embedder.Batch {
+	round := make([]embedder.Batch, len(batches))
+	for i, b := range batches {
+		b.Index = i
+		round[i] = b
+	}
+	return round
+}
+
+// roundProgress reports batches embedded in rounds as a single run.
+type roundProgress struct {
+	onProgress   BatchProgressCallback
+	totalBatches int
+	totalChunks  int
+	chunksBefore []int // chunks in the batches preceding each batch
+}
+
+func newRoundProgress(onProgress BatchProgressCallback, batches []embedder.Batch) *roundProgress {
+	p := &roundProgress{onProgress: onProgress, totalBatches: len(batches), chunksBefore: make([]int, len(batches))}
+	for i, b := range batches {
+		p.chunksBefore[i] = p.totalChunks
+		p.totalChunks += b.Size()
+	}
+	return p
+}
+
+// forRound returns the progress callback of the round starting at batch start.
+func (p *roundProgress) forRound(start int) embedder.BatchProgress {
+	if p.onProgress == nil {
+		return nil
+	}
+	done := p.chunksBefore[start]
+	return wrapBatchProgress(func(info BatchProgressInfo) {
+		info.BatchIndex += start
+		info.TotalBatches = p.totalBatches
+		info.CompletedChunks += done
+		info.TotalChunks = p.totalChunks
+		p.onProgress(info)
+	})
+}
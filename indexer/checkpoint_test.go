@@ -0,0 +1,239 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/store"
+)
+
+// persistCountingStore counts checkpoints.
+type persistCountingStore struct {
+	*mockStore
+	persists int
+}
+
+func (s *persistCountingStore) Persist(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.persists++
+	return nil
+}
+
+// interruptingEmbedder cancels the run when asked to embed for the n-th time.
+type interruptingEmbedder struct {
+	*mockEmbedder
+	calls  int
+	n      int
+	cancel context.CancelFunc
+}
+
+func (e *interruptingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	if e.calls == e.n {
+		e.cancel()
+		return nil, ctx.Err()
+	}
+	return e.mockEmbedder.EmbedBatch(ctx, texts)
+}
+
+func TestIndexAll_ResumesAfterInterruption(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n\nfunc "+name[:1]+"() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	journalPath := filepath.Join(tmpDir, ".grepai", "index.journal")
+	checkpoint := CheckpointConfig{JournalPath: journalPath, Files: 1, Interval: time.Hour}
+
+	// First run: interrupted while embedding the second file.
+	st := &persistCountingStore{mockStore: newMockStore()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	emb := &interruptingEmbedder{mockEmbedder: newMockEmbedder(), n: 2, cancel: cancel}
+	idx := NewIndexer(tmpDir, st, emb, NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	idx.SetCheckpoint(checkpoint)
+
+	if _, err := idx.IndexAll(ctx); err == nil {
+		t.Fatal("expected interrupted run to fail")
+	}
+	if len(st.documents) != 1 {
+		t.Fatalf("expected the first file to be saved, got %v", st.documents)
+	}
+	if st.persists != 2 {
+		t.Errorf("expected a checkpoint after the first file and on interruption, got %d", st.persists)
+	}
+	journal, err := LoadIndexJournal(journalPath)
+	if err != nil || journal == nil || len(journal.Files) != 1 {
+		t.Fatalf("expected journal with the in-flight file, got %+v, %v", journal, err)
+	}
+	inFlight := journal.Files[0].Path
+	if _, saved := st.documents[inFlight]; saved {
+		t.Fatalf("in-flight file %s should not be saved", inFlight)
+	}
+
+	// A stale document for the in-flight file would normally be skipped by
+	// the mod-time gate; the journal makes the next run index it anyway.
+	st.documents[inFlight] = store.Document{Path: inFlight, Hash: "stale", ChunkIDs: []string{"gone"}}
+
+	idx = NewIndexer(tmpDir, st, newMockEmbedder(), NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Now().Add(time.Hour))
+	idx.SetCheckpoint(checkpoint)
+	stats, err := idx.IndexAll(context.Background())
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if stats.FilesIndexed != 2 {
+		t.Errorf("expected the in-flight and the never-started file to be indexed, got %d", stats.FilesIndexed)
+	}
+	if doc := st.documents[inFlight]; doc.Hash == "stale" {
+		t.Errorf("expected %s to be re-indexed", inFlight)
+	}
+	if journal, _ := LoadIndexJournal(journalPath); journal != nil {
+		t.Errorf("expected journal to be removed after a complete run, got %+v", journal)
+	}
+}
+
+func TestIndexAll_CheckpointsWithinGroupOnInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n\nfunc "+name[:1]+"() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+
+	// All files fit in one group, so only the interval can trigger checkpoints.
+	st := &persistCountingStore{mockStore: newMockStore()}
+	idx := NewIndexer(tmpDir, st, newMockEmbedder(), NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	idx.SetCheckpoint(CheckpointConfig{Files: 1000, Interval: time.Nanosecond})
+
+	if _, err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatalf("IndexAll failed: %v", err)
+	}
+	if st.persists != 3 {
+		t.Errorf("expected a checkpoint after each file of the group, got %d", st.persists)
+	}
+}
+
+func TestIndexAll_KeepsJournalAcrossInterruptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n\nfunc "+name[:1]+"() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	journalPath := filepath.Join(tmpDir, ".grepai", "index.journal")
+	startedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := SaveIndexJournal(journalPath, &IndexJournal{StartedAt: startedAt, Files: []JournalEntry{{Path: "c.go", Hash: "old"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted again while embedding the first group, before c.go is reached.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	emb := &interruptingEmbedder{mockEmbedder: newMockEmbedder(), n: 1, cancel: cancel}
+	idx := NewIndexer(tmpDir, newMockStore(), emb, NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	idx.SetCheckpoint(CheckpointConfig{JournalPath: journalPath, Files: 1, Interval: time.Hour})
+	if _, err := idx.IndexAll(ctx); err == nil {
+		t.Fatal("expected interrupted run to fail")
+	}
+
+	journal, err := LoadIndexJournal(journalPath)
+	if err != nil || journal == nil {
+		t.Fatalf("expected a journal, got %+v, %v", journal, err)
+	}
+	paths := make(map[string]bool)
+	for _, entry := range journal.Files {
+		paths[entry.Path] = true
+	}
+	if !paths["c.go"] || len(paths) != 2 {
+		t.Errorf("expected the new and the earlier in-flight files, got %+v", journal.Files)
+	}
+	if !journal.StartedAt.Equal(startedAt) {
+		t.Errorf("expected the start of the first interrupted run, got %v", journal.StartedAt)
+	}
+}
+
+func TestIndexAll_ResumeSkipsFilesSavedAtJournaledHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n\nfunc "+name[:1]+"() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	st := newMockStore()
+	idx := NewIndexer(tmpDir, st, newMockEmbedder(), NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	if _, err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatalf("IndexAll failed: %v", err)
+	}
+
+	// a.go was saved at the hash it was journaled with; b.go was not.
+	journalPath := filepath.Join(tmpDir, ".grepai", "index.journal")
+	staleB := st.documents["b.go"]
+	staleB.Hash = "stale"
+	st.documents["b.go"] = staleB
+	if err := SaveIndexJournal(journalPath, &IndexJournal{Files: []JournalEntry{
+		{Path: "a.go", Hash: st.documents["a.go"].Hash},
+		{Path: "b.go", Hash: "new"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	idx = NewIndexer(tmpDir, st, newMockEmbedder(), NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Now().Add(time.Hour))
+	idx.SetCheckpoint(CheckpointConfig{JournalPath: journalPath, Files: 10, Interval: time.Hour})
+	stats, err := idx.IndexAll(context.Background())
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if stats.FilesIndexed != 1 || st.documents["b.go"].Hash == "stale" {
+		t.Errorf("expected only b.go re-indexed, got %d indexed", stats.FilesIndexed)
+	}
+	if stats.FilesSkipped != 2 {
+		t.Errorf("expected a.go and c.go skipped by the mod-time gate, got %d", stats.FilesSkipped)
+	}
+}
+
+func TestNextRoundSize(t *testing.T) {
+	for _, tc := range []struct {
+		last      int
+		elapsed   time.Duration
+		remaining int
+		want      int
+	}{
+		{last: 8, elapsed: time.Second, remaining: 1000, want: 240},
+		{last: 8, elapsed: time.Minute, remaining: 1000, want: minCheckpointRoundBatches},
+		{last: 8, elapsed: time.Second, remaining: 20, want: 20},
+		{last: 8, elapsed: 0, remaining: 50, want: 50},
+	} {
+		if got := nextRoundSize(tc.last, tc.elapsed, 30*time.Second, tc.remaining); got != tc.want {
+			t.Errorf("nextRoundSize(%d, %v, 30s, %d) = %d, want %d", tc.last, tc.elapsed, tc.remaining, got, tc.want)
+		}
+	}
+}
+
+func TestLoadIndexJournal_Missing(t *testing.T) {
+	journal, err := LoadIndexJournal(filepath.Join(t.TempDir(), "index.journal"))
+	if err != nil || journal != nil {
+		t.Errorf("expected no journal, got %+v, %v", journal, err)
+	}
+}
@@ -0,0 +1,304 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// File policies for large, generated and vendored files.
+const (
+	PolicySkip     = "skip"     // Do not index the file
+	PolicyPenalize = "penalize" // Index the file but rank it lower in search
+	PolicyIndex    = "index"    // Index the file normally
+	PolicyRedact   = "redact"   // Index the file with its credentials redacted (secrets only)
+)
+
+// Reasons reported for files that are skipped or penalized.
+const (
+	ReasonMinified  = "minified"
+	ReasonTooLarge  = "too large"
+	ReasonGenerated = "generated"
+	ReasonVendored  = "vendored"
+	ReasonSecrets   = "secrets"
+)
+
+// generatedHeaderBytes bounds how much of a file is inspected for markers.
+const generatedHeaderBytes = 1024
+
+// GeneratedPatterns lists file name suffixes of generated files and lockfiles
+// that are detected without reading their content.
+var GeneratedPatterns = []string{
+	".pb.go",
+	".pb.gw.go",
+	"_pb2.py",
+	"_pb2_grpc.py",
+	".pb.ts",
+	"_generated.go",
+	"_generated.ts",
+	".generated.ts",
+	".generated.cs",
+	".designer.cs",
+	".g.dart",
+	".freezed.dart",
+	".snap",
+	"package-lock.json",
+	"pnpm-lock.yaml",
+	"composer.lock",
+	"yarn.lock",
+	"cargo.lock",
+	"poetry.lock",
+	"gemfile.lock",
+}
+
+// FilePolicyConfig controls how special files are handled during scanning.
+type FilePolicyConfig struct {
+	MaxFileSize int64  // Files larger than this are subject to LargeFiles
+	LargeFiles  string // skip | penalize | index
+	Generated   string // skip | penalize | index
+	Vendored    string // skip | penalize | index
+	Secrets     string // redact | skip | index: files containing credentials
+}
+
+// DefaultFilePolicy matches the historical scanner behaviour: large files are
+// skipped, generated and vendored files are indexed normally. Credentials
+// are redacted.
+func DefaultFilePolicy() FilePolicyConfig {
+	return FilePolicyConfig{
+		MaxFileSize: maxFileSize,
+		LargeFiles:  PolicySkip,
+		Generated:   PolicyIndex,
+		Vendored:    PolicyIndex,
+		Secrets:     PolicyRedact,
+	}
+}
+
+// gitAttributeRule is a single .gitattributes line that sets a linguist attribute.
+type gitAttributeRule struct {
+	matcher   *ignore.GitIgnore
+	generated *bool
+	vendored  *bool
+}
+
+// FileClassifier detects large, generated and vendored files and resolves the
+// configured policy for them.
+type FileClassifier struct {
+	cfg   FilePolicyConfig
+	rules []gitAttributeRule
+}
+
+// NewFileClassifier creates a classifier that honours linguist-generated and
+// linguist-vendored attributes from the project's root .gitattributes.
+func NewFileClassifier(root string, cfg FilePolicyConfig) *FileClassifier {
+	defaults := DefaultFilePolicy()
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = defaults.MaxFileSize
+	}
+	if cfg.LargeFiles == "" {
+		cfg.LargeFiles = defaults.LargeFiles
+	}
+	if cfg.Generated == "" {
+		cfg.Generated = defaults.Generated
+	}
+	if cfg.Vendored == "" {
+		cfg.Vendored = defaults.Vendored
+	}
+	if cfg.Secrets == "" {
+		cfg.Secrets = defaults.Secrets
+	}
+
+	c := &FileClassifier{cfg: cfg}
+	if root != "" {
+		c.rules = loadGitAttributes(filepath.Join(root, ".gitattributes"))
+	}
+	return c
+}
+
+// withGitAttributes returns a copy of c using the linguist rules parsed from
+// attributes instead of the working tree .gitattributes.
+func (c *FileClassifier) withGitAttributes(attributes []byte) *FileClassifier {
+	return &FileClassifier{cfg: c.cfg, rules: parseGitAttributes(bytes.NewReader(attributes))}
+}
+
+// ClassifyPath classifies a file from its path and size alone.
+// It returns an empty reason when the file needs no special handling.
+func (c *FileClassifier) ClassifyPath(relPath string, size int64) (reason, policy string) {
+	if size > c.cfg.MaxFileSize {
+		return ReasonTooLarge, c.cfg.LargeFiles
+	}
+
+	generated, vendored := c.attributes(relPath)
+	if vendored != nil && *vendored {
+		return ReasonVendored, c.cfg.Vendored
+	}
+	if generated != nil {
+		if *generated {
+			return ReasonGenerated, c.cfg.Generated
+		}
+		// Explicit -linguist-generated overrides name-based detection.
+		return "", PolicyIndex
+	}
+	if isGeneratedName(relPath) {
+		return ReasonGenerated, c.cfg.Generated
+	}
+	return "", PolicyIndex
+}
+
+// ClassifyFile classifies a file on disk. Only the first bytes are read, and
+// only when the generated-code policy needs them.
+func (c *FileClassifier) ClassifyFile(absPath, relPath string, size int64) (reason, policy string) {
+	reason, policy = c.ClassifyPath(relPath, size)
+	if reason != "" || !c.needsHeader(relPath) {
+		return reason, policy
+	}
+	header, err := readHeader(absPath, generatedHeaderBytes)
+	if err != nil {
+		return reason, policy
+	}
+	if hasGeneratedHeader(header) {
+		return ReasonGenerated, c.cfg.Generated
+	}
+	return "", PolicyIndex
+}
+
+// ClassifyContent classifies a file whose content is already in memory,
+// such as a blob read from git.
+func (c *FileClassifier) ClassifyContent(relPath string, content []byte) (reason, policy string) {
+	reason, policy = c.ClassifyPath(relPath, int64(len(content)))
+	if reason != "" || !c.needsHeader(relPath) {
+		return reason, policy
+	}
+	if hasGeneratedHeader(content) {
+		return ReasonGenerated, c.cfg.Generated
+	}
+	return "", PolicyIndex
+}
+
+// needsHeader reports whether header markers can change the outcome for relPath.
+func (c *FileClassifier) needsHeader(relPath string) bool {
+	if c.cfg.Generated == PolicyIndex {
+		return false
+	}
+	generated, _ := c.attributes(relPath)
+	return generated == nil
+}
+
+// attributes returns the last linguist-generated and linguist-vendored values
+// set for relPath, or nil when unset.
+func (c *FileClassifier) attributes(relPath string) (generated, vendored *bool) {
+	relPath = filepath.ToSlash(relPath)
+	for _, rule := range c.rules {
+		if !rule.matcher.MatchesPath(relPath) {
+			continue
+		}
+		if rule.generated != nil {
+			generated = rule.generated
+		}
+		if rule.vendored != nil {
+			vendored = rule.vendored
+		}
+	}
+	return generated, vendored
+}
+
+func isGeneratedName(relPath string) bool {
+	lower := strings.ToLower(filepath.ToSlash(relPath))
+	for _, pattern := range GeneratedPatterns {
+		if strings.HasSuffix(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGeneratedHeader looks for the standard "Code generated ... DO NOT EDIT."
+// marker (https://go.dev/s/generatedcode) or an @generated tag near the top.
+func hasGeneratedHeader(content []byte) bool {
+	if len(content) > generatedHeaderBytes {
+		content = content[:generatedHeaderBytes]
+	}
+	if bytes.Contains(content, []byte("@generated")) {
+		return true
+	}
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if bytes.Contains(line, []byte("Code generated")) && bytes.Contains(line, []byte("DO NOT EDIT")) {
+			return true
+		}
+	}
+	return false
+}
+
+func readHeader(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// loadGitAttributes parses linguist attributes from a .gitattributes file.
+// Missing or unreadable files yield no rules.
+func loadGitAttributes(path string) []gitAttributeRule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return parseGitAttributes(f)
+}
+
+// parseGitAttributes parses linguist attributes in .gitattributes format.
+func parseGitAttributes(r io.Reader) []gitAttributeRule {
+	var rules []gitAttributeRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rule := gitAttributeRule{}
+		for _, attr := range fields[1:] {
+			name, value := parseGitAttribute(attr)
+			switch name {
+			case "linguist-generated":
+				rule.generated = &value
+			case "linguist-vendored":
+				rule.vendored = &value
+			}
+		}
+		if rule.generated == nil && rule.vendored == nil {
+			continue
+		}
+		rule.matcher = ignore.CompileIgnoreLines(fields[0])
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseGitAttribute handles the "attr", "-attr" and "attr=value" forms.
+// "!attr" (unspecified) is ignored.
+func parseGitAttribute(attr string) (string, bool) {
+	switch {
+	case strings.HasPrefix(attr, "!"):
+		return "", false
+	case strings.HasPrefix(attr, "-"):
+		return attr[1:], false
+	case strings.Contains(attr, "="):
+		name, value, _ := strings.Cut(attr, "=")
+		return name, value != "false" && value != "0"
+	default:
+		return attr, true
+	}
+}
@@ -0,0 +1,187 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileClassifier_GeneratedDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	writeFile("gen.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n")
+	writeFile("schema.ts", "/** @generated */\nexport type A = string;\n")
+	writeFile("api.pb.go", "package api\n")
+	writeFile("main.go", "package main\n")
+	writeFile("late.go", "package main\n"+strings.Repeat("// filler\n", 200)+"// Code generated DO NOT EDIT.\n")
+
+	c := NewFileClassifier(tmpDir, FilePolicyConfig{Generated: PolicySkip})
+	tests := []struct {
+		path       string
+		wantReason string
+	}{
+		{"gen.go", ReasonGenerated},
+		{"schema.ts", ReasonGenerated},
+		{"api.pb.go", ReasonGenerated},
+		{"main.go", ""},
+		{"late.go", ""}, // marker outside the inspected header
+	}
+	for _, tt := range tests {
+		info, err := os.Stat(filepath.Join(tmpDir, tt.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reason, _ := c.ClassifyFile(filepath.Join(tmpDir, tt.path), tt.path, info.Size())
+		if reason != tt.wantReason {
+			t.Errorf("ClassifyFile(%s) reason = %q, want %q", tt.path, reason, tt.wantReason)
+		}
+	}
+}
+
+func TestFileClassifier_GitAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	attrs := "# comment\n" +
+		"third_party/** linguist-vendored\n" +
+		"assets/*.js linguist-generated=true\n" +
+		"*.pb.go -linguist-generated\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitattributes"), []byte(attrs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewFileClassifier(tmpDir, FilePolicyConfig{Generated: PolicySkip, Vendored: PolicyPenalize})
+	tests := []struct {
+		path       string
+		wantReason string
+		wantPolicy string
+	}{
+		{"third_party/lib/x.go", ReasonVendored, PolicyPenalize},
+		{"assets/app.js", ReasonGenerated, PolicySkip},
+		{"api/api.pb.go", "", PolicyIndex}, // explicitly not generated
+		{"src/app.js", "", PolicyIndex},
+	}
+	for _, tt := range tests {
+		reason, policy := c.ClassifyPath(tt.path, 10)
+		if reason != tt.wantReason || policy != tt.wantPolicy {
+			t.Errorf("ClassifyPath(%s) = (%q, %q), want (%q, %q)", tt.path, reason, policy, tt.wantReason, tt.wantPolicy)
+		}
+	}
+}
+
+func TestFileClassifier_MaxFileSize(t *testing.T) {
+	c := NewFileClassifier("", FilePolicyConfig{MaxFileSize: 100, LargeFiles: PolicyPenalize})
+	if reason, policy := c.ClassifyPath("big.go", 101); reason != ReasonTooLarge || policy != PolicyPenalize {
+		t.Errorf("ClassifyPath(big.go) = (%q, %q), want (%q, %q)", reason, policy, ReasonTooLarge, PolicyPenalize)
+	}
+	if reason, _ := c.ClassifyPath("small.go", 100); reason != "" {
+		t.Errorf("expected file at the limit to be accepted, got reason %q", reason)
+	}
+}
+
+func TestScanner_FilePolicyReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":               "package main\n",
+		"api.pb.go":             "package api\n",
+		"gen/types.go":          "// Code generated by tool. DO NOT EDIT.\npackage gen\n",
+		"third_party/vendor.go": "package tp\n",
+		"app.min.js":            "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitattributes"), []byte("third_party/** linguist-vendored\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+	scanner.SetFilePolicy(FilePolicyConfig{Generated: PolicySkip, Vendored: PolicyPenalize})
+
+	metas, skipped, err := scanner.ScanMetadata()
+	if err != nil {
+		t.Fatalf("ScanMetadata failed: %v", err)
+	}
+
+	indexed := map[string]bool{}
+	for _, m := range metas {
+		indexed[filepath.ToSlash(m.Path)] = true
+	}
+	if !indexed["main.go"] || !indexed["third_party/vendor.go"] {
+		t.Errorf("expected main.go and penalized vendored file to be indexed, got %v", indexed)
+	}
+	if indexed["api.pb.go"] || indexed["gen/types.go"] {
+		t.Errorf("expected generated files to be skipped, got %v", indexed)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("expected 3 skipped files, got %v", skipped)
+	}
+
+	report := scanner.Report()
+	if report.Skipped["gen/types.go"] != ReasonGenerated || report.Skipped["app.min.js"] != ReasonMinified {
+		t.Errorf("unexpected skip reasons: %v", report.Skipped)
+	}
+	if report.Penalized["third_party/vendor.go"] != ReasonVendored {
+		t.Errorf("unexpected penalized files: %v", report.Penalized)
+	}
+
+	// ScanFile keeps the report in sync when a file stops being generated.
+	if err := os.WriteFile(filepath.Join(tmpDir, "gen", "types.go"), []byte("package gen\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := scanner.ScanFile("gen/types.go")
+	if err != nil || info == nil {
+		t.Fatalf("expected gen/types.go to be scanned, got info=%v err=%v", info, err)
+	}
+	if _, ok := scanner.Report().Skipped["gen/types.go"]; ok {
+		t.Error("expected stale skip reason to be cleared")
+	}
+}
+
+func TestScanReport_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".grepai", "scan_report.json")
+
+	missing, err := LoadScanReport(path)
+	if err != nil || len(missing.Skipped) != 0 {
+		t.Fatalf("expected empty report for missing file, got %v, %v", missing, err)
+	}
+
+	report := NewScanReport()
+	report.Skipped["a.pb.go"] = ReasonGenerated
+	report.Skipped["b.pb.go"] = ReasonGenerated
+	report.Penalized["big.json"] = ReasonTooLarge
+	if err := SaveScanReport(path, report); err != nil {
+		t.Fatalf("SaveScanReport failed: %v", err)
+	}
+
+	loaded, err := LoadScanReport(path)
+	if err != nil {
+		t.Fatalf("LoadScanReport failed: %v", err)
+	}
+	if got := FormatCounts(loaded.SkipCounts()); got != "generated: 2" {
+		t.Errorf("skip counts = %q", got)
+	}
+	if loaded.Penalized["big.json"] != ReasonTooLarge {
+		t.Errorf("penalized = %v", loaded.Penalized)
+	}
+}
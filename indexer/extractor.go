@@ -0,0 +1,123 @@
+package indexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExtractResult is the embeddable text produced by a content extractor.
+type ExtractResult struct {
+	Extractor             string
+	Text                  string
+	GeneratedToSourceLine []int // 1-indexed generated line -> source line. 0 means unmapped.
+}
+
+// ContentExtractor converts a structured file into text suited for embedding.
+// Extract returns an empty Text when the file is not in a shape the extractor
+// understands (e.g. a plain JSON file handed to the OpenAPI extractor); the
+// registry then falls back to the raw file content.
+type ContentExtractor interface {
+	Name() string
+	Supports(filePath string) bool
+	Extract(filePath, source string) (ExtractResult, error)
+}
+
+// ExtractorRegistry resolves and executes content extractors.
+type ExtractorRegistry struct {
+	extractors []ContentExtractor
+}
+
+func NewExtractorRegistry(extractors ...ContentExtractor) *ExtractorRegistry {
+	return &ExtractorRegistry{extractors: extractors}
+}
+
+// DefaultExtractors returns the built-in content extractors.
+func DefaultExtractors() []ContentExtractor {
+	return []ContentExtractor{
+		&NotebookExtractor{},
+		&OpenAPIExtractor{},
+		&MarkdownExtractor{},
+		&RSTExtractor{},
+	}
+}
+
+// Names returns the names of the registered extractors, in resolution order.
+func (r *ExtractorRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, len(r.extractors))
+	for i, e := range r.extractors {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+// Extract runs the first extractor that supports filePath and produces text.
+// The boolean result reports whether any extractor handled the file.
+func (r *ExtractorRegistry) Extract(filePath, source string) (ExtractResult, bool, error) {
+	if r == nil {
+		return ExtractResult{}, false, nil
+	}
+	for _, e := range r.extractors {
+		if !e.Supports(filePath) {
+			continue
+		}
+		res, err := e.Extract(filePath, source)
+		if err != nil {
+			return ExtractResult{}, false, fmt.Errorf("%s extractor failed for %s: %w", e.Name(), filePath, err)
+		}
+		if res.Text == "" {
+			continue
+		}
+		if res.Extractor == "" {
+			res.Extractor = e.Name()
+		}
+		return res, true, nil
+	}
+	return ExtractResult{}, false, nil
+}
+
+// extractBuilder accumulates extracted text together with its line map.
+type extractBuilder struct {
+	lines   []string
+	lineMap []int
+}
+
+// add appends text (which may span several lines) mapped to sourceLine.
+func (b *extractBuilder) add(text string, sourceLine int) {
+	for _, line := range strings.Split(text, "\n") {
+		b.lines = append(b.lines, line)
+		b.lineMap = append(b.lineMap, sourceLine)
+	}
+}
+
+// blank appends an unmapped separator line, collapsing consecutive blanks.
+func (b *extractBuilder) blank() {
+	if len(b.lines) == 0 || b.lines[len(b.lines)-1] == "" {
+		return
+	}
+	b.lines = append(b.lines, "")
+	b.lineMap = append(b.lineMap, 0)
+}
+
+func (b *extractBuilder) result(name string) ExtractResult {
+	if len(b.lines) == 0 {
+		return ExtractResult{}
+	}
+	return ExtractResult{
+		Extractor:             name,
+		Text:                  strings.Join(b.lines, "\n"),
+		GeneratedToSourceLine: b.lineMap,
+	}
+}
+
+func hasExtension(filePath string, exts ...string) bool {
+	lower := strings.ToLower(filePath)
+	for _, ext := range exts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
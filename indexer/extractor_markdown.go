@@ -0,0 +1,155 @@
+package indexer
+
+import (
+	"strings"
+)
+
+// MarkdownExtractor splits Markdown and AsciiDoc documents into heading-aware
+// sections. Each section is prefixed with its heading breadcrumb so chunks
+// taken from the middle of a long section keep their document context.
+type MarkdownExtractor struct{}
+
+func (e *MarkdownExtractor) Name() string { return "markdown" }
+
+func (e *MarkdownExtractor) Supports(filePath string) bool {
+	return hasExtension(filePath, ".md", ".markdown", ".mdx", ".adoc", ".asciidoc")
+}
+
+func (e *MarkdownExtractor) Extract(filePath, source string) (ExtractResult, error) {
+	asciidoc := hasExtension(filePath, ".adoc", ".asciidoc")
+	lines := strings.Split(source, "\n")
+
+	var (
+		b       extractBuilder
+		stack   []string // heading titles indexed by level-1
+		fence   string
+		matched bool
+	)
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			b.add(line, i+1)
+			continue
+		}
+		if i == 0 && trimmed == "---" && !asciidoc {
+			fence = "---" // YAML front matter.
+			b.add(line, i+1)
+			continue
+		}
+		if f := fenceMarker(trimmed, asciidoc); f != "" {
+			fence = f
+			b.add(line, i+1)
+			continue
+		}
+
+		level, title := headingOf(line, asciidoc)
+		consumed := 0
+		if level == 0 && !asciidoc && i+1 < len(lines) && trimmed != "" {
+			level, title = setextHeading(trimmed, strings.TrimSpace(lines[i+1]))
+			if level > 0 {
+				consumed = 1
+			}
+		}
+		if level == 0 {
+			b.add(line, i+1)
+			continue
+		}
+
+		matched = true
+		if level > len(stack) {
+			for len(stack) < level-1 {
+				stack = append(stack, "")
+			}
+			stack = append(stack, title)
+		} else {
+			stack = append(stack[:level-1], title)
+		}
+
+		b.blank()
+		b.add("Section: "+breadcrumb(stack), i+1)
+		b.add(line, i+1)
+		for j := 1; j <= consumed; j++ {
+			b.add(strings.TrimRight(lines[i+j], "\r"), i+j+1)
+		}
+		i += consumed
+	}
+
+	if !matched {
+		// No headings: the raw document already embeds well.
+		return ExtractResult{}, nil
+	}
+	return b.result(e.Name()), nil
+}
+
+// headingOf detects ATX (# Title) or AsciiDoc (= Title) headings.
+func headingOf(line string, asciidoc bool) (int, string) {
+	marker := byte('#')
+	if asciidoc {
+		marker = '='
+	}
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, "" // Indented code block.
+	}
+	level := 0
+	for level < len(trimmed) && trimmed[level] == marker {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), string(marker)))
+	if title == "" {
+		return 0, ""
+	}
+	return level, title
+}
+
+// setextHeading detects a "Title" line underlined with === or ---.
+func setextHeading(title, underline string) (int, string) {
+	if underline == "" || strings.HasPrefix(title, "-") || strings.HasPrefix(title, ">") {
+		return 0, ""
+	}
+	switch {
+	case strings.Trim(underline, "=") == "":
+		return 1, title
+	case strings.Trim(underline, "-") == "" && len(underline) >= 2:
+		return 2, title
+	}
+	return 0, ""
+}
+
+func fenceMarker(trimmed string, asciidoc bool) string {
+	if asciidoc {
+		if trimmed == "----" || trimmed == "...." {
+			return trimmed
+		}
+		return ""
+	}
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+func breadcrumb(stack []string) string {
+	parts := make([]string, 0, len(stack))
+	for _, s := range stack {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
@@ -0,0 +1,131 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NotebookExtractor turns Jupyter notebooks into their cell sources, dropping
+// outputs and metadata that would otherwise dominate the embedding.
+type NotebookExtractor struct{}
+
+type notebookFile struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+}
+
+func (e *NotebookExtractor) Name() string { return "notebook" }
+
+func (e *NotebookExtractor) Supports(filePath string) bool {
+	return hasExtension(filePath, ".ipynb")
+}
+
+func (e *NotebookExtractor) Extract(filePath, source string) (ExtractResult, error) {
+	var nb notebookFile
+	if err := json.Unmarshal([]byte(source), &nb); err != nil {
+		return ExtractResult{}, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	language := nb.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = nb.Metadata.KernelSpec.Language
+	}
+
+	locator := newJSONLineLocator(source)
+	var b extractBuilder
+	for i, cell := range nb.Cells {
+		if cell.CellType != "code" && cell.CellType != "markdown" {
+			continue
+		}
+		lines, err := notebookSourceLines(cell.Source)
+		if err != nil {
+			return ExtractResult{}, fmt.Errorf("invalid source in cell %d: %w", i+1, err)
+		}
+		if strings.TrimSpace(strings.Join(lines, "")) == "" {
+			continue
+		}
+
+		header := fmt.Sprintf("# Cell %d [%s]", i+1, cell.CellType)
+		if cell.CellType == "code" && language != "" {
+			header = fmt.Sprintf("# Cell %d [code: %s]", i+1, language)
+		}
+
+		b.blank()
+		first := locator.locate(lines[0])
+		b.add(header, first)
+		for j, line := range lines {
+			sourceLine := first
+			if j > 0 {
+				sourceLine = locator.locate(line)
+			}
+			b.add(strings.TrimRight(line, "\r\n"), sourceLine)
+		}
+	}
+
+	return b.result(e.Name()), nil
+}
+
+// notebookSourceLines normalizes cell sources, which nbformat allows to be
+// either a single string or a list of lines.
+func notebookSourceLines(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(single, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// jsonLineLocator maps decoded JSON strings back to the raw file line holding
+// them. Lookups only move forward, mirroring the order cells appear in.
+type jsonLineLocator struct {
+	lines  []string
+	cursor int
+}
+
+func newJSONLineLocator(source string) *jsonLineLocator {
+	return &jsonLineLocator{lines: strings.Split(source, "\n")}
+}
+
+func (l *jsonLineLocator) locate(value string) int {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return l.cursor + 1
+	}
+	// Drop the surrounding quotes so single-string sources, where every line
+	// lives inside one JSON string, still match.
+	needle := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(buf.String()), `"`), `"`)
+	for i := l.cursor; i < len(l.lines); i++ {
+		if strings.Contains(l.lines[i], needle) {
+			l.cursor = i
+			return i + 1
+		}
+	}
+	return l.cursor + 1
+}
@@ -0,0 +1,188 @@
+package indexer
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIExtractor summarizes OpenAPI/Swagger specs and JSON Schema documents
+// into operation and schema descriptions. Other JSON/YAML files are left to
+// the default raw-text path.
+type OpenAPIExtractor struct{}
+
+const maxSchemaDepth = 4
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head", "options", "trace"}
+
+func (e *OpenAPIExtractor) Name() string { return "openapi" }
+
+func (e *OpenAPIExtractor) Supports(filePath string) bool {
+	return hasExtension(filePath, ".json", ".yaml", ".yml")
+}
+
+func (e *OpenAPIExtractor) Extract(filePath, source string) (ExtractResult, error) {
+	// Cheap pre-check so ordinary config files skip the full YAML parse.
+	if !strings.Contains(source, "openapi") && !strings.Contains(source, "swagger") && !strings.Contains(source, "$schema") {
+		return ExtractResult{}, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		// Not our format; let the raw content be indexed as-is.
+		return ExtractResult{}, nil
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return ExtractResult{}, nil
+	}
+	root := doc.Content[0]
+
+	var b extractBuilder
+	switch {
+	case mappingValue(root, "openapi") != nil || mappingValue(root, "swagger") != nil:
+		extractOpenAPI(&b, root)
+	case mappingValue(root, "$schema") != nil:
+		extractSchema(&b, "Schema", root, root.Line, 0)
+	default:
+		return ExtractResult{}, nil
+	}
+	return b.result(e.Name()), nil
+}
+
+func extractOpenAPI(b *extractBuilder, root *yaml.Node) {
+	if info := mappingValue(root, "info"); info != nil {
+		title := scalarValue(info, "title")
+		if version := scalarValue(info, "version"); version != "" {
+			title = strings.TrimSpace(title + " " + version)
+		}
+		b.add("API: "+title, info.Line)
+		addDescription(b, info)
+	}
+
+	if paths := mappingValue(root, "paths"); paths != nil && paths.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(paths.Content); i += 2 {
+			path := paths.Content[i].Value
+			item := paths.Content[i+1]
+			for _, method := range openAPIMethods {
+				key, op := mappingEntry(item, method)
+				if op == nil {
+					continue
+				}
+				b.blank()
+				b.add(fmt.Sprintf("%s %s", strings.ToUpper(method), path), key.Line)
+				if id := scalarValue(op, "operationId"); id != "" {
+					b.add("operationId: "+id, mappingValue(op, "operationId").Line)
+				}
+				if summary := scalarValue(op, "summary"); summary != "" {
+					b.add("summary: "+summary, mappingValue(op, "summary").Line)
+				}
+				addDescription(b, op)
+				if tags := mappingValue(op, "tags"); tags != nil && tags.Kind == yaml.SequenceNode {
+					b.add("tags: "+strings.Join(sequenceValues(tags), ", "), tags.Line)
+				}
+				if params := mappingValue(op, "parameters"); params != nil && params.Kind == yaml.SequenceNode {
+					for _, p := range params.Content {
+						name := scalarValue(p, "name")
+						if name == "" {
+							continue
+						}
+						line := fmt.Sprintf("parameter %s (in %s)", name, scalarValue(p, "in"))
+						if desc := scalarValue(p, "description"); desc != "" {
+							line += ": " + desc
+						}
+						b.add(line, p.Line)
+					}
+				}
+			}
+		}
+	}
+
+	schemas := mappingValue(root, "definitions") // Swagger 2.0
+	if components := mappingValue(root, "components"); components != nil {
+		schemas = mappingValue(components, "schemas")
+	}
+	if schemas != nil && schemas.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			b.blank()
+			extractSchema(b, "Schema "+schemas.Content[i].Value, schemas.Content[i+1], schemas.Content[i].Line, 0)
+		}
+	}
+}
+
+func extractSchema(b *extractBuilder, name string, schema *yaml.Node, line, depth int) {
+	header := name
+	if title := scalarValue(schema, "title"); title != "" && depth == 0 {
+		header += " (" + title + ")"
+	}
+	if typ := scalarValue(schema, "type"); typ != "" {
+		header += ": " + typ
+	}
+	b.add(header, line)
+	addDescription(b, schema)
+
+	if depth >= maxSchemaDepth {
+		return
+	}
+	props := mappingValue(schema, "properties")
+	if props == nil || props.Kind != yaml.MappingNode {
+		return
+	}
+	required := map[string]bool{}
+	if req := mappingValue(schema, "required"); req != nil && req.Kind == yaml.SequenceNode {
+		for _, v := range sequenceValues(req) {
+			required[v] = true
+		}
+	}
+	for i := 0; i+1 < len(props.Content); i += 2 {
+		propName := props.Content[i].Value
+		prefix := strings.Repeat("  ", depth+1) + "property " + propName
+		if required[propName] {
+			prefix += " (required)"
+		}
+		extractSchema(b, prefix, props.Content[i+1], props.Content[i].Line, depth+1)
+	}
+}
+
+func addDescription(b *extractBuilder, node *yaml.Node) {
+	desc := mappingValue(node, "description")
+	if desc == nil || desc.Kind != yaml.ScalarNode || strings.TrimSpace(desc.Value) == "" {
+		return
+	}
+	b.add(strings.TrimSpace(desc.Value), desc.Line)
+}
+
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	_, v := mappingEntry(node, key)
+	return v
+}
+
+func scalarValue(node *yaml.Node, key string) string {
+	v := mappingValue(node, key)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+func sequenceValues(node *yaml.Node) []string {
+	var out []string
+	for _, v := range node.Content {
+		if v.Kind == yaml.ScalarNode {
+			out = append(out, v.Value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"strings"
+)
+
+// RSTExtractor splits reStructuredText documents into heading-aware sections,
+// like MarkdownExtractor. reStructuredText has no fixed heading levels: a
+// level is assigned to each adornment style (underline character, with or
+// without overline) in the order the styles first appear.
+type RSTExtractor struct{}
+
+func (e *RSTExtractor) Name() string { return "rst" }
+
+func (e *RSTExtractor) Supports(filePath string) bool {
+	return hasExtension(filePath, ".rst")
+}
+
+func (e *RSTExtractor) Extract(filePath, source string) (ExtractResult, error) {
+	lines := strings.Split(source, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	var (
+		b       extractBuilder
+		stack   []string
+		styles  []string // adornment styles in order of first appearance
+		matched bool
+	)
+
+	for i := 0; i < len(lines); i++ {
+		title, style, consumed := rstHeading(lines, i)
+		if consumed == 0 {
+			b.add(lines[i], i+1)
+			continue
+		}
+
+		level := 0
+		for j, s := range styles {
+			if s == style {
+				level = j + 1
+				break
+			}
+		}
+		if level == 0 {
+			styles = append(styles, style)
+			level = len(styles)
+		}
+
+		matched = true
+		if level > len(stack) {
+			for len(stack) < level-1 {
+				stack = append(stack, "")
+			}
+			stack = append(stack, title)
+		} else {
+			stack = append(stack[:level-1], title)
+		}
+
+		b.blank()
+		b.add("Section: "+breadcrumb(stack), i+1)
+		for j := 0; j < consumed; j++ {
+			b.add(lines[i+j], i+j+1)
+		}
+		i += consumed - 1
+	}
+
+	if !matched {
+		return ExtractResult{}, nil
+	}
+	return b.result(e.Name()), nil
+}
+
+// rstHeading detects a section title starting at line i, either underlined
+// ("Title\n=====") or over- and underlined ("=====\nTitle\n====="). It returns
+// the title, its adornment style and the number of lines the heading spans.
+func rstHeading(lines []string, i int) (string, string, int) {
+	if over := rstAdornment(lines[i]); over != 0 && i+2 < len(lines) {
+		title := strings.TrimSpace(lines[i+1])
+		if title != "" && rstAdornment(lines[i+2]) == over &&
+			len(strings.TrimSpace(lines[i+2])) >= len(title) {
+			return title, "over" + string(over), 3
+		}
+	}
+
+	line := lines[i]
+	if i+1 >= len(lines) || strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+		return "", "", 0
+	}
+	if rstAdornment(line) != 0 {
+		return "", "", 0
+	}
+	under := rstAdornment(lines[i+1])
+	title := strings.TrimSpace(line)
+	if under == 0 || len(strings.TrimSpace(lines[i+1])) < len(title) {
+		return "", "", 0
+	}
+	return title, string(under), 2
+}
+
+// rstAdornment returns the punctuation character a line consists of, or 0 if
+// the line is not a section adornment.
+func rstAdornment(line string) byte {
+	if len(line) < 2 || line[0] == ' ' || line[0] == '\t' {
+		return 0
+	}
+	trimmed := strings.TrimRight(line, " \t")
+	c := trimmed[0]
+	if !strings.ContainsRune("=-`:'\"~^_*+#<>.", rune(c)) {
+		return 0
+	}
+	if strings.Trim(trimmed, string(c)) != "" || len(trimmed) < 2 {
+		return 0
+	}
+	return c
+}
@@ -0,0 +1,229 @@
+package indexer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/framework"
+)
+
+func TestNotebookExtractor_CodeAndMarkdownCells(t *testing.T) {
+	source := `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": [
+    "# Load data\n",
+    "Reads the CSV."
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [{"output_type": "stream", "text": ["noise noise noise\n"]}],
+   "source": [
+    "import pandas as pd\n",
+    "df = pd.read_csv(\"data.csv\")"
+   ]
+  }
+ ],
+ "metadata": {"language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+	res, err := (&NotebookExtractor{}).Extract("analysis.ipynb", source)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if strings.Contains(res.Text, "noise") {
+		t.Errorf("expected outputs to be dropped, got:\n%s", res.Text)
+	}
+	for _, want := range []string{"# Cell 1 [markdown]", "# Load data", "# Cell 2 [code: python]", `df = pd.read_csv("data.csv")`} {
+		if !strings.Contains(res.Text, want) {
+			t.Errorf("expected extracted text to contain %q, got:\n%s", want, res.Text)
+		}
+	}
+
+	lines := strings.Split(res.Text, "\n")
+	if len(res.GeneratedToSourceLine) != len(lines) {
+		t.Fatalf("line map length = %d, want %d", len(res.GeneratedToSourceLine), len(lines))
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "df = ") && res.GeneratedToSourceLine[i] != 18 {
+			t.Errorf("code line mapped to source line %d, want 18", res.GeneratedToSourceLine[i])
+		}
+	}
+}
+
+func TestNotebookExtractor_InvalidJSON(t *testing.T) {
+	if _, err := (&NotebookExtractor{}).Extract("broken.ipynb", "{not json"); err == nil {
+		t.Fatal("expected error for invalid notebook")
+	}
+}
+
+func TestMarkdownExtractor_HeadingBreadcrumbs(t *testing.T) {
+	source := "# Guide\n\nIntro.\n\n## Install\n\n```sh\n# not a heading\n```\n\nSetup\n-----\n\nSteps."
+
+	res, err := (&MarkdownExtractor{}).Extract("README.md", source)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	for _, want := range []string{"Section: Guide\n# Guide", "Section: Guide > Install\n## Install", "Section: Guide > Setup\nSetup\n-----"} {
+		if !strings.Contains(res.Text, want) {
+			t.Errorf("expected extracted text to contain %q, got:\n%s", want, res.Text)
+		}
+	}
+	if strings.Contains(res.Text, "Section: Guide > Install > not a heading") {
+		t.Error("fenced code comment must not be treated as a heading")
+	}
+
+	start, end := framework.RemapLineRange(res.GeneratedToSourceLine, 1, len(res.GeneratedToSourceLine))
+	if start != 1 || end != 14 {
+		t.Errorf("remapped range = %d-%d, want 1-14", start, end)
+	}
+}
+
+func TestMarkdownExtractor_NoHeadingsFallsBack(t *testing.T) {
+	res, err := (&MarkdownExtractor{}).Extract("notes.md", "just some text\nmore text")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if res.Text != "" {
+		t.Errorf("expected empty text for heading-less document, got %q", res.Text)
+	}
+}
+
+func TestOpenAPIExtractor_Operations(t *testing.T) {
+	source := `openapi: 3.0.0
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      summary: Fetch a pet by id
+      parameters:
+        - name: id
+          in: path
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          description: Display name
+`
+	res, err := (&OpenAPIExtractor{}).Extract("api/openapi.yaml", source)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	for _, want := range []string{"API: Pets 1.0", "GET /pets/{id}", "operationId: getPet", "parameter id (in path)", "Schema Pet: object", "property name (required): string", "Display name"} {
+		if !strings.Contains(res.Text, want) {
+			t.Errorf("expected extracted text to contain %q, got:\n%s", want, res.Text)
+		}
+	}
+	for i, line := range strings.Split(res.Text, "\n") {
+		if line == "GET /pets/{id}" && res.GeneratedToSourceLine[i] != 7 {
+			t.Errorf("operation mapped to line %d, want 7", res.GeneratedToSourceLine[i])
+		}
+	}
+}
+
+func TestOpenAPIExtractor_IgnoresPlainJSON(t *testing.T) {
+	res, err := (&OpenAPIExtractor{}).Extract("package.json", `{"name": "app", "version": "1.0.0"}`)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if res.Text != "" {
+		t.Errorf("expected plain JSON to be left untouched, got %q", res.Text)
+	}
+}
+
+type failingExtractor struct{}
+
+func (failingExtractor) Name() string                  { return "failing" }
+func (failingExtractor) Supports(filePath string) bool { return true }
+func (failingExtractor) Extract(filePath, source string) (ExtractResult, error) {
+	return ExtractResult{}, errors.New("boom")
+}
+
+func TestExtractorRegistry(t *testing.T) {
+	reg := NewExtractorRegistry(DefaultExtractors()...)
+
+	if _, ok, err := reg.Extract("main.go", "package main"); ok || err != nil {
+		t.Errorf("expected unsupported file to pass through, got ok=%v err=%v", ok, err)
+	}
+	res, ok, err := reg.Extract("docs/guide.md", "# Title\nbody")
+	if err != nil || !ok {
+		t.Fatalf("expected markdown to be extracted, got ok=%v err=%v", ok, err)
+	}
+	if res.Extractor != "markdown" {
+		t.Errorf("Extractor = %q, want markdown", res.Extractor)
+	}
+
+	if _, _, err := NewExtractorRegistry(failingExtractor{}).Extract("x.txt", "x"); err == nil {
+		t.Error("expected extractor error to be returned")
+	}
+
+	var nilReg *ExtractorRegistry
+	if _, ok, err := nilReg.Extract("a.md", "# A"); ok || err != nil {
+		t.Errorf("nil registry should pass through, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestExtensionSet(t *testing.T) {
+	set := ExtensionSet([]string{"tpl", ".J2"}, []string{".md"})
+	if !set[".tpl"] || !set[".j2"] {
+		t.Error("expected extra extensions to be added and normalized")
+	}
+	if set[".md"] {
+		t.Error("expected excluded extension to be removed")
+	}
+	if !set[".go"] || !set[".ipynb"] {
+		t.Error("expected built-in extensions to be kept")
+	}
+	if !SupportedExtensions[".md"] {
+		t.Error("ExtensionSet must not mutate SupportedExtensions")
+	}
+}
+
+func TestRSTExtractor_SectionAdornments(t *testing.T) {
+	source := "=====\nGuide\n=====\n\nIntro.\n\nInstall\n-------\n\nSteps::\n\n    pip install grepai\n\nUsage\n-----\n\nRun it.\n\nFlags\n~~~~~\n\nSee --help."
+
+	res, err := (&RSTExtractor{}).Extract("index.rst", source)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	for _, want := range []string{
+		"Section: Guide\n=====\nGuide\n=====",
+		"Section: Guide > Install\nInstall\n-------",
+		"Section: Guide > Usage\nUsage\n-----",
+		"Section: Guide > Usage > Flags\nFlags\n~~~~~",
+	} {
+		if !strings.Contains(res.Text, want) {
+			t.Errorf("expected extracted text to contain %q, got:\n%s", want, res.Text)
+		}
+	}
+
+	start, end := framework.RemapLineRange(res.GeneratedToSourceLine, 1, len(res.GeneratedToSourceLine))
+	if start != 1 || end != 22 {
+		t.Errorf("remapped range = %d-%d, want 1-22", start, end)
+	}
+}
+
+func TestRSTExtractor_NoSectionsFallsBack(t *testing.T) {
+	res, err := (&RSTExtractor{}).Extract("notes.rst", "just some text\n\n----\n\nmore text")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if res.Text != "" {
+		t.Errorf("expected empty text for section-less document, got %q", res.Text)
+	}
+}
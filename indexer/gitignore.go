@@ -2,7 +2,7 @@ package indexer
 
 import (
 	"bufio"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -63,9 +63,9 @@ func NewIgnoreMatcher(projectRoot string, extraIgnore []string, externalGitignor
 		gi, err := ignore.CompileIgnoreFile(expandedPath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				log.Printf("Warning: external gitignore file not found: %s", expandedPath)
+				slog.Warn("External gitignore file not found", "path", expandedPath)
 			} else {
-				log.Printf("Warning: failed to load external gitignore: %v", err)
+				slog.Warn("Failed to load external gitignore", "path", expandedPath, "err", err)
 			}
 		} else {
 			m.nestedMatchers = append(m.nestedMatchers, nestedMatcher{
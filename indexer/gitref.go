@@ -0,0 +1,262 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yoanbernabeu/grepai/git"
+)
+
+// RefScanner lists and reads indexable files of a git commit without checking
+// it out. It applies the same ignore, extension and file policy rules as
+// Scanner, with linguist attributes taken from the commit's .gitattributes.
+type RefScanner struct {
+	root       string
+	commit     *git.Commit
+	ignore     *IgnoreMatcher
+	extensions map[string]bool
+	classifier *FileClassifier
+	report     scanReportRecorder
+	entries    map[string]git.TreeEntry
+	blobs      *git.BlobReader
+}
+
+// NewRefScanner creates a scanner for commit. root is the project root inside
+// the repository; only files below it are returned.
+func NewRefScanner(root string, commit *git.Commit, ignore *IgnoreMatcher) *RefScanner {
+	return &RefScanner{
+		root:       root,
+		commit:     commit,
+		ignore:     ignore,
+		extensions: SupportedExtensions,
+		classifier: NewFileClassifier("", DefaultFilePolicy()),
+	}
+}
+
+// SetExtensions overrides the set of file extensions the scanner indexes.
+func (s *RefScanner) SetExtensions(extensions map[string]bool) {
+	s.extensions = extensions
+}
+
+// SetFilePolicy configures how large, generated and vendored files, and
+// files containing credentials, are handled.
+func (s *RefScanner) SetFilePolicy(cfg FilePolicyConfig) {
+	s.classifier = NewFileClassifier("", cfg)
+}
+
+// Report returns a snapshot of the files skipped or penalized so far.
+func (s *RefScanner) Report() *ScanReport {
+	return s.report.snapshot()
+}
+
+// ScanMetadata lists indexable files of the commit. Generated-code headers
+// are checked later by ScanFile, once the blob is read.
+func (s *RefScanner) ScanMetadata(ctx context.Context) ([]FileMeta, []string, error) {
+	entries, err := git.ListTree(s.root, s.commit.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.blobs == nil {
+		if s.blobs, err = git.NewBlobReader(ctx, s.root); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s.report.reset()
+	s.entries = make(map[string]git.TreeEntry, len(entries))
+	for _, entry := range entries {
+		if entry.Path == ".gitattributes" {
+			if attributes, err := s.blobs.Read(entry.Object); err == nil {
+				s.classifier = s.classifier.withGitAttributes(attributes)
+			} else {
+				slog.Warn("Failed to read .gitattributes", "commit", s.commit.Hash, "err", err)
+			}
+		}
+	}
+
+	var files []FileMeta
+	var skipped []string
+	skipDirs := make(map[string]bool)
+	for _, entry := range entries {
+		relPath := filepath.FromSlash(entry.Path)
+		if s.ignored(relPath, skipDirs) {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(relPath))
+		if !s.extensions[ext] {
+			continue
+		}
+
+		if isMinifiedFile(relPath) {
+			s.report.record(relPath, ReasonMinified, PolicySkip)
+			skipped = append(skipped, relPath+" ("+ReasonMinified+")")
+			continue
+		}
+		reason, policy := s.classifier.ClassifyPath(relPath, entry.Size)
+		s.report.record(relPath, reason, policy)
+		if policy == PolicySkip {
+			skipped = append(skipped, relPath+" ("+reason+")")
+			continue
+		}
+
+		s.entries[relPath] = entry
+		files = append(files, FileMeta{
+			Path:    relPath,
+			Size:    entry.Size,
+			ModTime: s.commit.Time.Unix(),
+		})
+	}
+
+	return files, skipped, nil
+}
+
+// ignored mirrors the directory pruning of Scanner's walk: a file is ignored
+// when any of its parent directories would be skipped.
+func (s *RefScanner) ignored(relPath string, skipDirs map[string]bool) bool {
+	dir := filepath.Dir(relPath)
+	if dir != "." {
+		parts := strings.Split(filepath.ToSlash(dir), "/")
+		for i := range parts {
+			parent := filepath.FromSlash(strings.Join(parts[:i+1], "/"))
+			skip, ok := skipDirs[parent]
+			if !ok {
+				skip = s.ignore.ShouldSkipDir(parent)
+				skipDirs[parent] = skip
+			}
+			if skip {
+				return true
+			}
+		}
+	}
+	return s.ignore.ShouldIgnore(relPath)
+}
+
+// ScanFile reads a file returned by ScanMetadata from the commit. It returns
+// nil for binary files and files skipped by the generated-code policy.
+func (s *RefScanner) ScanFile(relPath string) (*FileInfo, error) {
+	entry, ok := s.entries[relPath]
+	if !ok {
+		return nil, fmt.Errorf("%s is not part of the scanned tree", relPath)
+	}
+
+	content, err := s.blobs.Read(entry.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, policy := s.classifier.ClassifyContent(relPath, content)
+	s.report.record(relPath, reason, policy)
+	if policy == PolicySkip {
+		return nil, nil
+	}
+
+	if !utf8.Valid(content) || containsNull(content) {
+		return nil, nil // Skip binary files
+	}
+
+	text, ok := applySecretsPolicy(&s.report, relPath, string(content), s.classifier.cfg.Secrets)
+	if !ok {
+		return nil, nil // Skip files containing credentials per policy
+	}
+
+	hash := sha256.Sum256(content)
+
+	return &FileInfo{
+		Path:    relPath,
+		Size:    entry.Size,
+		ModTime: s.commit.Time.Unix(),
+		Hash:    hex.EncodeToString(hash[:]),
+		Content: text,
+	}, nil
+}
+
+// Close stops the git process used to read blobs.
+func (s *RefScanner) Close() error {
+	if s.blobs == nil {
+		return nil
+	}
+	err := s.blobs.Close()
+	s.blobs = nil
+	return err
+}
+
+// IndexRef indexes the files of a git commit into the indexer's store.
+// Files whose content hash matches the stored document are left untouched and
+// files no longer present at the commit are removed, so re-indexing a moved
+// branch only embeds what changed.
+func (idx *Indexer) IndexRef(ctx context.Context, rs *RefScanner, onProgress ProgressCallback, onBatchProgress BatchProgressCallback) (*IndexStats, error) {
+	start := time.Now()
+	stats := &IndexStats{}
+
+	fileMetas, skipped, err := rs.ScanMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", rs.commit.Hash, err)
+	}
+	stats.FilesSkipped = len(skipped)
+	stats.ScannedFiles = fileMetas
+
+	existingDocs, err := idx.store.ListDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	existingMap := make(map[string]bool)
+	for _, doc := range existingDocs {
+		existingMap[doc] = true
+	}
+
+	filesToIndex := make([]FileInfo, 0, len(fileMetas))
+	for i, fileMeta := range fileMetas {
+		if onProgress != nil {
+			onProgress(ProgressInfo{
+				Current:     i + 1,
+				Total:       len(fileMetas),
+				CurrentFile: fileMeta.Path,
+			})
+		}
+		delete(existingMap, fileMeta.Path)
+
+		file, err := rs.ScanFile(fileMeta.Path)
+		if err != nil {
+			slog.Error("Failed to read file", "path", fileMeta.Path, "commit", rs.commit.Hash, "err", err)
+			stats.FilesSkipped++
+			continue
+		}
+		if file == nil {
+			stats.FilesSkipped++
+			continue
+		}
+
+		doc, err := idx.store.GetDocument(ctx, file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get document %s: %w", file.Path, err)
+		}
+		if doc != nil && doc.Hash == file.Hash && len(doc.ChunkIDs) > 0 {
+			continue // Blob unchanged since the last snapshot of this ref
+		}
+		filesToIndex = append(filesToIndex, *file)
+	}
+
+	stats.FilesIndexed, stats.ChunksCreated, err = idx.indexFiles(ctx, filesToIndex, onBatchProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range existingMap {
+		if err := idx.RemoveFile(ctx, path); err != nil {
+			slog.Error("Failed to remove file from index", "path", path, "err", err)
+			continue
+		}
+		stats.FilesRemoved++
+	}
+
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
@@ -0,0 +1,191 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/git"
+)
+
+// setupRefRepo commits files to a new repository, tags the commit and returns
+// the repository path.
+func setupRefRepo(t *testing.T, files map[string]string, tag string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	run("init")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("add", ".")
+	run("commit", "-m", "snapshot")
+	run("tag", tag)
+	return dir
+}
+
+type stubEmbeddingCache map[string][]float32
+
+func (c stubEmbeddingCache) LookupByContentHash(ctx context.Context, contentHash string) ([]float32, bool, error) {
+	vec, ok := c[contentHash]
+	return vec, ok, nil
+}
+
+func newTestRefScanner(t *testing.T, root, ref string) *RefScanner {
+	t.Helper()
+	commit, err := git.ResolveRef(root, ref)
+	if err != nil {
+		t.Fatalf("ResolveRef failed: %v", err)
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(root, []string{"third_party"}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	rs := NewRefScanner(root, commit, ignoreMatcher)
+	rs.SetFilePolicy(FilePolicyConfig{Generated: PolicySkip})
+	t.Cleanup(func() { _ = rs.Close() })
+	return rs
+}
+
+func TestIndexRef_ReadsCommittedContent(t *testing.T) {
+	root := setupRefRepo(t, map[string]string{
+		"main.go":             "package main\n\nfunc OldName() {}\n",
+		"api/api.pb.go":       "package api\n",
+		"gen/types.go":        "// Code generated by tool. DO NOT EDIT.\npackage gen\n",
+		"third_party/x.go":    "package x\n",
+		"docs/logo.png":       "\x89PNG",
+		".gitattributes":      "legacy/** linguist-generated\n",
+		"legacy/old_api.go":   "package legacy\n",
+		"internal/helper.txt": "helper notes\n",
+	}, "v1.0.0")
+
+	// Working tree changes after the tag must not leak into the snapshot.
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc NewName() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "untracked.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	st := newMockStore()
+	emb := newMockEmbedder()
+	idx := NewIndexer(root, st, emb, NewChunker(512, 50), nil, time.Time{})
+
+	rs := newTestRefScanner(t, root, "v1.0.0")
+	stats, err := idx.IndexRef(ctx, rs, nil, nil)
+	if err != nil {
+		t.Fatalf("IndexRef failed: %v", err)
+	}
+
+	docs, _ := st.ListDocuments(ctx)
+	indexed := map[string]bool{}
+	for _, d := range docs {
+		indexed[filepath.ToSlash(d)] = true
+	}
+	if !indexed["main.go"] || !indexed["internal/helper.txt"] {
+		t.Errorf("expected committed files to be indexed, got %v", indexed)
+	}
+	for _, path := range []string{"untracked.go", "api/api.pb.go", "gen/types.go", "third_party/x.go", "legacy/old_api.go", "docs/logo.png"} {
+		if indexed[path] {
+			t.Errorf("expected %s to be excluded, got %v", path, indexed)
+		}
+	}
+
+	chunks, _ := st.GetChunksForFile(ctx, "main.go")
+	if len(chunks) == 0 || !strings.Contains(chunks[0].Content, "OldName") {
+		t.Errorf("expected snapshot to contain committed content, got %+v", chunks)
+	}
+	if rs.Report().Skipped[filepath.FromSlash("legacy/old_api.go")] != ReasonGenerated {
+		t.Errorf("expected .gitattributes of the ref to apply, got %v", rs.Report().Skipped)
+	}
+
+	// Re-indexing the same ref embeds nothing.
+	emb.embedCalled = false
+	stats, err = idx.IndexRef(ctx, newTestRefScanner(t, root, "v1.0.0"), nil, nil)
+	if err != nil {
+		t.Fatalf("second IndexRef failed: %v", err)
+	}
+	if emb.embedCalled || stats.FilesIndexed != 0 {
+		t.Errorf("expected unchanged blobs to be skipped, indexed %d files", stats.FilesIndexed)
+	}
+}
+
+func TestIndexRef_ReusesEmbeddingCache(t *testing.T) {
+	root := setupRefRepo(t, map[string]string{"main.go": "package main\n"}, "v1")
+
+	ctx := context.Background()
+	emb := newMockEmbedder()
+
+	// Index once to learn the content hashes, then serve them from a cache.
+	first := newMockStore()
+	if _, err := NewIndexer(root, first, emb, NewChunker(512, 50), nil, time.Time{}).IndexRef(ctx, newTestRefScanner(t, root, "v1"), nil, nil); err != nil {
+		t.Fatalf("IndexRef failed: %v", err)
+	}
+	cache := stubEmbeddingCache{}
+	chunks, _ := first.GetAllChunks(ctx)
+	for _, c := range chunks {
+		cache[c.ContentHash] = c.Vector
+	}
+
+	emb.embedCalled = false
+	idx := NewIndexer(root, newMockStore(), emb, NewChunker(512, 50), nil, time.Time{})
+	idx.SetEmbeddingCache(cache)
+	stats, err := idx.IndexRef(ctx, newTestRefScanner(t, root, "v1"), nil, nil)
+	if err != nil {
+		t.Fatalf("IndexRef failed: %v", err)
+	}
+	if emb.embedCalled {
+		t.Error("expected cached embeddings to be reused")
+	}
+	if stats.FilesIndexed != 1 {
+		t.Errorf("FilesIndexed = %d, want 1", stats.FilesIndexed)
+	}
+}
+
+func TestSnapshotManifest_SaveListLoad(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"v2", "v1"} {
+		m := &SnapshotManifest{Name: name, Ref: name, Commit: "abc"}
+		if err := SaveSnapshotManifest(filepath.Join(dir, name, "snapshot.json"), m); err != nil {
+			t.Fatalf("SaveSnapshotManifest failed: %v", err)
+		}
+	}
+
+	manifests, err := ListSnapshots(dir, "snapshot.json")
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if len(manifests) != 2 || manifests[0].Name != "v1" || manifests[1].Name != "v2" {
+		t.Errorf("unexpected manifests %+v", manifests)
+	}
+
+	if _, err := LoadSnapshotManifest(filepath.Join(dir, "missing", "snapshot.json")); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+	if none, err := ListSnapshots(filepath.Join(dir, "absent"), "snapshot.json"); err != nil || len(none) != 0 {
+		t.Errorf("expected no snapshots for missing dir, got %v, %v", none, err)
+	}
+}
@@ -3,7 +3,9 @@ package indexer
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/yoanbernabeu/grepai/embedder"
@@ -18,7 +20,14 @@ type Indexer struct {
 	chunker       *Chunker
 	scanner       *Scanner
 	processor     *framework.ProcessorRegistry
+	extractors    *ExtractorRegistry
+	cache         store.EmbeddingCache
 	lastIndexTime time.Time
+
+	checkpoint      CheckpointConfig
+	sinceCheckpoint int
+	lastCheckpoint  time.Time
+	resumed         *IndexJournal // journal of the interrupted run being resumed
 }
 
 type IndexStats struct {
@@ -26,8 +35,16 @@ type IndexStats struct {
 	FilesSkipped  int
 	ChunksCreated int
 	FilesRemoved  int
+	FilesMoved    int
 	Duration      time.Duration
 	ScannedFiles  []FileMeta // All files found during scan (for reuse by callers)
+	MovedFiles    []FileMove // Files moved without re-embedding (for reuse by callers)
+}
+
+// FileMove is a file whose index entries were moved to a new path.
+type FileMove struct {
+	From string
+	To   string
 }
 
 // ProgressInfo contains progress information for indexing
@@ -75,10 +92,36 @@ func NewIndexer(
 		chunker:       chunker,
 		scanner:       scanner,
 		processor:     processor,
+		extractors:    NewExtractorRegistry(DefaultExtractors()...),
 		lastIndexTime: lastIndexTime,
 	}
 }
 
+// SetExtractors replaces the content extractors used before chunking.
+// A nil registry disables extraction and files are embedded as raw text.
+func (idx *Indexer) SetExtractors(extractors *ExtractorRegistry) {
+	idx.extractors = extractors
+}
+
+// SetEmbeddingCache adds a cache consulted after the store's own embeddings,
+// e.g. the main index when building a snapshot of a git ref.
+func (idx *Indexer) SetEmbeddingCache(cache store.EmbeddingCache) {
+	idx.cache = cache
+}
+
+// embeddingCache returns the cache used for content-addressed deduplication.
+func (idx *Indexer) embeddingCache() (store.EmbeddingCache, bool) {
+	own, ok := idx.store.(store.EmbeddingCache)
+	switch {
+	case idx.cache == nil:
+		return own, ok
+	case !ok:
+		return idx.cache, true
+	default:
+		return store.ChainEmbeddingCache{own, idx.cache}, true
+	}
+}
+
 // IndexAll performs a full index of the project (no progress reporting)
 func (idx *Indexer) IndexAll(ctx context.Context) (*IndexStats, error) {
 	return idx.IndexAllWithProgress(ctx, nil)
@@ -114,8 +157,14 @@ func (idx *Indexer) IndexAllWithBatchProgress(ctx context.Context, onProgress Pr
 		existingMap[doc] = true
 	}
 
+	// Files in flight when a previous run was interrupted may have been
+	// persisted half-updated, so they bypass the mod-time gate below unless
+	// their document was saved with the hash they were being indexed at.
+	interrupted := idx.interruptedFiles()
+
 	// Filter files that need indexing
 	filesToIndex := make([]FileInfo, 0, len(fileMetas))
+	newFiles := make(map[string]bool)
 	for i, fileMeta := range fileMetas {
 		// Report progress for scanning phase
 		if onProgress != nil {
@@ -135,7 +184,14 @@ func (idx *Indexer) IndexAllWithBatchProgress(ctx context.Context, onProgress Pr
 		// Skip files modified before lastIndexTime — but only if they have chunks.
 		// Files with no chunks need re-indexing even if their mod_time is old
 		// (e.g., a prior indexing run created the document but failed to embed).
-		if !idx.lastIndexTime.IsZero() && doc != nil && len(doc.ChunkIDs) > 0 {
+		// Files with credentials are read again when they must be skipped, so
+		// that those indexed before the policy changed are removed.
+		// Files that failed to index last time are retried.
+		journalHash, inFlight := interrupted[fileMeta.Path]
+		halfSaved := inFlight && (doc == nil || doc.Hash != journalHash)
+		rescan := halfSaved || idx.scanner.report.hasFailed(fileMeta.Path) ||
+			(idx.scanner.SkipsSecrets() && idx.scanner.HasSecrets(fileMeta.Path))
+		if !idx.lastIndexTime.IsZero() && doc != nil && len(doc.ChunkIDs) > 0 && !rescan {
 			fileModTime := time.Unix(fileMeta.ModTime, 0)
 			if fileModTime.Before(idx.lastIndexTime) || fileModTime.Equal(idx.lastIndexTime) {
 				stats.FilesSkipped++
@@ -147,14 +203,17 @@ func (idx *Indexer) IndexAllWithBatchProgress(ctx context.Context, onProgress Pr
 		// Load file content and hash only after metadata filtering.
 		file, err := idx.scanner.ScanFile(fileMeta.Path)
 		if err != nil {
-			log.Printf("Failed to scan %s: %v", fileMeta.Path, err)
+			slog.Error("Failed to scan file", "path", fileMeta.Path, "err", err)
 			stats.FilesSkipped++
 			delete(existingMap, fileMeta.Path)
 			continue
 		}
 		if file == nil {
 			stats.FilesSkipped++
-			delete(existingMap, fileMeta.Path)
+			// Files skipped for their credentials are removed from the index
+			if !(idx.scanner.SkipsSecrets() && idx.scanner.HasSecrets(fileMeta.Path)) {
+				delete(existingMap, fileMeta.Path)
+			}
 			continue
 		}
 
@@ -163,52 +222,26 @@ func (idx *Indexer) IndexAllWithBatchProgress(ctx context.Context, onProgress Pr
 			continue // File unchanged and has chunks
 		}
 
+		if doc == nil {
+			newFiles[file.Path] = true
+		}
 		filesToIndex = append(filesToIndex, *file)
 		delete(existingMap, fileMeta.Path)
 	}
 
-	// Index files using batch processing if available, otherwise sequentially
-	if batchEmbedder, ok := idx.embedder.(embedder.BatchEmbedder); ok && len(filesToIndex) > 0 {
-		indexed, chunks, err := idx.indexFilesBatched(ctx, filesToIndex, batchEmbedder, onBatchProgress)
-		if err != nil {
-			return nil, err
-		}
-		stats.FilesIndexed = indexed
-		stats.ChunksCreated = chunks
-	} else if len(filesToIndex) > 0 {
-		// Sequential indexing for non-batch embedders (e.g., Ollama)
-		total := len(filesToIndex)
-		for i, file := range filesToIndex {
-			if onBatchProgress != nil {
-				onBatchProgress(BatchProgressInfo{
-					BatchIndex:      i,
-					TotalBatches:    total,
-					CompletedChunks: i,
-					TotalChunks:     total,
-				})
-			}
-			chunks, err := idx.IndexFile(ctx, file)
-			if err != nil {
-				log.Printf("Failed to index %s: %v", file.Path, err)
-				continue
-			}
-			stats.FilesIndexed++
-			stats.ChunksCreated += chunks
-		}
-		if onBatchProgress != nil {
-			onBatchProgress(BatchProgressInfo{
-				BatchIndex:      total,
-				TotalBatches:    total,
-				CompletedChunks: total,
-				TotalChunks:     total,
-			})
-		}
+	// Files that disappeared and reappeared elsewhere with the same content
+	// were moved: re-key them instead of re-embedding.
+	filesToIndex = idx.moveRenamedFiles(ctx, filesToIndex, newFiles, existingMap, stats)
+
+	stats.FilesIndexed, stats.ChunksCreated, err = idx.indexFiles(ctx, filesToIndex, onBatchProgress)
+	if err != nil {
+		return nil, err
 	}
 
 	// Remove deleted files
 	for path := range existingMap {
 		if err := idx.RemoveFile(ctx, path); err != nil {
-			log.Printf("Failed to remove %s: %v", path, err)
+			slog.Error("Failed to remove file from index", "path", path, "err", err)
 			continue
 		}
 		stats.FilesRemoved++
@@ -218,6 +251,83 @@ func (idx *Indexer) IndexAllWithBatchProgress(ctx context.Context, onProgress Pr
 	return stats, nil
 }
 
+// indexFiles embeds and stores files, using cross-file batching when the
+// embedder implements BatchEmbedder and indexing sequentially otherwise.
+// With checkpoints enabled, files are processed in groups so that an
+// interrupted run keeps the groups already saved.
+func (idx *Indexer) indexFiles(ctx context.Context, files []FileInfo, onBatchProgress BatchProgressCallback) (filesIndexed int, chunksCreated int, err error) {
+	if len(files) == 0 {
+		idx.endGroups()
+		return 0, 0, nil
+	}
+
+	groupSize := len(files)
+	if idx.checkpoint.Files > 0 {
+		groupSize = idx.checkpoint.Files
+	}
+	idx.sinceCheckpoint = 0
+	idx.lastCheckpoint = time.Now()
+
+	for start := 0; start < len(files); start += groupSize {
+		group := files[start:min(start+groupSize, len(files))]
+		idx.beginGroup(group)
+
+		// indexGroup checkpoints as files are saved, so that a slow group
+		// is checkpointed part-way once the interval has passed.
+		indexed, chunks, err := idx.indexGroup(ctx, group, onBatchProgress)
+		filesIndexed += indexed
+		chunksCreated += chunks
+		if err != nil {
+			// Keep what was saved; the journal makes the next run retry the rest.
+			idx.maybeCheckpoint(ctx, 0, true)
+			return filesIndexed, chunksCreated, err
+		}
+	}
+
+	idx.endGroups()
+	return filesIndexed, chunksCreated, nil
+}
+
+// indexGroup embeds and stores a group of files.
+func (idx *Indexer) indexGroup(ctx context.Context, files []FileInfo, onBatchProgress BatchProgressCallback) (filesIndexed int, chunksCreated int, err error) {
+	if batchEmbedder, ok := idx.embedder.(embedder.BatchEmbedder); ok {
+		return idx.indexFilesBatched(ctx, files, batchEmbedder, onBatchProgress)
+	}
+
+	// Sequential indexing for non-batch embedders (e.g., Ollama)
+	total := len(files)
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return filesIndexed, chunksCreated, err
+		}
+		if onBatchProgress != nil {
+			onBatchProgress(BatchProgressInfo{
+				BatchIndex:      i,
+				TotalBatches:    total,
+				CompletedChunks: i,
+				TotalChunks:     total,
+			})
+		}
+		chunks, err := idx.IndexFile(ctx, file)
+		if err != nil {
+			slog.Error("Failed to index file", "path", file.Path, "err", err)
+			continue
+		}
+		filesIndexed++
+		chunksCreated += chunks
+		idx.maybeCheckpoint(ctx, 1, false)
+	}
+	if onBatchProgress != nil {
+		onBatchProgress(BatchProgressInfo{
+			BatchIndex:      total,
+			TotalBatches:    total,
+			CompletedChunks: total,
+			TotalChunks:     total,
+		})
+	}
+	return filesIndexed, chunksCreated, nil
+}
+
 // fileChunkData holds chunking information for a single file during batch processing.
 type fileChunkData struct {
 	fileIndex  int // Index in the files slice (for result mapping)
@@ -244,6 +354,7 @@ func (idx *Indexer) prepareFileChunks(
 		embedContent, lineMap := idx.embeddingContent(ctx, file)
 		chunkInfos := idx.chunker.ChunkWithContext(file.Path, embedContent)
 		if len(chunkInfos) == 0 {
+			idx.recordFailure(ctx, file.Path, nil)
 			continue
 		}
 
@@ -349,7 +460,7 @@ func (idx *Indexer) indexFilesBatched(
 	}
 
 	// Check embedding cache for content-addressed deduplication
-	cache, hasCache := idx.store.(store.EmbeddingCache)
+	cache, hasCache := idx.embeddingCache()
 	var totalCacheHits int
 
 	// Pre-fill cached embeddings and filter out fully-cached files
@@ -379,7 +490,7 @@ func (idx *Indexer) indexFilesBatched(
 			}
 			vec, found, err := cache.LookupByContentHash(ctx, chunk.ContentHash)
 			if err != nil {
-				log.Printf("Warning: cache lookup failed: %v", err)
+				slog.Warn("Embedding cache lookup failed", "err", err)
 				allCached = false
 				continue
 			}
@@ -400,7 +511,7 @@ func (idx *Indexer) indexFilesBatched(
 	}
 
 	if totalCacheHits > 0 {
-		log.Printf("Reused %d cached embeddings across %d files", totalCacheHits, len(preFilledFiles))
+		slog.Info("Reused cached embeddings", "embeddings", totalCacheHits, "files", len(preFilledFiles))
 	}
 
 	// Save fully-cached files immediately
@@ -410,36 +521,88 @@ func (idx *Indexer) indexFilesBatched(
 		idx.remapChunksToSource(fd.chunkInfos, fd.file.Path, fd.source, fd.lineMap)
 		chunks, chunkIDs := createStoreChunks(fd.chunkInfos, pf.vectors, now)
 		if err := idx.saveFileData(ctx, fd, chunks, chunkIDs); err != nil {
+			idx.recordFailure(ctx, fd.file.Path, err)
 			return filesIndexed, chunksCreated, err
 		}
+		idx.recordFailure(ctx, fd.file.Path, nil)
 		filesIndexed++
 		chunksCreated += len(chunks)
 	}
+	idx.maybeCheckpoint(ctx, len(preFilledFiles), false)
 
 	// Embed remaining (non-cached) files
 	if len(remainingFileChunks) > 0 {
 		batches := embedder.FormBatches(remainingFileChunks)
-		results, err := batchEmb.EmbedBatches(ctx, batches, wrapBatchProgress(onProgress))
-		if err != nil {
-			return filesIndexed, chunksCreated, fmt.Errorf("failed to embed batches: %w", err)
+		fileEmbeddings := make([][][]float32, len(files))
+		pending := make([]int, len(files)) // chunks still to embed, per file
+		for _, fd := range remainingFileData {
+			fileEmbeddings[fd.fileIndex] = make([][]float32, len(fd.chunkInfos))
+			pending[fd.fileIndex] = len(fd.chunkInfos)
+		}
+		saved := make([]bool, len(files))
+
+		// With a checkpoint interval, batches are embedded in rounds and the
+		// files they complete are saved between rounds, so that a long
+		// embedding run can be checkpointed before the whole group is done.
+		roundSize := len(batches)
+		if idx.checkpoint.Interval > 0 {
+			roundSize = minCheckpointRoundBatches
 		}
+		progress := newRoundProgress(onProgress, batches)
+
+		for start, end := 0, 0; start < len(batches); start = end {
+			end = min(start+roundSize, len(batches))
+			round := renumberBatches(batches[start:end])
+			roundStart := time.Now()
+			results, err := batchEmb.EmbedBatches(ctx, round, progress.forRound(start))
+			if err != nil {
+				err = fmt.Errorf("failed to embed batches: %w", err)
+				for _, fd := range remainingFileData {
+					if !saved[fd.fileIndex] {
+						idx.recordFailure(ctx, fd.file.Path, err)
+					}
+				}
+				return filesIndexed, chunksCreated, err
+			}
+			for _, result := range results {
+				for i, entry := range round[result.BatchIndex].Entries {
+					if i < len(result.Embeddings) {
+						fileEmbeddings[entry.FileIndex][entry.ChunkIndex] = result.Embeddings[i]
+						pending[entry.FileIndex]--
+					}
+				}
+			}
 
-		fileEmbeddings := embedder.MapResultsToFiles(batches, results, len(files))
+			savedInRound := 0
+			for _, fd := range remainingFileData {
+				if saved[fd.fileIndex] || pending[fd.fileIndex] > 0 {
+					continue
+				}
+				idx.remapChunksToSource(fd.chunkInfos, fd.file.Path, fd.source, fd.lineMap)
+				chunks, chunkIDs := createStoreChunks(fd.chunkInfos, fileEmbeddings[fd.fileIndex], now)
+				if err := idx.saveFileData(ctx, fd, chunks, chunkIDs); err != nil {
+					idx.recordFailure(ctx, fd.file.Path, err)
+					return filesIndexed, chunksCreated, err
+				}
+				saved[fd.fileIndex] = true
+				idx.recordFailure(ctx, fd.file.Path, nil)
+				filesIndexed++
+				chunksCreated += len(chunks)
+				savedInRound++
+			}
+			idx.maybeCheckpoint(ctx, savedInRound, false)
+			if idx.checkpoint.Interval > 0 {
+				roundSize = nextRoundSize(len(round), time.Since(roundStart), idx.checkpoint.Interval, len(batches)-end)
+			}
+		}
 
 		for _, fd := range remainingFileData {
-			embeddings := fileEmbeddings[fd.fileIndex]
-			if len(embeddings) != len(fd.chunkInfos) {
-				log.Printf("Warning: embedding count mismatch for %s: got %d, expected %d",
-					fd.file.Path, len(embeddings), len(fd.chunkInfos))
+			if saved[fd.fileIndex] {
 				continue
 			}
-			idx.remapChunksToSource(fd.chunkInfos, fd.file.Path, fd.source, fd.lineMap)
-			chunks, chunkIDs := createStoreChunks(fd.chunkInfos, embeddings, now)
-			if err := idx.saveFileData(ctx, fd, chunks, chunkIDs); err != nil {
-				return filesIndexed, chunksCreated, err
-			}
-			filesIndexed++
-			chunksCreated += len(chunks)
+			got := len(fd.chunkInfos) - pending[fd.fileIndex]
+			slog.Warn("Embedding count mismatch", "path", fd.file.Path, "got", got, "expected", len(fd.chunkInfos))
+			idx.recordFailure(ctx, fd.file.Path, fmt.Errorf("embedding count mismatch: got %d, expected %d", got, len(fd.chunkInfos)))
 		}
 	}
 
@@ -452,6 +615,25 @@ const maxReChunkAttempts = 3
 
 // IndexFile indexes a single file
 func (idx *Indexer) IndexFile(ctx context.Context, file FileInfo) (int, error) {
+	chunks, err := idx.indexFile(ctx, file)
+	idx.recordFailure(ctx, file.Path, err)
+	return chunks, err
+}
+
+// recordFailure keeps the indexing error of path in the scan report, or
+// clears it when err is nil. Errors caused by cancellation are not failures.
+func (idx *Indexer) recordFailure(ctx context.Context, path string, err error) {
+	if idx.scanner == nil || (err != nil && ctx.Err() != nil) {
+		return
+	}
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+	}
+	idx.scanner.report.recordFailure(path, reason)
+}
+
+func (idx *Indexer) indexFile(ctx context.Context, file FileInfo) (int, error) {
 	// Remove existing chunks for this file
 	if err := idx.store.DeleteByFile(ctx, file.Path); err != nil {
 		return 0, fmt.Errorf("failed to delete existing chunks: %w", err)
@@ -468,7 +650,7 @@ func (idx *Indexer) IndexFile(ctx context.Context, file FileInfo) (int, error) {
 	// Check embedding cache for content-addressed deduplication
 	cachedVectors, cacheHits := idx.lookupCachedEmbeddings(ctx, chunkInfos)
 	if cacheHits > 0 {
-		log.Printf("Reused %d cached embeddings for %s", cacheHits, file.Path)
+		slog.Info("Reused cached embeddings", "embeddings", cacheHits, "path", file.Path)
 	}
 
 	// Separate cached and uncached chunks
@@ -616,8 +798,12 @@ func (idx *Indexer) embedWithReChunking(ctx context.Context, chunks []ChunkInfo)
 		}
 
 		failedChunk := currentChunks[failedIndex]
-		log.Printf("Re-chunking %s chunk %d (attempt %d/%d): context limit exceeded",
-			failedChunk.FilePath, failedIndex, attempt+1, maxReChunkAttempts)
+		slog.Warn("Context limit exceeded, re-chunking",
+			"path", failedChunk.FilePath,
+			"chunk", failedIndex,
+			"attempt", attempt+1,
+			"max_attempts", maxReChunkAttempts,
+		)
 
 		// Embed all chunks before the failed one (they should work)
 		if failedIndex > 0 {
@@ -643,7 +829,7 @@ func (idx *Indexer) embedWithReChunking(ctx context.Context, chunks []ChunkInfo)
 			return nil, nil, fmt.Errorf("re-chunking produced no chunks for %s", failedChunk.FilePath)
 		}
 
-		log.Printf("Split chunk into %d sub-chunks", len(subChunks))
+		slog.Info("Split chunk into sub-chunks", "path", failedChunk.FilePath, "sub_chunks", len(subChunks))
 
 		// Prepare for next iteration: sub-chunks + remaining chunks
 		currentChunks = append(subChunks, currentChunks[failedIndex+1:]...)
@@ -652,14 +838,25 @@ func (idx *Indexer) embedWithReChunking(ctx context.Context, chunks []ChunkInfo)
 	return nil, nil, fmt.Errorf("exceeded maximum re-chunk attempts (%d) for file", maxReChunkAttempts)
 }
 
+// embeddingContent returns the text to embed for file and its line map back to
+// the source. Content extractors and the framework processor are mutually
+// exclusive: an extractor that handles the file wins, and the framework
+// transform only runs for files no extractor produced text for.
 func (idx *Indexer) embeddingContent(ctx context.Context, file FileInfo) (string, []int) {
+	extracted, ok, err := idx.extractors.Extract(file.Path, file.Content)
+	if err != nil {
+		slog.Warn("Content extraction failed", "path", file.Path, "err", err)
+	} else if ok {
+		return extracted.Text, extracted.GeneratedToSourceLine
+	}
+
 	if idx.processor == nil {
 		return file.Content, nil
 	}
 
 	res, err := idx.processor.TransformForEmbedding(ctx, file.Path, file.Content)
 	if err != nil {
-		log.Printf("Warning: framework embedding transform failed for %s: %v", file.Path, err)
+		slog.Warn("Framework embedding transform failed", "path", file.Path, "err", err)
 		return file.Content, nil
 	}
 	framework.LogWarningsOnce(res.Warnings)
@@ -686,7 +883,7 @@ func (idx *Indexer) remapChunksToSource(chunks []ChunkInfo, filePath, source str
 // cached vectors for chunks with matching content hashes. The returned map maps
 // chunk index to cached vector. Chunks not in the map need fresh embedding.
 func (idx *Indexer) lookupCachedEmbeddings(ctx context.Context, chunks []ChunkInfo) (map[int][]float32, int) {
-	cache, ok := idx.store.(store.EmbeddingCache)
+	cache, ok := idx.embeddingCache()
 	if !ok {
 		return nil, 0
 	}
@@ -698,7 +895,7 @@ func (idx *Indexer) lookupCachedEmbeddings(ctx context.Context, chunks []ChunkIn
 		}
 		vec, found, err := cache.LookupByContentHash(ctx, chunk.ContentHash)
 		if err != nil {
-			log.Printf("Warning: cache lookup failed for content hash %s: %v", chunk.ContentHash[:8], err)
+			slog.Warn("Embedding cache lookup failed", "content_hash", chunk.ContentHash[:8], "err", err)
 			continue
 		}
 		if found {
@@ -709,6 +906,91 @@ func (idx *Indexer) lookupCachedEmbeddings(ctx context.Context, chunks []ChunkIn
 	return cached, len(cached)
 }
 
+// MoveFile re-keys the index entries of oldPath to file.Path without
+// re-embedding, when the store implements store.FileMover and oldPath was
+// indexed with the same content. It reports whether the file was moved;
+// otherwise the caller should index file normally.
+func (idx *Indexer) MoveFile(ctx context.Context, oldPath string, file FileInfo) (bool, error) {
+	mover, ok := idx.store.(store.FileMover)
+	if !ok {
+		return false, nil
+	}
+
+	doc, err := idx.store.GetDocument(ctx, oldPath)
+	if err != nil {
+		return false, err
+	}
+	if doc == nil || doc.Hash == "" || doc.Hash != file.Hash {
+		return false, nil
+	}
+
+	if err := mover.MoveFile(ctx, oldPath, file.Path); err != nil {
+		return false, fmt.Errorf("failed to move %s: %w", oldPath, err)
+	}
+
+	doc.Path = file.Path
+	doc.ModTime = time.Unix(file.ModTime, 0)
+	if moved, err := idx.store.GetDocument(ctx, file.Path); err == nil && moved != nil {
+		doc.ChunkIDs = moved.ChunkIDs
+	}
+	if err := idx.store.SaveDocument(ctx, *doc); err != nil {
+		return false, fmt.Errorf("failed to save document for %s: %w", file.Path, err)
+	}
+	return true, nil
+}
+
+// moveRenamedFiles pairs files no longer on disk with new files of the same
+// extension and content, moves them, and returns the files still to index.
+func (idx *Indexer) moveRenamedFiles(ctx context.Context, files []FileInfo, newFiles map[string]bool, removed map[string]bool, stats *IndexStats) []FileInfo {
+	if _, ok := idx.store.(store.FileMover); !ok || len(newFiles) == 0 || len(removed) == 0 {
+		return files
+	}
+
+	byHash := make(map[string][]string)
+	for path := range removed {
+		doc, err := idx.store.GetDocument(ctx, path)
+		if err != nil || doc == nil || doc.Hash == "" {
+			continue
+		}
+		key := moveKey(path, doc.Hash)
+		byHash[key] = append(byHash[key], path)
+	}
+	if len(byHash) == 0 {
+		return files
+	}
+
+	remaining := files[:0]
+	for _, file := range files {
+		key := moveKey(file.Path, file.Hash)
+		candidates := byHash[key]
+		if !newFiles[file.Path] || len(candidates) == 0 {
+			remaining = append(remaining, file)
+			continue
+		}
+
+		oldPath := candidates[0]
+		moved, err := idx.MoveFile(ctx, oldPath, file)
+		if err != nil {
+			slog.Error("Failed to move file", "from", oldPath, "to", file.Path, "err", err)
+		}
+		if !moved {
+			remaining = append(remaining, file)
+			continue
+		}
+		byHash[key] = candidates[1:]
+		delete(removed, oldPath)
+		stats.FilesMoved++
+		stats.MovedFiles = append(stats.MovedFiles, FileMove{From: oldPath, To: file.Path})
+	}
+	return remaining
+}
+
+// moveKey identifies files that can be moved onto each other: same content
+// and same extension, so chunking and symbol extraction are unchanged.
+func moveKey(path, hash string) string {
+	return strings.ToLower(filepath.Ext(path)) + ":" + hash
+}
+
 // RemoveFile removes a file from the index
 func (idx *Indexer) RemoveFile(ctx context.Context, path string) error {
 	if err := idx.store.DeleteByFile(ctx, path); err != nil {
@@ -2,6 +2,7 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -457,6 +458,7 @@ func TestIndexAllWithProgress_DeletedFilesRemoved(t *testing.T) {
 type mockBatchEmbedder struct {
 	embedCalled bool
 	delay       time.Duration // Optional delay per batch for testing concurrency
+	err         error         // Optional error returned by EmbedBatches
 }
 
 func newMockBatchEmbedder() *mockBatchEmbedder {
@@ -487,6 +489,9 @@ func (m *mockBatchEmbedder) Close() error {
 
 func (m *mockBatchEmbedder) EmbedBatches(ctx context.Context, batches []embedder.Batch, progress embedder.BatchProgress) ([]embedder.BatchResult, error) {
 	m.embedCalled = true
+	if m.err != nil {
+		return nil, m.err
+	}
 
 	// Calculate total chunks for progress reporting
 	totalChunks := 0
@@ -1230,3 +1235,139 @@ func TestIndexFile_UsesTransformedContentAndStoresSourceSnippet(t *testing.T) {
 		t.Fatalf("expected remapped start line 2, got %d", chunks[0].StartLine)
 	}
 }
+
+func TestIndexAll_MovesRenamedFilesWithoutEmbedding(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old/auth.go", "package auth\n\nfunc Login() {}\n")
+	write("keep.go", "package main\n")
+
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	st := store.NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	ctx := context.Background()
+
+	idx := NewIndexer(tmpDir, st, newMockEmbedder(), NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	if _, err := idx.IndexAll(ctx); err != nil {
+		t.Fatalf("initial index failed: %v", err)
+	}
+
+	if err := os.Rename(filepath.Join(tmpDir, "old"), filepath.Join(tmpDir, "pkg")); err != nil {
+		t.Fatal(err)
+	}
+	// Same content under another extension is not a move.
+	write("copy.txt", "package auth\n\nfunc Login() {}\n")
+
+	emb := newMockEmbedder()
+	idx = NewIndexer(tmpDir, st, emb, NewChunker(512, 50), NewScanner(tmpDir, ignoreMatcher), time.Time{})
+	stats, err := idx.IndexAll(ctx)
+	if err != nil {
+		t.Fatalf("reindex failed: %v", err)
+	}
+
+	if stats.FilesMoved != 1 || len(stats.MovedFiles) != 1 || stats.MovedFiles[0] != (FileMove{From: "old/auth.go", To: "pkg/auth.go"}) {
+		t.Fatalf("expected old/auth.go to move to pkg/auth.go, got %+v", stats)
+	}
+	if stats.FilesRemoved != 0 || stats.FilesIndexed != 1 {
+		t.Errorf("expected only copy.txt to be indexed, got indexed=%d removed=%d", stats.FilesIndexed, stats.FilesRemoved)
+	}
+	for _, text := range emb.lastBatch {
+		if strings.Contains(text, "pkg/auth.go") {
+			t.Errorf("moved file should not be re-embedded, got batch %q", emb.lastBatch)
+		}
+	}
+
+	chunks, _ := st.GetChunksForFile(ctx, "pkg/auth.go")
+	if len(chunks) == 0 || !strings.HasPrefix(chunks[0].Content, "File: pkg/auth.go\n\n") {
+		t.Errorf("expected chunks re-keyed to pkg/auth.go, got %+v", chunks)
+	}
+	if doc, _ := st.GetDocument(ctx, "old/auth.go"); doc != nil {
+		t.Errorf("expected old/auth.go to be gone, got %+v", doc)
+	}
+}
+
+// failingEmbedder fails every embedding while fail is set.
+type failingEmbedder struct {
+	mockEmbedder
+	fail bool
+}
+
+func (m *failingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if m.fail {
+		return nil, errors.New("provider unavailable")
+	}
+	return m.mockEmbedder.EmbedBatch(ctx, texts)
+}
+
+func TestIndexAll_RecordsAndRetriesFailedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+
+	// Indexed before, so that only a retry gets past the mod-time gate
+	st := newMockStore()
+	st.documents["a.go"] = store.Document{Path: "a.go", Hash: "old", ChunkIDs: []string{"a.go_0"}}
+
+	emb := &failingEmbedder{fail: true}
+	idx := NewIndexer(tmpDir, st, emb, NewChunker(512, 50), scanner, time.Time{})
+	if _, err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatalf("IndexAll failed: %v", err)
+	}
+	if reason := scanner.Report().Failed["a.go"]; !strings.Contains(reason, "provider unavailable") {
+		t.Fatalf("expected a.go to be recorded as failed, got %q", reason)
+	}
+
+	emb.fail = false
+	idx = NewIndexer(tmpDir, st, emb, NewChunker(512, 50), scanner, time.Now().Add(time.Hour))
+	stats, err := idx.IndexAll(context.Background())
+	if err != nil {
+		t.Fatalf("IndexAll failed: %v", err)
+	}
+	if stats.FilesIndexed != 1 {
+		t.Errorf("expected the failed file to be retried, got %d indexed", stats.FilesIndexed)
+	}
+	if failed := scanner.Report().Failed; len(failed) != 0 {
+		t.Errorf("expected no failed files after the retry, got %v", failed)
+	}
+}
+
+func TestIndexAll_RecordsFailedBatches(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+	emb := &mockBatchEmbedder{err: errors.New("rate limited")}
+	idx := NewIndexer(tmpDir, newMockStore(), emb, NewChunker(512, 50), scanner, time.Time{})
+
+	if _, err := idx.IndexAll(context.Background()); err == nil {
+		t.Fatal("expected the batch error to be returned")
+	}
+	failed := scanner.Report().Failed
+	if len(failed) != 2 || !strings.Contains(failed["a.go"], "rate limited") {
+		t.Errorf("expected both files recorded as failed, got %v", failed)
+	}
+}
@@ -0,0 +1,263 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanReport records which files the scanner skipped or penalized and why.
+// It is persisted next to the index so `grepai status` and search can use it.
+type ScanReport struct {
+	Skipped   map[string]string          `json:"skipped"`           // path -> reason
+	Penalized map[string]string          `json:"penalized"`         // path -> reason
+	Secrets   map[string][]SecretFinding `json:"secrets,omitempty"` // path -> credentials found
+	Failed    map[string]string          `json:"failed,omitempty"`  // path -> indexing error
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// SecretFinding locates a credential found in a file. The credential itself
+// is never recorded.
+type SecretFinding struct {
+	Kind string `json:"kind"`
+	Line int    `json:"line"`
+}
+
+// NewScanReport returns an empty report.
+func NewScanReport() *ScanReport {
+	return &ScanReport{
+		Skipped:   make(map[string]string),
+		Penalized: make(map[string]string),
+	}
+}
+
+// SkipCounts returns the number of skipped files per reason.
+func (r *ScanReport) SkipCounts() map[string]int {
+	return countReasons(r.Skipped)
+}
+
+// PenaltyCounts returns the number of penalized files per reason.
+func (r *ScanReport) PenaltyCounts() map[string]int {
+	return countReasons(r.Penalized)
+}
+
+// SecretCounts returns the number of credentials found per kind.
+func (r *ScanReport) SecretCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, findings := range r.Secrets {
+		for _, f := range findings {
+			counts[f.Kind]++
+		}
+	}
+	return counts
+}
+
+// FormatCounts renders reason counts as "generated: 3, too large: 1".
+func FormatCounts(counts map[string]int) string {
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%s: %d", reason, counts[reason])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func countReasons(m map[string]string) map[string]int {
+	counts := make(map[string]int)
+	for _, reason := range m {
+		counts[reason]++
+	}
+	return counts
+}
+
+// SaveScanReport writes the report as JSON to path.
+func SaveScanReport(path string, report *ScanReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scan report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create scan report directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write scan report: %w", err)
+	}
+	return nil
+}
+
+// LoadScanReport reads a report written by SaveScanReport. A missing file
+// yields an empty report.
+func LoadScanReport(path string) (*ScanReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewScanReport(), nil
+		}
+		return nil, fmt.Errorf("failed to read scan report: %w", err)
+	}
+	report := NewScanReport()
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to parse scan report: %w", err)
+	}
+	if report.Skipped == nil {
+		report.Skipped = make(map[string]string)
+	}
+	if report.Penalized == nil {
+		report.Penalized = make(map[string]string)
+	}
+	return report, nil
+}
+
+// scanReportRecorder is the scanner's concurrency-safe view of a ScanReport.
+type scanReportRecorder struct {
+	mu     sync.Mutex
+	report *ScanReport
+}
+
+// reset starts a new scan. Secret findings and indexing failures are kept:
+// files the indexer doesn't read again because they didn't change keep
+// theirs.
+func (r *scanReportRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var secrets map[string][]SecretFinding
+	var failed map[string]string
+	if r.report != nil {
+		secrets = r.report.Secrets
+		failed = r.report.Failed
+	}
+	r.report = NewScanReport()
+	r.report.Secrets = secrets
+	r.report.Failed = failed
+	r.report.UpdatedAt = time.Now()
+}
+
+// recordSecrets stores the credentials found in path, clearing any previous
+// findings.
+func (r *scanReportRecorder) recordSecrets(path string, findings []SecretFinding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		r.report = NewScanReport()
+	}
+	if len(findings) == 0 {
+		delete(r.report.Secrets, path)
+		return
+	}
+	if r.report.Secrets == nil {
+		r.report.Secrets = make(map[string][]SecretFinding)
+	}
+	r.report.Secrets[path] = findings
+	r.report.UpdatedAt = time.Now()
+}
+
+// retain drops the findings and failures of files that are no longer
+// indexable.
+func (r *scanReportRecorder) retain(paths map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		return
+	}
+	for path := range r.report.Secrets {
+		if !paths[path] {
+			delete(r.report.Secrets, path)
+		}
+	}
+	for path := range r.report.Failed {
+		if !paths[path] {
+			delete(r.report.Failed, path)
+		}
+	}
+}
+
+// recordFailure stores why path could not be indexed. An empty reason
+// clears the entry.
+func (r *scanReportRecorder) recordFailure(path, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		r.report = NewScanReport()
+	}
+	if reason == "" {
+		delete(r.report.Failed, path)
+		return
+	}
+	if r.report.Failed == nil {
+		r.report.Failed = make(map[string]string)
+	}
+	r.report.Failed[path] = reason
+	r.report.UpdatedAt = time.Now()
+}
+
+// hasFailed reports whether the last attempt to index path failed.
+func (r *scanReportRecorder) hasFailed(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report != nil && r.report.Failed[path] != ""
+}
+
+// hasSecrets reports whether credentials were found in path.
+func (r *scanReportRecorder) hasSecrets(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report != nil && len(r.report.Secrets[path]) > 0
+}
+
+// record stores the outcome for path, clearing any previous entry.
+func (r *scanReportRecorder) record(path, reason, policy string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		r.report = NewScanReport()
+	}
+	delete(r.report.Skipped, path)
+	delete(r.report.Penalized, path)
+	switch {
+	case reason == "":
+	case policy == PolicySkip:
+		r.report.Skipped[path] = reason
+	case policy == PolicyPenalize:
+		r.report.Penalized[path] = reason
+	}
+	r.report.UpdatedAt = time.Now()
+}
+
+func (r *scanReportRecorder) snapshot() *ScanReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := NewScanReport()
+	if r.report == nil {
+		return out
+	}
+	for k, v := range r.report.Skipped {
+		out.Skipped[k] = v
+	}
+	for k, v := range r.report.Penalized {
+		out.Penalized[k] = v
+	}
+	if len(r.report.Secrets) > 0 {
+		out.Secrets = make(map[string][]SecretFinding, len(r.report.Secrets))
+		for k, v := range r.report.Secrets {
+			out.Secrets[k] = append([]SecretFinding(nil), v...)
+		}
+	}
+	if len(r.report.Failed) > 0 {
+		out.Failed = make(map[string]string, len(r.report.Failed))
+		for k, v := range r.report.Failed {
+			out.Failed[k] = v
+		}
+	}
+	out.UpdatedAt = r.report.UpdatedAt
+	return out
+}
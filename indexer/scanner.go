@@ -36,71 +36,116 @@ func isMinifiedFile(path string) bool {
 
 // SupportedExtensions lists file extensions to index
 var SupportedExtensions = map[string]bool{
-	".go":     true,
-	".js":     true,
-	".ts":     true,
-	".jsx":    true,
-	".tsx":    true,
-	".py":     true,
-	".rb":     true,
-	".java":   true,
-	".c":      true,
-	".cpp":    true,
-	".cc":     true,
-	".h":      true,
-	".hpp":    true,
-	".cs":     true,
-	".php":    true,
-	".rs":     true,
-	".swift":  true,
-	".kt":     true,
-	".scala":  true,
-	".vue":    true,
-	".svelte": true,
-	".html":   true,
-	".css":    true,
-	".scss":   true,
-	".less":   true,
-	".sql":    true,
-	".sh":     true,
-	".bash":   true,
-	".zsh":    true,
-	".yaml":   true,
-	".yml":    true,
-	".json":   true,
-	".xml":    true,
-	".md":     true,
-	".txt":    true,
-	".toml":   true,
-	".ini":    true,
-	".cfg":    true,
-	".conf":   true,
-	".env":    true,
-	".lua":    true,
-	".r":      true,
-	".R":      true,
-	".dart":   true,
-	".ex":     true,
-	".exs":    true,
-	".erl":    true,
-	".clj":    true,
-	".hs":     true,
-	".ml":     true,
-	".fs":     true,
-	".elm":    true,
-	".nim":    true,
-	".zig":    true,
-	".proto":  true,
-	".tf":     true,
-	".hcl":    true,
-	".pas":    true, // Pascal source file
-	".dpr":    true, // Delphi project file
+	".go":       true,
+	".js":       true,
+	".ts":       true,
+	".jsx":      true,
+	".tsx":      true,
+	".py":       true,
+	".rb":       true,
+	".java":     true,
+	".c":        true,
+	".cpp":      true,
+	".cc":       true,
+	".h":        true,
+	".hpp":      true,
+	".cs":       true,
+	".php":      true,
+	".rs":       true,
+	".swift":    true,
+	".kt":       true,
+	".scala":    true,
+	".vue":      true,
+	".svelte":   true,
+	".html":     true,
+	".css":      true,
+	".scss":     true,
+	".less":     true,
+	".sql":      true,
+	".sh":       true,
+	".bash":     true,
+	".zsh":      true,
+	".yaml":     true,
+	".yml":      true,
+	".json":     true,
+	".xml":      true,
+	".md":       true,
+	".txt":      true,
+	".toml":     true,
+	".ini":      true,
+	".cfg":      true,
+	".conf":     true,
+	".env":      true,
+	".lua":      true,
+	".r":        true,
+	".R":        true,
+	".dart":     true,
+	".ex":       true,
+	".exs":      true,
+	".erl":      true,
+	".clj":      true,
+	".hs":       true,
+	".ml":       true,
+	".fs":       true,
+	".elm":      true,
+	".nim":      true,
+	".zig":      true,
+	".proto":    true,
+	".tf":       true,
+	".hcl":      true,
+	".pas":      true, // Pascal source file
+	".dpr":      true, // Delphi project file
+	".ipynb":    true, // Jupyter notebook (code and markdown cells are extracted)
+	".markdown": true,
+	".mdx":      true,
+	".rst":      true,
+	".adoc":     true,
+	".asciidoc": true,
+	".graphql":  true,
+	".gql":      true,
+	".prisma":   true,
+}
+
+// ExtensionSet returns SupportedExtensions with extra extensions added and
+// excluded ones removed. Entries are normalized to lowercase with a leading dot.
+func ExtensionSet(extra, exclude []string) map[string]bool {
+	set := make(map[string]bool, len(SupportedExtensions)+len(extra))
+	for ext, ok := range SupportedExtensions {
+		if ok {
+			set[ext] = true
+		}
+	}
+	for _, ext := range extra {
+		if ext = normalizeExtension(ext); ext != "" {
+			set[ext] = true
+		}
+	}
+	for _, ext := range exclude {
+		if ext = normalizeExtension(ext); ext != "" {
+			delete(set, ext)
+		}
+	}
+	return set
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext == "" {
+		return ""
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
 }
 
 type FileInfo struct {
 	Path    string
 	Size    int64
 	ModTime int64
+	// Hash is the SHA-256 of the file as read, not of Content, which may be
+	// redacted: it identifies the file on disk and is compared with HashFile
+	// to detect changes and moves.
 	Hash    string
 	Content string
 }
@@ -112,15 +157,111 @@ type FileMeta struct {
 }
 
 type Scanner struct {
-	root   string
-	ignore *IgnoreMatcher
+	root       string
+	ignore     *IgnoreMatcher
+	extensions map[string]bool
+	classifier *FileClassifier
+	report     scanReportRecorder
 }
 
 func NewScanner(root string, ignore *IgnoreMatcher) *Scanner {
 	return &Scanner{
-		root:   root,
-		ignore: ignore,
+		root:       root,
+		ignore:     ignore,
+		extensions: SupportedExtensions,
+		classifier: NewFileClassifier("", DefaultFilePolicy()),
+	}
+}
+
+// SetFilePolicy configures how large, generated and vendored files, and
+// files containing credentials, are handled. Linguist attributes are read
+// from the root .gitattributes.
+func (s *Scanner) SetFilePolicy(cfg FilePolicyConfig) {
+	s.classifier = NewFileClassifier(s.root, cfg)
+}
+
+// Report returns a snapshot of the files skipped or penalized so far.
+// Full scans reset the report; ScanFile updates the entry for its file.
+func (s *Scanner) Report() *ScanReport {
+	return s.report.snapshot()
+}
+
+// admitFile applies the minified check and the large/generated/vendored file
+// policy. It returns false (with the skip reason recorded) when the file must
+// be skipped.
+func (s *Scanner) admitFile(relPath string, size int64) (bool, string) {
+	if isMinifiedFile(relPath) {
+		s.report.record(relPath, ReasonMinified, PolicySkip)
+		return false, ReasonMinified
+	}
+	reason, policy := s.classifier.ClassifyFile(filepath.Join(s.root, relPath), relPath, size)
+	s.report.record(relPath, reason, policy)
+	if policy == PolicySkip {
+		return false, reason
 	}
+	return true, ""
+}
+
+// SetSecretFindings restores the credentials found by a previous scan, as
+// persisted in its ScanReport. Files the indexer doesn't read again because
+// they didn't change keep their findings.
+func (s *Scanner) SetSecretFindings(findings map[string][]SecretFinding) {
+	for path, f := range findings {
+		s.report.recordSecrets(path, f)
+	}
+}
+
+// HasSecrets reports whether credentials were found in relPath.
+func (s *Scanner) HasSecrets(relPath string) bool {
+	return s.report.hasSecrets(relPath)
+}
+
+// SkipsSecrets reports whether files containing credentials are skipped.
+func (s *Scanner) SkipsSecrets() bool {
+	return s.classifier.cfg.Secrets == PolicySkip
+}
+
+// SetIndexFailures restores the indexing failures recorded by a previous
+// run, as persisted in its ScanReport, so that those files are retried.
+func (s *Scanner) SetIndexFailures(failed map[string]string) {
+	for path, reason := range failed {
+		s.report.recordFailure(path, reason)
+	}
+}
+
+// retainFindings drops the findings and failures of files missing from files.
+func (s *Scanner) retainFindings(files []FileMeta) {
+	paths := make(map[string]bool, len(files))
+	for _, f := range files {
+		paths[f.Path] = true
+	}
+	s.report.retain(paths)
+}
+
+// SetExtensions overrides the set of file extensions the scanner indexes.
+func (s *Scanner) SetExtensions(extensions map[string]bool) {
+	s.extensions = extensions
+}
+
+// Accepts reports whether relPath would be considered for indexing based on
+// ignore rules, extension and minified naming alone. File policies that need
+// the file size or content are not applied. Like the full scan, it rejects
+// files under a directory the walk would skip.
+func (s *Scanner) Accepts(relPath string) bool {
+	if s.ignore != nil {
+		if s.ignore.ShouldIgnore(relPath) {
+			return false
+		}
+		for dir := filepath.Dir(relPath); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			if s.ignore.ShouldSkipDir(dir) {
+				return false
+			}
+		}
+	}
+	if !s.extensions[strings.ToLower(filepath.Ext(relPath))] {
+		return false
+	}
+	return !isMinifiedFile(relPath)
 }
 
 // ScanMetadata scans indexable files and returns only file metadata.
@@ -128,6 +269,7 @@ func NewScanner(root string, ignore *IgnoreMatcher) *Scanner {
 func (s *Scanner) ScanMetadata() ([]FileMeta, []string, error) {
 	var files []FileMeta
 	var skipped []string
+	s.report.reset()
 
 	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -154,13 +296,7 @@ func (s *Scanner) ScanMetadata() ([]FileMeta, []string, error) {
 
 		// Check extension
 		ext := strings.ToLower(filepath.Ext(path))
-		if !SupportedExtensions[ext] {
-			return nil
-		}
-
-		// Skip minified files
-		if isMinifiedFile(relPath) {
-			skipped = append(skipped, relPath+" (minified)")
+		if !s.extensions[ext] {
 			return nil
 		}
 
@@ -169,9 +305,9 @@ func (s *Scanner) ScanMetadata() ([]FileMeta, []string, error) {
 			return nil
 		}
 
-		// Skip large files
-		if info.Size() > maxFileSize {
-			skipped = append(skipped, relPath+" (too large)")
+		// Skip minified, oversized, generated and vendored files per policy
+		if ok, reason := s.admitFile(relPath, info.Size()); !ok {
+			skipped = append(skipped, relPath+" ("+reason+")")
 			return nil
 		}
 
@@ -184,12 +320,15 @@ func (s *Scanner) ScanMetadata() ([]FileMeta, []string, error) {
 		return nil
 	})
 
+	s.retainFindings(files)
 	return files, skipped, err
 }
 
 func (s *Scanner) Scan() ([]FileInfo, []string, error) {
 	var files []FileInfo
 	var skipped []string
+	admitted := make(map[string]bool)
+	s.report.reset()
 
 	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -216,13 +355,7 @@ func (s *Scanner) Scan() ([]FileInfo, []string, error) {
 
 		// Check extension
 		ext := strings.ToLower(filepath.Ext(path))
-		if !SupportedExtensions[ext] {
-			return nil
-		}
-
-		// Skip minified files
-		if isMinifiedFile(relPath) {
-			skipped = append(skipped, relPath+" (minified)")
+		if !s.extensions[ext] {
 			return nil
 		}
 
@@ -231,9 +364,9 @@ func (s *Scanner) Scan() ([]FileInfo, []string, error) {
 			return nil
 		}
 
-		// Skip large files
-		if info.Size() > maxFileSize {
-			skipped = append(skipped, relPath+" (too large)")
+		// Skip minified, oversized, generated and vendored files per policy
+		if ok, reason := s.admitFile(relPath, info.Size()); !ok {
+			skipped = append(skipped, relPath+" ("+reason+")")
 			return nil
 		}
 
@@ -247,6 +380,14 @@ func (s *Scanner) Scan() ([]FileInfo, []string, error) {
 		if !utf8.Valid(content) || containsNull(content) {
 			return nil
 		}
+		admitted[relPath] = true
+
+		// Redact or skip files containing credentials per policy
+		text, ok := applySecretsPolicy(&s.report, relPath, string(content), s.classifier.cfg.Secrets)
+		if !ok {
+			skipped = append(skipped, relPath+" ("+ReasonSecrets+")")
+			return nil
+		}
 
 		// Calculate hash
 		hash := sha256.Sum256(content)
@@ -256,12 +397,13 @@ func (s *Scanner) Scan() ([]FileInfo, []string, error) {
 			Size:    info.Size(),
 			ModTime: info.ModTime().Unix(),
 			Hash:    hex.EncodeToString(hash[:]),
-			Content: string(content),
+			Content: text,
 		})
 
 		return nil
 	})
 
+	s.report.retain(admitted)
 	return files, skipped, err
 }
 
@@ -270,6 +412,7 @@ func (s *Scanner) ScanFile(relPath string) (*FileInfo, error) {
 
 	// Skip minified files
 	if isMinifiedFile(relPath) {
+		s.report.record(relPath, ReasonMinified, PolicySkip)
 		return nil, nil
 	}
 
@@ -278,8 +421,8 @@ func (s *Scanner) ScanFile(relPath string) (*FileInfo, error) {
 		return nil, err
 	}
 
-	if info.Size() > maxFileSize {
-		return nil, nil // Skip large files
+	if ok, _ := s.admitFile(relPath, info.Size()); !ok {
+		return nil, nil // Skip large, generated or vendored files per policy
 	}
 
 	content, err := os.ReadFile(absPath)
@@ -291,6 +434,11 @@ func (s *Scanner) ScanFile(relPath string) (*FileInfo, error) {
 		return nil, nil // Skip binary files
 	}
 
+	text, ok := applySecretsPolicy(&s.report, relPath, string(content), s.classifier.cfg.Secrets)
+	if !ok {
+		return nil, nil // Skip files containing credentials per policy
+	}
+
 	hash := sha256.Sum256(content)
 
 	return &FileInfo{
@@ -298,7 +446,7 @@ func (s *Scanner) ScanFile(relPath string) (*FileInfo, error) {
 		Size:    info.Size(),
 		ModTime: info.ModTime().Unix(),
 		Hash:    hex.EncodeToString(hash[:]),
-		Content: string(content),
+		Content: text,
 	}, nil
 }
 
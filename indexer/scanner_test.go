@@ -7,7 +7,7 @@ import (
 )
 
 func TestSupportedExtensions(t *testing.T) {
-	supported := []string{".go", ".js", ".ts", ".py", ".rs", ".java"}
+	supported := []string{".go", ".js", ".ts", ".py", ".rs", ".java", ".adoc", ".asciidoc"}
 	unsupported := []string{".exe", ".bin", ".png", ".jpg", ".mp3"}
 
 	for _, ext := range supported {
@@ -73,6 +73,49 @@ func TestScanner_Scan(t *testing.T) {
 	}
 }
 
+func TestScanner_Accepts(t *testing.T) {
+	tmpDir := t.TempDir()
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{"vendor"}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+
+	for path, want := range map[string]bool{
+		"main.go":          true,
+		"web/app.js":       true,
+		"web/app.min.js":   false,
+		"vendor/lib/x.go":  false,
+		"assets/logo.png":  false,
+		"cmd/tool/main.go": true,
+	} {
+		if got := scanner.Accepts(path); got != want {
+			t.Errorf("Accepts(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestScanner_AcceptsSkipsIgnoredParentDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	// The negation re-includes .go files, but the walk still skips build/
+	// because .grepaiignore ignores the directory itself.
+	if err := os.WriteFile(filepath.Join(tmpDir, ".grepaiignore"), []byte("build/\n!*.go\n"), 0644); err != nil {
+		t.Fatalf("failed to write .grepaiignore: %v", err)
+	}
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, nil, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+
+	if scanner.Accepts("build/gen/x.go") {
+		t.Error("expected a file under a skipped directory to be rejected")
+	}
+	if !scanner.Accepts("src/x.go") {
+		t.Error("expected a file outside the skipped directory to be accepted")
+	}
+}
+
 func TestScanner_ScanMetadata(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -352,3 +395,67 @@ func TestScanner_ScanFile_SkipsMinified(t *testing.T) {
 		t.Error("expected nil for minified file, got file info")
 	}
 }
+
+func TestScanner_RedactsSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "DB_HOST=localhost\nDB_PASSWORD=hunter2hunter2\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+	fileInfo, err := scanner.ScanFile(".env")
+	if err != nil {
+		t.Fatalf("scan file failed: %v", err)
+	}
+	if fileInfo == nil {
+		t.Fatal("expected file info, got nil")
+	}
+	if fileInfo.Content != "DB_HOST=localhost\nDB_PASSWORD=[REDACTED]\n" {
+		t.Errorf("expected the password to be redacted, got %q", fileInfo.Content)
+	}
+	if hash, _ := HashFile(filepath.Join(tmpDir, ".env")); fileInfo.Hash != hash {
+		t.Error("expected the hash of the file on disk")
+	}
+
+	findings := scanner.Report().Secrets[".env"]
+	if len(findings) != 1 || findings[0].Line != 2 {
+		t.Errorf("expected one finding on line 2, got %+v", findings)
+	}
+}
+
+func TestScanner_SkipsSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("DB_PASSWORD=hunter2hunter2\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	ignoreMatcher, err := NewIgnoreMatcher(tmpDir, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+
+	scanner := NewScanner(tmpDir, ignoreMatcher)
+	scanner.SetFilePolicy(FilePolicyConfig{Secrets: PolicySkip})
+	files, _, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "main.go" {
+		t.Fatalf("expected only main.go, got %+v", files)
+	}
+	if reason := scanner.Report().Skipped[".env"]; reason != ReasonSecrets {
+		t.Errorf("expected .env to be skipped for secrets, got %q", reason)
+	}
+	if !scanner.HasSecrets(".env") {
+		t.Error("expected the finding to be kept")
+	}
+}
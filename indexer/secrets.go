@@ -0,0 +1,48 @@
+package indexer
+
+import (
+	"strings"
+
+	"github.com/yoanbernabeu/grepai/internal/secrets"
+)
+
+// applySecretsPolicy looks for credentials in the content of relPath,
+// records where they are in report and applies policy. It returns the
+// content to index, redacted with the redact policy, and false when the
+// file must be skipped.
+func applySecretsPolicy(report *scanReportRecorder, relPath, content, policy string) (string, bool) {
+	found := secrets.Find(content)
+	findings := make([]SecretFinding, len(found))
+	for i, f := range found {
+		findings[i] = SecretFinding{Kind: f.Kind, Line: strings.Count(content[:f.Start], "\n") + 1}
+	}
+	report.recordSecrets(relPath, findings)
+	if len(found) == 0 {
+		return content, true
+	}
+
+	text, ok := ApplySecretsPolicy(content, policy)
+	if !ok {
+		report.record(relPath, ReasonSecrets, PolicySkip)
+	}
+	return text, ok
+}
+
+// ApplySecretsPolicy applies the indexing.secrets policy to content, such as
+// a changed hunk, as Scanner does to the files it reads. It returns the
+// content to embed, redacted with the redact policy (the default), and false
+// when content contains credentials and must be skipped.
+func ApplySecretsPolicy(content, policy string) (string, bool) {
+	switch policy {
+	case PolicyIndex:
+		return content, true
+	case PolicySkip:
+		if len(secrets.Find(content)) > 0 {
+			return "", false
+		}
+		return content, true
+	default:
+		redacted, _ := secrets.Redact(content)
+		return redacted, true
+	}
+}
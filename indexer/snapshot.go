@@ -0,0 +1,94 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotManifest describes a git ref indexed as a named snapshot.
+// It is written next to the snapshot's index so search and trace can find it.
+type SnapshotManifest struct {
+	Name      string    `json:"name"`
+	Ref       string    `json:"ref"`
+	Commit    string    `json:"commit"`
+	Files     int       `json:"files"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// SaveSnapshotManifest writes the manifest as JSON to path.
+func SaveSnapshotManifest(path string, m *SnapshotManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshotManifest reads a manifest written by SaveSnapshotManifest.
+// The returned error satisfies os.IsNotExist when the snapshot does not exist.
+func LoadSnapshotManifest(path string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &SnapshotManifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest: %w", err)
+	}
+	return m, nil
+}
+
+// ListSnapshots returns the manifests found in the subdirectories of dir,
+// sorted by name. Directories without a readable manifest are ignored.
+func ListSnapshots(dir, manifestName string) ([]SnapshotManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+
+	var manifests []SnapshotManifest
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m, err := LoadSnapshotManifest(filepath.Join(dir, entry.Name(), manifestName))
+		if err != nil {
+			continue
+		}
+		manifests = append(manifests, *m)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Name < manifests[j].Name
+	})
+	return manifests, nil
+}
+
+// FindSnapshot returns the manifest of the snapshot created from ref, or else
+// of the snapshot named name, so snapshots created with a custom name can
+// still be found by their ref. The returned error satisfies os.IsNotExist
+// when neither exists.
+func FindSnapshot(dir, manifestName, ref, name string) (*SnapshotManifest, error) {
+	manifests, err := ListSnapshots(dir, manifestName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range manifests {
+		if manifests[i].Ref == ref {
+			return &manifests[i], nil
+		}
+	}
+	return LoadSnapshotManifest(filepath.Join(dir, name, manifestName))
+}
@@ -0,0 +1,137 @@
+// Package logging configures the structured logger of background watchers:
+// text or JSON records, a minimum level and size-based rotation of the log
+// file.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// FileEnv names the environment variable holding the log file of a
+// background watcher. When set, the watcher writes to the file itself and
+// rotates it instead of relying on its redirected output.
+const FileEnv = "GREPAI_LOG_FILE"
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures Setup.
+type Options struct {
+	Format string    // text (default) or json
+	Level  string    // debug, info (default), warn or error
+	Output io.Writer // Destination of the records
+}
+
+// ParseLevel parses a level name.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q (must be debug, info, warn or error)", name)
+	}
+}
+
+// ValidateFormat checks a format name.
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format %q (must be text or json)", format)
+	}
+}
+
+// NewHandler returns the slog handler for opts.
+func NewHandler(opts Options) (slog.Handler, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFormat(opts.Format); err != nil {
+		return nil, err
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if opts.Format == FormatJSON {
+		return slog.NewJSONHandler(opts.Output, handlerOpts), nil
+	}
+	return slog.NewTextHandler(opts.Output, handlerOpts), nil
+}
+
+// Setup makes a logger built from opts the default slog logger and routes
+// the standard log package through it, so that packages still using
+// log.Printf produce records of the same format. It returns a function
+// restoring the previous loggers.
+func Setup(opts Options) (func(), error) {
+	handler, err := NewHandler(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	oldDefault := slog.Default()
+	oldWriter := log.Writer()
+	oldFlags := log.Flags()
+	oldPrefix := log.Prefix()
+
+	slog.SetDefault(slog.New(handler))
+	// SetDefault bridges the log package at INFO; replace the bridge to
+	// recover the level of legacy "Warning: ..." messages.
+	log.SetOutput(&legacyWriter{handler: handler})
+	log.SetFlags(0)
+	log.SetPrefix("")
+
+	return func() {
+		slog.SetDefault(oldDefault)
+		log.SetOutput(oldWriter)
+		log.SetFlags(oldFlags)
+		log.SetPrefix(oldPrefix)
+	}, nil
+}
+
+// legacyWriter turns log package output into slog records.
+type legacyWriter struct {
+	handler slog.Handler
+}
+
+func (w *legacyWriter) Write(p []byte) (int, error) {
+	level, msg := LegacyLevel(strings.TrimRight(string(p), "\r\n"))
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, level) {
+		return len(p), nil
+	}
+	if err := w.handler.Handle(ctx, slog.NewRecord(time.Now(), level, msg, 0)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// LegacyLevel infers the level of a free-form log message: "Warning: ..."
+// is a warning and "Error: ..." or "Failed ..." an error. The "Warning: " and
+// "Error: " prefixes are removed from the returned message.
+func LegacyLevel(msg string) (slog.Level, string) {
+	switch {
+	case strings.HasPrefix(msg, "Warning: "):
+		return slog.LevelWarn, strings.TrimPrefix(msg, "Warning: ")
+	case strings.HasPrefix(msg, "Error: "):
+		return slog.LevelError, strings.TrimPrefix(msg, "Error: ")
+	case strings.HasPrefix(msg, "Failed "):
+		return slog.LevelError, msg
+	default:
+		return slog.LevelInfo, msg
+	}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLegacyLevel(t *testing.T) {
+	tests := []struct {
+		msg       string
+		wantLevel slog.Level
+		wantMsg   string
+	}{
+		{"Warning: failed to persist index: disk full", slog.LevelWarn, "failed to persist index: disk full"},
+		{"Error: boom", slog.LevelError, "boom"},
+		{"Failed to index main.go: timeout", slog.LevelError, "Failed to index main.go: timeout"},
+		{"Watching for changes...", slog.LevelInfo, "Watching for changes..."},
+	}
+	for _, tt := range tests {
+		level, msg := LegacyLevel(tt.msg)
+		if level != tt.wantLevel || msg != tt.wantMsg {
+			t.Errorf("LegacyLevel(%q) = %v, %q; want %v, %q", tt.msg, level, msg, tt.wantLevel, tt.wantMsg)
+		}
+	}
+}
+
+func TestSetup_JSONRoutesLegacyLogs(t *testing.T) {
+	var buf bytes.Buffer
+	restore, err := Setup(Options{Format: FormatJSON, Level: "warn", Output: &buf})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	log.Printf("Indexed main.go (3 chunks)")
+	log.Printf("Warning: failed to save config: %v", "read-only")
+	slog.Info("dropped below level")
+	slog.Error("Failed to index file", "path", "main.go")
+	restore()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records at warn level, got %d: %q", len(lines), buf.String())
+	}
+	var warn, failure map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &warn); err != nil {
+		t.Fatalf("invalid JSON record %q: %v", lines[0], err)
+	}
+	if warn["level"] != "WARN" || warn["msg"] != "failed to save config: read-only" {
+		t.Fatalf("unexpected legacy record %v", warn)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatalf("invalid JSON record %q: %v", lines[1], err)
+	}
+	if failure["level"] != "ERROR" || failure["path"] != "main.go" {
+		t.Fatalf("unexpected record %v", failure)
+	}
+}
+
+func TestSetup_InvalidOptions(t *testing.T) {
+	if _, err := Setup(Options{Format: "xml", Output: &bytes.Buffer{}}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if _, err := Setup(Options{Level: "verbose", Output: &bytes.Buffer{}}); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const prettyTimeLayout = "2006-01-02 15:04:05.000"
+
+type logField struct {
+	key   string
+	value string
+}
+
+// FormatLine renders a JSON or text (logfmt) record for humans as
+// "time LEVEL message key=value...". Other lines, such as output written
+// before structured logging, are returned unchanged.
+func FormatLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	var fields []logField
+	var ok bool
+	if strings.HasPrefix(trimmed, "{") {
+		fields, ok = parseJSONRecord(trimmed)
+	} else {
+		fields, ok = parseLogfmt(trimmed)
+	}
+	if !ok {
+		return line
+	}
+
+	var ts, level, msg string
+	var hasLevel, hasMsg bool
+	attrs := make([]logField, 0, len(fields))
+	for _, field := range fields {
+		switch field.key {
+		case "time":
+			ts = field.value
+		case "level":
+			level, hasLevel = field.value, true
+		case "msg":
+			msg, hasMsg = field.value, true
+		default:
+			attrs = append(attrs, field)
+		}
+	}
+	if !hasLevel || !hasMsg {
+		return line
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		ts = parsed.Local().Format(prettyTimeLayout)
+	}
+
+	var b strings.Builder
+	if ts != "" {
+		b.WriteString(ts)
+		b.WriteByte(' ')
+	}
+	b.WriteString(padRight(level, 5))
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, attr := range attrs {
+		b.WriteByte(' ')
+		b.WriteString(attr.key)
+		b.WriteByte('=')
+		b.WriteString(quoteValue(attr.value))
+	}
+	return b.String()
+}
+
+// parseJSONRecord returns the top-level fields of a JSON object in order.
+// Nested values are kept as raw JSON.
+func parseJSONRecord(line string) ([]logField, bool) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	var fields []logField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, false
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		value := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			value = s
+		}
+		fields = append(fields, logField{key: key, value: value})
+	}
+	return fields, true
+}
+
+// parseLogfmt parses the key=value pairs written by slog's text handler.
+func parseLogfmt(line string) ([]logField, bool) {
+	var fields []logField
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+		eq := strings.IndexByte(line[i:], '=')
+		if eq <= 0 {
+			return nil, false
+		}
+		key := line[i : i+eq]
+		if strings.ContainsAny(key, " \"") {
+			return nil, false
+		}
+		i += eq + 1
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, false
+			}
+			value = unquoted
+			i = end + 1
+		} else {
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			value = line[i : i+end]
+			i += end
+		}
+		fields = append(fields, logField{key: key, value: value})
+	}
+	return fields, len(fields) > 0
+}
+
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
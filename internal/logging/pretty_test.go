@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatLine(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 890000000, time.UTC)
+	local := ts.Local().Format(prettyTimeLayout)
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "json",
+			line: `{"time":"2026-03-04T05:06:07.89Z","level":"WARN","msg":"Embedding batch failed, retrying","batch":3,"err":"rate limited (429)"}`,
+			want: local + ` WARN  Embedding batch failed, retrying batch=3 err="rate limited (429)"`,
+		},
+		{
+			name: "text",
+			line: `time=2026-03-04T05:06:07.890Z level=INFO msg="Indexed file" path=cli/watch.go chunks=12`,
+			want: local + ` INFO  Indexed file path=cli/watch.go chunks=12`,
+		},
+		{
+			name: "legacy",
+			line: "[grepai-watch] 2026/03/04 05:06:07.890000 Watching for changes...",
+			want: "[grepai-watch] 2026/03/04 05:06:07.890000 Watching for changes...",
+		},
+		{
+			name: "json without level",
+			line: `{"status":"ok"}`,
+			want: `{"status":"ok"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatLine(tt.line); got != tt.want {
+				t.Fatalf("FormatLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
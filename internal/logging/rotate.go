@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingWriter appends to a log file and rotates it once it would grow
+// past a maximum size: path becomes path.1, path.1 becomes path.2 and so on,
+// keeping at most a given number of rotated files.
+type RotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// OpenRotating opens path for appending. A maxSize of 0 disables rotation;
+// with maxBackups 0 the file is truncated instead of rotated.
+func OpenRotating(path string, maxSize int64, maxBackups int) (*RotatingWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	w := &RotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating it first if needed.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	w.file = nil
+
+	// Renaming fails on some platforms while another process has the file
+	// open (the redirected output of a background watcher): the file then
+	// keeps growing until the next attempt.
+	if w.maxBackups > 0 {
+		_ = os.Remove(BackupPath(w.path, w.maxBackups))
+		for i := w.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(BackupPath(w.path, i), BackupPath(w.path, i+1))
+		}
+		_ = os.Rename(w.path, BackupPath(w.path, 1))
+	} else {
+		_ = os.Truncate(w.path, 0)
+	}
+	return w.open()
+}
+
+// Close closes the log file.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// BackupPath returns the path of the n-th rotated file of a log file.
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriter_RotatesAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grepai-watch.log")
+	w, err := OpenRotating(path, 20, 2)
+	if err != nil {
+		t.Fatalf("OpenRotating failed: %v", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		path:                "fourth line\n",
+		BackupPath(path, 1): "third line\n",
+		BackupPath(path, 2): "second line\n",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+		}
+	}
+	if _, err := os.Stat(BackupPath(path, 3)); !os.IsNotExist(err) {
+		t.Fatalf("expected only 2 backups, stat err = %v", err)
+	}
+}
+
+func TestRotatingWriter_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grepai-watch.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 15)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := OpenRotating(path, 20, 1)
+	if err != nil {
+		t.Fatalf("OpenRotating failed: %v", err)
+	}
+	if _, err := w.Write([]byte("new record\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	w.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new record\n" {
+		t.Fatalf("expected the existing content to count toward the size, got %q", data)
+	}
+}
+
+func TestRotatingWriter_NoBackupsTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grepai-watch.log")
+	w, err := OpenRotating(path, 10, 0)
+	if err != nil {
+		t.Fatalf("OpenRotating failed: %v", err)
+	}
+	defer w.Close()
+	_, _ = w.Write([]byte("12345678\n"))
+	_, _ = w.Write([]byte("abc\n"))
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "abc\n" {
+		t.Fatalf("expected truncated log, got %q", data)
+	}
+	if _, err := os.Stat(BackupPath(path, 1)); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup, stat err = %v", err)
+	}
+}
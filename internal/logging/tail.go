@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	tailChunkSize      = 64 * 1024
+	followPollInterval = 500 * time.Millisecond
+)
+
+// TailLines returns the last n lines of a file and the file size, which is
+// the offset to Follow it from.
+func TailLines(path string, n int) ([]string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+	if n <= 0 {
+		return nil, size, nil
+	}
+
+	// Read backwards until the data holds n complete lines
+	var data []byte
+	offset := size
+	for offset > 0 && bytes.Count(data, []byte{'\n'}) <= n {
+		chunk := int64(tailChunkSize)
+		if chunk > offset {
+			chunk = offset
+		}
+		offset -= chunk
+		buf := make([]byte, chunk)
+		if _, err := file.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		data = append(buf, data...)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, size, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, size, nil
+}
+
+// Follow calls fn for every line appended to path after offset until ctx is
+// done. It reopens the file when it is rotated and starts over when it is
+// truncated.
+func Follow(ctx context.Context, path string, offset int64, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var pending []byte
+	buf := make([]byte, tailChunkSize)
+	drain := func() error {
+		for {
+			n, err := file.Read(buf)
+			if n > 0 {
+				offset += int64(n)
+				pending = append(pending, buf[:n]...)
+				for {
+					newline := bytes.IndexByte(pending, '\n')
+					if newline < 0 {
+						break
+					}
+					fn(string(pending[:newline]))
+					pending = pending[newline+1:]
+				}
+			}
+			if errors.Is(err, io.EOF) || n == 0 {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := drain(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		latest, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// Between the rename and the creation of the new file
+			continue
+		case !os.SameFile(current, latest):
+			if err := drain(); err != nil {
+				return err
+			}
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file, offset, pending = reopened, 0, nil
+		case latest.Size() < offset:
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset, pending = 0, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grepai-watch.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lines, offset, err := TailLines(path, 2)
+	if err != nil {
+		t.Fatalf("TailLines failed: %v", err)
+	}
+	if strings.Join(lines, ",") != "three,four" || offset != 19 {
+		t.Fatalf("TailLines = %q, %d", lines, offset)
+	}
+	lines, _, _ = TailLines(path, 10)
+	if len(lines) != 4 {
+		t.Fatalf("expected all 4 lines, got %q", lines)
+	}
+}
+
+func TestFollow_ReadsAppendedLinesAcrossRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grepai-watch.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, 4, func(line string) {
+			mu.Lock()
+			got = append(got, line)
+			mu.Unlock()
+		})
+	}()
+
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			count := len(got)
+			mu.Unlock()
+			if count >= n {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %d lines, got %q", n, got)
+	}
+
+	w, err := OpenRotating(path, 16, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, _ = w.Write([]byte("appended\n"))
+	waitFor(1)
+	_, _ = w.Write([]byte("rotated\n"))
+	waitFor(2)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+	if strings.Join(got, ",") != "appended,rotated" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
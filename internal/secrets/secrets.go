@@ -0,0 +1,162 @@
+// Package secrets finds and redacts credentials in text.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Placeholder replaces redacted credentials.
+const Placeholder = "[REDACTED]"
+
+// Finding is a credential found in a text.
+type Finding struct {
+	Kind  string // e.g. "aws_access_key", "private_key"
+	Start int    // byte offset of the credential
+	End   int
+}
+
+// rule matches a kind of credential. When group is non-zero only that
+// submatch is the credential, e.g. the value of a "password = ..." pair.
+// When check is set, matches it rejects are not credentials.
+type rule struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+	check func(value string) bool
+}
+
+// minEntropy is the Shannon entropy, in bits per character, above which a
+// random-looking literal is taken for a credential.
+const minEntropy = 4.0
+
+var rules = []rule{
+	{"private_key", regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY( BLOCK)?-----`), 0, nil},
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`), 0, nil},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), 1, nil},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 0, nil},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0, nil},
+	{"stripe_key", regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}`), 0, nil},
+	{"api_key", regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`), 0, nil},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), 0, nil},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), 0, nil},
+	{"connection_string", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s:/@"']+:([^\s@/"']{3,})@`), 1, nil},
+	// KEY=value lines of .env, .ini and YAML files
+	{"assignment", regexp.MustCompile(`(?im)^[ \t]*(?:export[ \t]+)?[A-Z0-9_.-]*(?:password|passwd|pwd|secret|token|api_?key|access_?key|private_?key|credentials?)[A-Z0-9_.-]*[ \t]*[:=][ \t]*["']?([^\s"'#]{6,})`), 1, nil},
+	// String literals assigned to credential-like names in code
+	{"assignment", regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api_?key|access_?key|private_?key)\w*["']?[ \t]*(?::=|=|:)[ \t]*["']([^"'\s]{8,})["']`), 1, nil},
+	// Random-looking string literals and KEY=value values
+	{"high_entropy", regexp.MustCompile(`(?m)(?:["'\x60]|^[ \t]*(?:export[ \t]+)?[A-Za-z0-9_.-]+[ \t]*=[ \t]*)([A-Za-z0-9+/_-]{24,}={0,2})(?:["'\x60]|[ \t]*$)`), 1, isHighEntropy},
+}
+
+// Find returns the credentials found in text, ordered by offset and without
+// overlaps.
+func Find(text string) []Finding {
+	var findings []Finding
+	for _, r := range rules {
+		for _, m := range r.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*r.group], m[2*r.group+1]
+			if start < 0 || isPlaceholderValue(text[start:end]) {
+				continue
+			}
+			if r.check != nil && !r.check(text[start:end]) {
+				continue
+			}
+			findings = append(findings, Finding{Kind: r.kind, Start: start, End: end})
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Start != findings[j].Start {
+			return findings[i].Start < findings[j].Start
+		}
+		return findings[i].End > findings[j].End
+	})
+	merged := findings[:1]
+	for _, f := range findings[1:] {
+		last := &merged[len(merged)-1]
+		if f.Start < last.End {
+			last.End = max(last.End, f.End)
+			continue
+		}
+		merged = append(merged, f)
+	}
+	return merged
+}
+
+// Redact replaces the credentials found in text with Placeholder, keeping
+// the line breaks of multi-line credentials so that line numbers don't
+// change. It returns the redacted text and the number of credentials
+// replaced.
+func Redact(text string) (string, int) {
+	findings := Find(text)
+	if len(findings) == 0 {
+		return text, 0
+	}
+	var buf strings.Builder
+	prev := 0
+	for _, f := range findings {
+		buf.WriteString(text[prev:f.Start])
+		buf.WriteString(Placeholder)
+		buf.WriteString(strings.Repeat("\n", strings.Count(text[f.Start:f.End], "\n")))
+		prev = f.End
+	}
+	buf.WriteString(text[prev:])
+	return buf.String(), len(findings)
+}
+
+// isPlaceholderValue reports whether the value of an assignment refers to
+// a credential instead of containing one, e.g. "${DB_PASSWORD}".
+func isPlaceholderValue(value string) bool {
+	if value == Placeholder {
+		return true
+	}
+	switch value[0] {
+	case '$', '<', '{', '%':
+		return true
+	}
+	return strings.Trim(value, "*xX.") == ""
+}
+
+// isHighEntropy reports whether value looks random: its entropy is high and
+// digits are mixed with letters, unlike identifiers such as "NewStore2024".
+func isHighEntropy(value string) bool {
+	digits, lastLetter, firstDigit := 0, -1, -1
+	for i, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+			if firstDigit < 0 {
+				firstDigit = i
+			}
+		case unicode.IsLetter(r):
+			lastLetter = i
+		}
+	}
+	if digits < 2 || firstDigit > lastLetter {
+		return false
+	}
+	return entropy(value) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
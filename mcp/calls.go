@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultToolTimeout is the time a tool call may take unless the server is
+// configured otherwise with SetToolTimeouts.
+const DefaultToolTimeout = 2 * time.Minute
+
+// toolStopGracePeriod is how long a stopped tool call waits for its handler
+// to return before answering the client without it.
+var toolStopGracePeriod = 5 * time.Second
+
+const (
+	cancelledNotification = "notifications/cancelled"
+	progressNotification  = "notifications/progress"
+
+	// requestIDMeta is the _meta field where the JSON-RPC ID of a tool call
+	// is kept for the tool handler middlewares, which don't receive it.
+	requestIDMeta = "grepai/requestId"
+)
+
+// toolCalls tracks the tool calls in progress, so that the
+// notifications/cancelled sent by clients cancel them.
+type toolCalls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newToolCalls() *toolCalls {
+	return &toolCalls{cancels: make(map[string]context.CancelFunc)}
+}
+
+func (c *toolCalls) add(key string, cancel context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancels[key] = cancel
+}
+
+func (c *toolCalls) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cancels, key)
+}
+
+// cancel cancels the call registered under key, if it is still running.
+func (c *toolCalls) cancel(key string) bool {
+	c.mu.Lock()
+	cancel, ok := c.cancels[key]
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// toolCallKey identifies a request of the client session of ctx. Request IDs
+// are only unique within a session.
+func toolCallKey(ctx context.Context, id mcp.RequestId) string {
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return sessionID + "/" + id.String()
+}
+
+// SetToolTimeouts sets the time tool calls may take before they are
+// cancelled: timeout for every tool, overridden by perTool for the tools it
+// names. A zero timeout disables the limit.
+func (s *Server) SetToolTimeouts(timeout time.Duration, perTool map[string]time.Duration) {
+	s.toolTimeout = timeout
+	s.toolTimeouts = perTool
+}
+
+// toolTimeoutFor returns the time calls to the tool called name may take.
+func (s *Server) toolTimeoutFor(name string) time.Duration {
+	if timeout, ok := s.toolTimeouts[name]; ok {
+		return timeout
+	}
+	return s.toolTimeout
+}
+
+// newToolCallHooks returns the hooks keeping the JSON-RPC ID of tool calls
+// for limitToolCall.
+func newToolCallHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(func(_ context.Context, id any, request *mcp.CallToolRequest) {
+		if id == nil {
+			return
+		}
+		if request.Params.Meta == nil {
+			request.Params.Meta = &mcp.Meta{}
+		}
+		if request.Params.Meta.AdditionalFields == nil {
+			request.Params.Meta.AdditionalFields = make(map[string]any)
+		}
+		request.Params.Meta.AdditionalFields[requestIDMeta] = mcp.NewRequestId(id)
+	})
+	return hooks
+}
+
+// handleCancelled cancels the tool call named by a notifications/cancelled
+// sent by the client.
+func (s *Server) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	requestID, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	s.calls.cancel(toolCallKey(ctx, mcp.NewRequestId(requestID)))
+}
+
+// limitToolCall wraps every tool handler: the call is cancelled when the
+// client cancels it or when it takes longer than the tool's timeout. The
+// handler stops at its next cancellation check, which is awaited for
+// toolStopGracePeriod; handlers still running after that are logged.
+func (s *Server) limitToolCall(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if request.Params.Meta != nil {
+			if requestID, ok := request.Params.Meta.AdditionalFields[requestIDMeta].(mcp.RequestId); ok {
+				key := toolCallKey(ctx, requestID)
+				s.calls.add(key, cancel)
+				defer s.calls.remove(key)
+			}
+		}
+		timeout := s.toolTimeoutFor(request.Params.Name)
+		if timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+			defer cancelTimeout()
+		}
+
+		type outcome struct {
+			result *mcp.CallToolResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := next(ctx, request)
+			done <- outcome{result, err}
+		}()
+
+		select {
+		case o := <-done:
+			// Handlers report failures as error results; a cancelled
+			// call is reported as such rather than as the error it caused.
+			if ctx.Err() != nil && (o.err != nil || o.result == nil || o.result.IsError) {
+				return toolCallStopped(ctx, request.Params.Name, timeout), nil
+			}
+			return o.result, o.err
+		case <-ctx.Done():
+			stopped := time.Now()
+			grace := time.NewTimer(toolStopGracePeriod)
+			defer grace.Stop()
+			select {
+			case <-done:
+			case <-grace.C:
+				log.Printf("Warning: tool %s still running %s after it was stopped", request.Params.Name, toolStopGracePeriod)
+				go func() {
+					<-done
+					log.Printf("Tool %s returned %s after it was stopped", request.Params.Name, time.Since(stopped).Round(time.Millisecond))
+				}()
+			}
+			return toolCallStopped(ctx, request.Params.Name, timeout), nil
+		}
+	}
+}
+
+// toolCallStopped returns the result of a tool call stopped by ctx.
+func toolCallStopped(ctx context.Context, name string, timeout time.Duration) *mcp.CallToolResult {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s timed out after %s; narrow the request or raise the timeout with --tool-timeout", name, timeout))
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("%s was cancelled", name))
+}
+
+// progress sends notifications/progress for a tool call whose client asked
+// for them with a progress token. It sends nothing otherwise.
+type progress struct {
+	ctx   context.Context
+	srv   *server.MCPServer
+	token mcp.ProgressToken
+	total int
+}
+
+// newProgress returns the progress of a tool call done in total steps.
+func newProgress(ctx context.Context, request mcp.CallToolRequest, total int) *progress {
+	p := &progress{ctx: ctx, total: total}
+	if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+		p.token = request.Params.Meta.ProgressToken
+		p.srv = server.ServerFromContext(ctx)
+	}
+	return p
+}
+
+// setTotal changes the number of steps once it is known.
+func (p *progress) setTotal(total int) {
+	p.total = total
+}
+
+// report tells the client that done steps are complete and what the call is
+// doing now.
+func (p *progress) report(done int, message string) {
+	if p.srv == nil {
+		return
+	}
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      done,
+		"message":       message,
+	}
+	if p.total > 0 {
+		params["total"] = p.total
+	}
+	// Clients without a session, or gone, simply get no progress
+	_ = p.srv.SendNotificationToClient(p.ctx, progressNotification, params)
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// blockingTool returns a tool handler that blocks until its call is
+// cancelled, after signalling started.
+func blockingTool(started chan<- struct{}) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return mcp.NewToolResultError(ctx.Err().Error()), nil
+	}
+}
+
+func toolResultText(result *mcp.CallToolResult) string {
+	var text strings.Builder
+	for _, content := range result.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			text.WriteString(tc.Text)
+		}
+	}
+	return text.String()
+}
+
+func TestLimitToolCall_Timeout(t *testing.T) {
+	s := &Server{calls: newToolCalls()}
+	s.SetToolTimeouts(time.Hour, map[string]time.Duration{"grepai_trace_graph": 10 * time.Millisecond})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "grepai_trace_graph"
+	result, err := s.limitToolCall(blockingTool(make(chan struct{})))(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(toolResultText(result), "timed out after 10ms") {
+		t.Fatalf("expected a timeout error, got %s", toolResultText(result))
+	}
+}
+
+func TestLimitToolCall_CancelledByClient(t *testing.T) {
+	s, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	s.mcpServer.AddTool(mcp.NewTool("slow_tool"), blockingTool(started))
+
+	replies := make(chan json.RawMessage, 1)
+	go func() {
+		replies <- policyTestCall(t, s, "tools/call", map[string]any{"name": "slow_tool"})
+	}()
+	<-started
+	cancelled, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  cancelledNotification,
+		"params":  map[string]any{"requestId": 1, "reason": "user aborted"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mcpServer.HandleMessage(context.Background(), cancelled)
+
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(<-replies, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || toolResultText(&result) != "slow_tool was cancelled" {
+		t.Fatalf("expected a cancelled error, got %s", toolResultText(&result))
+	}
+	if len(s.calls.cancels) != 0 {
+		t.Fatalf("expected the call to be unregistered, got %v", s.calls.cancels)
+	}
+}
+
+func TestLimitToolCall_WaitsForStoppedHandler(t *testing.T) {
+	s := &Server{calls: newToolCalls()}
+	s.SetToolTimeouts(10*time.Millisecond, nil)
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "grepai_search"
+
+	// A handler finishing its cleanup within the grace period is awaited
+	var returned atomic.Bool
+	result, err := s.limitToolCall(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		returned.Store(true)
+		return nil, ctx.Err()
+	})(context.Background(), request)
+	if err != nil || !result.IsError {
+		t.Fatalf("expected a timeout error, got %+v, %v", result, err)
+	}
+	if !returned.Load() {
+		t.Fatal("expected the stopped handler to be awaited")
+	}
+
+	// A handler ignoring cancellation is not awaited past the grace period
+	defer func(grace time.Duration) { toolStopGracePeriod = grace }(toolStopGracePeriod)
+	toolStopGracePeriod = 10 * time.Millisecond
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	release := make(chan struct{})
+	defer close(release)
+	start := time.Now()
+	result, err = s.limitToolCall(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-release
+		return nil, nil
+	})(context.Background(), request)
+	if err != nil || !result.IsError {
+		t.Fatalf("expected a timeout error, got %+v, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the call to return after the grace period, took %s", elapsed)
+	}
+	if !strings.Contains(logs.String(), "grepai_search still running") {
+		t.Errorf("expected the overrunning handler to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/search"
+)
+
+func TestHandleContext_BundlesSearchHits(t *testing.T) {
+	embedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	defer embedServer.Close()
+
+	projectRoot := seedResourceProject(t)
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Embedder.Endpoint = embedServer.URL
+	if err := cfg.Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.handleContext(context.Background(), refsTestRequest(map[string]any{"query": "login"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := textResultPayload(t, result)
+	if result.IsError {
+		t.Fatalf("grepai_context failed: %s", payload)
+	}
+	var bundle search.ContextBundle
+	if err := json.Unmarshal([]byte(payload), &bundle); err != nil {
+		t.Fatalf("unexpected output %q: %v", payload, err)
+	}
+	if len(bundle.Items) != 1 || bundle.Items[0].Symbol != "Login" || bundle.Items[0].FilePath != "src/my_auth/login.go" {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	if bundle.Tokens == 0 || bundle.Tokens > bundle.MaxTokens {
+		t.Fatalf("unexpected token accounting %+v", bundle)
+	}
+
+	result, err = s.handleContext(context.Background(), refsTestRequest(map[string]any{"query": "login", "format": "xml"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Fatalf("expected an invalid format to fail, got %s", textResultPayload(t, result))
+	}
+}
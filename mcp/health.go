@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// maxHealthFilesListed bounds the files listed by IndexHealth; the counts
+// cover them all.
+const maxHealthFilesListed = 20
+
+// Issues of the files listed by IndexHealth.
+const (
+	healthModified      = "modified"       // changed on disk since it was indexed
+	healthDeleted       = "deleted"        // removed from disk, still indexed
+	healthFailed        = "failed"         // could not be indexed
+	healthSymbolsBehind = "symbols_behind" // symbol index older than the vector index
+	healthRPGBehind     = "rpg_behind"     // RPG graph older than the vector index
+)
+
+// IndexHealth reports how far the indexes are behind the working tree.
+// grepai_reindex brings the files it lists up to date.
+type IndexHealth struct {
+	StaleFiles       int          `json:"stale_files"`
+	FailedFiles      int          `json:"failed_files"`
+	SymbolLag        int          `json:"symbol_lag"`
+	SymbolLagUnknown bool         `json:"symbol_lag_unknown,omitempty"` // No symbol index, or one without content hashes
+	RPGLag           int          `json:"rpg_lag,omitempty"`
+	RPGOutdated      bool         `json:"rpg_outdated,omitempty"`
+	Files            []FileHealth `json:"files,omitempty"`
+	Truncated        bool         `json:"truncated,omitempty"`
+}
+
+// FileHealth is a file listed by IndexHealth.
+type FileHealth struct {
+	Path   string `json:"path"`
+	Issue  string `json:"issue"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// fileContentHasher is implemented by symbol stores that record the content
+// hash of the files they index.
+type fileContentHasher interface {
+	GetFileContentHash(filePath string) (string, bool)
+}
+
+// symbolContentHasher returns the content hashes recorded by symbols, or nil
+// when it records none.
+func symbolContentHasher(symbols trace.SymbolStore) fileContentHasher {
+	if shared, ok := symbols.(sharedSymbolStore); ok {
+		symbols = shared.SymbolStore
+	}
+	hasher, _ := symbols.(fileContentHasher)
+	return hasher
+}
+
+// indexHealth compares the vector index of projectRoot with the files on
+// disk, and the symbol index and RPG graph with the vector index. Files are
+// hashed only when their modification time changed. symbols and rpgGraph
+// may be nil; without the content hashes of symbols the symbol lag is
+// reported unknown.
+func indexHealth(ctx context.Context, projectRoot string, cfg *config.Config, st store.VectorStore, symbols trace.SymbolStore, rpgGraph *rpg.Graph) (*IndexHealth, error) {
+	health := &IndexHealth{}
+	add := func(path, issue, detail string) {
+		if len(health.Files) == maxHealthFilesListed {
+			health.Truncated = true
+			return
+		}
+		health.Files = append(health.Files, FileHealth{Path: path, Issue: issue, Detail: detail})
+	}
+
+	// Failed files first: they need attention, the others a reindex
+	report, err := indexer.LoadScanReport(config.GetScanReportPath(projectRoot))
+	if err != nil {
+		return nil, err
+	}
+	failed := make([]string, 0, len(report.Failed))
+	for path := range report.Failed {
+		failed = append(failed, path)
+	}
+	sort.Strings(failed)
+	health.FailedFiles = len(failed)
+	for _, path := range failed {
+		add(path, healthFailed, report.Failed[path])
+	}
+
+	// One listing rather than a lookup per file, which is a round trip on
+	// the postgres and qdrant backends
+	files, err := st.ListFilesWithStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	traced := cfg.Trace.TracedLanguages()
+	hasher := symbolContentHasher(symbols)
+	health.SymbolLagUnknown = hasher == nil
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		path := file.Path
+
+		info, err := os.Stat(filepath.Join(projectRoot, path))
+		switch {
+		case err != nil:
+			health.StaleFiles++
+			add(path, healthDeleted, "")
+			continue
+		case info.ModTime().Unix() != file.ModTime.Unix():
+			if hash, err := indexer.HashFile(filepath.Join(projectRoot, path)); err == nil && hash != file.Hash {
+				health.StaleFiles++
+				add(path, healthModified, "")
+				continue
+			}
+		}
+
+		if !isTracedFile(path, traced) {
+			continue
+		}
+		if hasher != nil {
+			if hash, ok := hasher.GetFileContentHash(path); !ok || hash != file.Hash {
+				health.SymbolLag++
+				add(path, healthSymbolsBehind, "")
+			}
+		}
+		if rpgGraph != nil {
+			node := rpgGraph.GetNode(rpg.MakeNodeID(rpg.KindFile, path))
+			if node == nil || node.UpdatedAt.Before(file.ModTime) {
+				health.RPGLag++
+				add(path, healthRPGBehind, "")
+			}
+		}
+	}
+	return health, nil
+}
+
+// isTracedFile reports whether symbols are extracted from path.
+func isTracedFile(path string, traced []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, lang := range traced {
+		if lang == ext {
+			return true
+		}
+	}
+	return false
+}
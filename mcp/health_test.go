@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+func TestIndexHealth_ReportsStaleFailedAndLaggingFiles(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+	cfg := config.DefaultConfig()
+
+	write := func(rel, content string) (string, time.Time) {
+		t.Helper()
+		path := filepath.Join(projectRoot, rel)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := indexer.HashFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash, time.Unix(info.ModTime().Unix(), 0)
+	}
+	upToDateHash, upToDateTime := write("fresh.go", "package fresh\n")
+	laggingHash, laggingTime := write("lagging.go", "package lagging\n")
+	_, editedTime := write("edited.go", "package edited\n")
+	touchedHash, _ := write("touched.md", "# Notes\n")
+
+	st := store.NewGOBStore(filepath.Join(projectRoot, "index.gob"))
+	for _, doc := range []store.Document{
+		{Path: "fresh.go", Hash: upToDateHash, ModTime: upToDateTime},
+		{Path: "lagging.go", Hash: laggingHash, ModTime: laggingTime},
+		{Path: "edited.go", Hash: "before-edit", ModTime: editedTime.Add(-time.Minute)},
+		{Path: "touched.md", Hash: touchedHash, ModTime: time.Unix(1700000000, 0)}, // same content, new mtime
+		{Path: "gone.go", Hash: "gone", ModTime: upToDateTime},
+	} {
+		if err := st.SaveDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symbols := trace.NewGOBSymbolStore(filepath.Join(projectRoot, "symbols.gob"))
+	if err := symbols.SaveFileWithContentHash(ctx, "fresh.go", upToDateHash, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbols.SaveFileWithContentHash(ctx, "lagging.go", "older", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	graph := rpg.NewGraph()
+	graph.AddNode(&rpg.Node{ID: rpg.MakeNodeID(rpg.KindFile, "fresh.go"), Kind: rpg.KindFile, Path: "fresh.go", UpdatedAt: upToDateTime.Add(time.Second)})
+	graph.AddNode(&rpg.Node{ID: rpg.MakeNodeID(rpg.KindFile, "lagging.go"), Kind: rpg.KindFile, Path: "lagging.go", UpdatedAt: laggingTime.Add(-time.Hour)})
+
+	report := indexer.NewScanReport()
+	report.Failed = map[string]string{"broken.go": "failed to embed chunks: provider unavailable"}
+	if err := indexer.SaveScanReport(config.GetScanReportPath(projectRoot), report); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := indexHealth(ctx, projectRoot, cfg, st, symbols, graph)
+	if err != nil {
+		t.Fatalf("indexHealth failed: %v", err)
+	}
+	if health.StaleFiles != 2 || health.FailedFiles != 1 || health.SymbolLag != 1 || health.RPGLag != 1 {
+		t.Fatalf("unexpected counts: %+v", health)
+	}
+	want := []FileHealth{
+		{Path: "broken.go", Issue: healthFailed, Detail: "failed to embed chunks: provider unavailable"},
+		{Path: "edited.go", Issue: healthModified},
+		{Path: "gone.go", Issue: healthDeleted},
+		{Path: "lagging.go", Issue: healthSymbolsBehind},
+		{Path: "lagging.go", Issue: healthRPGBehind},
+	}
+	if len(health.Files) != len(want) {
+		t.Fatalf("expected files %+v, got %+v", want, health.Files)
+	}
+	for i := range want {
+		if health.Files[i] != want[i] {
+			t.Errorf("file %d: expected %+v, got %+v", i, want[i], health.Files[i])
+		}
+	}
+}
+
+func TestHandleIndexStatus_IncludesHealth(t *testing.T) {
+	s, err := NewServer(seedResourceProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.handleIndexStatus(context.Background(), refsTestRequest(map[string]any{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := textResultPayload(t, result)
+	var status IndexStatus
+	if err := json.Unmarshal([]byte(payload), &status); err != nil {
+		t.Fatalf("unexpected output %q: %v", payload, err)
+	}
+	// The seeded document hash never matched the file on disk
+	if status.Health == nil || status.Health.StaleFiles != 1 || len(status.Health.Files) != 1 || status.Health.Files[0].Path != "src/my_auth/login.go" {
+		t.Fatalf("expected login.go to be reported stale, got %s", payload)
+	}
+}
+
+func TestIndexHealth_SymbolLagUnknownWithoutContentHashes(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+	path := filepath.Join(projectRoot, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := indexer.HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewGOBStore(filepath.Join(projectRoot, "index.gob"))
+	if err := st.SaveDocument(ctx, store.Document{Path: "main.go", Hash: hash, ModTime: info.ModTime()}); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := indexHealth(ctx, projectRoot, config.DefaultConfig(), st, nil, nil)
+	if err != nil {
+		t.Fatalf("indexHealth failed: %v", err)
+	}
+	if !health.SymbolLagUnknown || health.SymbolLag != 0 {
+		t.Fatalf("expected an unknown symbol lag without a symbol index, got %+v", health)
+	}
+
+	// The shared stores of the MCP server keep the content hashes
+	symbols := trace.NewGOBSymbolStore(filepath.Join(projectRoot, "symbols.gob"))
+	shared := sharedSymbolStore{symbols, func() {}}
+	health, err = indexHealth(ctx, projectRoot, config.DefaultConfig(), st, shared, nil)
+	if err != nil {
+		t.Fatalf("indexHealth failed: %v", err)
+	}
+	if health.SymbolLagUnknown || health.SymbolLag != 1 {
+		t.Fatalf("expected main.go to lag in the symbol index, got %+v", health)
+	}
+}
+
+// listOnlyStore fails lookups of single documents, as indexHealth must list
+// them in one pass.
+type listOnlyStore struct {
+	*store.GOBStore
+}
+
+func (s listOnlyStore) GetDocument(ctx context.Context, filePath string) (*store.Document, error) {
+	return nil, errors.New("unexpected document lookup")
+}
+
+func TestIndexHealth_ListsDocumentsOnceAndCapsFiles(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+	st := store.NewGOBStore(filepath.Join(projectRoot, "index.gob"))
+	deleted := maxHealthFilesListed + 10
+	for i := 0; i < deleted; i++ {
+		if err := st.SaveDocument(ctx, store.Document{Path: fmt.Sprintf("gone%02d.go", i), Hash: "gone"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := indexer.NewScanReport()
+	report.Failed = map[string]string{"broken.go": "failed to embed chunks"}
+	if err := indexer.SaveScanReport(config.GetScanReportPath(projectRoot), report); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := indexHealth(ctx, projectRoot, config.DefaultConfig(), listOnlyStore{st}, nil, nil)
+	if err != nil {
+		t.Fatalf("indexHealth failed: %v", err)
+	}
+	if health.StaleFiles != deleted || health.FailedFiles != 1 {
+		t.Fatalf("expected every file counted, got %+v", health)
+	}
+	if len(health.Files) != maxHealthFilesListed || !health.Truncated {
+		t.Fatalf("expected %d files listed and truncated, got %d (truncated=%v)", maxHealthFilesListed, len(health.Files), health.Truncated)
+	}
+	if health.Files[0].Issue != healthFailed {
+		t.Errorf("expected the failed file listed first, got %+v", health.Files[0])
+	}
+}
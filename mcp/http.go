@@ -0,0 +1,261 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// HTTP endpoints of the MCP server.
+const (
+	HTTPEndpoint       = "/mcp"     // Streamable HTTP transport
+	SSEEndpoint        = "/sse"     // Legacy SSE transport: event stream
+	SSEMessageEndpoint = "/message" // Legacy SSE transport: client messages
+)
+
+// HTTPOptions configures the HTTP transport.
+type HTTPOptions struct {
+	// Addr is the host:port to listen on.
+	Addr string
+	// AuthToken, when set, must be sent by clients as a bearer token.
+	AuthToken string
+	// AllowedOrigins lists the browser origins, besides loopback ones, that
+	// may send requests, e.g. "https://app.example.com".
+	AllowedOrigins []string
+}
+
+// HTTPHandler returns a handler serving the streamable HTTP transport on
+// HTTPEndpoint and the legacy SSE transport on SSEEndpoint and
+// SSEMessageEndpoint. Indexes are loaded once and shared by all clients.
+// opts.Addr is the address the server is bound to: requests for another host
+// are rejected, as are browser requests from origins that are neither
+// loopback nor in opts.AllowedOrigins.
+func (s *Server) HTTPHandler(opts HTTPOptions) http.Handler {
+	streamable := server.NewStreamableHTTPServer(s.mcpServer, server.WithEndpointPath(HTTPEndpoint))
+	sse := server.NewSSEServer(s.mcpServer,
+		server.WithSSEEndpoint(SSEEndpoint),
+		server.WithMessageEndpoint(SSEMessageEndpoint),
+		server.WithUseFullURLForMessageEndpoint(false),
+		server.WithKeepAlive(true),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(HTTPEndpoint, streamable)
+	mux.Handle(SSEEndpoint, sse.SSEHandler())
+	mux.Handle(SSEMessageEndpoint, sse.MessageHandler())
+
+	var handler http.Handler = mux
+	handler = titleFixHandler(handler)
+	if opts.AuthToken != "" {
+		handler = bearerAuth(opts.AuthToken, handler)
+	}
+	return originGuard(opts.Addr, opts.AllowedOrigins, handler)
+}
+
+// ListenAndServeHTTP listens on opts.Addr and serves MCP clients over HTTP
+// until ctx is done.
+func (s *Server) ListenAndServeHTTP(ctx context.Context, opts HTTPOptions) error {
+	if err := validateHTTPAddr(opts.Addr, opts.AuthToken); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+	log.Printf("MCP server listening on http://%s%s (legacy SSE on %s)", listener.Addr(), HTTPEndpoint, SSEEndpoint)
+
+	// Check Host against the bound address, which has the actual port when
+	// opts.Addr asked for any
+	boundOpts := opts
+	boundOpts.Addr = listener.Addr().String()
+	httpServer := &http.Server{
+		Handler:           s.HTTPHandler(boundOpts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.watchResources(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		// Event streams stay open until clients leave; don't wait for them
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		_ = httpServer.Close()
+		return nil
+	}
+}
+
+// validateHTTPAddr refuses to expose the server beyond the loopback interface
+// without authentication.
+func validateHTTPAddr(addr, authToken string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if authToken != "" || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to serve on %q without an auth token; use a loopback address (e.g. 127.0.0.1:7777) or set a token", addr)
+}
+
+// bearerAuth rejects requests without "Authorization: Bearer <token>".
+func bearerAuth(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="grepai"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originGuard protects against DNS rebinding and cross-site requests from
+// browsers. It rejects requests whose Host is not addr, the bound address,
+// and requests carrying an Origin header that is neither a loopback origin
+// nor one of allowedOrigins. When addr is a loopback address, Host may name
+// any loopback host; when it is unspecified (e.g. 0.0.0.0), only the port is
+// checked.
+func originGuard(addr string, allowedOrigins []string, next http.Handler) http.Handler {
+	boundHost, boundPort, _ := net.SplitHostPort(addr)
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimSuffix(strings.ToLower(origin), "/")] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hostMatches(r.Host, boundHost, boundPort) {
+			http.Error(w, "invalid host", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !allowed[strings.ToLower(origin)] && !isLoopbackOrigin(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostMatches reports whether the Host header of a request names the bound
+// address boundHost:boundPort.
+func hostMatches(hostHeader, boundHost, boundPort string) bool {
+	host, port, err := net.SplitHostPort(hostHeader)
+	if err != nil || port != boundPort {
+		return false
+	}
+	if strings.EqualFold(host, boundHost) {
+		return true
+	}
+	if ip := net.ParseIP(boundHost); ip != nil && ip.IsUnspecified() {
+		return true
+	}
+	return isLoopbackHost(boundHost) && isLoopbackHost(host)
+}
+
+// isLoopbackOrigin reports whether origin, e.g. "http://localhost:3000", is
+// served from the local machine.
+func isLoopbackOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return isLoopbackHost(u.Hostname())
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// titleFixHandler applies the titleFixWriter fixes to JSON responses and to
+// the messages of event streams.
+func titleFixHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&titleFixResponseWriter{ResponseWriter: w}, r)
+	})
+}
+
+type titleFixResponseWriter struct {
+	http.ResponseWriter
+}
+
+// Write fixes tools/list and subscription responses. The transports write
+// each JSON response and each event in a single call.
+func (w *titleFixResponseWriter) Write(p []byte) (int, error) {
+	contentType := w.Header().Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		if fixed, ok := fixResponse(p); ok {
+			if _, err := w.ResponseWriter.Write(fixed); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		if fixed, ok := fixEventToolTitles(p); ok {
+			if _, err := w.ResponseWriter.Write(fixed); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *titleFixResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *titleFixResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// fixEventToolTitles fixes tools/list and subscription responses carried by
+// the data lines of server-sent events.
+func fixEventToolTitles(p []byte) ([]byte, bool) {
+	lines := bytes.Split(p, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		data, ok := bytes.CutPrefix(line, []byte("data: "))
+		if !ok {
+			continue
+		}
+		if fixed, ok := fixResponse(data); ok {
+			lines[i] = append([]byte("data: "), bytes.TrimSuffix(fixed, []byte("\n"))...)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, false
+	}
+	return bytes.Join(lines, []byte("\n")), true
+}
@@ -0,0 +1,286 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testInitialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+const testToolsList = `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+
+func postMCP(t *testing.T, url, token, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// assertToolsFixed checks that a tools/list response carries the fixes of
+// titleFixWriter.
+func assertToolsFixed(t *testing.T, body []byte) {
+	t.Helper()
+	var msg struct {
+		Result struct {
+			Tools []map[string]any `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("invalid tools/list response %q: %v", body, err)
+	}
+	if len(msg.Result.Tools) == 0 {
+		t.Fatalf("no tools in response: %s", body)
+	}
+	for _, tool := range msg.Result.Tools {
+		if annotations, ok := tool["annotations"].(map[string]any); ok {
+			if _, ok := annotations["title"]; ok {
+				t.Fatalf("tool %v still has its title in annotations", tool["name"])
+			}
+		}
+		schema, _ := tool["inputSchema"].(map[string]any)
+		if _, ok := schema["$schema"]; !ok {
+			t.Fatalf("tool %v has no $schema", tool["name"])
+		}
+	}
+}
+
+// startHTTPServer serves srv over HTTP with opts, bound to the test server
+// address.
+func startHTTPServer(t *testing.T, srv *Server, opts HTTPOptions) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(nil)
+	opts.Addr = ts.Listener.Addr().String()
+	ts.Config.Handler = srv.HTTPHandler(opts)
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestHTTPHandler_StreamableHTTP(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := startHTTPServer(t, srv, HTTPOptions{AuthToken: "secret"})
+	url := ts.URL + HTTPEndpoint
+
+	resp := postMCP(t, url, "", "", testInitialize)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+	resp = postMCP(t, url, "wrong", "", testInitialize)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong token, got %d", resp.StatusCode)
+	}
+
+	resp = postMCP(t, url, "secret", "", testInitialize)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize failed with status %d", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	resp = postMCP(t, url, "secret", sessionID, testToolsList)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("tools/list failed with status %d: %s", resp.StatusCode, body)
+	}
+	assertToolsFixed(t, body)
+}
+
+func TestHTTPHandler_LegacySSE(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := startHTTPServer(t, srv, HTTPOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+SSEEndpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+
+	events := make(chan string, 8)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(stream.Body)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- strings.TrimSpace(data)
+			}
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case data, ok := <-events:
+			if !ok {
+				t.Fatal("event stream closed")
+			}
+			return data
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for an event")
+		}
+		return ""
+	}
+
+	endpoint := next()
+	if !strings.HasPrefix(endpoint, SSEMessageEndpoint) {
+		t.Fatalf("unexpected endpoint event %q", endpoint)
+	}
+	for _, msg := range []string{testInitialize, testToolsList} {
+		resp := postMCP(t, ts.URL+endpoint, "", "", msg)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("message rejected with status %d", resp.StatusCode)
+		}
+		data := next()
+		if msg == testToolsList {
+			assertToolsFixed(t, []byte(data))
+		}
+	}
+}
+
+func TestFixEventToolTitles(t *testing.T) {
+	event := []byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":2,\"result\":{\"tools\":[{\"name\":\"x\",\"annotations\":{\"title\":\"X\"},\"inputSchema\":{}}]}}\n\n")
+	fixed, ok := fixEventToolTitles(event)
+	if !ok {
+		t.Fatal("expected the event to be fixed")
+	}
+	if !bytes.HasPrefix(fixed, []byte("event: message\ndata: {")) || !bytes.HasSuffix(fixed, []byte("}\n\n")) {
+		t.Fatalf("event framing not preserved: %q", fixed)
+	}
+	if !bytes.Contains(fixed, []byte(`"title":"X"`)) {
+		t.Fatalf("title not moved: %q", fixed)
+	}
+	if _, ok := fixEventToolTitles([]byte("event: endpoint\ndata: /message?sessionId=1\n\n")); ok {
+		t.Fatal("expected other events to be left alone")
+	}
+}
+
+func TestValidateHTTPAddr(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:7777", "localhost:7777", "[::1]:7777"} {
+		if err := validateHTTPAddr(addr, ""); err != nil {
+			t.Errorf("validateHTTPAddr(%q) = %v", addr, err)
+		}
+	}
+	if err := validateHTTPAddr("0.0.0.0:7777", ""); err == nil {
+		t.Error("expected a non-loopback address without a token to be refused")
+	}
+	if err := validateHTTPAddr("0.0.0.0:7777", "secret"); err != nil {
+		t.Errorf("expected a non-loopback address with a token to be allowed, got %v", err)
+	}
+}
+
+func TestHTTPHandler_RejectsForeignOrigins(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := startHTTPServer(t, srv, HTTPOptions{AllowedOrigins: []string{"https://app.example.com"}})
+
+	for origin, want := range map[string]int{
+		"":                        http.StatusOK,
+		"http://localhost:3000":   http.StatusOK,
+		"http://127.0.0.1":        http.StatusOK,
+		"https://app.example.com": http.StatusOK,
+		"https://evil.example":    http.StatusForbidden,
+		"null":                    http.StatusForbidden,
+	} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+HTTPEndpoint, strings.NewReader(testInitialize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Origin %q: expected status %d, got %d", origin, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestHTTPHandler_RejectsForeignHosts(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := startHTTPServer(t, srv, HTTPOptions{})
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	for host, want := range map[string]int{
+		"127.0.0.1:" + port:    http.StatusOK,
+		"localhost:" + port:    http.StatusOK,
+		"evil.example:" + port: http.StatusForbidden,
+		"127.0.0.1:1":          http.StatusForbidden,
+		"evil.example":         http.StatusForbidden,
+	} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+HTTPEndpoint, strings.NewReader(testInitialize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = host
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Host %q: expected status %d, got %d", host, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestHostMatches_UnspecifiedAddress(t *testing.T) {
+	if !hostMatches("grepai.internal:7777", "0.0.0.0", "7777") {
+		t.Error("expected any host to match a wildcard bind on the same port")
+	}
+	if hostMatches("grepai.internal:8888", "0.0.0.0", "7777") {
+		t.Error("expected another port to be rejected")
+	}
+}
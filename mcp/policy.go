@@ -0,0 +1,352 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alpkeskin/gotoon"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/internal/secrets"
+)
+
+// policyPathKeys are the JSON fields of tool and resource results holding
+// indexed file paths.
+var policyPathKeys = []string{"file_path", "file", "path", "caller_file"}
+
+// chunkIDSuffix matches the "_<n>" or "_<n>_<m>" suffix chunk IDs append to
+// the file path.
+var chunkIDSuffix = regexp.MustCompile(`_\d+(_\d+)?$`)
+
+// policy is the access policy of the server, or of one of its workspaces,
+// compiled from a config.MCPConfig.
+type policy struct {
+	tools     map[string]bool   // nil exposes every tool
+	allow     *ignore.GitIgnore // nil allows every path
+	deny      *ignore.GitIgnore
+	redact    bool
+	workspace string // workspace whose "<workspace>/<project>/" prefix is stripped from paths
+}
+
+func newPolicy(cfg config.MCPConfig, workspace string) *policy {
+	p := &policy{redact: cfg.RedactsSecrets(), workspace: workspace}
+	if len(cfg.Tools) > 0 {
+		p.tools = make(map[string]bool, len(cfg.Tools))
+		for _, name := range cfg.Tools {
+			p.tools[name] = true
+		}
+	}
+	if len(cfg.AllowPaths) > 0 {
+		p.allow = ignore.CompileIgnoreLines(cfg.AllowPaths...)
+	}
+	if len(cfg.DenyPaths) > 0 {
+		p.deny = ignore.CompileIgnoreLines(cfg.DenyPaths...)
+	}
+	return p
+}
+
+// allowsTool reports whether the tool called name is exposed.
+func (p *policy) allowsTool(name string) bool {
+	return p.tools == nil || p.tools[name]
+}
+
+// restrictsPaths reports whether results are filtered by path.
+func (p *policy) restrictsPaths() bool {
+	return p.allow != nil || p.deny != nil
+}
+
+// passthrough reports whether results are returned unchanged.
+func (p *policy) passthrough() bool {
+	return !p.redact && !p.restrictsPaths()
+}
+
+// allowsPath reports whether results for the indexed path filePath may be
+// returned. Absolute paths, such as project directories, are not indexed
+// paths and are always allowed.
+func (p *policy) allowsPath(filePath string) bool {
+	if !p.restrictsPaths() || filePath == "" || filepath.IsAbs(filePath) {
+		return true
+	}
+	rel := filepath.ToSlash(filePath)
+	if p.workspace != "" {
+		if after, ok := strings.CutPrefix(rel, p.workspace+"/"); ok {
+			if _, projectRel, ok := strings.Cut(after, "/"); ok {
+				rel = projectRel
+			}
+		}
+	}
+	rel = path.Clean(rel)
+	if p.allow != nil && !p.allow.MatchesPath(rel) {
+		return false
+	}
+	return p.deny == nil || !p.deny.MatchesPath(rel)
+}
+
+// redactText redacts credentials from text when the policy asks for it.
+func (p *policy) redactText(text string) (string, bool) {
+	if !p.redact {
+		return text, false
+	}
+	redacted, n := secrets.Redact(text)
+	return redacted, n > 0
+}
+
+// filterValue applies the policy to a decoded JSON value. An object holding
+// a denied path, directly or in a nested object, is removed from the
+// closest array containing it; outside arrays only the field holding it is
+// removed. It returns the filtered value, whether it changed and whether
+// the value itself is denied.
+func (p *policy) filterValue(v any, inArray bool) (any, bool, bool) {
+	switch v := v.(type) {
+	case string:
+		redacted, changed := p.redactText(v)
+		return redacted, changed, false
+	case []any:
+		kept := v[:0]
+		changed := false
+		for _, elem := range v {
+			filtered, elemChanged, denied := p.filterValue(elem, true)
+			if denied {
+				changed = true
+				continue
+			}
+			changed = changed || elemChanged
+			kept = append(kept, filtered)
+		}
+		return kept, changed, false
+	case map[string]any:
+		for _, key := range policyPathKeys {
+			if filePath, ok := v[key].(string); ok && !p.allowsPath(filePath) {
+				return nil, true, true
+			}
+		}
+		changed := false
+		for key, child := range v {
+			filtered, childChanged, denied := p.filterValue(child, inArray)
+			if denied {
+				if inArray {
+					return nil, true, true
+				}
+				delete(v, key)
+				changed = true
+				continue
+			}
+			changed = changed || childChanged
+			v[key] = filtered
+		}
+		return v, changed, false
+	default:
+		return v, false, false
+	}
+}
+
+// filterJSON applies the policy to JSON data. It returns false when data
+// is not JSON. Unchanged data is returned as is.
+func (p *policy) filterJSON(data string) (string, bool, error) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return "", false, nil
+	}
+	filtered, changed, denied := p.filterValue(v, false)
+	if denied {
+		return "", true, errPolicyDenied
+	}
+	if !changed {
+		return data, true, nil
+	}
+	out, err := json.MarshalIndent(filtered, "", "  ")
+	if err != nil {
+		return "", true, err
+	}
+	return string(out), true, nil
+}
+
+// errPolicyDenied is returned for results that are entirely outside the
+// paths allowed by the policy.
+var errPolicyDenied = errors.New("result is outside the paths allowed by the MCP server policy")
+
+// filterText applies the policy to a result: structured JSON results are
+// filtered field by field, other text is only redacted.
+func (p *policy) filterText(text string, structured bool) (string, error) {
+	if structured {
+		filtered, isJSON, err := p.filterJSON(text)
+		if err != nil || isJSON {
+			return filtered, err
+		}
+	}
+	redacted, _ := p.redactText(text)
+	return redacted, nil
+}
+
+// policyFor returns the policy applying to a request for workspace, or for
+// the server's project when workspace is empty and the server has no
+// workspace. Errors must deny the request: an invalid policy is never
+// ignored.
+func (s *Server) policyFor(workspace string) (*policy, error) {
+	if workspace = s.resolveWorkspace(workspace); workspace != "" {
+		ws, err := s.loadWorkspace(workspace)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.ValidateMCPConfig(ws.MCP); err != nil {
+			return nil, fmt.Errorf("invalid MCP policy of workspace %q: %w", workspace, err)
+		}
+		return newPolicy(ws.MCP, ws.Name), nil
+	}
+	return s.projectPolicy()
+}
+
+// projectPolicy returns the policy of the server's project. Projects
+// without a configuration get the default policy.
+func (s *Server) projectPolicy() (*policy, error) {
+	if s.projectRoot == "" || !config.Exists(s.projectRoot) {
+		return newPolicy(config.MCPConfig{}, ""), nil
+	}
+	cfg, err := s.loadConfig(s.projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return newPolicy(cfg.MCP, ""), nil
+}
+
+// filterTools hides the tools the server's policy does not expose from
+// tools/list.
+func (s *Server) filterTools(_ context.Context, tools []mcp.Tool) []mcp.Tool {
+	p, err := s.policyFor("")
+	if err != nil {
+		return nil
+	}
+	kept := tools[:0:0]
+	for _, tool := range tools {
+		if p.allowsTool(tool.Name) {
+			kept = append(kept, tool)
+		}
+	}
+	return kept
+}
+
+// enforceToolPolicy wraps every tool handler: calls to tools the policy
+// does not expose are refused, and results are filtered by path and
+// redacted before they are returned.
+func (s *Server) enforceToolPolicy(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		p, err := s.policyFor(request.GetString("workspace", ""))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to load MCP server policy: %v", err)), nil
+		}
+		if !p.allowsTool(request.Params.Name) {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %s is disabled by the MCP server policy", request.Params.Name)), nil
+		}
+		if p.passthrough() {
+			return next(ctx, request)
+		}
+
+		// TOON results are filtered as JSON, then encoded again; TOON
+		// encodes objects with sorted keys, as decoded JSON objects are.
+		toon := false
+		if args := request.GetArguments(); args["format"] == "toon" {
+			jsonArgs := make(map[string]any, len(args))
+			for k, v := range args {
+				jsonArgs[k] = v
+			}
+			jsonArgs["format"] = "json"
+			request.Params.Arguments = jsonArgs
+			toon = true
+		}
+
+		result, err := next(ctx, request)
+		if err != nil || result == nil {
+			return result, err
+		}
+		for i, content := range result.Content {
+			text, ok := content.(mcp.TextContent)
+			if !ok {
+				continue
+			}
+			filtered, err := p.filterText(text.Text, true)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if toon && !result.IsError {
+				if filtered, err = jsonToTOON(filtered); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to encode output: %v", err)), nil
+				}
+			}
+			text.Text = filtered
+			result.Content[i] = text
+		}
+		return result, nil
+	}
+}
+
+// jsonToTOON encodes JSON data in TOON format. Other data is returned as is.
+func jsonToTOON(data string) (string, error) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return data, nil
+	}
+	return gotoon.Encode(v)
+}
+
+// enforceResourcePolicy wraps the resource handlers: file and chunk
+// resources outside the allowed paths are refused, and every returned
+// content is filtered by path and redacted.
+func (s *Server) enforceResourcePolicy(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		scope, kind, id, err := s.parseResourceURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		p, err := s.scopePolicy(scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load MCP server policy: %w", err)
+		}
+		if p.passthrough() {
+			return next(ctx, request)
+		}
+		switch kind {
+		case resourceKindFile:
+			if !p.allowsPath(id) {
+				return nil, errPolicyDenied
+			}
+		case resourceKindChunk:
+			if !p.allowsPath(chunkIDSuffix.ReplaceAllString(id, "")) {
+				return nil, errPolicyDenied
+			}
+		}
+
+		contents, err := next(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		// File and chunk resources hold source code, which is only redacted
+		structured := kind == resourceKindSymbol || kind == resourceKindRPGNode
+		for i, content := range contents {
+			text, ok := content.(mcp.TextResourceContents)
+			if !ok {
+				continue
+			}
+			if text.Text, err = p.filterText(text.Text, structured); err != nil {
+				return nil, err
+			}
+			contents[i] = text
+		}
+		return contents, nil
+	}
+}
+
+// scopePolicy returns the policy applying to the resources of scope.
+func (s *Server) scopePolicy(scope resourceScope) (*policy, error) {
+	if scope.workspace != nil {
+		return s.policyFor(scope.workspace.Name)
+	}
+	return s.projectPolicy()
+}
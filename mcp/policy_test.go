@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+)
+
+func TestPolicy_FilterJSON(t *testing.T) {
+	p := newPolicy(config.MCPConfig{DenyPaths: []string{"secret/"}}, "")
+	data := `{
+  "symbol": {"name": "Key", "file": "secret/key.go"},
+  "callers": [
+    {"symbol": {"name": "A", "file": "a.go"}, "call_site": {"file": "a.go", "context": "token := \"0123456789abcdef\""}},
+    {"symbol": {"name": "B", "file": "b.go"}, "call_site": {"file": "secret/b.go"}}
+  ],
+  "nodes": {"A": {"file": "a.go"}, "S": {"file": "secret/s.go"}}
+}`
+	out, isJSON, err := p.filterJSON(data)
+	if err != nil || !isJSON {
+		t.Fatalf("filterJSON failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["symbol"]; ok {
+		t.Errorf("expected the denied symbol to be removed, got %v", got["symbol"])
+	}
+	callers := got["callers"].([]any)
+	if len(callers) != 1 {
+		t.Fatalf("expected the caller with a denied call site to be removed, got %v", callers)
+	}
+	if context := callers[0].(map[string]any)["call_site"].(map[string]any)["context"]; context != `token := "[REDACTED]"` {
+		t.Errorf("expected the token to be redacted, got %v", context)
+	}
+	if nodes := got["nodes"].(map[string]any); len(nodes) != 1 || nodes["A"] == nil {
+		t.Errorf("expected only the allowed node, got %v", nodes)
+	}
+
+	if _, _, err := p.filterJSON(`{"file_path": "secret/key.go"}`); err != errPolicyDenied {
+		t.Errorf("expected a denied result, got %v", err)
+	}
+	if out, _, _ := p.filterJSON(`[{"file_path": "a.go"}]`); out != `[{"file_path": "a.go"}]` {
+		t.Errorf("expected unchanged data to be kept as is, got %s", out)
+	}
+}
+
+func TestPolicy_WorkspacePaths(t *testing.T) {
+	p := newPolicy(config.MCPConfig{AllowPaths: []string{"src/"}}, "acme")
+	for filePath, want := range map[string]bool{
+		"acme/backend/src/main.go":  true,
+		"acme/backend/docs/main.md": false,
+		"src/main.go":               true,
+		"/home/user/backend":        true,
+	} {
+		if got := p.allowsPath(filePath); got != want {
+			t.Errorf("allowsPath(%q) = %v, want %v", filePath, got, want)
+		}
+	}
+}
+
+// policyTestCall sends a JSON-RPC request through the MCP server, so that
+// its middlewares apply, and returns its result, or the whole reply on
+// errors.
+func policyTestCall(t *testing.T, s *Server, method string, params any) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := json.Marshal(s.mcpServer.HandleMessage(context.Background(), raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(reply, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Result == nil {
+		return reply
+	}
+	return msg.Result
+}
+
+func policyTestToolText(t *testing.T, s *Server, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	var result mcp.CallToolResult
+	raw := policyTestCall(t, s, "tools/call", map[string]any{"name": name, "arguments": args})
+	if err := json.Unmarshal(raw, &result); err != nil {
+		t.Fatalf("unexpected result %s: %v", raw, err)
+	}
+	var text strings.Builder
+	for _, content := range result.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			text.WriteString(tc.Text)
+		}
+	}
+	return text.String(), result.IsError
+}
+
+func TestServerPolicy_EnforcedOnToolsAndResources(t *testing.T) {
+	projectRoot := seedResourceProject(t)
+	if err := os.MkdirAll(filepath.Join(projectRoot, "secret"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, "secret", "keys.go"), []byte("package secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, "settings.ini"), []byte("[db]\npassword = hunter2hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MCP = config.MCPConfig{Tools: []string{"grepai_read"}, DenyPaths: []string{"secret/"}}
+	if err := cfg.Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(policyTestCall(t, s, "tools/list", map[string]any{}), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Tools) != 1 || list.Tools[0].Name != "grepai_read" {
+		t.Fatalf("expected only grepai_read to be listed, got %+v", list.Tools)
+	}
+
+	if text, isError := policyTestToolText(t, s, "grepai_search", map[string]any{"query": "login"}); !isError || !strings.Contains(text, "disabled") {
+		t.Fatalf("expected grepai_search to be refused, got %s", text)
+	}
+	if text, isError := policyTestToolText(t, s, "grepai_read", map[string]any{"path": "settings.ini"}); isError || strings.Contains(text, "hunter2") || !strings.Contains(text, "[REDACTED]") {
+		t.Fatalf("expected the password to be redacted, got %s", text)
+	}
+	if text, isError := policyTestToolText(t, s, "grepai_read", map[string]any{"path": "secret/keys.go"}); isError || strings.TrimSpace(text) != "[]" {
+		t.Fatalf("expected the denied file to be filtered out, got %s", text)
+	}
+	if text, isError := policyTestToolText(t, s, "grepai_read", map[string]any{"path": "settings.ini", "format": "toon"}); isError || strings.HasPrefix(strings.TrimSpace(text), "[{") || !strings.Contains(text, "[REDACTED]") {
+		t.Fatalf("expected a redacted TOON result, got %s", text)
+	}
+
+	raw := policyTestCall(t, s, "resources/read", map[string]any{"uri": "grepai://file/secret/keys.go"})
+	if !strings.Contains(string(raw), "outside the paths allowed") {
+		t.Fatalf("expected the denied resource to be refused, got %s", raw)
+	}
+	if err := s.syncResources(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for uri := range s.resourceFiles {
+		if strings.Contains(uri, "secret/") {
+			t.Fatalf("expected denied files not to be listed, got %s", uri)
+		}
+	}
+}
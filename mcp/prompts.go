@@ -0,0 +1,460 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// Prompt names.
+const (
+	promptExplainSymbol  = "explain-symbol"
+	promptImpactOfChange = "impact-of-change"
+	promptOnboardToArea  = "onboard-to-area"
+)
+
+// maxPromptItems bounds each list of a prompt's context bundle.
+const maxPromptItems = 50
+
+// registerPrompts registers the code-navigation prompts. Each assembles a
+// context bundle from the indexes so clients can offer grepai workflows as
+// slash commands.
+func (s *Server) registerPrompts() {
+	workspaceArg := mcp.WithArgument("workspace", mcp.ArgumentDescription("Workspace name for cross-project context (optional)"))
+	projectArg := mcp.WithArgument("project", mcp.ArgumentDescription("Project name within the workspace (optional)"))
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt(promptExplainSymbol,
+		mcp.WithPromptDescription("Explain a symbol from its definition, callers, callees and RPG feature path"),
+		mcp.WithArgument("symbol", mcp.ArgumentDescription("Name of the function, method or type to explain"), mcp.RequiredArgument()),
+		workspaceArg,
+		projectArg,
+	), s.handleExplainSymbolPrompt)
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt(promptImpactOfChange,
+		mcp.WithPromptDescription("Assess the impact of changing a symbol from its call graph and the code writing to it"),
+		mcp.WithArgument("symbol", mcp.ArgumentDescription("Name of the symbol about to change"), mcp.RequiredArgument()),
+		mcp.WithArgument("depth", mcp.ArgumentDescription("Call graph depth (default: 2)")),
+		workspaceArg,
+		projectArg,
+	), s.handleImpactOfChangePrompt)
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt(promptOnboardToArea,
+		mcp.WithPromptDescription("Introduce a feature area of the codebase from the RPG graph (requires RPG)"),
+		mcp.WithArgument("area", mcp.ArgumentDescription("Feature area, category or path (e.g. 'auth' or 'cli/commands')"), mcp.RequiredArgument()),
+		mcp.WithArgument("limit", mcp.ArgumentDescription("Maximum number of nodes to include (default: 50)")),
+		workspaceArg,
+		projectArg,
+	), s.handleOnboardToAreaPrompt)
+}
+
+// promptStores opens the symbol stores a prompt reads from and returns the
+// directory of the project they index, which is empty when they span
+// several workspace projects.
+func (s *Server) promptStores(ctx context.Context, args map[string]string) ([]trace.SymbolStore, string, error) {
+	workspace := s.resolveWorkspace(args["workspace"])
+	project := args["project"]
+
+	if workspace != "" {
+		stores, err := s.loadWorkspaceSymbolStores(ctx, workspace, project)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load workspace symbol stores: %w", err)
+		}
+		root := ""
+		if project != "" {
+			if scope, err := workspaceResourceScope(workspace, project); err == nil {
+				root = scope.root
+			}
+		}
+		return stores, root, nil
+	}
+
+	if s.projectRoot == "" {
+		return nil, "", fmt.Errorf("prompt requires a project context; pass a workspace or start mcp-serve from a project directory")
+	}
+	symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load symbol index: %w. Run 'grepai watch' first", err)
+	}
+	return []trace.SymbolStore{symbolStore}, s.projectRoot, nil
+}
+
+// handleExplainSymbolPrompt handles the explain-symbol prompt.
+func (s *Server) handleExplainSymbolPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	symbolName := strings.TrimSpace(args["symbol"])
+	if symbolName == "" {
+		return nil, fmt.Errorf("symbol argument is required")
+	}
+	p, err := s.promptPolicy(args)
+	if err != nil {
+		return nil, err
+	}
+	stores, root, err := s.promptStores(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer trace.CloseSymbolStores(stores)
+
+	definitions := p.allowedSymbols(lookupPromptSymbols(ctx, stores, symbolName))
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("symbol not found: %s", symbolName)
+	}
+
+	var callers, callees []trace.Reference
+	for _, ss := range stores {
+		if refs, err := ss.LookupCallers(ctx, symbolName); err == nil {
+			callers = append(callers, p.allowedReferences(refs)...)
+		}
+		if refs, err := ss.LookupCallees(ctx, symbolName, definitions[0].File); err == nil {
+			callees = append(callees, p.allowedReferences(refs)...)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Explain the symbol `%s`: what it does, how it is used and where it fits in the codebase. Base the explanation on the context below, gathered from the grepai index.\n", symbolName)
+	s.writePromptDefinitions(ctx, &b, root, definitions)
+
+	fmt.Fprintf(&b, "\n## Callers (%d)\n\n", len(callers))
+	writePromptReferences(&b, callers, func(ref trace.Reference) string { return ref.CallerName })
+	fmt.Fprintf(&b, "\n## Callees (%d)\n\n", len(callees))
+	writePromptReferences(&b, callees, func(ref trace.Reference) string { return ref.SymbolName })
+
+	return p.promptResult(fmt.Sprintf("Explain %s", symbolName), b.String()), nil
+}
+
+// handleImpactOfChangePrompt handles the impact-of-change prompt.
+func (s *Server) handleImpactOfChangePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	symbolName := strings.TrimSpace(args["symbol"])
+	if symbolName == "" {
+		return nil, fmt.Errorf("symbol argument is required")
+	}
+	depth := 2
+	if v := args["depth"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("depth must be a positive integer")
+		}
+		depth = n
+	}
+	p, err := s.promptPolicy(args)
+	if err != nil {
+		return nil, err
+	}
+	stores, root, err := s.promptStores(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer trace.CloseSymbolStores(stores)
+
+	definitions := p.allowedSymbols(lookupPromptSymbols(ctx, stores, symbolName))
+	var edges []trace.CallEdge
+	var writers []trace.Reference
+	edgeSeen := make(map[string]bool)
+	for _, ss := range stores {
+		if graph, err := ss.GetCallGraph(ctx, symbolName, depth); err == nil && graph != nil {
+			for _, edge := range graph.Edges {
+				key := edge.Caller + "->" + edge.Callee
+				if !edgeSeen[key] && p.allowsPath(edge.File) {
+					edgeSeen[key] = true
+					edges = append(edges, edge)
+				}
+			}
+		}
+		if refs, err := ss.LookupWriters(ctx, symbolName); err == nil {
+			writers = append(writers, p.allowedReferences(refs)...)
+		}
+	}
+	if len(definitions) == 0 && len(edges) == 0 && len(writers) == 0 {
+		return nil, fmt.Errorf("symbol not found: %s", symbolName)
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		// Direct callers first: they break first
+		return edges[i].Callee == symbolName && edges[j].Callee != symbolName
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Assess the impact of changing `%s`. List the callers and data flows that could break, the behavior that could change for them, and the tests to run or write. Base the assessment on the context below, gathered from the grepai index.\n", symbolName)
+	if len(definitions) > 0 {
+		s.writePromptDefinitions(ctx, &b, root, definitions)
+	}
+
+	fmt.Fprintf(&b, "\n## Call graph (depth %d, %d edges)\n\n", depth, len(edges))
+	if len(edges) == 0 {
+		b.WriteString("No calls found.\n")
+	}
+	for i, edge := range edges {
+		if i == maxPromptItems {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(edges)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s` -> `%s` at %s:%d\n", edge.Caller, edge.Callee, edge.File, edge.Line)
+	}
+
+	fmt.Fprintf(&b, "\n## Writers (%d)\n\n", len(writers))
+	writePromptReferences(&b, writers, func(ref trace.Reference) string { return ref.CallerName })
+
+	return p.promptResult(fmt.Sprintf("Impact of changing %s", symbolName), b.String()), nil
+}
+
+// handleOnboardToAreaPrompt handles the onboard-to-area prompt.
+func (s *Server) handleOnboardToAreaPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	area := strings.Trim(strings.TrimSpace(args["area"]), "/")
+	if area == "" {
+		return nil, fmt.Errorf("area argument is required")
+	}
+	limit := maxPromptItems
+	if v := args["limit"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		limit = n
+	}
+	p, err := s.promptPolicy(args)
+	if err != nil {
+		return nil, err
+	}
+
+	root := s.projectRoot
+	if workspace := s.resolveWorkspace(args["workspace"]); workspace != "" {
+		if args["project"] == "" {
+			return nil, fmt.Errorf("project argument is required in workspace mode")
+		}
+		scope, err := workspaceResourceScope(workspace, args["project"])
+		if err != nil {
+			return nil, err
+		}
+		root = scope.root
+	}
+
+	rpgSt, qe, err := s.openRPG(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RPG: %w", err)
+	}
+	if rpgSt == nil {
+		return nil, fmt.Errorf("RPG is not enabled or index is empty")
+	}
+	defer rpgSt.Close()
+
+	start := findAreaNode(ctx, rpgSt.GetGraph(), qe, area)
+	if start == nil {
+		return nil, fmt.Errorf("feature area not found: %s", area)
+	}
+	explored, err := qe.Explore(ctx, rpg.ExploreRequest{
+		StartNodeID: start.ID,
+		Direction:   "forward", // Feature-parent and contains edges point to children
+		Depth:       4,
+		EdgeTypes:   []rpg.EdgeType{rpg.EdgeFeatureParent, rpg.EdgeContains},
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("explore failed: %w", err)
+	}
+
+	var subAreas, files, symbols []*rpg.Node
+	for _, node := range explored.Nodes {
+		if node.ID == start.ID || !p.allowsPath(node.Path) {
+			continue
+		}
+		switch node.Kind {
+		case rpg.KindArea, rpg.KindCategory, rpg.KindSubcategory:
+			subAreas = append(subAreas, node)
+		case rpg.KindFile:
+			files = append(files, node)
+		case rpg.KindSymbol:
+			symbols = append(symbols, node)
+		}
+	}
+	for _, nodes := range [][]*rpg.Node{subAreas, files, symbols} {
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].Path != nodes[j].Path {
+				return nodes[i].Path < nodes[j].Path
+			}
+			if nodes[i].StartLine != nodes[j].StartLine {
+				return nodes[i].StartLine < nodes[j].StartLine
+			}
+			return nodes[i].Feature < nodes[j].Feature
+		})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Give me an onboarding tour of the `%s` feature area: its purpose, its main files and entry points, how its parts fit together, and where to start reading. Base the tour on the context below, gathered from the grepai RPG graph.\n", start.Feature)
+	fmt.Fprintf(&b, "\n## Area\n\n- Feature path: %s\n", start.Feature)
+	if start.Summary != "" {
+		fmt.Fprintf(&b, "- Summary: %s\n", start.Summary)
+	}
+
+	if len(subAreas) > 0 {
+		fmt.Fprintf(&b, "\n## Sub-areas (%d)\n\n", len(subAreas))
+		for _, node := range subAreas {
+			fmt.Fprintf(&b, "- %s%s\n", node.Feature, promptNodeSummary(node))
+		}
+	}
+	fmt.Fprintf(&b, "\n## Files (%d)\n\n", len(files))
+	if len(files) == 0 {
+		b.WriteString("No files found.\n")
+	}
+	for _, node := range files {
+		fmt.Fprintf(&b, "- %s%s\n", node.Path, promptNodeSummary(node))
+	}
+	if len(symbols) > 0 {
+		fmt.Fprintf(&b, "\n## Symbols (%d)\n\n", len(symbols))
+		for _, node := range symbols {
+			name := node.SymbolName
+			if node.Signature != "" {
+				name = node.Signature
+			}
+			fmt.Fprintf(&b, "- `%s` at %s:%d%s\n", name, node.Path, node.StartLine, promptNodeSummary(node))
+		}
+	}
+	if len(explored.Nodes) >= limit {
+		fmt.Fprintf(&b, "\nThe area was truncated to %d nodes; use the grepai_rpg_explore tool from node `%s` to see more.\n", limit, start.ID)
+	}
+
+	return p.promptResult(fmt.Sprintf("Onboarding to %s", start.Feature), b.String()), nil
+}
+
+// promptPolicy returns the access policy applying to a prompt request.
+func (s *Server) promptPolicy(args map[string]string) (*policy, error) {
+	p, err := s.policyFor(args["workspace"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MCP server policy: %w", err)
+	}
+	return p, nil
+}
+
+// allowedSymbols returns the symbols defined in files the policy allows.
+func (p *policy) allowedSymbols(symbols []trace.Symbol) []trace.Symbol {
+	kept := symbols[:0]
+	for _, sym := range symbols {
+		if p.allowsPath(sym.File) {
+			kept = append(kept, sym)
+		}
+	}
+	return kept
+}
+
+// allowedReferences returns the references whose call site and caller are in
+// files the policy allows.
+func (p *policy) allowedReferences(refs []trace.Reference) []trace.Reference {
+	kept := refs[:0]
+	for _, ref := range refs {
+		if p.allowsPath(ref.File) && p.allowsPath(ref.CallerFile) {
+			kept = append(kept, ref)
+		}
+	}
+	return kept
+}
+
+// findAreaNode returns the hierarchy node whose feature path or ID is area,
+// or the best match of a search for it.
+func findAreaNode(ctx context.Context, graph *rpg.Graph, qe *rpg.QueryEngine, area string) *rpg.Node {
+	kinds := []rpg.NodeKind{rpg.KindArea, rpg.KindCategory, rpg.KindSubcategory}
+	if node := graph.GetNode(area); node != nil {
+		return node
+	}
+	for _, kind := range kinds {
+		for _, node := range graph.GetNodesByKind(kind) {
+			if strings.EqualFold(node.Feature, area) {
+				return node
+			}
+		}
+	}
+	results, err := qe.SearchNode(ctx, rpg.SearchNodeRequest{Query: strings.ReplaceAll(area, "/", " "), Kinds: kinds, Limit: 1})
+	if err != nil || len(results) == 0 {
+		return nil
+	}
+	return results[0].Node
+}
+
+// lookupPromptSymbols returns the definitions of name across stores.
+func lookupPromptSymbols(ctx context.Context, stores []trace.SymbolStore, name string) []trace.Symbol {
+	var symbols []trace.Symbol
+	for _, ss := range stores {
+		if found, err := ss.LookupSymbol(ctx, name); err == nil {
+			symbols = append(symbols, found...)
+		}
+	}
+	return symbols
+}
+
+// writePromptDefinitions writes the definitions of a symbol with their RPG
+// feature path and, when root is known, their source.
+func (s *Server) writePromptDefinitions(ctx context.Context, b *strings.Builder, root string, definitions []trace.Symbol) {
+	symPtrs := make([]*trace.Symbol, len(definitions))
+	for i := range definitions {
+		symPtrs[i] = &definitions[i]
+	}
+	s.enrichTraceSymbolsAt(ctx, root, symPtrs...)
+
+	fmt.Fprintf(b, "\n## Definition")
+	if len(definitions) > 1 {
+		fmt.Fprintf(b, "s (%d)", len(definitions))
+	}
+	b.WriteString("\n")
+	for _, sym := range definitions {
+		title := sym.Name
+		if sym.Signature != "" {
+			title = sym.Signature
+		}
+		fmt.Fprintf(b, "\n### `%s` (%s, %s:%d)\n\n", title, sym.Kind, sym.File, sym.Line)
+		if sym.FeaturePath != "" {
+			fmt.Fprintf(b, "Feature path: %s\n\n", sym.FeaturePath)
+		}
+		if sym.Docstring != "" {
+			fmt.Fprintf(b, "Documentation: %s\n\n", strings.TrimSpace(sym.Docstring))
+		}
+		if source := symbolSource(root, sym); source != "" {
+			fmt.Fprintf(b, "```%s\n%s\n```\n", sym.Language, source)
+		}
+	}
+}
+
+// writePromptReferences writes refs as a list, naming each by name(ref).
+func writePromptReferences(b *strings.Builder, refs []trace.Reference, name func(trace.Reference) string) {
+	if len(refs) == 0 {
+		b.WriteString("None found.\n")
+		return
+	}
+	for i, ref := range refs {
+		if i == maxPromptItems {
+			fmt.Fprintf(b, "- ... and %d more\n", len(refs)-i)
+			return
+		}
+		fmt.Fprintf(b, "- `%s` at %s:%d", name(ref), ref.File, ref.Line)
+		if line := strings.TrimSpace(ref.Context); line != "" {
+			fmt.Fprintf(b, ": `%s`", line)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// promptNodeSummary describes an RPG node for a list item.
+func promptNodeSummary(node *rpg.Node) string {
+	switch {
+	case node.Summary != "":
+		return " - " + node.Summary
+	case node.SemanticLabel != "":
+		return " - " + node.SemanticLabel
+	default:
+		return ""
+	}
+}
+
+// promptResult returns a prompt made of a single user message, with the
+// credentials in text redacted when the policy asks for it.
+func (p *policy) promptResult(description, text string) *mcp.GetPromptResult {
+	text, _ = p.redactText(text)
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
@@ -0,0 +1,200 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// seedPromptProject creates a project where HandleLogin calls Login, which
+// calls validate, and TestSetup assigns Login, with an RPG graph for the auth
+// area.
+func seedPromptProject(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	projectRoot := seedResourceProject(t)
+
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.RPG.Enabled = true
+	if err := cfg.Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	file := "src/my_auth/login.go"
+	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
+	if err := symbolStore.SaveFile(ctx, file,
+		[]trace.Symbol{
+			{Name: "Login", Kind: trace.KindFunction, File: file, Line: 3, EndLine: 5, Signature: "func Login(user string) error", Language: "go"},
+			{Name: "HandleLogin", Kind: trace.KindFunction, File: "src/api/handler.go", Line: 10, Language: "go"},
+		},
+		[]trace.Reference{
+			{SymbolName: "Login", File: "src/api/handler.go", Line: 12, Context: "err := Login(user)", CallerName: "HandleLogin", CallerFile: "src/api/handler.go", CallerLine: 10},
+			{SymbolName: "validate", File: file, Line: 4, Context: "validate(user)", CallerName: "Login", CallerFile: file, CallerLine: 3},
+			{SymbolName: "Login", Kind: trace.RefKindWrite, File: "src/api/handler.go", Line: 14, Context: "Login = mockLogin", CallerName: "TestSetup", CallerFile: "src/api/handler.go", CallerLine: 13},
+		},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbolStore.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rpgStore := rpg.NewGOBRPGStore(config.GetRPGIndexPath(projectRoot))
+	graph := rpgStore.GetGraph()
+	graph.AddNode(&rpg.Node{ID: "area:auth", Kind: rpg.KindArea, Feature: "auth", Summary: "User authentication"})
+	graph.AddNode(&rpg.Node{ID: "cat:auth/session", Kind: rpg.KindCategory, Feature: "auth/session"})
+	graph.AddNode(&rpg.Node{ID: "file:" + file, Kind: rpg.KindFile, Path: file, Feature: "login"})
+	graph.AddNode(&rpg.Node{ID: "sym:" + file + ":Login", Kind: rpg.KindSymbol, Path: file, SymbolName: "Login", StartLine: 3, Signature: "func Login(user string) error"})
+	graph.AddEdge(&rpg.Edge{From: "area:auth", To: "cat:auth/session", Type: rpg.EdgeFeatureParent})
+	graph.AddEdge(&rpg.Edge{From: "cat:auth/session", To: "file:" + file, Type: rpg.EdgeFeatureParent})
+	graph.AddEdge(&rpg.Edge{From: "file:" + file, To: "sym:" + file + ":Login", Type: rpg.EdgeContains})
+	if err := rpgStore.Persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return projectRoot
+}
+
+func getTestPrompt(t *testing.T, s *Server, name string, args map[string]string) string {
+	t.Helper()
+	var request mcp.GetPromptRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+
+	handlers := map[string]func(context.Context, mcp.GetPromptRequest) (*mcp.GetPromptResult, error){
+		promptExplainSymbol:  s.handleExplainSymbolPrompt,
+		promptImpactOfChange: s.handleImpactOfChangePrompt,
+		promptOnboardToArea:  s.handleOnboardToAreaPrompt,
+	}
+	result, err := handlers[name](context.Background(), request)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Role != mcp.RoleUser {
+		t.Fatalf("expected a single user message, got %+v", result.Messages)
+	}
+	text, ok := result.Messages[0].Content.(mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", result.Messages[0].Content)
+	}
+	return text.Text
+}
+
+func assertContainsAll(t *testing.T, text string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Errorf("expected %q in:\n%s", w, text)
+		}
+	}
+}
+
+func TestExplainSymbolPrompt(t *testing.T) {
+	s, err := NewServer(seedPromptProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := getTestPrompt(t, s, promptExplainSymbol, map[string]string{"symbol": "Login"})
+	assertContainsAll(t, text,
+		"### `func Login(user string) error` (function, src/my_auth/login.go:3)",
+		"Feature path: auth/session",
+		"```go\nfunc Login(user string) error {\n\treturn nil\n}\n```",
+		"## Callers (1)\n\n- `HandleLogin` at src/api/handler.go:12: `err := Login(user)`",
+		"## Callees (1)\n\n- `validate` at src/my_auth/login.go:4",
+	)
+
+	var request mcp.GetPromptRequest
+	request.Params.Arguments = map[string]string{"symbol": "Missing"}
+	if _, err := s.handleExplainSymbolPrompt(context.Background(), request); err == nil {
+		t.Fatal("expected an error for an unknown symbol")
+	}
+}
+
+func TestImpactOfChangePrompt(t *testing.T) {
+	s, err := NewServer(seedPromptProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := getTestPrompt(t, s, promptImpactOfChange, map[string]string{"symbol": "Login", "depth": "1"})
+	assertContainsAll(t, text,
+		"Assess the impact of changing `Login`",
+		"## Call graph (depth 1, 3 edges)\n\n- `HandleLogin` -> `Login` at src/api/handler.go:12\n- `TestSetup` -> `Login` at src/api/handler.go:14\n- `Login` -> `validate`",
+		"## Writers (1)\n\n- `TestSetup` at src/api/handler.go:14: `Login = mockLogin`",
+	)
+}
+
+func TestOnboardToAreaPrompt(t *testing.T) {
+	s, err := NewServer(seedPromptProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, area := range []string{"auth", "area:auth", "authentication auth"} {
+		text := getTestPrompt(t, s, promptOnboardToArea, map[string]string{"area": area})
+		assertContainsAll(t, text,
+			"onboarding tour of the `auth` feature area",
+			"- Summary: User authentication",
+			"## Sub-areas (1)\n\n- auth/session",
+			"## Files (1)\n\n- src/my_auth/login.go",
+			"- `func Login(user string) error` at src/my_auth/login.go:3",
+		)
+	}
+}
+
+func TestPrompts_ApplyPolicy(t *testing.T) {
+	projectRoot := seedPromptProject(t)
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MCP.DenyPaths = []string{"src/my_auth/"}
+	if err := cfg.Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var request mcp.GetPromptRequest
+	request.Params.Arguments = map[string]string{"symbol": "Login"}
+	if _, err := s.handleExplainSymbolPrompt(context.Background(), request); err == nil {
+		t.Fatal("expected a symbol defined in a denied file not to be found")
+	}
+
+	impact := getTestPrompt(t, s, promptImpactOfChange, map[string]string{"symbol": "Login", "depth": "1"})
+	onboard := getTestPrompt(t, s, promptOnboardToArea, map[string]string{"area": "auth"})
+	for _, text := range []string{impact, onboard} {
+		if strings.Contains(text, "src/my_auth") || strings.Contains(text, "return nil") {
+			t.Errorf("expected nothing from the denied path, got:\n%s", text)
+		}
+	}
+	assertContainsAll(t, impact, "## Call graph (depth 1, 2 edges)", "`HandleLogin` -> `Login` at src/api/handler.go:12")
+	assertContainsAll(t, onboard, "## Files (0)")
+}
+
+func TestPrompts_RedactSource(t *testing.T) {
+	projectRoot := seedPromptProject(t)
+	source := "package auth\n\nfunc Login(user string) error {\n\ttoken := \"0123456789abcdef\"\n}\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, "src", "my_auth", "login.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := getTestPrompt(t, s, promptExplainSymbol, map[string]string{"symbol": "Login"})
+	if strings.Contains(text, "0123456789abcdef") {
+		t.Fatalf("expected the token to be redacted, got:\n%s", text)
+	}
+	assertContainsAll(t, text, "token := \"[REDACTED]\"")
+}
@@ -0,0 +1,448 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// minCollapsedLines is the smallest function body replaced by a marker when
+// trimming content to a token budget.
+const minCollapsedLines = 2
+
+// ReadResult is a line range of a file returned by grepai_read.
+type ReadResult struct {
+	FilePath   string      `json:"file_path"`
+	StartLine  int         `json:"start_line"`
+	EndLine    int         `json:"end_line"`
+	TotalLines int         `json:"total_lines"`
+	Symbol     string      `json:"symbol,omitempty"`
+	Content    string      `json:"content"`
+	Tokens     int         `json:"tokens"`
+	Collapsed  []LineRange `json:"collapsed,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	NextLine   int         `json:"next_line,omitempty"`
+}
+
+// LineRange is an inclusive range of lines.
+type LineRange struct {
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// readTarget is a line range of a project file to read. An endLine of zero
+// reads to the end of the file.
+type readTarget struct {
+	scope     resourceScope
+	rel       string
+	startLine int
+	endLine   int
+	symbol    string
+}
+
+// handleRead handles the grepai_read tool call.
+func (s *Server) handleRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filePath := request.GetString("path", "")
+	chunkID := request.GetString("chunk_id", "")
+	symbolName := request.GetString("symbol", "")
+	startLine := request.GetInt("start_line", 0)
+	endLine := request.GetInt("end_line", 0)
+	maxTokens := request.GetInt("max_tokens", 0)
+	format := request.GetString("format", "json")
+	workspace := s.resolveWorkspace(request.GetString("workspace", ""))
+	project := request.GetString("project", "")
+
+	if format != "json" && format != "toon" {
+		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
+	}
+	given := 0
+	for _, v := range []string{filePath, chunkID, symbolName} {
+		if v != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return mcp.NewToolResultError("exactly one of path, chunk_id or symbol is required"), nil
+	}
+	if startLine < 0 || endLine < 0 || (endLine > 0 && endLine < startLine) {
+		return mcp.NewToolResultError("invalid line range: start_line and end_line must be positive and start_line <= end_line"), nil
+	}
+
+	var targets []readTarget
+	switch {
+	case filePath != "":
+		scope, rel, err := s.resolveReadPath(workspace, project, filePath)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		targets = []readTarget{{scope: scope, rel: rel, startLine: startLine, endLine: endLine}}
+	case chunkID != "":
+		scope, id, err := s.resolveReadPath(workspace, project, chunkID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		chunk, rel, err := s.lookupChunk(ctx, scope, id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		targets = []readTarget{{scope: scope, rel: rel, startLine: chunk.StartLine, endLine: chunk.EndLine}}
+	default:
+		var err error
+		targets, err = s.symbolReadTargets(ctx, workspace, project, symbolName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	// Definitions of a symbol share the budget, in lookup order
+	results := make([]ReadResult, 0, len(targets))
+	budget := maxTokens
+	for _, target := range targets {
+		if maxTokens > 0 && budget <= 0 {
+			break
+		}
+		result, err := s.readTargetRange(target, budget)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		results = append(results, result)
+		budget -= result.Tokens
+	}
+
+	output, err := encodeOutput(results, format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode results: %v", err)), nil
+	}
+	return mcp.NewToolResultText(output), nil
+}
+
+// resolveReadPath resolves a path or chunk ID as returned by other tools to
+// the project it belongs to and its project-relative form. In workspace mode
+// the project is taken from project or from a "<workspace>/<project>/" prefix.
+func (s *Server) resolveReadPath(workspace, project, p string) (resourceScope, string, error) {
+	p = filepath.ToSlash(strings.TrimSpace(p))
+
+	var scope resourceScope
+	if workspace == "" {
+		if s.projectRoot == "" {
+			return resourceScope{}, "", fmt.Errorf("read requires a project context; use --workspace parameter or start mcp-serve from a project directory")
+		}
+		if filepath.IsAbs(filepath.FromSlash(p)) {
+			rel, err := filepath.Rel(s.projectRoot, filepath.FromSlash(p))
+			if err != nil {
+				return resourceScope{}, "", fmt.Errorf("invalid path %q: %w", p, err)
+			}
+			p = filepath.ToSlash(rel)
+		}
+		scope = resourceScope{root: s.projectRoot}
+	} else {
+		if project == "" {
+			parts := strings.SplitN(p, "/", 3)
+			if len(parts) != 3 || parts[0] != workspace {
+				return resourceScope{}, "", fmt.Errorf("in workspace mode, set project or pass a path of the form %s/<project>/<path> as returned by grepai_search", workspace)
+			}
+			project, p = parts[1], parts[2]
+		} else {
+			p = strings.TrimPrefix(p, workspace+"/"+project+"/")
+		}
+		var err error
+		scope, err = workspaceResourceScope(workspace, project)
+		if err != nil {
+			return resourceScope{}, "", err
+		}
+	}
+
+	p = path.Clean(p)
+	if !filepath.IsLocal(filepath.FromSlash(p)) {
+		return resourceScope{}, "", fmt.Errorf("invalid path %q: must be inside the project", p)
+	}
+	return scope, p, nil
+}
+
+// symbolReadTargets returns the definitions of symbolName in the working
+// tree, in a workspace project, or in every project of a workspace.
+func (s *Server) symbolReadTargets(ctx context.Context, workspace, project, symbolName string) ([]readTarget, error) {
+	var scopes []resourceScope
+	switch {
+	case workspace == "":
+		if s.projectRoot == "" {
+			return nil, fmt.Errorf("read requires a project context; use --workspace parameter or start mcp-serve from a project directory")
+		}
+		scopes = []resourceScope{{root: s.projectRoot}}
+	case project != "":
+		scope, err := workspaceResourceScope(workspace, project)
+		if err != nil {
+			return nil, err
+		}
+		scopes = []resourceScope{scope}
+	default:
+		ws, err := s.loadWorkspace(workspace)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ws.Projects {
+			scopes = append(scopes, resourceScope{root: p.Path, workspace: ws, project: p.Name})
+		}
+	}
+
+	var targets []readTarget
+	for _, scope := range scopes {
+		symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(scope.root))
+		if err != nil {
+			if scope.workspace == nil {
+				return nil, fmt.Errorf("failed to load symbol index: %v. Run 'grepai watch' first", err)
+			}
+			continue
+		}
+		symbols, err := symbolStore.LookupSymbol(ctx, symbolName)
+		symbolStore.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup symbol: %w", err)
+		}
+		for _, sym := range symbols {
+			targets = append(targets, readTarget{scope: scope, rel: sym.File, startLine: sym.Line, endLine: sym.EndLine, symbol: sym.Name})
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("symbol not found: %s", symbolName)
+	}
+	return targets, nil
+}
+
+// readTargetRange reads the line range of target, trimmed to maxTokens.
+func (s *Server) readTargetRange(target readTarget, maxTokens int) (ReadResult, error) {
+	content, err := s.readProjectFile(target.scope, target.rel)
+	if err != nil {
+		return ReadResult{}, err
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	spans := trace.NewRegexExtractor().ExtractFunctionSpans(target.rel, content)
+
+	start := max(target.startLine, 1)
+	if start > len(lines) {
+		return ReadResult{}, fmt.Errorf("start_line %d is past the end of %s (%d lines)", start, target.rel, len(lines))
+	}
+	end := target.endLine
+	if target.symbol != "" && end == 0 {
+		end = symbolEndLine(lines, spans, start)
+	}
+	if end == 0 || end > len(lines) {
+		end = len(lines)
+	}
+
+	result := fitToBudget(lines, start, end, spans, maxTokens)
+	result.FilePath = target.scope.storePath(target.rel)
+	result.TotalLines = len(lines)
+	result.Symbol = target.symbol
+	return result, nil
+}
+
+// symbolEndLine returns the last line of the definition starting at line
+// when the symbol index doesn't record it: the end of the function body, or
+// the line before the next function otherwise.
+func symbolEndLine(lines []string, spans []trace.Symbol, line int) int {
+	next := len(lines) + 1
+	for _, span := range spans {
+		if span.Line == line {
+			return span.EndLine
+		}
+		if span.Line > line && span.Line < next {
+			next = span.Line
+		}
+	}
+	end := next - 1
+	for end > line && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// readProjectFile returns the content of the project file rel, refusing
+// files outside the project and files excluded by its ignore rules.
+func (s *Server) readProjectFile(scope resourceScope, rel string) (string, error) {
+	root, err := filepath.EvalSymlinks(scope.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(scope.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if inside, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(inside) {
+		return "", fmt.Errorf("%s resolves outside the project", rel)
+	}
+
+	var extraIgnore []string
+	var externalGitignore string
+	if cfg, err := s.loadConfig(scope.root); err == nil {
+		extraIgnore, externalGitignore = cfg.Ignore, cfg.ExternalGitignore
+	} else {
+		extraIgnore = config.DefaultConfig().Ignore
+	}
+	matcher, err := indexer.NewIgnoreMatcher(scope.root, extraIgnore, externalGitignore)
+	if err != nil {
+		return "", fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if matcher.ShouldIgnore(p) {
+			return "", fmt.Errorf("%s is excluded by the project's ignore rules", rel)
+		}
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", rel)
+	}
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(content), nil
+}
+
+// readLine is a line of rendered content and the source lines it stands for.
+type readLine struct {
+	text       string
+	start, end int
+}
+
+// fitToBudget renders lines start to end of a file. When the content
+// exceeds maxTokens, the largest function bodies are collapsed to a marker,
+// keeping their signatures, and the content is then cut at the last line
+// fitting in the budget. A maxTokens of zero disables the budget.
+func fitToBudget(lines []string, start, end int, spans []trace.Symbol, maxTokens int) ReadResult {
+	var collapsed []LineRange
+	rendered := renderReadLines(lines, start, end, collapsed)
+	if maxTokens > 0 && renderedTokens(rendered) > maxTokens {
+		for _, body := range collapsibleBodies(lines, start, end, spans) {
+			if overlapsAny(body, collapsed) {
+				continue
+			}
+			collapsed = append(collapsed, body)
+			rendered = renderReadLines(lines, start, end, collapsed)
+			if renderedTokens(rendered) <= maxTokens {
+				break
+			}
+		}
+	}
+
+	result := ReadResult{StartLine: start, EndLine: end}
+	if maxTokens > 0 && renderedTokens(rendered) > maxTokens {
+		// Keep at least one line so that reading can progress
+		size := 0
+		for i, line := range rendered {
+			size += len(line.text) + 1
+			if i > 0 && (size+3)/4 > maxTokens {
+				result.EndLine = rendered[i-1].end
+				result.NextLine = line.start
+				result.Truncated = true
+				rendered = rendered[:i]
+				break
+			}
+		}
+	}
+	for _, r := range collapsed {
+		if r.EndLine <= result.EndLine {
+			result.Collapsed = append(result.Collapsed, r)
+		}
+	}
+	sort.Slice(result.Collapsed, func(i, j int) bool { return result.Collapsed[i].StartLine < result.Collapsed[j].StartLine })
+
+	texts := make([]string, len(rendered))
+	for i, line := range rendered {
+		texts[i] = line.text
+	}
+	result.Content = strings.Join(texts, "\n")
+	result.Tokens = embedder.EstimateTokens(result.Content)
+	return result
+}
+
+// renderReadLines returns lines start to end with the collapsed ranges
+// replaced by a marker line.
+func renderReadLines(lines []string, start, end int, collapsed []LineRange) []readLine {
+	rendered := make([]readLine, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		if r, ok := collapsedAt(collapsed, n); ok {
+			indent := lines[r.StartLine-1][:len(lines[r.StartLine-1])-len(strings.TrimLeft(lines[r.StartLine-1], " \t"))]
+			text := fmt.Sprintf("%s... %d lines collapsed (%d-%d)", indent, r.EndLine-r.StartLine+1, r.StartLine, r.EndLine)
+			rendered = append(rendered, readLine{text: text, start: r.StartLine, end: r.EndLine})
+			n = r.EndLine
+			continue
+		}
+		rendered = append(rendered, readLine{text: lines[n-1], start: n, end: n})
+	}
+	return rendered
+}
+
+func renderedTokens(rendered []readLine) int {
+	size := 0
+	for _, line := range rendered {
+		size += len(line.text) + 1
+	}
+	return (size + 3) / 4
+}
+
+func collapsedAt(collapsed []LineRange, line int) (LineRange, bool) {
+	for _, r := range collapsed {
+		if r.StartLine == line {
+			return r, true
+		}
+	}
+	return LineRange{}, false
+}
+
+func overlapsAny(r LineRange, ranges []LineRange) bool {
+	for _, other := range ranges {
+		if r.StartLine <= other.EndLine && other.StartLine <= r.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// collapsibleBodies returns the bodies of the functions within lines start
+// to end, largest first. A body excludes the signature, which may span
+// several lines, and the closing line.
+func collapsibleBodies(lines []string, start, end int, spans []trace.Symbol) []LineRange {
+	var bodies []LineRange
+	for _, span := range spans {
+		if span.Line < start || span.EndLine > end {
+			continue
+		}
+		bodyStart := span.Line + 1
+		for n := span.Line; n < span.EndLine; n++ {
+			trimmed := strings.TrimSpace(lines[n-1])
+			if strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, ":") {
+				bodyStart = n + 1
+				break
+			}
+		}
+		bodyEnd := span.EndLine
+		for bodyEnd >= bodyStart && strings.TrimSpace(lines[bodyEnd-1]) == "" {
+			bodyEnd--
+		}
+		if trimmed := strings.TrimSpace(lines[bodyEnd-1]); bodyEnd > bodyStart && (strings.HasPrefix(trimmed, "}") || trimmed == "end") {
+			bodyEnd--
+		}
+		if bodyEnd-bodyStart+1 >= minCollapsedLines {
+			bodies = append(bodies, LineRange{StartLine: bodyStart, EndLine: bodyEnd})
+		}
+	}
+	sort.SliceStable(bodies, func(i, j int) bool {
+		return bodies[i].EndLine-bodies[i].StartLine > bodies[j].EndLine-bodies[j].StartLine
+	})
+	return bodies
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+const readTestSource = `package big
+
+func First() {
+	a := 1
+	b := 2
+	c := 3
+	_ = a + b + c
+}
+
+func Second() {
+	x := 1
+	_ = x
+}`
+
+func readTestResults(t *testing.T, s *Server, args map[string]any) []ReadResult {
+	t.Helper()
+	result, err := s.handleRead(context.Background(), refsTestRequest(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := textResultPayload(t, result)
+	if result.IsError {
+		t.Fatalf("grepai_read %v failed: %s", args, payload)
+	}
+	var results []ReadResult
+	if err := json.Unmarshal([]byte(payload), &results); err != nil {
+		t.Fatalf("unexpected output %q: %v", payload, err)
+	}
+	return results
+}
+
+func TestHandleRead_PathChunkAndSymbol(t *testing.T) {
+	s, err := NewServer(seedResourceProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := readTestResults(t, s, map[string]any{"path": "src/my_auth/login.go", "start_line": 3, "end_line": 4})
+	if len(results) != 1 || results[0].Content != "func Login(user string) error {\n\treturn nil" || results[0].TotalLines != 5 {
+		t.Fatalf("unexpected path result %+v", results)
+	}
+
+	results = readTestResults(t, s, map[string]any{"chunk_id": "src/my_auth/login.go_0"})
+	if len(results) != 1 || results[0].StartLine != 3 || results[0].EndLine != 5 {
+		t.Fatalf("unexpected chunk result %+v", results)
+	}
+
+	results = readTestResults(t, s, map[string]any{"symbol": "Login"})
+	if len(results) != 1 || results[0].Symbol != "Login" || results[0].Content != "func Login(user string) error {\n\treturn nil\n}" {
+		t.Fatalf("unexpected symbol result %+v", results)
+	}
+
+	for _, args := range []map[string]any{
+		{"path": ".grepai/config.yaml"},
+		{"path": "../outside.go"},
+		{"path": "src/my_auth/login.go", "chunk_id": "src/my_auth/login.go_0"},
+		{"path": "src/my_auth/login.go", "start_line": 10},
+		{"symbol": "Missing"},
+	} {
+		result, err := s.handleRead(context.Background(), refsTestRequest(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsError {
+			t.Errorf("expected %v to fail, got %s", args, textResultPayload(t, result))
+		}
+	}
+}
+
+func TestFitToBudget_CollapsesLargestBodiesFirst(t *testing.T) {
+	lines := strings.Split(readTestSource, "\n")
+	spans := trace.NewRegexExtractor().ExtractFunctionSpans("big.go", readTestSource)
+
+	full := fitToBudget(lines, 1, len(lines), spans, 0)
+	if full.Content != readTestSource || full.Collapsed != nil || full.Truncated {
+		t.Fatalf("expected the whole file without a budget, got %+v", full)
+	}
+
+	withFirstCollapsed := renderedTokens(renderReadLines(lines, 1, len(lines), []LineRange{{StartLine: 4, EndLine: 7}}))
+	got := fitToBudget(lines, 1, len(lines), spans, withFirstCollapsed)
+	if !reflect.DeepEqual(got.Collapsed, []LineRange{{StartLine: 4, EndLine: 7}}) || got.Truncated {
+		t.Fatalf("expected only the largest body to be collapsed, got %+v", got)
+	}
+	if !strings.Contains(got.Content, "func First() {\n\t... 4 lines collapsed (4-7)\n}") || !strings.Contains(got.Content, "\tx := 1") {
+		t.Fatalf("unexpected content:\n%s", got.Content)
+	}
+
+	got = fitToBudget(lines, 1, len(lines), spans, 8)
+	if !got.Truncated || got.NextLine != got.EndLine+1 || got.Tokens > 8 {
+		t.Fatalf("expected the content to be cut within the budget, got %+v", got)
+	}
+	for _, r := range got.Collapsed {
+		if r.EndLine > got.EndLine {
+			t.Fatalf("expected only collapsed ranges before the cut, got %+v", got.Collapsed)
+		}
+	}
+}
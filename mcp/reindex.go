@@ -0,0 +1,242 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+)
+
+// reindexPollInterval is how often grepai_reindex checks whether a watcher
+// finished the reindex it waits for.
+const reindexPollInterval = 250 * time.Millisecond
+
+// Reindexer reindexes paths of projectRoot ("." for the whole project)
+// without a watcher. onProgress is called before each file. It returns the
+// number of files reindexed.
+type Reindexer func(ctx context.Context, projectRoot string, paths []string, onProgress func(done, total int, path string)) (int, error)
+
+// ReindexResult is the outcome of a grepai_reindex call.
+type ReindexResult struct {
+	Mode    string   `json:"mode"` // "watcher" or "inline"
+	Paths   []string `json:"paths"`
+	Done    bool     `json:"done"`
+	Files   int      `json:"files,omitempty"` // Files reindexed inline
+	Message string   `json:"message"`
+}
+
+// SetReindexer makes grepai_reindex hand reindexes to the background
+// watcher whose control socket findWatcher returns for the project, and
+// run reindex itself when no watcher runs.
+func (s *Server) SetReindexer(findWatcher func(projectRoot string) string, reindex Reindexer) {
+	s.findWatcher = findWatcher
+	s.reindexer = reindex
+}
+
+// watcherSocket returns the control socket of the watcher of the working
+// tree, or "".
+func (s *Server) watcherSocket() string {
+	if s.findWatcher == nil || s.projectRoot == "" {
+		return ""
+	}
+	return s.findWatcher(s.projectRoot)
+}
+
+// watcherProjectStatus returns the status of the working tree in the
+// watcher listening on socketPath.
+func (s *Server) watcherProjectStatus(socketPath string) (*daemon.ProjectStatus, error) {
+	resp, err := daemon.SendControl(socketPath, daemon.ControlRequest{Command: daemon.ControlStatus})
+	if err != nil {
+		return nil, err
+	}
+	root := s.projectRoot
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if project := resp.Status.Project(filepath.Clean(root)); project != nil {
+		return project, nil
+	}
+	return nil, fmt.Errorf("the watcher no longer watches %s", s.projectRoot)
+}
+
+// reindexPending reports whether a reindex requested with grepai_reindex
+// is still running.
+func (s *Server) reindexPending() bool {
+	if s.reindexing.Load() {
+		return true
+	}
+	if socketPath := s.watcherSocket(); socketPath != "" {
+		if project, err := s.watcherProjectStatus(socketPath); err == nil {
+			return project.PendingReindex > 0
+		}
+	}
+	return false
+}
+
+// handleReindex handles the grepai_reindex tool call.
+func (s *Server) handleReindex(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pathsArg := request.GetString("paths", "")
+	wait := request.GetBool("wait", false)
+	format := request.GetString("format", "json")
+
+	if format != "json" && format != "toon" {
+		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
+	}
+	if s.projectRoot == "" {
+		return mcp.NewToolResultError("reindex requires a project context; start mcp-serve from a project directory"), nil
+	}
+
+	paths, err := s.reindexPaths(pathsArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	progress := newProgress(ctx, request, len(paths))
+
+	var result *ReindexResult
+	if socketPath := s.watcherSocket(); socketPath != "" {
+		result, err = s.reindexWithWatcher(ctx, socketPath, paths, wait, progress)
+		if errors.Is(err, daemon.ErrControlUnavailable) {
+			// The watcher stopped in between
+			result, err = s.reindexInline(ctx, paths, wait, progress)
+		}
+	} else {
+		result, err = s.reindexInline(ctx, paths, wait, progress)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output, err := encodeOutput(result, format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(output), nil
+}
+
+// reindexPaths parses the comma-separated paths of a grepai_reindex call
+// into project-relative paths; none means the whole project. Paths denied
+// by the MCP server policy are refused.
+func (s *Server) reindexPaths(pathsArg string) ([]string, error) {
+	p, err := s.policyFor("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MCP server policy: %w", err)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, arg := range strings.Split(pathsArg, ",") {
+		if strings.TrimSpace(arg) == "" {
+			continue
+		}
+		_, rel, err := s.resolveReadPath("", "", arg)
+		if err != nil {
+			return nil, err
+		}
+		if rel != "." && !p.allowsPath(rel) {
+			return nil, fmt.Errorf("path %s is denied by the MCP server policy", rel)
+		}
+		if !seen[rel] {
+			seen[rel] = true
+			paths = append(paths, rel)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	return paths, nil
+}
+
+// reindexWithWatcher queues the reindex of paths in the watcher listening
+// on socketPath and, with wait, polls it until it has no reindex pending.
+func (s *Server) reindexWithWatcher(ctx context.Context, socketPath string, paths []string, wait bool, progress *progress) (*ReindexResult, error) {
+	for _, rel := range paths {
+		req := daemon.ControlRequest{Command: daemon.ControlReindex, Path: filepath.Join(s.projectRoot, filepath.FromSlash(rel))}
+		if _, err := daemon.SendControl(socketPath, req); err != nil {
+			return nil, err
+		}
+	}
+	result := &ReindexResult{Mode: "watcher", Paths: paths}
+	if !wait {
+		result.Message = "Reindex queued in the background watcher; grepai_index_status reports reindexing until it is done"
+		return result, nil
+	}
+
+	progress.report(0, "Waiting for the background watcher")
+	ticker := time.NewTicker(reindexPollInterval)
+	defer ticker.Stop()
+	for {
+		project, err := s.watcherProjectStatus(socketPath)
+		if err != nil {
+			return nil, err
+		}
+		if project.PendingReindex == 0 {
+			break
+		}
+		// Requests of other clients are counted too
+		progress.report(max(len(paths)-project.PendingReindex, 0), "Waiting for the background watcher")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	result.Done = true
+	result.Message = "Reindexed by the background watcher"
+	return result, nil
+}
+
+// reindexInline runs the reindex of paths in the server, waiting for it
+// with wait. One inline reindex runs at a time.
+func (s *Server) reindexInline(ctx context.Context, paths []string, wait bool, progress *progress) (*ReindexResult, error) {
+	if s.reindexer == nil {
+		return nil, errors.New("no background watcher runs for this project; start one with 'grepai watch --background'")
+	}
+	if !s.reindexing.CompareAndSwap(false, true) {
+		return nil, errors.New("a reindex is already running; check grepai_index_status until reindexing is false")
+	}
+	// A watcher without a control socket, such as a foreground 'grepai
+	// watch', still writes the index: refuse before reporting the reindex
+	// started. The reindexer takes the lock for good.
+	release, err := daemon.LockIndex(config.GetWatchLockPath(s.projectRoot))
+	if err != nil {
+		s.reindexing.Store(false)
+		return nil, fmt.Errorf("%w; it reindexes files as they change, stop it to reindex from here", err)
+	}
+	release()
+
+	result := &ReindexResult{Mode: "inline", Paths: paths}
+	if !wait {
+		// The reindex outlives the call
+		go func() {
+			defer s.reindexing.Store(false)
+			if _, err := s.reindexer(context.WithoutCancel(ctx), s.projectRoot, paths, nil); err != nil {
+				log.Printf("Warning: reindex of %s failed: %v", s.projectRoot, err)
+			}
+		}()
+		result.Message = "Reindexing in the background; grepai_index_status reports reindexing until it is done"
+		return result, nil
+	}
+
+	defer s.reindexing.Store(false)
+	files, err := s.reindexer(ctx, s.projectRoot, paths, func(done, total int, path string) {
+		progress.setTotal(total)
+		progress.report(done, "Reindexing "+path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Done = true
+	result.Files = files
+	result.Message = fmt.Sprintf("Reindexed %d file(s)", files)
+	return result, nil
+}
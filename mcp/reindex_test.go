@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
+)
+
+func reindexTestResult(t *testing.T, s *Server, args map[string]any) ReindexResult {
+	t.Helper()
+	result, err := s.handleReindex(context.Background(), refsTestRequest(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := textResultPayload(t, result)
+	if result.IsError {
+		t.Fatalf("grepai_reindex %v failed: %s", args, payload)
+	}
+	var out ReindexResult
+	if err := json.Unmarshal([]byte(payload), &out); err != nil {
+		t.Fatalf("unexpected output %q: %v", payload, err)
+	}
+	return out
+}
+
+func TestHandleReindex_Inline(t *testing.T) {
+	projectRoot := seedResourceProject(t)
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MCP.DenyPaths = []string{"private/"}
+	if err := cfg.Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	s.SetReindexer(func(string) string { return "" }, func(_ context.Context, root string, paths []string, _ func(done, total int, path string)) (int, error) {
+		if root != projectRoot {
+			t.Errorf("expected project root %s, got %s", projectRoot, root)
+		}
+		got = paths
+		return 3, nil
+	})
+
+	out := reindexTestResult(t, s, map[string]any{"paths": "src/my_auth, src/my_auth/login.go,src/my_auth/", "wait": true})
+	if out.Mode != "inline" || !out.Done || out.Files != 3 {
+		t.Fatalf("unexpected result %+v", out)
+	}
+	if want := []string{"src/my_auth", "src/my_auth/login.go"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected paths %v, got %v", want, got)
+	}
+
+	reindexTestResult(t, s, map[string]any{"wait": true})
+	if !reflect.DeepEqual(got, []string{"."}) {
+		t.Fatalf("expected the whole project to be reindexed, got %v", got)
+	}
+
+	for _, args := range []map[string]any{
+		{"paths": "../outside.go"},
+		{"paths": "private/keys.go"},
+	} {
+		result, err := s.handleReindex(context.Background(), refsTestRequest(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsError {
+			t.Errorf("expected %v to fail, got %s", args, textResultPayload(t, result))
+		}
+	}
+}
+
+func TestHandleReindex_InlineInBackground(t *testing.T) {
+	s, err := NewServer(seedResourceProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.SetReindexer(nil, func(ctx context.Context, _ string, _ []string, _ func(done, total int, path string)) (int, error) {
+		defer wg.Done()
+		<-release
+		return 1, ctx.Err()
+	})
+
+	out := reindexTestResult(t, s, map[string]any{})
+	if out.Mode != "inline" || out.Done {
+		t.Fatalf("expected the reindex to run in the background, got %+v", out)
+	}
+	if !s.reindexPending() {
+		t.Fatal("expected a pending reindex")
+	}
+
+	result, err := s.handleReindex(context.Background(), refsTestRequest(map[string]any{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(textResultPayload(t, result), "already running") {
+		t.Fatalf("expected a second reindex to be refused, got %s", textResultPayload(t, result))
+	}
+
+	close(release)
+	wg.Wait()
+	deadline := time.Now().Add(time.Second)
+	for s.reindexPending() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the reindex to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleReindex_WithoutReindexer(t *testing.T) {
+	s, err := NewServer(seedResourceProject(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.handleReindex(context.Background(), refsTestRequest(map[string]any{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(textResultPayload(t, result), "grepai watch --background") {
+		t.Fatalf("expected a hint to start a watcher, got %s", textResultPayload(t, result))
+	}
+}
+
+// reindexTestWatcher is a watcher whose reindexes finish after one status
+// request.
+type reindexTestWatcher struct {
+	root string
+
+	mu      sync.Mutex
+	paths   []string
+	pending int
+}
+
+func (w *reindexTestWatcher) Status() daemon.WatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := daemon.WatchStatus{Projects: []daemon.ProjectStatus{{Root: w.root, State: "running", PendingReindex: w.pending}}}
+	w.pending = 0
+	return status
+}
+
+func (w *reindexTestWatcher) Reindex(path string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paths = append(w.paths, path)
+	w.pending++
+	return "queued", nil
+}
+
+func (w *reindexTestWatcher) Pause() error        { return nil }
+func (w *reindexTestWatcher) Resume() error       { return nil }
+func (w *reindexTestWatcher) ReloadConfig() error { return nil }
+func (w *reindexTestWatcher) Stop() error         { return nil }
+
+func TestHandleReindex_DelegatesToWatcher(t *testing.T) {
+	projectRoot := seedResourceProject(t)
+	root, err := filepath.EvalSymlinks(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher := &reindexTestWatcher{root: root}
+	socketPath := daemon.GetControlSocketPath(t.TempDir())
+	control, err := daemon.ServeControl(socketPath, watcher)
+	if err != nil {
+		t.Fatalf("ServeControl failed: %v", err)
+	}
+	defer control.Close()
+
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetReindexer(func(string) string { return socketPath }, func(context.Context, string, []string, func(done, total int, path string)) (int, error) {
+		t.Error("expected the watcher to reindex")
+		return 0, nil
+	})
+
+	out := reindexTestResult(t, s, map[string]any{"paths": "src/my_auth/login.go", "wait": true})
+	if out.Mode != "watcher" || !out.Done {
+		t.Fatalf("unexpected result %+v", out)
+	}
+	if want := []string{filepath.Join(projectRoot, "src", "my_auth", "login.go")}; !reflect.DeepEqual(watcher.paths, want) {
+		t.Fatalf("expected the watcher to get %v, got %v", want, watcher.paths)
+	}
+	if s.reindexPending() {
+		t.Fatal("expected no pending reindex once the watcher is done")
+	}
+}
+
+func TestHandleReindex_RefusedWhileWatchedWithoutSocket(t *testing.T) {
+	projectRoot := seedResourceProject(t)
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	s.SetReindexer(func(string) string { return "" }, func(context.Context, string, []string, func(done, total int, path string)) (int, error) {
+		called = true
+		return 0, nil
+	})
+
+	// A foreground watcher serves no control socket
+	release, err := daemon.HoldWatchLock(config.GetWatchLockPath(projectRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.handleReindex(context.Background(), refsTestRequest(map[string]any{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(textResultPayload(t, result), daemon.ErrIndexBusy.Error()) {
+		t.Fatalf("expected the reindex to be refused, got %s", textResultPayload(t, result))
+	}
+	if called || s.reindexPending() {
+		t.Fatal("expected no reindex to run")
+	}
+
+	release()
+	reindexTestResult(t, s, map[string]any{"wait": true})
+	if !called {
+		t.Fatal("expected the reindex to run once the watcher stopped")
+	}
+}
@@ -0,0 +1,522 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/framework"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// Resource URIs. Project resources are addressed relative to the working
+// tree, workspace resources by workspace and project name:
+//
+//	grepai://file/{path}
+//	grepai://workspace/{workspace}/{project}/file/{path}
+const (
+	resourceScheme      = "grepai://"
+	resourceWorkspace   = "workspace"
+	resourceKindFile    = "file"
+	resourceKindChunk   = "chunk"
+	resourceKindSymbol  = "symbol"
+	resourceKindRPGNode = "rpg"
+)
+
+// resourcePollInterval is how often the index is checked for files updated
+// by the watcher.
+const resourcePollInterval = 5 * time.Second
+
+// maxResourceUpdates bounds the update notifications sent for one index
+// change; larger changes only notify that the resource list changed.
+const maxResourceUpdates = 100
+
+// resourceScope is the project a resource belongs to.
+type resourceScope struct {
+	root      string            // project directory
+	workspace *config.Workspace // nil for the working tree
+	project   string            // project name within the workspace
+}
+
+// uri returns the URI of the resource of kind identified by id.
+func (sc resourceScope) uri(kind, id string) string {
+	if sc.workspace == nil {
+		return resourceScheme + kind + "/" + id
+	}
+	return resourceScheme + resourceWorkspace + "/" + sc.workspace.Name + "/" + sc.project + "/" + kind + "/" + id
+}
+
+// storePath returns the path under which the store indexes rel.
+func (sc resourceScope) storePath(rel string) string {
+	if sc.workspace == nil {
+		return rel
+	}
+	return sc.workspace.Name + "/" + sc.project + "/" + rel
+}
+
+// relPath is the inverse of storePath. It returns false for paths of other
+// projects.
+func (sc resourceScope) relPath(storePath string) (string, bool) {
+	if sc.workspace == nil {
+		return storePath, true
+	}
+	return strings.CutPrefix(storePath, sc.workspace.Name+"/"+sc.project+"/")
+}
+
+// registerResources registers the resource templates of files, chunks,
+// symbols and RPG nodes. Indexed files are listed as resources by
+// syncResources.
+func (s *Server) registerResources() {
+	templates := []struct {
+		kind, variable, name, description, mimeType string
+	}{
+		{resourceKindFile, "{+path}", "Indexed file", "Content of an indexed file, with its hash, modification time and chunk URIs", ""},
+		{resourceKindChunk, "{+id}", "Indexed chunk", "Content and line range of an indexed chunk", "text/plain"},
+		{resourceKindSymbol, "{name}", "Symbol definition", "Definitions of a symbol from the trace index, with their source", "application/json"},
+		{resourceKindRPGNode, "{+node_id}", "RPG node", "RPG node with its feature path, hierarchy, edges and code preview", "application/json"},
+	}
+	for _, t := range templates {
+		opts := []mcp.ResourceTemplateOption{mcp.WithTemplateDescription(t.description)}
+		if t.mimeType != "" {
+			opts = append(opts, mcp.WithTemplateMIMEType(t.mimeType))
+		}
+		s.mcpServer.AddResourceTemplate(
+			mcp.NewResourceTemplate(resourceScheme+t.kind+"/"+t.variable, t.name, opts...),
+			s.handleReadResource,
+		)
+		s.mcpServer.AddResourceTemplate(
+			mcp.NewResourceTemplate(resourceScheme+resourceWorkspace+"/{workspace}/{project}/"+t.kind+"/"+t.variable, t.name+" (workspace)", opts...),
+			s.handleReadResource,
+		)
+	}
+}
+
+// handleReadResource handles resources/read for all grepai:// URIs.
+func (s *Server) handleReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	scope, kind, id, err := s.parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case resourceKindFile:
+		return s.readFileResource(ctx, scope, uri, id)
+	case resourceKindChunk:
+		return s.readChunkResource(ctx, scope, uri, id)
+	case resourceKindSymbol:
+		return s.readSymbolResource(ctx, scope, uri, id)
+	case resourceKindRPGNode:
+		return s.readRPGResource(ctx, scope, uri, id)
+	default:
+		return nil, fmt.Errorf("unknown resource kind %q in %s", kind, uri)
+	}
+}
+
+// parseResourceURI splits uri into the project it belongs to, the resource
+// kind and the resource identifier.
+func (s *Server) parseResourceURI(uri string) (resourceScope, string, string, error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return resourceScope{}, "", "", fmt.Errorf("unsupported resource URI %q", uri)
+	}
+
+	var scope resourceScope
+	if after, ok := strings.CutPrefix(rest, resourceWorkspace+"/"); ok {
+		parts := strings.SplitN(after, "/", 3)
+		if len(parts) < 3 {
+			return resourceScope{}, "", "", fmt.Errorf("invalid workspace resource URI %q", uri)
+		}
+		var err error
+		if scope, err = workspaceResourceScope(parts[0], parts[1]); err != nil {
+			return resourceScope{}, "", "", err
+		}
+		rest = parts[2]
+	} else {
+		if s.projectRoot == "" {
+			return resourceScope{}, "", "", fmt.Errorf("%s requires a project context; use a grepai://workspace/ URI", uri)
+		}
+		scope = resourceScope{root: s.projectRoot}
+	}
+
+	kind, id, _ := strings.Cut(rest, "/")
+	id, err := url.PathUnescape(id)
+	if err != nil {
+		return resourceScope{}, "", "", fmt.Errorf("invalid resource URI %q: %w", uri, err)
+	}
+	if id == "" {
+		return resourceScope{}, "", "", fmt.Errorf("invalid resource URI %q: missing identifier", uri)
+	}
+	return scope, kind, id, nil
+}
+
+// workspaceResourceScope resolves a project of a configured workspace.
+func workspaceResourceScope(workspaceName, projectName string) (resourceScope, error) {
+	wsCfg, err := config.LoadWorkspaceConfig()
+	if err != nil {
+		return resourceScope{}, fmt.Errorf("failed to load workspace config: %w", err)
+	}
+	if wsCfg == nil {
+		return resourceScope{}, fmt.Errorf("no workspaces configured")
+	}
+	ws, err := wsCfg.GetWorkspace(workspaceName)
+	if err != nil {
+		return resourceScope{}, fmt.Errorf("workspace not found: %w", err)
+	}
+	for _, p := range ws.Projects {
+		if p.Name == projectName {
+			return resourceScope{root: p.Path, workspace: ws, project: p.Name}, nil
+		}
+	}
+	return resourceScope{}, fmt.Errorf("project %q not found in workspace %q", projectName, workspaceName)
+}
+
+// openResourceStore opens the vector store indexing the project of scope.
+func (s *Server) openResourceStore(ctx context.Context, scope resourceScope) (store.VectorStore, error) {
+	if scope.workspace != nil {
+		return s.openWorkspaceStore(ctx, scope.workspace)
+	}
+	cfg, err := s.loadConfig(scope.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return s.openStore(ctx, cfg)
+}
+
+// closeReadOnlyStore closes a store that was only read from. GOB stores are
+// not closed: closing persists them, which would overwrite a newer index
+// written by the watcher in the meantime.
+func closeReadOnlyStore(st store.VectorStore) {
+	if _, ok := st.(*store.GOBStore); !ok {
+		_ = st.Close()
+	}
+}
+
+// readFileResource returns the content of an indexed file. Files that are
+// not indexed are refused, so the resource cannot read outside the index.
+func (s *Server) readFileResource(ctx context.Context, scope resourceScope, uri, rel string) ([]mcp.ResourceContents, error) {
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return nil, fmt.Errorf("invalid file path %q", rel)
+	}
+	st, err := s.openResourceStore(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReadOnlyStore(st)
+
+	doc, err := st.GetDocument(ctx, scope.storePath(rel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("file not indexed: %s", rel)
+	}
+	content, err := os.ReadFile(filepath.Join(scope.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	chunkURIs := make([]string, 0, len(doc.ChunkIDs))
+	for _, id := range doc.ChunkIDs {
+		if relID, ok := scope.relPath(id); ok {
+			chunkURIs = append(chunkURIs, scope.uri(resourceKindChunk, relID))
+		}
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: resourceMIMEType(rel),
+		Text:     string(content),
+		Meta: map[string]any{
+			"path":     rel,
+			"hash":     doc.Hash,
+			"mod_time": doc.ModTime.Format(time.RFC3339),
+			"chunks":   chunkURIs,
+		},
+	}}, nil
+}
+
+// readChunkResource returns the content of an indexed chunk.
+func (s *Server) readChunkResource(ctx context.Context, scope resourceScope, uri, id string) ([]mcp.ResourceContents, error) {
+	chunk, rel, err := s.lookupChunk(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "text/plain",
+		Text:     chunk.Content,
+		Meta: map[string]any{
+			"file":       rel,
+			"file_uri":   scope.uri(resourceKindFile, rel),
+			"start_line": chunk.StartLine,
+			"end_line":   chunk.EndLine,
+		},
+	}}, nil
+}
+
+// lookupChunk finds the indexed chunk with the project-relative ID id and
+// returns it with the project-relative path of its file.
+func (s *Server) lookupChunk(ctx context.Context, scope resourceScope, id string) (*store.Chunk, string, error) {
+	st, err := s.openResourceStore(ctx, scope)
+	if err != nil {
+		return nil, "", err
+	}
+	defer closeReadOnlyStore(st)
+
+	for _, rel := range chunkFileCandidates(id) {
+		chunks, err := st.GetChunksForFile(ctx, scope.storePath(rel))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get chunks: %w", err)
+		}
+		for i := range chunks {
+			if chunks[i].ID == scope.storePath(id) {
+				return &chunks[i], rel, nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("chunk not found: %s", id)
+}
+
+// chunkFileCandidates returns the paths a chunk ID may belong to. Chunk IDs
+// are "<path>_<index>[_<sub-index>]" and paths may contain underscores.
+func chunkFileCandidates(id string) []string {
+	var candidates []string
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndexByte(id, '_')
+		if idx <= 0 {
+			break
+		}
+		if _, err := strconv.Atoi(id[idx+1:]); err != nil {
+			break
+		}
+		id = id[:idx]
+		candidates = append(candidates, id)
+	}
+	return candidates
+}
+
+// symbolResource is a symbol definition served as a resource.
+type symbolResource struct {
+	Symbol  trace.Symbol `json:"symbol"`
+	FileURI string       `json:"file_uri"`
+	Source  string       `json:"source,omitempty"`
+}
+
+// readSymbolResource returns the definitions of a symbol with their source.
+func (s *Server) readSymbolResource(ctx context.Context, scope resourceScope, uri, name string) ([]mcp.ResourceContents, error) {
+	symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(scope.root))
+	if err != nil {
+		return nil, fmt.Errorf("symbol index not available: %w", err)
+	}
+	defer symbolStore.Close()
+
+	symbols, err := symbolStore.LookupSymbol(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup symbol: %w", err)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbol not found: %s", name)
+	}
+
+	definitions := make([]symbolResource, 0, len(symbols))
+	for _, sym := range symbols {
+		definitions = append(definitions, symbolResource{
+			Symbol:  sym,
+			FileURI: scope.uri(resourceKindFile, filepath.ToSlash(sym.File)),
+			Source:  symbolSource(scope.root, sym),
+		})
+	}
+	data, err := json.MarshalIndent(definitions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// symbolSource returns the source lines of sym in the project at root, or ""
+// when they cannot be read.
+func symbolSource(root string, sym trace.Symbol) string {
+	if root == "" || !filepath.IsLocal(sym.File) {
+		return ""
+	}
+	content, err := os.ReadFile(filepath.Join(root, sym.File))
+	if err != nil {
+		return ""
+	}
+	return framework.SourceSnippet(string(content), sym.Line, sym.EndLine)
+}
+
+// readRPGResource returns an RPG node with its context.
+func (s *Server) readRPGResource(ctx context.Context, scope resourceScope, uri, nodeID string) ([]mcp.ResourceContents, error) {
+	rpgSt, qe, err := s.openRPG(ctx, scope.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RPG: %w", err)
+	}
+	if rpgSt == nil {
+		return nil, fmt.Errorf("RPG is not enabled or index is empty")
+	}
+	defer rpgSt.Close()
+
+	result, err := qe.FetchNode(ctx, rpg.FetchNodeRequest{NodeID: nodeID})
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("node not found: %s", nodeID)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// resourceMIMEType guesses the MIME type of a file from its extension.
+func resourceMIMEType(rel string) string {
+	if mimeType := mime.TypeByExtension(path.Ext(rel)); mimeType != "" {
+		return mimeType
+	}
+	return "text/plain"
+}
+
+// watchResources keeps the listed file resources in sync with the index
+// until ctx is done, notifying clients of the files the watcher updates.
+func (s *Server) watchResources(ctx context.Context) {
+	ticker := time.NewTicker(resourcePollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.syncResources(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Warning: failed to refresh MCP resources: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncResources lists the indexed files of the server's project, or of its
+// workspace, as resources. Files whose modification time changed since the
+// previous call are announced with notifications/resources/updated to the
+// sessions subscribed to them, and added or removed files with
+// notifications/resources/list_changed to every session. It must
+// not be called concurrently.
+func (s *Server) syncResources(ctx context.Context) error {
+	var scopes []resourceScope
+	var fingerprint string
+	switch {
+	case s.workspaceName != "":
+		wsCfg, err := config.LoadWorkspaceConfig()
+		if err != nil || wsCfg == nil {
+			return err
+		}
+		ws, err := wsCfg.GetWorkspace(s.workspaceName)
+		if err != nil {
+			return err
+		}
+		for _, p := range ws.Projects {
+			scopes = append(scopes, resourceScope{root: p.Path, workspace: ws, project: p.Name})
+		}
+	case s.projectRoot != "":
+		scopes = append(scopes, resourceScope{root: s.projectRoot})
+		// Avoid reloading a GOB index that did not change
+		fingerprint = fileFingerprint(config.GetConfigPath(s.projectRoot), config.GetIndexPath(s.projectRoot))
+		if s.resourceFiles != nil && fingerprint == s.resourceFingerprint {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	st, err := s.openResourceStore(ctx, scopes[0])
+	if err != nil {
+		return err
+	}
+	defer closeReadOnlyStore(st)
+	files, err := st.ListFilesWithStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Files the server policy does not allow are not listed
+	p, err := s.scopePolicy(scopes[0])
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]time.Time, len(files))
+	names := make(map[string]string, len(files))
+	for _, f := range files {
+		for _, scope := range scopes {
+			if rel, ok := scope.relPath(filepath.ToSlash(f.Path)); ok {
+				if !p.allowsPath(rel) {
+					break
+				}
+				uri := scope.uri(resourceKindFile, rel)
+				current[uri] = f.ModTime
+				names[uri] = path.Join(scope.project, rel)
+				break
+			}
+		}
+	}
+
+	previous := s.resourceFiles
+	s.resourceFiles = current
+	s.resourceFingerprint = fingerprint
+
+	listChanged := previous == nil || len(previous) != len(current)
+	var updated []string
+	for uri, modTime := range current {
+		prevModTime, ok := previous[uri]
+		if !ok {
+			listChanged = true
+		} else if !prevModTime.Equal(modTime) {
+			updated = append(updated, uri)
+		}
+	}
+
+	if listChanged {
+		resources := make([]server.ServerResource, 0, len(current))
+		for uri, name := range names {
+			resources = append(resources, server.ServerResource{
+				Resource: mcp.NewResource(uri, name, mcp.WithMIMEType(resourceMIMEType(name))),
+				Handler:  s.handleReadResource,
+			})
+		}
+		// SetResources notifies clients that the list changed
+		s.mcpServer.SetResources(resources...)
+	}
+	if previous == nil || len(updated) == 0 {
+		return nil
+	}
+	if len(updated) > maxResourceUpdates {
+		if !listChanged {
+			s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+		}
+		return nil
+	}
+	for _, uri := range updated {
+		for _, sessionID := range s.subscriptions.subscribers(uri) {
+			_ = s.mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+const resourceTestSource = "package auth\n\nfunc Login(user string) error {\n\treturn nil\n}\n"
+
+// seedResourceProject creates a project with one indexed file, its chunk and
+// its symbols.
+func seedResourceProject(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	projectRoot := t.TempDir()
+	if err := config.DefaultConfig().Save(projectRoot); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(projectRoot, "src", "my_auth"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectRoot, "src", "my_auth", "login.go"), []byte(resourceTestSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.NewGOBStore(config.GetIndexPath(projectRoot))
+	chunk := store.Chunk{ID: "src/my_auth/login.go_0", FilePath: "src/my_auth/login.go", StartLine: 3, EndLine: 5, Content: "func Login(user string) error {\n\treturn nil\n}", Vector: []float32{1, 0, 0}}
+	if err := st.SaveChunks(ctx, []store.Chunk{chunk}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SaveDocument(ctx, store.Document{Path: chunk.FilePath, Hash: "abc", ModTime: time.Unix(1700000000, 0), ChunkIDs: []string{chunk.ID}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(projectRoot))
+	if err := symbolStore.SaveFile(ctx, chunk.FilePath, []trace.Symbol{
+		{Name: "Login", Kind: trace.KindFunction, File: chunk.FilePath, Line: 3, EndLine: 5, Language: "go"},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbolStore.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return projectRoot
+}
+
+func readTestResource(t *testing.T, s *Server, uri string) mcp.TextResourceContents {
+	t.Helper()
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	contents, err := s.handleReadResource(context.Background(), request)
+	if err != nil {
+		t.Fatalf("read %s: %v", uri, err)
+	}
+	if len(contents) != 1 {
+		t.Fatalf("expected 1 content, got %d", len(contents))
+	}
+	text, ok := contents[0].(mcp.TextResourceContents)
+	if !ok {
+		t.Fatalf("expected text contents, got %T", contents[0])
+	}
+	return text
+}
+
+func TestReadResource_FileChunkAndSymbol(t *testing.T) {
+	projectRoot := seedResourceProject(t)
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := readTestResource(t, s, "grepai://file/src/my_auth/login.go")
+	if file.Text != resourceTestSource {
+		t.Fatalf("unexpected file content %q", file.Text)
+	}
+	if file.Meta["hash"] != "abc" || !reflect.DeepEqual(file.Meta["chunks"], []string{"grepai://chunk/src/my_auth/login.go_0"}) {
+		t.Fatalf("unexpected file metadata %v", file.Meta)
+	}
+
+	chunk := readTestResource(t, s, "grepai://chunk/src/my_auth/login.go_0")
+	if chunk.Meta["start_line"] != 3 || chunk.Meta["file_uri"] != "grepai://file/src/my_auth/login.go" {
+		t.Fatalf("unexpected chunk metadata %v", chunk.Meta)
+	}
+
+	symbol := readTestResource(t, s, "grepai://symbol/Login")
+	var defs []symbolResource
+	if err := json.Unmarshal([]byte(symbol.Text), &defs); err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 || defs[0].Source != "func Login(user string) error {\n\treturn nil\n}" {
+		t.Fatalf("unexpected symbol definitions %+v", defs)
+	}
+
+	// Files outside the index are not served
+	for _, uri := range []string{"grepai://file/.grepai/config.yaml", "grepai://file/../etc/passwd", "grepai://chunk/missing.go_0"} {
+		var request mcp.ReadResourceRequest
+		request.Params.URI = uri
+		if _, err := s.handleReadResource(context.Background(), request); err == nil {
+			t.Errorf("expected reading %s to fail", uri)
+		}
+	}
+}
+
+func TestChunkFileCandidates(t *testing.T) {
+	got := chunkFileCandidates("src/my_file.go_3_1")
+	want := []string{"src/my_file.go_3", "src/my_file.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("chunkFileCandidates = %v, want %v", got, want)
+	}
+	if got := chunkFileCandidates("no_index"); got != nil {
+		t.Fatalf("expected no candidates, got %v", got)
+	}
+}
+
+func TestSyncResources_ListsFilesAndDetectsUpdates(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := seedResourceProject(t)
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.syncResources(ctx); err != nil {
+		t.Fatal(err)
+	}
+	uri := "grepai://file/src/my_auth/login.go"
+	if _, ok := s.resourceFiles[uri]; !ok || len(s.resourceFiles) != 1 {
+		t.Fatalf("unexpected resources %v", s.resourceFiles)
+	}
+	before, err := os.Stat(config.GetIndexPath(projectRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reading resources must not rewrite the index
+	readTestResource(t, s, uri)
+	if after, _ := os.Stat(config.GetIndexPath(projectRoot)); !after.ModTime().Equal(before.ModTime()) {
+		t.Fatal("reading a resource rewrote the index")
+	}
+
+	// Simulate the watcher re-indexing the file
+	st := store.NewGOBStore(config.GetIndexPath(projectRoot))
+	if err := st.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SaveDocument(ctx, store.Document{Path: "src/my_auth/login.go", Hash: "def", ModTime: time.Unix(1700000100, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(config.GetIndexPath(projectRoot), time.Now(), before.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.syncResources(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.resourceFiles[uri]; !got.Equal(time.Unix(1700000100, 0)) {
+		t.Fatalf("expected the new modification time, got %v", got)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 
 	"time"
 
@@ -20,7 +21,9 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/daemon"
 	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/indexer"
 	"github.com/yoanbernabeu/grepai/rpg"
 	"github.com/yoanbernabeu/grepai/search"
 	"github.com/yoanbernabeu/grepai/stats"
@@ -34,6 +37,20 @@ type Server struct {
 	projectRoot   string
 	workspaceName string // non-empty when started via --workspace or auto-detect
 	recorder      *stats.Recorder
+	queryClient   func(projectRoot string) *daemon.QueryClient
+	findWatcher   func(projectRoot string) string // control socket of the project's watcher
+	reindexer     Reindexer
+	reindexing    atomic.Bool  // an inline reindex is running
+	warm          *warmIndexes // indexes kept loaded between tool calls
+	calls         *toolCalls   // tool calls in progress, for cancellation
+	subscriptions *resourceSubscriptions
+	toolTimeout   time.Duration
+	toolTimeouts  map[string]time.Duration // per-tool overrides of toolTimeout
+
+	// Indexed files listed as resources, by URI, and the index they were
+	// listed from; maintained by syncResources
+	resourceFiles       map[string]time.Time
+	resourceFingerprint string
 }
 
 // SearchResult is a lightweight struct for MCP output.
@@ -97,16 +114,18 @@ type CallEdgeCompact struct {
 
 // IndexStatus represents the current state of the index.
 type IndexStatus struct {
-	TotalFiles   int    `json:"total_files"`
-	TotalChunks  int    `json:"total_chunks"`
-	IndexSize    string `json:"index_size"`
-	LastUpdated  string `json:"last_updated"`
-	Provider     string `json:"provider"`
-	Model        string `json:"model"`
-	SymbolsReady bool   `json:"symbols_ready"`
-	RPGEnabled   bool   `json:"rpg_enabled"`
-	RPGNodes     int    `json:"rpg_nodes,omitempty"`
-	RPGEdges     int    `json:"rpg_edges,omitempty"`
+	TotalFiles   int          `json:"total_files"`
+	TotalChunks  int          `json:"total_chunks"`
+	IndexSize    string       `json:"index_size"`
+	LastUpdated  string       `json:"last_updated"`
+	Provider     string       `json:"provider"`
+	Model        string       `json:"model"`
+	SymbolsReady bool         `json:"symbols_ready"`
+	RPGEnabled   bool         `json:"rpg_enabled"`
+	RPGNodes     int          `json:"rpg_nodes,omitempty"`
+	RPGEdges     int          `json:"rpg_edges,omitempty"`
+	Reindexing   bool         `json:"reindexing,omitempty"` // a grepai_reindex call is still running
+	Health       *IndexHealth `json:"health,omitempty"`
 }
 
 // encodeOutput encodes data in the specified format (json or toon).
@@ -128,17 +147,34 @@ func NewServer(projectRoot string) (*Server, error) {
 	s := &Server{
 		projectRoot: projectRoot,
 		recorder:    stats.NewRecorder(projectRoot),
+		warm:        newWarmIndexes(),
+		calls:         newToolCalls(),
+		subscriptions: newResourceSubscriptions(),
+		toolTimeout:   DefaultToolTimeout,
 	}
 
+	hooks := newToolCallHooks()
+	s.addSubscriptionHooks(hooks)
+
 	// Create MCP server
 	s.mcpServer = server.NewMCPServer(
 		"grepai",
 		"1.0.0",
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
+		server.WithToolFilter(s.filterTools),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.limitToolCall),
+		server.WithToolHandlerMiddleware(s.enforceToolPolicy),
+		server.WithResourceHandlerMiddleware(s.enforceResourcePolicy),
 	)
+	s.mcpServer.AddNotificationHandler(cancelledNotification, s.handleCancelled)
 
-	// Register tools
+	// Register tools, resources and prompts
 	s.registerTools()
+	s.registerResources()
+	s.registerPrompts()
 
 	return s, nil
 }
@@ -150,19 +186,65 @@ func NewServerWithWorkspace(projectRoot, workspaceName string) (*Server, error)
 		projectRoot:   projectRoot,
 		workspaceName: workspaceName,
 		recorder:      stats.NewRecorder(projectRoot),
+		warm:          newWarmIndexes(),
+		calls:         newToolCalls(),
+		subscriptions: newResourceSubscriptions(),
+		toolTimeout:   DefaultToolTimeout,
 	}
 
+	hooks := newToolCallHooks()
+	s.addSubscriptionHooks(hooks)
+
 	s.mcpServer = server.NewMCPServer(
 		"grepai",
 		"1.0.0",
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
+		server.WithToolFilter(s.filterTools),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.limitToolCall),
+		server.WithToolHandlerMiddleware(s.enforceToolPolicy),
+		server.WithResourceHandlerMiddleware(s.enforceResourcePolicy),
 	)
+	s.mcpServer.AddNotificationHandler(cancelledNotification, s.handleCancelled)
 
 	s.registerTools()
+	s.registerResources()
+	s.registerPrompts()
 
 	return s, nil
 }
 
+// SetQueryClientFinder makes search, trace and refs tools use the indexes
+// loaded by a background watcher when find returns a client for the project.
+func (s *Server) SetQueryClientFinder(find func(projectRoot string) *daemon.QueryClient) {
+	s.queryClient = find
+}
+
+// findQueryClient returns a client for the watcher serving the working
+// tree's indexes, or nil.
+func (s *Server) findQueryClient() *daemon.QueryClient {
+	if s.queryClient == nil || s.projectRoot == "" {
+		return nil
+	}
+	return s.queryClient(s.projectRoot)
+}
+
+// openSymbolStore loads the symbol index at indexPath. The working tree's
+// index is served by a background watcher when one answers queries, and
+// loaded directly once a query to it fails.
+func (s *Server) openSymbolStore(ctx context.Context, indexPath string) (trace.SymbolStore, error) {
+	if indexPath == config.GetSymbolIndexPath(s.projectRoot) {
+		if client := s.findQueryClient(); client != nil {
+			return daemon.NewFallbackSymbolStore(client.SymbolStore(), func(ctx context.Context) (trace.SymbolStore, error) {
+				return s.warmSymbolStore(ctx, indexPath)
+			}), nil
+		}
+	}
+	return s.warmSymbolStore(ctx, indexPath)
+}
+
 // registerTools registers all grepai tools with the MCP server.
 func (s *Server) registerTools() {
 	// grepai_search tool
@@ -190,9 +272,45 @@ func (s *Server) registerTools() {
 		mcp.WithString("projects",
 			mcp.Description("Comma-separated list of project names to search within workspace (requires workspace)"),
 		),
+		mcp.WithString("ref",
+			mcp.Description("Search a git ref snapshot (e.g. 'v2.3.0') created with 'grepai snapshot create' instead of the working tree (optional, not supported with workspace)"),
+		),
 	)
 	s.mcpServer.AddTool(searchTool, s.handleSearch)
 
+	// grepai_read tool
+	readTool := mcp.NewTool("grepai_read",
+		mcp.WithDescription("Read code by file and line range, chunk ID or symbol name, e.g. after grepai_search with compact=true. With max_tokens, the largest function bodies are collapsed to keep signatures, then the content is cut and next_line tells where to continue."),
+		mcp.WithString("path",
+			mcp.Description("File path as returned by grepai_search, relative to the project root (in workspace mode: '<workspace>/<project>/<path>', or relative to project)"),
+		),
+		mcp.WithNumber("start_line",
+			mcp.Description("First line to read with path (default: 1)"),
+		),
+		mcp.WithNumber("end_line",
+			mcp.Description("Last line to read with path (default: end of file)"),
+		),
+		mcp.WithString("chunk_id",
+			mcp.Description("Chunk ID to read instead of a path (e.g. 'src/auth.go_2')"),
+		),
+		mcp.WithString("symbol",
+			mcp.Description("Symbol name whose definitions to read instead of a path"),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate token budget for the returned content (default: no limit)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default) or 'toon' (token-efficient)"),
+		),
+		mcp.WithString("workspace",
+			mcp.Description("Workspace name (optional)"),
+		),
+		mcp.WithString("project",
+			mcp.Description("Project name within the workspace (optional)"),
+		),
+	)
+	s.mcpServer.AddTool(readTool, s.handleRead)
+
 	// grepai_trace_callers tool
 	traceCallersTool := mcp.NewTool("grepai_trace_callers",
 		mcp.WithDescription("Find all functions that call the specified symbol. Useful for understanding code dependencies before modifying a function."),
@@ -212,6 +330,9 @@ func (s *Server) registerTools() {
 		mcp.WithString("project",
 			mcp.Description("Project name within workspace (requires workspace)"),
 		),
+		mcp.WithString("ref",
+			mcp.Description("Trace a git ref snapshot (e.g. 'v2.3.0') created with 'grepai snapshot create' instead of the working tree (optional, not supported with workspace)"),
+		),
 	)
 	s.mcpServer.AddTool(traceCallersTool, s.handleTraceCallers)
 
@@ -234,6 +355,9 @@ func (s *Server) registerTools() {
 		mcp.WithString("project",
 			mcp.Description("Project name within workspace (requires workspace)"),
 		),
+		mcp.WithString("ref",
+			mcp.Description("Trace a git ref snapshot (e.g. 'v2.3.0') created with 'grepai snapshot create' instead of the working tree (optional, not supported with workspace)"),
+		),
 	)
 	s.mcpServer.AddTool(traceCalleesTool, s.handleTraceCallees)
 
@@ -256,9 +380,59 @@ func (s *Server) registerTools() {
 		mcp.WithString("project",
 			mcp.Description("Project name within workspace (requires workspace)"),
 		),
+		mcp.WithString("ref",
+			mcp.Description("Trace a git ref snapshot (e.g. 'v2.3.0') created with 'grepai snapshot create' instead of the working tree (optional, not supported with workspace)"),
+		),
 	)
 	s.mcpServer.AddTool(traceGraphTool, s.handleTraceGraph)
 
+	// grepai_diff_search tool
+	diffSearchTool := mcp.NewTool("grepai_diff_search",
+		mcp.WithDescription("Semantic search over the changes between two git refs. Only the changed hunks are embedded and scored against the query; changed symbols touched by the matching hunks are returned with their callers. Useful for code review."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Natural language query (e.g., 'error handling changes', 'new database access')"),
+		),
+		mcp.WithString("base",
+			mcp.Description("Base ref of the comparison (default: main)"),
+		),
+		mcp.WithString("head",
+			mcp.Description("Head ref of the comparison (default: HEAD)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of hunks to return (default: 10)"),
+		),
+		mcp.WithBoolean("compact",
+			mcp.Description("Return minimal output without hunk content (default: false)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default) or 'toon' (token-efficient)"),
+		),
+	)
+	s.mcpServer.AddTool(diffSearchTool, s.handleDiffSearch)
+
+	// grepai_context tool
+	contextTool := mcp.NewTool("grepai_context",
+		mcp.WithDescription("Build a token-budgeted context bundle for a query in one call: semantic search hits with their enclosing symbols, top callers and callees, and RPG feature paths, deduplicated and ranked by score. Hits that don't fit the budget are kept without content when their location still fits."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Natural language query (e.g., 'how sessions are refreshed')"),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate token budget of the bundle (default: 4000)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of search hits considered (default: 20)"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Path prefix to filter search hits"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default) or 'toon' (token-efficient)"),
+		),
+	)
+	s.mcpServer.AddTool(contextTool, s.handleContext)
+
 	refsReadersTool := mcp.NewTool("grepai_refs_readers",
 		mcp.WithDescription("Find readers of a property/state symbol (non-call data usage such as store.uid reads)."),
 		mcp.WithString("symbol",
@@ -324,7 +498,7 @@ func (s *Server) registerTools() {
 
 	// grepai_index_status tool
 	indexStatusTool := mcp.NewTool("grepai_index_status",
-		mcp.WithDescription("Check the health and status of the grepai index. Returns statistics about indexed files, chunks, and configuration."),
+		mcp.WithDescription("Check the health and status of the grepai index. Returns statistics about indexed files, chunks, and configuration, plus the files changed on disk since they were indexed, files that failed to index, and files whose symbols or RPG nodes are behind the vector index. Use grepai_reindex to bring them up to date."),
 		mcp.WithBoolean("verbose", mcp.Description("Include additional debug details when available (optional).")),
 		mcp.WithString("format",
 			mcp.Description("Output format: 'json' (default) or 'toon' (token-efficient)"),
@@ -335,6 +509,21 @@ func (s *Server) registerTools() {
 	)
 	s.mcpServer.AddTool(indexStatusTool, s.handleIndexStatus)
 
+	// grepai_reindex tool
+	reindexTool := mcp.NewTool("grepai_reindex",
+		mcp.WithDescription("Reindex files of the project, for example those grepai_index_status reports as stale or failed. The running background watcher does the work when there is one; otherwise the MCP server indexes the files itself."),
+		mcp.WithString("paths",
+			mcp.Description("Comma-separated files or directories to reindex, relative to the project root (default: the whole project)"),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait until the reindex is done (default: false, the reindex runs in the background)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default) or 'toon' (token-efficient)"),
+		),
+	)
+	s.mcpServer.AddTool(reindexTool, s.handleReindex)
+
 	// grepai_list_workspaces tool
 	listWorkspacesTool := mcp.NewTool("grepai_list_workspaces",
 		mcp.WithDescription("List all available workspace names. Use this to discover valid values for tools that accept the workspace parameter."),
@@ -446,11 +635,15 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 	path := request.GetString("path", "")
 	workspace := request.GetString("workspace", "")
 	projects := request.GetString("projects", "")
+	ref := request.GetString("ref", "")
 
 	// Auto-inject workspace when server is in workspace mode
 	if workspace == "" && s.workspaceName != "" {
 		workspace = s.workspaceName
 	}
+	if ref != "" && workspace != "" {
+		return mcp.NewToolResultError("ref is not supported in workspace mode"), nil
+	}
 
 	// Validate format
 	if format != "json" && format != "toon" {
@@ -459,11 +652,14 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 
 	// Workspace mode
 	if workspace != "" {
-		return s.handleWorkspaceSearch(ctx, query, limit, compact, format, path, workspace, projects)
+		return s.handleWorkspaceSearch(ctx, newProgress(ctx, request, 3), query, limit, compact, format, path, workspace, projects)
 	}
 
+	progress := newProgress(ctx, request, 3)
+	progress.report(0, "Loading index")
+
 	// Load configuration
-	cfg, err := config.Load(s.projectRoot)
+	cfg, err := s.loadConfig(s.projectRoot)
 	if err != nil {
 		if s.projectRoot == "" {
 			wsCfg, wsErr := config.LoadWorkspaceConfig()
@@ -476,40 +672,36 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
 	}
 
-	// Initialize embedder
-	emb, err := s.createEmbedder(cfg)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize embedder: %v", err)), nil
-	}
-	defer emb.Close()
-
-	// Initialize store
-	st, err := s.createStore(ctx, cfg)
+	searcher, closeSearcher, err := s.openSearcher(ctx, cfg, ref)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize store: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	defer st.Close()
-
-	// Create searcher and search
-	searcher := search.NewSearcher(st, emb, cfg.Search)
+	defer closeSearcher()
 	normalizedPath, err := search.NormalizeProjectPathPrefix(path, s.projectRoot)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("invalid path parameter: %v", err)), nil
 	}
+	progress.report(1, "Searching")
 	results, err := searcher.Search(ctx, query, limit, normalizedPath)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
 	}
 
 	// RPG enrichment
+	progress.report(2, "Adding RPG context")
 	type rpgInfo struct {
 		featurePath string
 		symbolName  string
 	}
 	rpgData := make(map[int]rpgInfo)
-	rpgSt, qe, rpgErr := s.tryLoadRPG(ctx)
-	if rpgErr != nil {
-		log.Printf("Warning: RPG enrichment unavailable: %v", rpgErr)
+	var rpgSt rpg.RPGStore
+	var qe *rpg.QueryEngine
+	if ref == "" { // the RPG graph describes the working tree only
+		var rpgErr error
+		rpgSt, qe, rpgErr = s.tryLoadRPG(ctx)
+		if rpgErr != nil {
+			log.Printf("Warning: RPG enrichment unavailable: %v", rpgErr)
+		}
 	}
 	if rpgSt != nil && qe != nil {
 		defer rpgSt.Close()
@@ -572,7 +764,7 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 }
 
 // handleWorkspaceSearch handles workspace-level search via MCP.
-func (s *Server) handleWorkspaceSearch(ctx context.Context, query string, limit int, compact bool, format, pathPrefix, workspaceName, projectsStr string) (*mcp.CallToolResult, error) {
+func (s *Server) handleWorkspaceSearch(ctx context.Context, progress *progress, query string, limit int, compact bool, format, pathPrefix, workspaceName, projectsStr string) (*mcp.CallToolResult, error) {
 	// Load workspace config
 	wsCfg, err := config.LoadWorkspaceConfig()
 	if err != nil {
@@ -606,14 +798,15 @@ func (s *Server) handleWorkspaceSearch(ctx context.Context, query string, limit
 	}
 
 	// Initialize embedder
-	emb, err := s.createWorkspaceEmbedder(ws)
+	progress.report(0, "Loading workspace index")
+	emb, err := s.openEmbedder(ws.Embedder)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize embedder: %v", err)), nil
 	}
 	defer emb.Close()
 
 	// Initialize store
-	st, err := s.createWorkspaceStore(ctx, ws)
+	st, err := s.openWorkspaceStore(ctx, ws)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize store: %v", err)), nil
 	}
@@ -625,6 +818,7 @@ func (s *Server) handleWorkspaceSearch(ctx context.Context, query string, limit
 		Boost:  config.DefaultConfig().Search.Boost,
 	}
 	searcher := search.NewSearcher(st, emb, searchCfg)
+	applyWorkspaceFilePenalties(searcher, ws)
 
 	// Construct full path prefix for database query. Database stores paths as:
 	// workspaceName/projectName/relativePath. When a single project is specified,
@@ -645,11 +839,13 @@ func (s *Server) handleWorkspaceSearch(ctx context.Context, query string, limit
 	}
 
 	// Search
+	progress.report(1, "Searching")
 	var results []store.SearchResult
 	results, err = searcher.Search(ctx, query, limit, fullPathPrefix)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
 	}
+	progress.report(2, "Filtering results")
 
 	// If no single project was specified but a user path was provided, we
 	// post-filter results to match the relative path inside each project.
@@ -1024,25 +1220,104 @@ func (s *Server) resolveWorkspace(workspace string) string {
 	return workspace
 }
 
+// openSearcher returns the searcher of the working tree, or of the snapshot
+// of ref, and a function to call once searches are done. The indexes loaded
+// by a background watcher are reused when it serves queries, until a query
+// to it fails; snapshots are always read directly.
+func (s *Server) openSearcher(ctx context.Context, cfg *config.Config, ref string) (daemon.QuerySearcher, func(), error) {
+	if ref == "" {
+		if client := s.findQueryClient(); client != nil {
+			searcher := daemon.NewFallbackSearcher(client, func(ctx context.Context) (daemon.QuerySearcher, func(), error) {
+				return s.openLocalSearcher(ctx, cfg, "")
+			})
+			return searcher, searcher.Close, nil
+		}
+	}
+	return s.openLocalSearcher(ctx, cfg, ref)
+}
+
+// openLocalSearcher loads the searcher of the working tree, or of the
+// snapshot of ref.
+func (s *Server) openLocalSearcher(ctx context.Context, cfg *config.Config, ref string) (daemon.QuerySearcher, func(), error) {
+
+	emb, err := s.openEmbedder(cfg.Embedder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize embedder: %w", err)
+	}
+
+	var st store.VectorStore
+	reportPath := config.GetScanReportPath(s.projectRoot)
+	if ref != "" {
+		name, nameErr := s.snapshotName(ref)
+		if nameErr != nil {
+			emb.Close()
+			return nil, nil, nameErr
+		}
+		st, err = s.createSnapshotStore(ctx, cfg, name)
+		reportPath = config.GetSnapshotScanReportPath(s.projectRoot, name)
+	} else {
+		st, err = s.openStore(ctx, cfg)
+	}
+	if err != nil {
+		emb.Close()
+		return nil, nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+
+	searcher := search.NewSearcher(st, emb, cfg.Search)
+	if report, err := indexer.LoadScanReport(reportPath); err == nil {
+		searcher.SetFilePenalties(report.Penalized, cfg.Indexing.PenaltyFactor)
+	}
+	return searcher, func() {
+		st.Close()
+		emb.Close()
+	}, nil
+}
+
+// applyWorkspaceFilePenalties ranks the "penalize" policy files of every
+// workspace project lower, each with its own project's penalty factor.
+// Workspace index paths are "<workspace>/<project>/<path>", so the
+// project-relative scan report paths are prefixed to match.
+func applyWorkspaceFilePenalties(searcher *search.Searcher, ws *config.Workspace) {
+	for _, project := range ws.Projects {
+		report, err := indexer.LoadScanReport(config.GetScanReportPath(project.Path))
+		if err != nil || len(report.Penalized) == 0 {
+			continue
+		}
+		factor := float32(config.DefaultPenaltyFactor)
+		if config.Exists(project.Path) {
+			if cfg, err := config.Load(project.Path); err == nil {
+				factor = cfg.Indexing.PenaltyFactor
+			}
+		}
+		prefix := ws.Name + "/" + project.Name + "/"
+		files := make(map[string]string, len(report.Penalized))
+		for path, reason := range report.Penalized {
+			files[prefix+path] = reason
+		}
+		searcher.AddFilePenalties(files, factor)
+	}
+}
+
 // enrichTraceSymbols enriches trace symbols with RPG feature paths.
 // It loads the RPG store once and enriches all provided symbols in one pass.
 func (s *Server) enrichTraceSymbols(ctx context.Context, symbols ...*trace.Symbol) {
-	if s.projectRoot == "" {
-		return
-	}
-	cfg, err := config.Load(s.projectRoot)
-	if err != nil || !cfg.RPG.Enabled {
+	s.enrichTraceSymbolsAt(ctx, s.projectRoot, symbols...)
+}
+
+// enrichTraceSymbolsAt enriches trace symbols with the RPG feature paths of
+// the project at projectRoot.
+func (s *Server) enrichTraceSymbolsAt(ctx context.Context, projectRoot string, symbols ...*trace.Symbol) {
+	rpgStore, qe, err := s.openRPG(ctx, projectRoot)
+	if err != nil {
+		log.Printf("Warning: RPG enrichment unavailable for trace: %v", err)
 		return
 	}
-	rpgStore := rpg.NewGOBRPGStore(config.GetRPGIndexPath(s.projectRoot))
-	if err := rpgStore.Load(ctx); err != nil {
-		log.Printf("Warning: RPG enrichment unavailable for trace: %v", err)
+	if rpgStore == nil {
 		return
 	}
 	defer rpgStore.Close()
 
 	graph := rpgStore.GetGraph()
-	qe := rpg.NewQueryEngine(graph)
 
 	for _, sym := range symbols {
 		if sym == nil || sym.File == "" {
@@ -1072,15 +1347,20 @@ func (s *Server) handleTraceCallers(ctx context.Context, request mcp.CallToolReq
 	format := request.GetString("format", "json")
 	workspace := s.resolveWorkspace(request.GetString("workspace", ""))
 	project := request.GetString("project", "")
+	ref := request.GetString("ref", "")
 
 	// Validate format
 	if format != "json" && format != "toon" {
 		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
 	}
 
+	if ref != "" && workspace != "" {
+		return mcp.NewToolResultError("ref is not supported in workspace mode"), nil
+	}
+
 	// Workspace mode
 	if workspace != "" {
-		stores, loadErr := trace.LoadWorkspaceSymbolStores(ctx, workspace, project)
+		stores, loadErr := s.loadWorkspaceSymbolStores(ctx, workspace, project)
 		if loadErr != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load workspace symbol stores: %v", loadErr)), nil
 		}
@@ -1094,8 +1374,12 @@ func (s *Server) handleTraceCallers(ctx context.Context, request mcp.CallToolReq
 		return mcp.NewToolResultError("trace requires a project context; use --workspace parameter or start mcp-serve from a project directory"), nil
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := s.symbolIndexPath(ref)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	symbolStore, err := s.openSymbolStore(ctx, symbolIndexPath)
+	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load symbol index: %v. Run 'grepai watch' first", err)), nil
 	}
 	defer symbolStore.Close()
@@ -1250,15 +1534,20 @@ func (s *Server) handleTraceCallees(ctx context.Context, request mcp.CallToolReq
 	format := request.GetString("format", "json")
 	workspace := s.resolveWorkspace(request.GetString("workspace", ""))
 	project := request.GetString("project", "")
+	ref := request.GetString("ref", "")
 
 	// Validate format
 	if format != "json" && format != "toon" {
 		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
 	}
 
+	if ref != "" && workspace != "" {
+		return mcp.NewToolResultError("ref is not supported in workspace mode"), nil
+	}
+
 	// Workspace mode
 	if workspace != "" {
-		stores, loadErr := trace.LoadWorkspaceSymbolStores(ctx, workspace, project)
+		stores, loadErr := s.loadWorkspaceSymbolStores(ctx, workspace, project)
 		if loadErr != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load workspace symbol stores: %v", loadErr)), nil
 		}
@@ -1272,8 +1561,12 @@ func (s *Server) handleTraceCallees(ctx context.Context, request mcp.CallToolReq
 		return mcp.NewToolResultError("trace requires a project context; use --workspace parameter or start mcp-serve from a project directory"), nil
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := s.symbolIndexPath(ref)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	symbolStore, err := s.openSymbolStore(ctx, symbolIndexPath)
+	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load symbol index: %v. Run 'grepai watch' first", err)), nil
 	}
 	defer symbolStore.Close()
@@ -1433,15 +1726,23 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 	format := request.GetString("format", "json")
 	workspace := s.resolveWorkspace(request.GetString("workspace", ""))
 	project := request.GetString("project", "")
+	ref := request.GetString("ref", "")
 
 	// Validate format
 	if format != "json" && format != "toon" {
 		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
 	}
 
+	if ref != "" && workspace != "" {
+		return mcp.NewToolResultError("ref is not supported in workspace mode"), nil
+	}
+
+	progress := newProgress(ctx, request, 3)
+
 	// Workspace mode: merge call graphs across projects
 	if workspace != "" {
-		stores, loadErr := trace.LoadWorkspaceSymbolStores(ctx, workspace, project)
+		progress.report(0, "Loading symbol indexes")
+		stores, loadErr := s.loadWorkspaceSymbolStores(ctx, workspace, project)
 		if loadErr != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load workspace symbol stores: %v", loadErr)), nil
 		}
@@ -1455,9 +1756,14 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 		}
 		edgeSeen := make(map[string]bool)
 
-		for _, ss := range stores {
+		progress.setTotal(len(stores))
+		for i, ss := range stores {
+			progress.report(i, fmt.Sprintf("Building call graph (project %d of %d)", i+1, len(stores)))
 			graph, graphErr := ss.GetCallGraph(ctx, symbolName, depth)
 			if graphErr != nil {
+				if ctx.Err() != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to build call graph: %v", graphErr)), nil
+				}
 				continue
 			}
 			for name, sym := range graph.Nodes {
@@ -1492,8 +1798,13 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 		return mcp.NewToolResultError("trace requires a project context; use --workspace parameter or start mcp-serve from a project directory"), nil
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolIndexPath, err := s.symbolIndexPath(ref)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	progress.report(0, "Loading symbol index")
+	symbolStore, err := s.openSymbolStore(ctx, symbolIndexPath)
+	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load symbol index: %v. Run 'grepai watch' first", err)), nil
 	}
 	defer symbolStore.Close()
@@ -1503,6 +1814,7 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 		return mcp.NewToolResultError("symbol index is empty. Run 'grepai watch' first to build the index"), nil
 	}
 
+	progress.report(1, "Building call graph")
 	graph, err := symbolStore.GetCallGraph(ctx, symbolName, depth)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to build call graph: %v", err)), nil
@@ -1516,6 +1828,7 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 
 	// Enrich graph nodes with RPG
 	if result.Graph != nil {
+		progress.report(2, "Adding RPG context")
 		// Collect symbols as pointers for enrichment, paired with their map keys
 		type symEntry struct {
 			name string
@@ -1546,6 +1859,140 @@ func (s *Server) handleTraceGraph(ctx context.Context, request mcp.CallToolReque
 	return mcp.NewToolResultText(output), nil
 }
 
+// handleDiffSearch handles the grepai_diff_search tool call.
+func (s *Server) handleDiffSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError("query parameter is required"), nil
+	}
+
+	limit := request.GetInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	}
+	compact := request.GetBool("compact", false)
+	format := request.GetString("format", "json")
+	if format != "json" && format != "toon" {
+		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
+	}
+	if s.projectRoot == "" {
+		return mcp.NewToolResultError("diff search requires a project; it is not supported in workspace mode"), nil
+	}
+
+	cfg, err := s.loadConfig(s.projectRoot)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	emb, err := s.openEmbedder(cfg.Embedder)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize embedder: %v", err)), nil
+	}
+	defer emb.Close()
+
+	opts := search.DiffSearchOptions{
+		Base:    request.GetString("base", "main"),
+		Head:    request.GetString("head", "HEAD"),
+		Limit:   limit,
+		Secrets: cfg.Indexing.Secrets,
+	}
+	if ignoreMatcher, err := indexer.NewIgnoreMatcher(s.projectRoot, cfg.Ignore, cfg.ExternalGitignore); err == nil {
+		scanner := indexer.NewScanner(s.projectRoot, ignoreMatcher)
+		scanner.SetExtensions(indexer.ExtensionSet(cfg.Indexing.Extensions, cfg.Indexing.ExcludeExtensions))
+		opts.Include = scanner.Accepts
+	}
+	symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot))
+	if err != nil {
+		log.Printf("Warning: symbol index unavailable for diff search: %v", err)
+	} else {
+		defer symbolStore.Close()
+		opts.Symbols = symbolStore
+	}
+
+	result, err := search.DiffSearch(ctx, emb, s.projectRoot, query, opts)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("diff search failed: %v", err)), nil
+	}
+	if compact {
+		for i := range result.Chunks {
+			result.Chunks[i].Content = ""
+		}
+	}
+
+	output, err := encodeOutput(result, format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode results: %v", err)), nil
+	}
+
+	s.recordMCPStats(stats.DiffSearch, mcpOutputMode(compact, format), len(result.Chunks), output)
+	return mcp.NewToolResultText(output), nil
+}
+
+// handleContext handles the grepai_context tool call.
+func (s *Server) handleContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError("query parameter is required"), nil
+	}
+	maxTokens := request.GetInt("max_tokens", search.DefaultContextTokens)
+	limit := request.GetInt("limit", 0)
+	path := request.GetString("path", "")
+	format := request.GetString("format", "json")
+
+	if format != "json" && format != "toon" {
+		return mcp.NewToolResultError("format must be 'json' or 'toon'"), nil
+	}
+	if s.projectRoot == "" {
+		return mcp.NewToolResultError("context requires a project; it is not supported in workspace mode"), nil
+	}
+
+	cfg, err := s.loadConfig(s.projectRoot)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+	progress := newProgress(ctx, request, 2)
+	progress.report(0, "Loading indexes")
+	searcher, closeSearcher, err := s.openSearcher(ctx, cfg, "")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer closeSearcher()
+
+	normalizedPath, err := search.NormalizeProjectPathPrefix(path, s.projectRoot)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid path parameter: %v", err)), nil
+	}
+	opts := search.ContextOptions{MaxTokens: maxTokens, Limit: limit, PathPrefix: normalizedPath}
+	if symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot)); err != nil {
+		log.Printf("Warning: symbol index unavailable for context: %v", err)
+	} else {
+		defer symbolStore.Close()
+		opts.Symbols = symbolStore
+	}
+	rpgSt, qe, rpgErr := s.tryLoadRPG(ctx)
+	if rpgErr != nil {
+		log.Printf("Warning: RPG enrichment unavailable: %v", rpgErr)
+	}
+	if rpgSt != nil {
+		defer rpgSt.Close()
+		opts.FeaturePath = qe.FeaturePathAt
+	}
+
+	progress.report(1, "Building context bundle")
+	bundle, err := search.BuildContext(ctx, searcher, query, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output, err := encodeOutput(bundle, format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode context: %v", err)), nil
+	}
+
+	s.recordMCPStats(stats.Context, mcpOutputMode(false, format), len(bundle.Items), output)
+	return mcp.NewToolResultText(output), nil
+}
+
 func (s *Server) handleRefsReaders(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleRefsByKind(ctx, request, trace.RefKindRead)
 }
@@ -1571,7 +2018,7 @@ func (s *Server) handleRefsGraph(ctx context.Context, request mcp.CallToolReques
 
 	var stores []trace.SymbolStore
 	if workspace != "" {
-		stores, err = trace.LoadWorkspaceSymbolStores(ctx, workspace, project)
+		stores, err = s.loadWorkspaceSymbolStores(ctx, workspace, project)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load workspace symbol stores: %v", err)), nil
 		}
@@ -1580,8 +2027,8 @@ func (s *Server) handleRefsGraph(ctx context.Context, request mcp.CallToolReques
 		if s.projectRoot == "" {
 			return mcp.NewToolResultError("refs requires a project context; use --workspace parameter or start mcp-serve from a project directory"), nil
 		}
-		symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
-		if err := symbolStore.Load(ctx); err != nil {
+		symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot))
+		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load symbol index: %v. Run 'grepai watch' first", err)), nil
 		}
 		defer symbolStore.Close()
@@ -1691,7 +2138,7 @@ func (s *Server) handleRefsByKind(ctx context.Context, request mcp.CallToolReque
 
 	// Workspace mode
 	if workspace != "" {
-		stores, loadErr := trace.LoadWorkspaceSymbolStores(ctx, workspace, project)
+		stores, loadErr := s.loadWorkspaceSymbolStores(ctx, workspace, project)
 		if loadErr != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to load workspace symbol stores: %v", loadErr)), nil
 		}
@@ -1704,8 +2151,8 @@ func (s *Server) handleRefsByKind(ctx context.Context, request mcp.CallToolReque
 		return mcp.NewToolResultError("refs requires a project context; use --workspace parameter or start mcp-serve from a project directory"), nil
 	}
 
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
-	if err := symbolStore.Load(ctx); err != nil {
+	symbolStore, err := s.openSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot))
+	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load symbol index: %v. Run 'grepai watch' first", err)), nil
 	}
 	defer symbolStore.Close()
@@ -1860,8 +2307,7 @@ func (s *Server) handleIndexStatus(ctx context.Context, request mcp.CallToolRequ
 				Name: p.Name,
 				Path: p.Path,
 			}
-			ss := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(p.Path))
-			if loadErr := ss.Load(ctx); loadErr == nil {
+			if ss, loadErr := s.warmSymbolStore(ctx, config.GetSymbolIndexPath(p.Path)); loadErr == nil {
 				if symbolStats, statsErr := ss.GetStats(ctx); statsErr == nil && symbolStats.TotalSymbols > 0 {
 					ps.SymbolsReady = true
 					ps.TotalSymbols = symbolStats.TotalSymbols
@@ -1884,13 +2330,13 @@ func (s *Server) handleIndexStatus(ctx context.Context, request mcp.CallToolRequ
 	}
 
 	// Load configuration
-	cfg, err := config.Load(s.projectRoot)
+	cfg, err := s.loadConfig(s.projectRoot)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
 	}
 
 	// Initialize store
-	st, err := s.createStore(ctx, cfg)
+	st, err := s.openStore(ctx, cfg)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize store: %v", err)), nil
 	}
@@ -1903,13 +2349,13 @@ func (s *Server) handleIndexStatus(ctx context.Context, request mcp.CallToolRequ
 	}
 
 	// Check symbol index
-	symbolStore := trace.NewGOBSymbolStore(config.GetSymbolIndexPath(s.projectRoot))
 	symbolsReady := false
-	if err := symbolStore.Load(ctx); err == nil {
+	symbolStore, err := s.warmSymbolStore(ctx, config.GetSymbolIndexPath(s.projectRoot))
+	if err == nil {
 		if symbolStats, err := symbolStore.GetStats(ctx); err == nil && symbolStats.TotalSymbols > 0 {
 			symbolsReady = true
 		}
-		symbolStore.Close()
+		defer symbolStore.Close()
 	}
 
 	status := IndexStatus{
@@ -1923,16 +2369,31 @@ func (s *Server) handleIndexStatus(ctx context.Context, request mcp.CallToolRequ
 	}
 
 	// Check RPG status
+	var rpgGraph *rpg.Graph
 	rpgSt, _, rpgErr := s.tryLoadRPG(ctx)
 	if rpgErr != nil && !errors.Is(rpgErr, rpg.ErrRPGIndexOutdated) {
 		log.Printf("Warning: failed to load RPG status: %v", rpgErr)
 	}
 	if rpgSt != nil {
 		status.RPGEnabled = true
-		rpgStats := rpgSt.GetGraph().Stats()
+		rpgGraph = rpgSt.GetGraph()
+		rpgStats := rpgGraph.Stats()
 		status.RPGNodes = rpgStats.TotalNodes
 		status.RPGEdges = rpgStats.TotalEdges
-		rpgSt.Close()
+		defer rpgSt.Close()
+	}
+
+	// Check how far the indexes are behind the working tree
+	status.Reindexing = s.reindexPending()
+	health, err := indexHealth(ctx, s.projectRoot, cfg, st, symbolStore, rpgGraph)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("Warning: failed to check index health: %v", err)
+	} else {
+		health.RPGOutdated = errors.Is(rpgErr, rpg.ErrRPGIndexOutdated)
+		status.Health = health
 	}
 
 	output, err := encodeOutput(status, format)
@@ -2058,6 +2519,53 @@ func (s *Server) createStore(ctx context.Context, cfg *config.Config) (store.Vec
 	}
 }
 
+// createSnapshotStore creates the vector store of a named git ref snapshot.
+func (s *Server) createSnapshotStore(ctx context.Context, cfg *config.Config, name string) (store.VectorStore, error) {
+	switch cfg.Store.Backend {
+	case "gob":
+		gobStore := store.NewGOBStore(config.GetSnapshotIndexPath(s.projectRoot, name))
+		if err := gobStore.Load(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load snapshot index: %w", err)
+		}
+		return gobStore, nil
+	case "postgres":
+		return store.NewPostgresStore(ctx, cfg.Store.Postgres.DSN, store.SnapshotID(s.projectRoot, name), cfg.Embedder.GetDimensions())
+	case "qdrant":
+		collectionName := cfg.Store.Qdrant.Collection
+		if collectionName == "" {
+			collectionName = store.SanitizeCollectionName(s.projectRoot)
+		}
+		return store.NewQdrantStore(ctx, cfg.Store.Qdrant.Endpoint, cfg.Store.Qdrant.Port, cfg.Store.Qdrant.UseTLS, store.SnapshotID(collectionName, name), cfg.Store.Qdrant.APIKey, cfg.Embedder.GetDimensions())
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Store.Backend)
+	}
+}
+
+// snapshotName resolves ref (or a snapshot name) to an indexed snapshot.
+func (s *Server) snapshotName(ref string) (string, error) {
+	manifest, err := indexer.FindSnapshot(config.GetSnapshotsDir(s.projectRoot), config.SnapshotFileName, ref, config.SnapshotName(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("ref %q is not indexed; run 'grepai snapshot create %s' first", ref, ref)
+		}
+		return "", err
+	}
+	return manifest.Name, nil
+}
+
+// symbolIndexPath returns the symbol index of the working tree, or of the
+// snapshot of ref when set.
+func (s *Server) symbolIndexPath(ref string) (string, error) {
+	if ref == "" {
+		return config.GetSymbolIndexPath(s.projectRoot), nil
+	}
+	name, err := s.snapshotName(ref)
+	if err != nil {
+		return "", err
+	}
+	return config.GetSnapshotSymbolIndexPath(s.projectRoot, name), nil
+}
+
 // Serve starts the MCP server using stdio transport.
 func (s *Server) Serve() error {
 	// Create stdio server with title fix wrapper
@@ -2067,58 +2575,75 @@ func (s *Server) Serve() error {
 	fixedStdout := &titleFixWriter{Writer: os.Stdout}
 
 	// Start listening with fixed stdout
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.watchResources(ctx)
 	return stdioServer.Listen(ctx, os.Stdin, fixedStdout)
 }
 
-// titleFixWriter wraps io.Writer to fix tool titles in responses
+// titleFixWriter wraps io.Writer to fix tool titles and subscription
+// results in responses
 type titleFixWriter struct {
 	Writer io.Writer
 }
 
 func (w *titleFixWriter) Write(p []byte) (n int, err error) {
+	if fixed, ok := fixResponse(p); ok {
+		return w.Writer.Write(fixed)
+	}
+	return w.Writer.Write(p)
+}
+
+// fixToolTitles rewrites a tools/list response for clients that expect the
+// tool title at the root and a $schema in input schemas. It returns the fixed
+// message followed by a newline, and false when msg is not a tools/list
+// response.
+func fixToolTitles(msg []byte) ([]byte, bool) {
 	// Try to parse as JSON
 	var data map[string]interface{}
-	if err2 := json.Unmarshal(p, &data); err2 != nil {
-		// Not valid JSON, write as-is
-		return w.Writer.Write(p)
+	if err := json.Unmarshal(msg, &data); err != nil {
+		return nil, false
 	}
 
 	// Check if this is a tools/list response
-	if result, ok := data["result"].(map[string]interface{}); ok {
-		if tools, ok := result["tools"].([]interface{}); ok {
-			// Fix each tool
-			for _, toolIf := range tools {
-				tool, ok := toolIf.(map[string]interface{})
-				if !ok {
-					continue
-				}
+	result, ok := data["result"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	tools, ok := result["tools"].([]interface{})
+	if !ok {
+		return nil, false
+	}
 
-				// 1. Move title from annotations to root
-				if annotations, ok := tool["annotations"].(map[string]interface{}); ok {
-					if title, ok := annotations["title"].(string); ok {
-						tool["title"] = title
-						delete(annotations, "title")
-					}
-				}
+	// Fix each tool
+	for _, toolIf := range tools {
+		tool, ok := toolIf.(map[string]interface{})
+		if !ok {
+			continue
+		}
 
-				// 2. Add $schema to inputSchema (required by Windsurf)
-				if inputSchema, ok := tool["inputSchema"].(map[string]interface{}); ok {
-					if _, hasSchema := inputSchema["$schema"]; !hasSchema {
-						inputSchema["$schema"] = "http://json-schema.org/draft-07/schema#"
-					}
-				}
+		// 1. Move title from annotations to root
+		if annotations, ok := tool["annotations"].(map[string]interface{}); ok {
+			if title, ok := annotations["title"].(string); ok {
+				tool["title"] = title
+				delete(annotations, "title")
 			}
+		}
 
-			// Marshal back and write with newline
-			fixed, _ := json.Marshal(data)
-			fixed = append(fixed, '\n')
-			return w.Writer.Write(fixed)
+		// 2. Add $schema to inputSchema (required by Windsurf)
+		if inputSchema, ok := tool["inputSchema"].(map[string]interface{}); ok {
+			if _, hasSchema := inputSchema["$schema"]; !hasSchema {
+				inputSchema["$schema"] = "http://json-schema.org/draft-07/schema#"
+			}
 		}
 	}
 
-	// Write original data
-	return w.Writer.Write(p)
+	// Marshal back with newline
+	fixed, err := json.Marshal(data)
+	if err != nil {
+		return nil, false
+	}
+	return append(fixed, '\n'), true
 }
 
 // formatBytes formats bytes to human readable string.
@@ -2140,30 +2665,7 @@ func formatBytes(b int64) string {
 
 // tryLoadRPG attempts to load the RPG store. Returns nil values if RPG is disabled or unavailable.
 func (s *Server) tryLoadRPG(ctx context.Context) (rpg.RPGStore, *rpg.QueryEngine, error) {
-	if s.projectRoot == "" {
-		return nil, nil, nil
-	}
-	cfg, err := config.Load(s.projectRoot)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
-	}
-	if !cfg.RPG.Enabled {
-		return nil, nil, nil
-	}
-	rpgStore := rpg.NewGOBRPGStore(config.GetRPGIndexPath(s.projectRoot))
-	if err := rpgStore.Load(ctx); err != nil {
-		if errors.Is(err, rpg.ErrRPGIndexOutdated) {
-			return nil, nil, rpg.ErrRPGIndexOutdated
-		}
-		return nil, nil, fmt.Errorf("failed to load RPG store: %w", err)
-	}
-	graph := rpgStore.GetGraph()
-	if graph.Stats().TotalNodes == 0 {
-		rpgStore.Close()
-		return nil, nil, nil
-	}
-	qe := rpg.NewQueryEngine(graph)
-	return rpgStore, qe, nil
+	return s.openRPG(ctx, s.projectRoot)
 }
 
 // handleRPGSearch handles the grepai_rpg_search tool call.
@@ -2422,7 +2924,7 @@ func (s *Server) handleStats(ctx context.Context, request mcp.CallToolRequest) (
 	}
 
 	provider := ""
-	if cfg, cfgErr := config.Load(s.projectRoot); cfgErr == nil {
+	if cfg, cfgErr := s.loadConfig(s.projectRoot); cfgErr == nil {
 		provider = cfg.Embedder.Provider
 	}
 
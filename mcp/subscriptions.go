@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+)
+
+// errSubscriptionHandled ends resources/subscribe and resources/unsubscribe
+// requests once handleSubscriptionRequest recorded them: the MCP library has
+// no handler for these methods. fixSubscriptionResponse turns the error
+// response it produces into the empty result clients expect.
+var errSubscriptionHandled = errors.New("grepai: resource subscription recorded")
+
+// resourceSubscriptions tracks the resource URIs each client session
+// subscribed to.
+type resourceSubscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]bool // session ID -> subscribed URIs
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{sessions: make(map[string]map[string]bool)}
+}
+
+func (r *resourceSubscriptions) subscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	uris, ok := r.sessions[sessionID]
+	if !ok {
+		uris = make(map[string]bool)
+		r.sessions[sessionID] = uris
+	}
+	uris[uri] = true
+}
+
+func (r *resourceSubscriptions) unsubscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions[sessionID], uri)
+	if len(r.sessions[sessionID]) == 0 {
+		delete(r.sessions, sessionID)
+	}
+}
+
+// drop forgets the subscriptions of a session that ended.
+func (r *resourceSubscriptions) drop(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, sessionID)
+}
+
+// subscribers returns the IDs of the sessions subscribed to uri, sorted.
+func (r *resourceSubscriptions) subscribers(uri string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for sessionID, uris := range r.sessions {
+		if uris[uri] {
+			ids = append(ids, sessionID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// addSubscriptionHooks makes hooks record resource subscriptions, and forget
+// them when their session ends.
+func (s *Server) addSubscriptionHooks(hooks *server.Hooks) {
+	hooks.AddOnRequestInitialization(s.handleSubscriptionRequest)
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		s.subscriptions.drop(session.SessionID())
+	})
+}
+
+// handleSubscriptionRequest records the resources/subscribe and
+// resources/unsubscribe requests of the client session of ctx. Other
+// requests are left to the MCP server.
+func (s *Server) handleSubscriptionRequest(ctx context.Context, _ any, message any) error {
+	raw, ok := message.(json.RawMessage)
+	if !ok {
+		return nil
+	}
+	var request struct {
+		Method string `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil
+	}
+	if request.Method != methodResourcesSubscribe && request.Method != methodResourcesUnsubscribe {
+		return nil
+	}
+
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return errors.New("resource subscriptions require a client session")
+	}
+	if request.Params.URI == "" {
+		return errors.New("missing resource uri")
+	}
+	if request.Method == methodResourcesSubscribe {
+		s.subscriptions.subscribe(session.SessionID(), request.Params.URI)
+	} else {
+		s.subscriptions.unsubscribe(session.SessionID(), request.Params.URI)
+	}
+	return errSubscriptionHandled
+}
+
+// fixSubscriptionResponse replaces the error answering a recorded
+// subscription request with an empty result. It returns the fixed message
+// followed by a newline, and false for other messages.
+func fixSubscriptionResponse(msg []byte) ([]byte, bool) {
+	if !bytes.Contains(msg, []byte(errSubscriptionHandled.Error())) {
+		return nil, false
+	}
+	var response struct {
+		ID    mcp.RequestId `json:"id"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(msg, &response); err != nil || response.Error == nil || response.Error.Message != errSubscriptionHandled.Error() {
+		return nil, false
+	}
+	fixed, err := json.Marshal(mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      response.ID,
+		Result:  mcp.EmptyResult{},
+	})
+	if err != nil {
+		return nil, false
+	}
+	return append(fixed, '\n'), true
+}
+
+// fixResponse applies fixSubscriptionResponse and fixToolTitles to a message
+// written to a client.
+func fixResponse(msg []byte) ([]byte, bool) {
+	if fixed, ok := fixSubscriptionResponse(msg); ok {
+		return fixed, true
+	}
+	return fixToolTitles(msg)
+}
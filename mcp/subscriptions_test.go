@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResourceSubscriptions(t *testing.T) {
+	subs := newResourceSubscriptions()
+	subs.subscribe("b", "grepai://file/main.go")
+	subs.subscribe("a", "grepai://file/main.go")
+	subs.subscribe("a", "grepai://file/util.go")
+
+	if got := subs.subscribers("grepai://file/main.go"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("subscribers(main.go) = %v", got)
+	}
+	subs.unsubscribe("b", "grepai://file/main.go")
+	if got := subs.subscribers("grepai://file/main.go"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("expected b to be unsubscribed, got %v", got)
+	}
+	subs.drop("a")
+	if got := subs.subscribers("grepai://file/util.go"); got != nil {
+		t.Errorf("expected the subscriptions of an ended session to be dropped, got %v", got)
+	}
+}
+
+func TestHTTPHandler_ResourceSubscriptions(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := startHTTPServer(t, srv, HTTPOptions{})
+	url := ts.URL + HTTPEndpoint
+
+	resp := postMCP(t, url, "", "", testInitialize)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"subscribe":true`) {
+		t.Fatalf("expected resource subscriptions to be advertised, got %s", body)
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	uri := "grepai://file/main.go"
+	for _, tc := range []struct {
+		method string
+		want   []string
+	}{
+		{methodResourcesSubscribe, []string{sessionID}},
+		{methodResourcesUnsubscribe, nil},
+	} {
+		resp := postMCP(t, url, "", sessionID, `{"jsonrpc":"2.0","id":7,"method":"`+tc.method+`","params":{"uri":"`+uri+`"}}`)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s failed with status %d: %s", tc.method, resp.StatusCode, body)
+		}
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid %s response %q: %v", tc.method, body, err)
+		}
+		if _, isError := msg["error"]; isError || string(msg["result"]) != "{}" || string(msg["id"]) != "7" {
+			t.Fatalf("expected an empty result for %s, got %s", tc.method, body)
+		}
+		if got := srv.subscriptions.subscribers(uri); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("after %s, subscribers = %v, want %v", tc.method, got, tc.want)
+		}
+	}
+
+	// A subscription without a URI is an error
+	resp = postMCP(t, url, "", sessionID, `{"jsonrpc":"2.0","id":8,"method":"resources/subscribe","params":{}}`)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "missing resource uri") {
+		t.Errorf("expected an error for a subscription without a URI, got %s", body)
+	}
+}
@@ -0,0 +1,414 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// maxWarmEntries bounds how many configurations, embedders and indexes are
+// kept loaded. Each project uses up to four entries, so in workspace mode
+// the indexes of the least recently queried projects are dropped first.
+const maxWarmEntries = 32
+
+// warmIndexes keeps the configurations, embedders and indexes used by tool
+// calls loaded for the lifetime of the server, so that calls don't reload
+// index.gob, symbols.gob or rpg.gob every time. Entries are reloaded when the
+// files they were built from change on disk, and embedders with the same
+// settings are shared between projects.
+type warmIndexes struct {
+	mu         sync.Mutex
+	entries    map[string]*warmEntry
+	loading    map[string]*warmLoad // by key and fingerprint
+	maxEntries int
+	clock      uint64
+}
+
+// warmLoad is a load in progress, shared by the calls waiting for the same
+// key and fingerprint.
+type warmLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// warmEntry is a loaded value and the version of the files it was built from.
+// An entry replaced or evicted while in use is closed by its last release.
+type warmEntry struct {
+	key         string
+	fingerprint string
+	value       any
+	close       func()
+	refs        int
+	lastUsed    uint64
+	stale       bool
+}
+
+func newWarmIndexes() *warmIndexes {
+	return &warmIndexes{
+		entries:    make(map[string]*warmEntry),
+		loading:    make(map[string]*warmLoad),
+		maxEntries: maxWarmEntries,
+	}
+}
+
+// acquire returns the value cached under key, calling load when it is
+// missing or was built from another fingerprint, and a function to call once
+// the value is no longer used. A nil cache loads a fresh value every time.
+//
+// Loads run outside the lock, once for all the calls waiting for the same
+// key and fingerprint, and with a context that is not cancelled with ctx:
+// a caller giving up does not fail the others, and the value it asked for is
+// still cached.
+func (w *warmIndexes) acquire(ctx context.Context, key, fingerprint string, load func(ctx context.Context) (any, func(), error)) (any, func(), error) {
+	if w == nil {
+		value, closeValue, err := load(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if closeValue == nil {
+			closeValue = func() {}
+		}
+		return value, closeValue, nil
+	}
+
+	for {
+		w.mu.Lock()
+		if entry := w.entries[key]; entry != nil && entry.fingerprint == fingerprint {
+			release := w.use(entry)
+			w.mu.Unlock()
+			return entry.value, release, nil
+		}
+		loadKey := key + "\x00" + fingerprint
+		call := w.loading[loadKey]
+		if call == nil {
+			call = &warmLoad{done: make(chan struct{})}
+			w.loading[loadKey] = call
+			go w.load(context.WithoutCancel(ctx), loadKey, key, fingerprint, call, load)
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, nil, call.err
+		}
+		// The entry is cached now, unless it was replaced in the meantime
+	}
+}
+
+// load runs a load for the calls waiting on call and caches its value.
+func (w *warmIndexes) load(ctx context.Context, loadKey, key, fingerprint string, call *warmLoad, load func(ctx context.Context) (any, func(), error)) {
+	value, closeValue, err := load(ctx)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.loading, loadKey)
+	call.err = err
+	if err == nil {
+		if entry := w.entries[key]; entry != nil {
+			w.drop(entry)
+		}
+		w.clock++
+		w.entries[key] = &warmEntry{key: key, fingerprint: fingerprint, value: value, close: closeValue, lastUsed: w.clock}
+		w.evict()
+	}
+	close(call.done)
+}
+
+// use marks entry as used by one more caller and returns the function
+// releasing it. w.mu must be held.
+func (w *warmIndexes) use(entry *warmEntry) func() {
+	w.clock++
+	entry.lastUsed = w.clock
+	entry.refs++
+	w.evict()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			entry.refs--
+			if entry.stale && entry.refs == 0 {
+				entry.closeValue()
+			}
+		})
+	}
+}
+
+// evict drops the least recently used entries that are not in use until at
+// most maxEntries remain.
+func (w *warmIndexes) evict() {
+	for len(w.entries) > w.maxEntries {
+		var oldest *warmEntry
+		for _, entry := range w.entries {
+			if entry.refs == 0 && (oldest == nil || entry.lastUsed < oldest.lastUsed) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		w.drop(oldest)
+	}
+}
+
+// drop removes entry from the cache, closing it unless it is still in use.
+func (w *warmIndexes) drop(entry *warmEntry) {
+	delete(w.entries, entry.key)
+	entry.stale = true
+	if entry.refs == 0 {
+		entry.closeValue()
+	}
+}
+
+func (e *warmEntry) closeValue() {
+	if e.close != nil {
+		e.close()
+	}
+}
+
+// loadConfig returns the configuration of the project at projectRoot. The
+// returned configuration is shared and must not be modified.
+func (s *Server) loadConfig(projectRoot string) (*config.Config, error) {
+	value, release, err := s.warm.acquire(context.Background(), "config:"+projectRoot, fileFingerprint(config.GetConfigPath(projectRoot)), func(context.Context) (any, func(), error) {
+		cfg, err := config.Load(projectRoot)
+		return cfg, nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return value.(*config.Config), nil
+}
+
+// openEmbedder returns the embedder configured by cfg, shared with every
+// project using the same settings. Close releases it.
+func (s *Server) openEmbedder(cfg config.EmbedderConfig) (embedder.Embedder, error) {
+	key, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	value, release, err := s.warm.acquire(context.Background(), "embedder:"+string(key), "", func(context.Context) (any, func(), error) {
+		emb, err := s.createEmbedder(&config.Config{Embedder: cfg})
+		if err != nil {
+			return nil, nil, err
+		}
+		return emb, func() { _ = emb.Close() }, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sharedEmbedder{value.(embedder.Embedder), release}, nil
+}
+
+// openStore returns the vector store of the working tree, reloading it when
+// the configuration or the index changed. Close releases it.
+func (s *Server) openStore(ctx context.Context, cfg *config.Config) (store.VectorStore, error) {
+	fingerprint := fileFingerprint(config.GetConfigPath(s.projectRoot), config.GetIndexPath(s.projectRoot))
+	value, release, err := s.warm.acquire(ctx, "store:"+s.projectRoot, fingerprint, func(ctx context.Context) (any, func(), error) {
+		st, err := s.createStore(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return st, func() { closeReadOnlyStore(st) }, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sharedVectorStore{value.(store.VectorStore), release}, nil
+}
+
+// openWorkspaceStore returns the vector store of workspace ws, reconnecting
+// when the workspace configuration changed. Close releases it.
+func (s *Server) openWorkspaceStore(ctx context.Context, ws *config.Workspace) (store.VectorStore, error) {
+	var fingerprint string
+	if path, err := config.GetWorkspaceConfigPath(); err == nil {
+		fingerprint = fileFingerprint(path)
+	}
+	value, release, err := s.warm.acquire(ctx, "workspace-store:"+ws.Name, fingerprint, func(ctx context.Context) (any, func(), error) {
+		st, err := s.createWorkspaceStore(ctx, ws)
+		if err != nil {
+			return nil, nil, err
+		}
+		return st, func() { _ = st.Close() }, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sharedVectorStore{value.(store.VectorStore), release}, nil
+}
+
+// warmSymbolStore returns the symbol index at indexPath, reloading it when
+// the file changed. Close releases it.
+func (s *Server) warmSymbolStore(ctx context.Context, indexPath string) (trace.SymbolStore, error) {
+	value, release, err := s.warm.acquire(ctx, "symbols:"+indexPath, fileFingerprint(indexPath), func(ctx context.Context) (any, func(), error) {
+		symbolStore := trace.NewGOBSymbolStore(indexPath)
+		if err := symbolStore.Load(ctx); err != nil {
+			return nil, nil, err
+		}
+		// Dropped without Close, which would persist the index
+		return symbolStore, nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sharedSymbolStore{value.(trace.SymbolStore), release}, nil
+}
+
+// loadWorkspaceSymbolStores returns the symbol indexes of the projects of a
+// workspace, or of its project projectName when set.
+func (s *Server) loadWorkspaceSymbolStores(ctx context.Context, workspaceName, projectName string) ([]trace.SymbolStore, error) {
+	ws, err := s.loadWorkspace(workspaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []config.ProjectEntry
+	for _, p := range ws.Projects {
+		if projectName == "" || p.Name == projectName {
+			projects = append(projects, p)
+		}
+	}
+	if projectName != "" && len(projects) == 0 {
+		return nil, fmt.Errorf("project %q not found in workspace %q", projectName, workspaceName)
+	}
+
+	stores := make([]trace.SymbolStore, 0, len(projects))
+	for _, p := range projects {
+		ss, err := s.warmSymbolStore(ctx, config.GetSymbolIndexPath(p.Path))
+		if err != nil {
+			trace.CloseSymbolStores(stores)
+			return nil, fmt.Errorf("failed to load symbol index for project %s: %w", p.Name, err)
+		}
+		stores = append(stores, ss)
+	}
+	return stores, nil
+}
+
+// loadWorkspace returns the configuration of workspace workspaceName.
+func (s *Server) loadWorkspace(workspaceName string) (*config.Workspace, error) {
+	wsCfg, err := config.LoadWorkspaceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace config: %w", err)
+	}
+	if wsCfg == nil {
+		return nil, fmt.Errorf("no workspaces configured; create one with: grepai workspace create <name>")
+	}
+	return wsCfg.GetWorkspace(workspaceName)
+}
+
+// warmRPG is a loaded RPG graph and its query engine.
+type warmRPG struct {
+	store rpg.RPGStore
+	qe    *rpg.QueryEngine
+}
+
+// openRPG returns the RPG graph of the project at projectRoot, reloading it
+// when the file changed. It returns nil values when RPG is disabled or the
+// graph is empty. Close releases the returned store.
+func (s *Server) openRPG(ctx context.Context, projectRoot string) (rpg.RPGStore, *rpg.QueryEngine, error) {
+	if projectRoot == "" {
+		return nil, nil, nil
+	}
+	cfg, err := s.loadConfig(projectRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if !cfg.RPG.Enabled {
+		return nil, nil, nil
+	}
+
+	indexPath := config.GetRPGIndexPath(projectRoot)
+	value, release, err := s.warm.acquire(ctx, "rpg:"+indexPath, fileFingerprint(indexPath), func(ctx context.Context) (any, func(), error) {
+		rpgStore := rpg.NewGOBRPGStore(indexPath)
+		if err := rpgStore.Load(ctx); err != nil {
+			if errors.Is(err, rpg.ErrRPGIndexOutdated) {
+				return nil, nil, rpg.ErrRPGIndexOutdated
+			}
+			return nil, nil, fmt.Errorf("failed to load RPG store: %w", err)
+		}
+		// Dropped without Close, which would persist the graph
+		return &warmRPG{store: rpgStore, qe: rpg.NewQueryEngine(rpgStore.GetGraph())}, nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	loaded := value.(*warmRPG)
+	if loaded.store.GetGraph().Stats().TotalNodes == 0 {
+		release()
+		return nil, nil, nil
+	}
+	return sharedRPGStore{loaded.store, release}, loaded.qe, nil
+}
+
+// sharedEmbedder is an embedder owned by warmIndexes.
+type sharedEmbedder struct {
+	embedder.Embedder
+	release func()
+}
+
+func (e sharedEmbedder) Close() error {
+	e.release()
+	return nil
+}
+
+// sharedVectorStore is a vector store owned by warmIndexes.
+type sharedVectorStore struct {
+	store.VectorStore
+	release func()
+}
+
+func (s sharedVectorStore) Close() error {
+	s.release()
+	return nil
+}
+
+// sharedSymbolStore is a symbol store owned by warmIndexes.
+type sharedSymbolStore struct {
+	trace.SymbolStore
+	release func()
+}
+
+func (s sharedSymbolStore) Close() error {
+	s.release()
+	return nil
+}
+
+// sharedRPGStore is an RPG store owned by warmIndexes.
+type sharedRPGStore struct {
+	rpg.RPGStore
+	release func()
+}
+
+func (s sharedRPGStore) Close() error {
+	s.release()
+	return nil
+}
+
+// fileFingerprint identifies the current version of paths by their size and
+// modification time.
+func fileFingerprint(paths ...string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "%d:%d;", info.Size(), info.ModTime().UnixNano())
+		} else {
+			b.WriteString("-;")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,264 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/config"
+	"github.com/yoanbernabeu/grepai/rpg"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+func TestWarmIndexes_ReloadsChangedSymbolIndex(t *testing.T) {
+	ctx := context.Background()
+	indexPath := filepath.Join(t.TempDir(), "symbols.gob")
+	writeIndex := func(names ...string) {
+		t.Helper()
+		st := trace.NewGOBSymbolStore(indexPath)
+		var symbols []trace.Symbol
+		for _, name := range names {
+			symbols = append(symbols, trace.Symbol{Name: name, Kind: trace.KindFunction, File: "a.go", Line: 1})
+		}
+		if err := st.SaveFile(ctx, "a.go", symbols, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Persist(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Server{warm: newWarmIndexes()}
+	lookup := func(name string) int {
+		t.Helper()
+		st, err := s.warmSymbolStore(ctx, indexPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+		symbols, err := st.LookupSymbol(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(symbols)
+	}
+
+	writeIndex("Alpha")
+	if n := lookup("Alpha"); n != 1 {
+		t.Fatalf("expected Alpha, got %d symbols", n)
+	}
+	first, _ := s.warmSymbolStore(ctx, indexPath)
+	again, _ := s.warmSymbolStore(ctx, indexPath)
+	if first.(sharedSymbolStore).SymbolStore != again.(sharedSymbolStore).SymbolStore {
+		t.Fatal("expected the unchanged index to be shared")
+	}
+	first.Close()
+	again.Close()
+
+	// Make sure the modification time changes on coarse filesystems
+	time.Sleep(10 * time.Millisecond)
+	writeIndex("Alpha", "Beta")
+	if err := os.Chtimes(indexPath, time.Now(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n := lookup("Beta"); n != 1 {
+		t.Fatalf("expected the changed index to be reloaded, got %d symbols", n)
+	}
+}
+
+func TestWarmIndexes_EvictsLeastRecentlyUsed(t *testing.T) {
+	w := newWarmIndexes()
+	w.maxEntries = 2
+	closed := make(map[string]bool)
+	acquire := func(key string) func() {
+		t.Helper()
+		_, release, err := w.acquire(context.Background(), key, "", func(context.Context) (any, func(), error) {
+			return key, func() { closed[key] = true }, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return release
+	}
+
+	acquire("a")()
+	releaseB := acquire("b")
+	acquire("a")()
+	acquire("c")()
+	// b is the least recently used but still in use, so a is evicted
+	if _, ok := w.entries["b"]; !ok {
+		t.Fatal("expected the entry in use to be kept")
+	}
+	if !closed["a"] || len(w.entries) != 2 {
+		t.Fatalf("expected a to be evicted, closed=%v entries=%d", closed, len(w.entries))
+	}
+
+	releaseB()
+	acquire("d")()
+	if !closed["b"] || closed["c"] {
+		t.Fatalf("expected b to be evicted before c, closed=%v", closed)
+	}
+}
+
+func TestWarmIndexes_ClosesReplacedEntryAfterRelease(t *testing.T) {
+	w := newWarmIndexes()
+	closed := 0
+	load := func(context.Context) (any, func(), error) {
+		return "value", func() { closed++ }, nil
+	}
+
+	_, release, err := w.acquire(context.Background(), "key", "v1", load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, releaseNew, err := w.acquire(context.Background(), "key", "v2", load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed != 0 {
+		t.Fatal("expected the replaced entry to stay open while in use")
+	}
+	release()
+	release()
+	if closed != 1 {
+		t.Fatalf("expected the replaced entry to be closed once, got %d", closed)
+	}
+	releaseNew()
+	if closed != 1 {
+		t.Fatal("expected the current entry to stay open")
+	}
+}
+
+func TestOpenEmbedder_SharesInstances(t *testing.T) {
+	s := &Server{warm: newWarmIndexes()}
+	cfg := config.DefaultConfig().Embedder
+	first, err := s.openEmbedder(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := s.openEmbedder(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if first.(sharedEmbedder).Embedder != second.(sharedEmbedder).Embedder {
+		t.Fatal("expected embedders with the same settings to be shared")
+	}
+
+	cfg.Model = "other-model"
+	other, err := s.openEmbedder(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if other.(sharedEmbedder).Embedder == first.(sharedEmbedder).Embedder {
+		t.Fatal("expected embedders with different settings to be distinct")
+	}
+}
+
+func TestOpenRPG_ReloadsChangedGraph(t *testing.T) {
+	ctx := context.Background()
+	projectRoot := seedPromptProject(t)
+	s, err := NewServer(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := func() int {
+		t.Helper()
+		rpgSt, _, err := s.openRPG(ctx, projectRoot)
+		if err != nil || rpgSt == nil {
+			t.Fatalf("expected the RPG graph, got %v", err)
+		}
+		defer rpgSt.Close()
+		return rpgSt.GetGraph().Stats().TotalNodes
+	}
+
+	before := nodes()
+	info, err := os.Stat(config.GetRPGIndexPath(projectRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes() != before {
+		t.Fatal("expected the cached graph to be reused")
+	}
+	if after, _ := os.Stat(config.GetRPGIndexPath(projectRoot)); !after.ModTime().Equal(info.ModTime()) {
+		t.Fatal("releasing the cached graph rewrote it")
+	}
+
+	rpgStore := rpg.NewGOBRPGStore(config.GetRPGIndexPath(projectRoot))
+	if err := rpgStore.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rpgStore.GetGraph().AddNode(&rpg.Node{ID: "area:billing", Kind: rpg.KindArea, Feature: "billing"})
+	if err := rpgStore.Persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(config.GetRPGIndexPath(projectRoot), time.Now(), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := nodes(); got != before+1 {
+		t.Fatalf("expected the changed graph to be reloaded, got %d nodes, want %d", got, before+1)
+	}
+}
+
+func TestWarmIndexes_SharesLoadsAndOutlivesCancelledCallers(t *testing.T) {
+	w := newWarmIndexes()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	var loads atomic.Int32
+	load := func(ctx context.Context) (any, func(), error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-unblock
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		return "value", nil, nil
+	}
+
+	// The first caller gives up while the index loads
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := w.acquire(ctx, "key", "v1", load)
+		errs <- err
+	}()
+	<-started
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled caller to return, got %v", err)
+	}
+
+	// Other keys are not blocked by the load
+	if _, release, err := w.acquire(context.Background(), "other", "v1", func(context.Context) (any, func(), error) {
+		return "other", nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	} else {
+		release()
+	}
+
+	// A caller of the same key waits for the load in progress
+	values := make(chan any, 1)
+	go func() {
+		value, release, err := w.acquire(context.Background(), "key", "v1", load)
+		if err != nil {
+			t.Error(err)
+		} else {
+			release()
+		}
+		values <- value
+	}()
+	close(unblock)
+	if value := <-values; value != "value" {
+		t.Fatalf("expected the loaded value, got %v", value)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("expected one load for both callers, got %d", n)
+	}
+}
@@ -0,0 +1,357 @@
+// Package metrics implements the counters, gauges and histograms exported by
+// the watch daemon in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 60s.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Default is the registry served by the watch daemon. Packages register
+// their metrics on it when they are initialized.
+var Default = NewRegistry()
+
+// Registry holds metric families and writes them in the text format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.families[name] = f
+}
+
+// WriteText writes all metrics, sorted by name, in the Prometheus text
+// exposition format (version 0.0.4).
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]family, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.ReplaceAll(d.help, "\n", " "))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// CounterVec is a family of counters partitioned by labels.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*sample
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter family.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}, values: make(map[string]*sample)}
+	r.register(name, c)
+	return c
+}
+
+// Add adds v, which must not be negative, to the counter for labelValues.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// Inc increments the counter for labelValues.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value returns the counter for labelValues.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.values[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range sortedSamples(c.values) {
+		writeSample(w, c.name, c.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// GaugeVec is a family of gauges partitioned by labels.
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*sample
+}
+
+// NewGaugeVec registers a gauge family.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, values: make(map[string]*sample)}
+	r.register(name, g)
+	return g
+}
+
+// Set sets the gauge for labelValues.
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = s
+	}
+	s.value = v
+}
+
+// Delete removes the gauge for labelValues, e.g. when a project is no
+// longer watched.
+func (g *GaugeVec) Delete(labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.values, key)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, s := range sortedSamples(g.values) {
+		writeSample(w, g.name, g.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// Sample is a value reported by a GaugeFunc.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type gaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc registers a gauge family whose values are computed by collect
+// when the registry is written.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &gaugeFunc{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	samples := g.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	for _, s := range samples {
+		if len(s.LabelValues) != len(g.labels) {
+			continue
+		}
+		writeSample(w, g.name, g.labels, s.LabelValues, "", "", s.Value)
+	}
+}
+
+// HistogramVec is a family of histograms partitioned by labels.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64 // Per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec registers a histogram family with the given upper bounds,
+// in increasing order.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: append([]float64(nil), buckets...),
+		values:  make(map[string]*histogram),
+	}
+	r.register(name, h)
+	return h
+}
+
+// Observe records v in the histogram for labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.count++
+	hist.sum += v
+}
+
+// Count returns the number of observations for labelValues.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hist, ok := h.values[key]; ok {
+		return hist.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hist := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, hist.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, hist.labelValues, "le", "+Inf", float64(hist.count))
+		writeSample(w, h.name+"_sum", h.labels, hist.labelValues, "", "", hist.sum)
+		writeSample(w, h.name+"_count", h.labels, hist.labelValues, "", "", float64(hist.count))
+	}
+}
+
+func sortedSamples(values map[string]*sample) []*sample {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	samples := make([]*sample, len(keys))
+	for i, key := range keys {
+		samples[i] = values[key]
+	}
+	return samples
+}
+
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, label, labelValues[i])
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabel(w *bufio.Writer, name, value string) {
+	w.WriteString(name)
+	w.WriteString(`="`)
+	labelValueEscaper.WriteString(w, value)
+	w.WriteByte('"')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
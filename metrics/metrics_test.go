@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.", "op")
+	depth := r.NewGaugeVec("test_queue_depth", "Queue depth.")
+	latency := r.NewHistogramVec("test_duration_seconds", "Latency.", []float64{0.1, 1}, "op")
+	r.NewGaugeFunc("test_size", "Size.", []string{"path"}, func() []Sample {
+		return []Sample{{LabelValues: []string{`a"b`}, Value: 3}, {Value: 4}}
+	})
+
+	requests.Add(2, "search")
+	requests.Inc("search")
+	requests.Add(-1, "search")
+	depth.Set(7)
+	latency.Observe(0.05, "search")
+	latency.Observe(0.5, "search")
+	latency.Observe(5, "search")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_duration_seconds Latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{op="search",le="0.1"} 1
+test_duration_seconds_bucket{op="search",le="1"} 2
+test_duration_seconds_bucket{op="search",le="+Inf"} 3
+test_duration_seconds_sum{op="search"} 5.55
+test_duration_seconds_count{op="search"} 3
+# HELP test_queue_depth Queue depth.
+# TYPE test_queue_depth gauge
+test_queue_depth 7
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{op="search"} 3
+# HELP test_size Size.
+# TYPE test_size gauge
+test_size{path="a\"b"} 3
+`
+	if got := b.String(); got != want {
+		t.Fatalf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+	if got := requests.Value("search"); got != 3 {
+		t.Fatalf("Value() = %v, want 3", got)
+	}
+	if got := latency.Count("search"); got != 3 {
+		t.Fatalf("Count() = %d, want 3", got)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a duplicate metric")
+		}
+	}()
+	r.NewGaugeVec("test_total", "Test.")
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// ContentType is the content type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ValidateAddr checks that addr is a host:port on a loopback interface:
+// metrics expose project paths and must not be reachable from the network.
+func ValidateAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid metrics address %q: %w", addr, err)
+	}
+	if port == "" {
+		return fmt.Errorf("invalid metrics address %q: missing port", addr)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("metrics address %q must be on localhost (e.g. 127.0.0.1:9464)", addr)
+	}
+	return nil
+}
+
+// Handler serves the metrics of r.
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// Server serves a registry on /metrics over HTTP.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+	done     chan struct{}
+}
+
+// Serve listens on addr, which must be a loopback address, and serves the
+// metrics of r until Close is called.
+func Serve(addr string, r *Registry) (*Server, error) {
+	if err := ValidateAddr(addr); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(r))
+	s := &Server{
+		listener: listener,
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_ = listener.Close()
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server, waiting briefly for in-flight scrapes.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	<-s.done
+	return err
+}
+
+func init() {
+	Default.NewGaugeFunc("go_goroutines", "Number of goroutines.", nil, func() []Sample {
+		return []Sample{{Value: float64(runtime.NumGoroutine())}}
+	})
+	Default.NewGaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", nil, func() []Sample {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return []Sample{{Value: float64(stats.HeapAlloc)}}
+	})
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateAddr(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:9464", "localhost:9464", "[::1]:9464"} {
+		if err := ValidateAddr(addr); err != nil {
+			t.Errorf("ValidateAddr(%q) = %v, want nil", addr, err)
+		}
+	}
+	for _, addr := range []string{":9464", "0.0.0.0:9464", "192.168.1.2:9464", "127.0.0.1", "localhost:"} {
+		if err := ValidateAddr(addr); err == nil {
+			t.Errorf("ValidateAddr(%q) = nil, want an error", addr)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_scrapes_total", "Scrapes.").Inc()
+
+	s, err := Serve("127.0.0.1:0", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resp, err := http.Get("http://" + s.Addr() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != ContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !strings.Contains(string(body), "test_scrapes_total 1\n") {
+		t.Fatalf("unexpected body:\n%s", body)
+	}
+
+	post, err := http.Post("http://"+s.Addr()+"/metrics", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Body.Close()
+	if post.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status = %d, want 405", post.StatusCode)
+	}
+}
@@ -3,7 +3,7 @@ package rpg
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"path/filepath"
 	"strings"
@@ -225,7 +225,7 @@ func (idx *RPGEncoder) BuildFull(ctx context.Context, symbolStore trace.SymbolSt
 	// Step 5c: Generate semantic summaries for hierarchy nodes.
 	summarizer := NewSummarizer(graph, idx.extractor)
 	if err := summarizer.SummarizeHierarchy(ctx, false); err != nil {
-		log.Printf("Warning: RPG hierarchy summarization failed: %v\n", err)
+		slog.Warn("RPG hierarchy summarization failed", "err", err)
 	}
 
 	// Step 5d: Wire semantic edges (new in Phase 2)
@@ -259,6 +259,16 @@ func (idx *RPGEncoder) HandleFileEvent(ctx context.Context, eventType string, fi
 	return nil
 }
 
+// HandleFileMove moves the nodes of a renamed file to its new path without
+// re-extracting features. The caller is responsible for persisting the store.
+func (idx *RPGEncoder) HandleFileMove(ctx context.Context, oldPath, newPath string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.store.GetGraph().MoveFile(oldPath, newPath)
+	return nil
+}
+
 // RefreshDerivedEdgesFull rebuilds all derived edges from current graph nodes.
 func (idx *RPGEncoder) RefreshDerivedEdgesFull(ctx context.Context, symbolStore trace.SymbolStore) error {
 	idx.mu.Lock()
@@ -294,7 +304,7 @@ func (idx *RPGEncoder) refreshDerivedEdgesFullLocked(ctx context.Context, symbol
 	// Generate semantic summaries (Phase 3)
 	summarizer := NewSummarizer(graph, idx.extractor)
 	if err := summarizer.SummarizeHierarchy(ctx, false); err != nil {
-		log.Printf("Warning: RPG hierarchy summarization failed: %v\n", err)
+		slog.Warn("RPG hierarchy summarization failed", "err", err)
 	}
 	return nil
 }
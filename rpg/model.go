@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/yoanbernabeu/grepai/store"
 )
 
 // NodeKind represents the type of node in the RPG graph.
@@ -237,6 +239,57 @@ func (g *Graph) RemoveNode(id string) {
 	delete(g.Nodes, id)
 }
 
+// MoveFile re-keys the file, symbol and chunk nodes of oldPath to newPath,
+// keeping their features and edges. Nodes already at newPath are replaced.
+func (g *Graph) MoveFile(oldPath, newPath string) {
+	if len(g.byFile[oldPath]) == 0 {
+		return
+	}
+
+	replaced := make(map[string]bool)
+	for _, n := range g.byFile[newPath] {
+		replaced[n.ID] = true
+		delete(g.Nodes, n.ID)
+	}
+
+	renamed := make(map[string]string)
+	for _, n := range g.byFile[oldPath] {
+		oldID := n.ID
+		switch n.Kind {
+		case KindFile:
+			n.ID = MakeNodeID(KindFile, newPath)
+		case KindSymbol:
+			if rest, ok := strings.CutPrefix(n.ID, "sym:"+oldPath+":"); ok {
+				n.ID = "sym:" + newPath + ":" + rest
+			}
+		case KindChunk:
+			n.ChunkID = store.MovedChunkID(n.ChunkID, oldPath, newPath)
+			n.ID = MakeNodeID(KindChunk, n.ChunkID)
+		}
+		n.Path = newPath
+		delete(g.Nodes, oldID)
+		g.Nodes[n.ID] = n
+		renamed[oldID] = n.ID
+	}
+
+	edges := make([]*Edge, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		if replaced[e.From] || replaced[e.To] {
+			continue
+		}
+		if id, ok := renamed[e.From]; ok {
+			e.From = id
+		}
+		if id, ok := renamed[e.To]; ok {
+			e.To = id
+		}
+		edges = append(edges, e)
+	}
+	g.Edges = edges
+
+	g.RebuildIndexes()
+}
+
 // AddEdge adds an edge and updates adjacency indexes.
 func (g *Graph) AddEdge(e *Edge) {
 	g.Edges = append(g.Edges, e)
@@ -172,6 +172,50 @@ func TestRemoveNode(t *testing.T) {
 	}
 }
 
+func TestGraphMoveFile(t *testing.T) {
+	g := NewGraph()
+	now := time.Now()
+
+	fileID := MakeNodeID(KindFile, "old/a.go")
+	symID := MakeNodeID(KindSymbol, "old/a.go", "Run")
+	chunkID := MakeNodeID(KindChunk, "old/a.go_0")
+	g.AddNode(&Node{ID: "subcat:core/run", Kind: KindSubcategory, Feature: "core/run", UpdatedAt: now})
+	g.AddNode(&Node{ID: fileID, Kind: KindFile, Path: "old/a.go", Feature: "run-things", UpdatedAt: now})
+	g.AddNode(&Node{ID: symID, Kind: KindSymbol, Path: "old/a.go", SymbolName: "Run", UpdatedAt: now})
+	g.AddNode(&Node{ID: chunkID, Kind: KindChunk, Path: "old/a.go", ChunkID: "old/a.go_0", UpdatedAt: now})
+	g.AddNode(&Node{ID: MakeNodeID(KindFile, "new/a.go"), Kind: KindFile, Path: "new/a.go", UpdatedAt: now})
+	g.AddEdge(&Edge{From: "subcat:core/run", To: fileID, Type: EdgeFeatureParent, UpdatedAt: now})
+	g.AddEdge(&Edge{From: fileID, To: symID, Type: EdgeContains, UpdatedAt: now})
+	g.AddEdge(&Edge{From: symID, To: chunkID, Type: EdgeMapsToChunk, UpdatedAt: now})
+
+	g.MoveFile("old/a.go", "new/a.go")
+
+	if len(g.GetNodesByFile("old/a.go")) != 0 {
+		t.Error("expected no nodes left at the old path")
+	}
+	if got := len(g.GetNodesByFile("new/a.go")); got != 3 {
+		t.Fatalf("expected 3 nodes at the new path, got %d", got)
+	}
+	newFile := g.GetNode(MakeNodeID(KindFile, "new/a.go"))
+	if newFile == nil || newFile.Feature != "run-things" {
+		t.Errorf("expected the moved file node to replace the old one, got %+v", newFile)
+	}
+	newSym := MakeNodeID(KindSymbol, "new/a.go", "Run")
+	newChunk := g.GetNode(MakeNodeID(KindChunk, "new/a.go_0"))
+	if g.GetNode(newSym) == nil || newChunk == nil || newChunk.ChunkID != "new/a.go_0" {
+		t.Fatalf("expected symbol and chunk nodes to be re-keyed")
+	}
+	if len(g.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %d", len(g.Edges))
+	}
+	if out := g.GetOutgoing(newSym); len(out) != 1 || out[0].To != newChunk.ID {
+		t.Errorf("expected symbol edge to follow the move, got %+v", out)
+	}
+	if in := g.GetIncoming(newFile.ID); len(in) != 1 || in[0].From != "subcat:core/run" {
+		t.Errorf("expected hierarchy edge to follow the move, got %+v", in)
+	}
+}
+
 func TestAddEdge(t *testing.T) {
 	g := NewGraph()
 	now := time.Now()
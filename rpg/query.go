@@ -391,6 +391,32 @@ func (qe *QueryEngine) Explore(_ context.Context, req ExploreRequest) (*ExploreR
 	return result, nil
 }
 
+// FeaturePathAt returns the feature path of the symbol of filePath that
+// overlaps lines start-end the most, or of the file when no symbol does.
+func (qe *QueryEngine) FeaturePathAt(filePath string, start, end int) string {
+	if end < start {
+		end = start
+	}
+	var best *Node
+	bestOverlap := 0
+	for _, n := range qe.graph.GetNodesByFile(filePath) {
+		if n.Kind != KindSymbol {
+			continue
+		}
+		nodeEnd := max(n.EndLine, n.StartLine)
+		overlap := min(end, nodeEnd) - max(start, n.StartLine) + 1
+		if overlap > bestOverlap {
+			best, bestOverlap = n, overlap
+		}
+	}
+	if best != nil {
+		if path := qe.getFeaturePath(best.ID); path != "" {
+			return path
+		}
+	}
+	return qe.getFeaturePath(MakeNodeID(KindFile, filePath))
+}
+
 // getFeaturePath returns the full feature path for a node.
 // Hierarchy nodes (area/category/subcategory) already store their full path in Feature.
 // File nodes use incoming feature-parent links, symbol nodes inherit file path.
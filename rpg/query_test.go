@@ -913,3 +913,26 @@ func TestExplore_UsesStartCodeEntitiesAndEntityFilter(t *testing.T) {
 		t.Fatalf("expected symbol node to be included with function filter, got %+v", result.Nodes)
 	}
 }
+
+func TestFeaturePathAt(t *testing.T) {
+	g := NewGraph()
+	g.AddNode(&Node{ID: "area:auth", Kind: KindArea, Feature: "auth"})
+	g.AddNode(&Node{ID: "cat:auth/session", Kind: KindCategory, Feature: "auth/session"})
+	g.AddNode(&Node{ID: MakeNodeID(KindFile, "login.go"), Kind: KindFile, Path: "login.go", Feature: "login"})
+	g.AddNode(&Node{ID: "sym:login.go:Login", Kind: KindSymbol, Path: "login.go", SymbolName: "Login", StartLine: 3, EndLine: 9, Feature: "log-in-user"})
+	g.AddEdge(&Edge{From: "area:auth", To: "cat:auth/session", Type: EdgeFeatureParent})
+	g.AddEdge(&Edge{From: "cat:auth/session", To: MakeNodeID(KindFile, "login.go"), Type: EdgeFeatureParent})
+	g.AddEdge(&Edge{From: MakeNodeID(KindFile, "login.go"), To: "sym:login.go:Login", Type: EdgeContains})
+	qe := NewQueryEngine(g)
+
+	symbolPath := qe.FeaturePathAt("login.go", 5, 6)
+	if symbolPath == "" || symbolPath != qe.getFeaturePath("sym:login.go:Login") {
+		t.Fatalf("expected the symbol's feature path, got %q", symbolPath)
+	}
+	if got := qe.FeaturePathAt("login.go", 20, 30); got != qe.getFeaturePath(MakeNodeID(KindFile, "login.go")) || got == "" {
+		t.Fatalf("expected the file's feature path, got %q", got)
+	}
+	if got := qe.FeaturePathAt("missing.go", 1, 2); got != "" {
+		t.Fatalf("expected no feature path for an unknown file, got %q", got)
+	}
+}
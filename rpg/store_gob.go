@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
@@ -39,14 +39,14 @@ func NewGOBRPGStore(indexPath string) *GOBRPGStore {
 func (s *GOBRPGStore) Load(ctx context.Context) error {
 	lockFile, err := os.OpenFile(s.lockPath, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
-		log.Printf("rpg: flock open failed, proceeding without lock: %v", err)
+		slog.Warn("RPG store lock unavailable, proceeding without lock", "op", "load", "err", err)
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		return s.loadUnlocked()
 	}
 	defer lockFile.Close()
 	if err := fileutil.FlockShared(lockFile, true); err != nil {
-		log.Printf("rpg: flock shared acquire failed, proceeding without lock: %v", err)
+		slog.Warn("RPG store lock unavailable, proceeding without lock", "op", "load", "err", err)
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		return s.loadUnlocked()
@@ -108,14 +108,14 @@ func (s *GOBRPGStore) Persist(ctx context.Context) error {
 
 	lockFile, err := os.OpenFile(s.lockPath, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
-		log.Printf("rpg: flock open failed for persist, proceeding without lock: %v", err)
+		slog.Warn("RPG store lock unavailable, proceeding without lock", "op", "persist", "err", err)
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		return s.persistUnlocked()
 	}
 	defer lockFile.Close()
 	if err := fileutil.FlockExclusive(lockFile, true); err != nil {
-		log.Printf("rpg: flock exclusive acquire failed, proceeding without lock: %v", err)
+		slog.Warn("RPG store lock unavailable, proceeding without lock", "op", "persist", "err", err)
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		return s.persistUnlocked()
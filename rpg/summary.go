@@ -3,7 +3,7 @@ package rpg
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
 )
@@ -60,10 +60,10 @@ func (s *Summarizer) summarizeNodes(ctx context.Context, kind NodeKind, force bo
 			// Log error but continue? Or fail?
 			// For now, let's log and continue, as LLM failures shouldn't block everything.
 			// In a real app we'd have better logging.
-			log.Printf("Failed to summarize node %s: %v\n", node.ID, err)
+			slog.Error("Failed to summarize RPG node", "node", node.ID, "err", err)
 			consecutiveFailures++
 			if consecutiveFailures >= maxConsecutiveFailures {
-				log.Printf("rpg: summarization circuit breaker tripped after %d consecutive failures, skipping remaining nodes", maxConsecutiveFailures)
+				slog.Warn("RPG summarization circuit breaker tripped, skipping remaining nodes", "consecutive_failures", maxConsecutiveFailures)
 				break
 			}
 			continue
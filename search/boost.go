@@ -53,3 +53,28 @@ func computeBoostFactor(filePath string, boostCfg config.BoostConfig) float32 {
 func matchesPattern(filePath, pattern string) bool {
 	return strings.Contains(filePath, pattern)
 }
+
+// ApplyFilePenalties multiplies the score of results from penalized files by
+// factor and re-sorts them. Paths are matched exactly against the index paths.
+func ApplyFilePenalties(results []store.SearchResult, penalized map[string]string, factor float32) []store.SearchResult {
+	if len(penalized) == 0 || len(results) == 0 || factor <= 0 || factor >= 1 {
+		return results
+	}
+
+	changed := false
+	for i := range results {
+		if _, ok := penalized[results[i].Chunk.FilePath]; ok {
+			results[i].Score *= factor
+			changed = true
+		}
+	}
+	if !changed {
+		return results
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results
+}
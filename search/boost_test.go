@@ -140,3 +140,47 @@ func TestComputeBoostFactor(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyFilePenalties(t *testing.T) {
+	results := []store.SearchResult{
+		{Chunk: store.Chunk{FilePath: "api/api.pb.go"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "api/handler.go"}, Score: 0.6},
+	}
+	penalized := map[string]string{"api/api.pb.go": "generated"}
+
+	got := ApplyFilePenalties(results, penalized, 0.5)
+
+	if got[0].Chunk.FilePath != "api/handler.go" {
+		t.Errorf("expected non-penalized file first, got %s", got[0].Chunk.FilePath)
+	}
+	if got[1].Score != 0.45 {
+		t.Errorf("expected penalized score 0.45, got %f", got[1].Score)
+	}
+
+	unchanged := ApplyFilePenalties([]store.SearchResult{{Chunk: store.Chunk{FilePath: "a.go"}, Score: 1}}, nil, 0.5)
+	if unchanged[0].Score != 1 {
+		t.Errorf("expected no penalty without penalized files, got %f", unchanged[0].Score)
+	}
+}
+
+func TestAddFilePenalties(t *testing.T) {
+	s := NewSearcher(nil, nil, config.SearchConfig{})
+	s.AddFilePenalties(map[string]string{"acme/backend/api/api.pb.go": "generated"}, 0.5)
+	s.AddFilePenalties(map[string]string{"acme/frontend/vendor/lib.js": "vendored"}, 0.1)
+
+	results := []store.SearchResult{
+		{Chunk: store.Chunk{FilePath: "acme/backend/api/api.pb.go"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "acme/frontend/vendor/lib.js"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "acme/frontend/api/api.pb.go"}, Score: 0.6},
+	}
+	for _, p := range s.penalties {
+		results = ApplyFilePenalties(results, p.files, p.factor)
+	}
+
+	want := []string{"acme/frontend/api/api.pb.go", "acme/backend/api/api.pb.go", "acme/frontend/vendor/lib.js"}
+	for i, path := range want {
+		if results[i].Chunk.FilePath != path {
+			t.Errorf("result %d: expected %s, got %s", i, path, results[i].Chunk.FilePath)
+		}
+	}
+}
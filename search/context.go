@@ -0,0 +1,226 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+const (
+	// DefaultContextTokens is the token budget of a context bundle when none
+	// is given.
+	DefaultContextTokens = 4000
+	// defaultContextHits is the number of search hits considered for a bundle.
+	defaultContextHits = 20
+	// defaultContextRelated bounds the callers and callees listed per symbol.
+	defaultContextRelated = 3
+)
+
+// ContextSearcher runs the semantic search a context bundle starts from.
+type ContextSearcher interface {
+	Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error)
+}
+
+// ContextRef is a caller or callee of a symbol in a context bundle.
+type ContextRef struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// ContextItem is a search hit with its enclosing symbol, the symbol's
+// callers and callees, and its RPG feature path.
+type ContextItem struct {
+	FilePath    string       `json:"file_path"`
+	StartLine   int          `json:"start_line"`
+	EndLine     int          `json:"end_line"`
+	Score       float32      `json:"score"`
+	Symbol      string       `json:"symbol,omitempty"`
+	Signature   string       `json:"signature,omitempty"`
+	FeaturePath string       `json:"feature_path,omitempty"`
+	Content     string       `json:"content,omitempty"` // Dropped when only the location fits the budget
+	Callers     []ContextRef `json:"callers,omitempty"`
+	Callees     []ContextRef `json:"callees,omitempty"`
+	Tokens      int          `json:"tokens"`
+}
+
+// ContextBundle is the outcome of BuildContext.
+type ContextBundle struct {
+	Query     string        `json:"query"`
+	MaxTokens int           `json:"max_tokens"`
+	Tokens    int           `json:"tokens"`
+	Items     []ContextItem `json:"items"`
+	Omitted   int           `json:"omitted,omitempty"` // Hits left out to fit the budget
+}
+
+// ContextOptions configures BuildContext.
+type ContextOptions struct {
+	// MaxTokens is the token budget of the bundle (default DefaultContextTokens).
+	MaxTokens int
+	// Limit is the number of search hits considered (default 20).
+	Limit      int
+	PathPrefix string
+	// MaxRelated bounds the callers and callees listed per symbol (default 3).
+	MaxRelated int
+	// Symbols, when set, is used to find enclosing symbols, callers and callees.
+	Symbols trace.SymbolStore
+	// FeaturePath, when set, returns the RPG feature path of a line range.
+	FeaturePath func(filePath string, startLine, endLine int) string
+}
+
+// BuildContext packs the search hits of query, their enclosing symbols, the
+// symbols' callers and callees and their feature paths into a bundle that
+// fits opts.MaxTokens. Hits are kept in score order; hits within a symbol
+// or lines already in the bundle are dropped, and hits that don't fit are
+// kept without content when their location still fits.
+func BuildContext(ctx context.Context, searcher ContextSearcher, query string, opts ContextOptions) (*ContextBundle, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultContextTokens
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultContextHits
+	}
+	if opts.MaxRelated <= 0 {
+		opts.MaxRelated = defaultContextRelated
+	}
+
+	results, err := searcher.Search(ctx, query, opts.Limit, opts.PathPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	bundle := &ContextBundle{Query: query, MaxTokens: opts.MaxTokens, Items: []ContextItem{}}
+	fileSymbols := make(map[string][]trace.Symbol)
+	seenSymbols := make(map[string]bool)
+	for _, r := range results {
+		item := ContextItem{
+			FilePath:  r.Chunk.FilePath,
+			StartLine: r.Chunk.StartLine,
+			EndLine:   r.Chunk.EndLine,
+			Score:     r.Score,
+			Content:   stripFileHeader(r.Chunk.Content),
+		}
+		if overlapsItems(bundle.Items, item) {
+			continue
+		}
+
+		symbolKey := ""
+		if opts.Symbols != nil {
+			symbols, ok := fileSymbols[item.FilePath]
+			if !ok {
+				symbols, _ = opts.Symbols.GetSymbolsForFile(ctx, item.FilePath)
+				fileSymbols[item.FilePath] = symbols
+			}
+			if sym, ok := enclosingSymbol(symbols, item.StartLine, item.EndLine); ok {
+				symbolKey = sym.File + ":" + sym.Name
+				if seenSymbols[symbolKey] {
+					continue
+				}
+				item.Symbol, item.Signature = sym.Name, sym.Signature
+				item.Callers, item.Callees = relatedSymbols(ctx, opts.Symbols, sym, opts.MaxRelated)
+			}
+		}
+		if opts.FeaturePath != nil {
+			item.FeaturePath = opts.FeaturePath(item.FilePath, item.StartLine, item.EndLine)
+		}
+
+		remaining := opts.MaxTokens - bundle.Tokens
+		if item.Tokens = itemTokens(item); item.Tokens > remaining {
+			item.Content = ""
+			if item.Tokens = itemTokens(item); item.Tokens > remaining {
+				bundle.Omitted++
+				continue
+			}
+		}
+		bundle.Items = append(bundle.Items, item)
+		bundle.Tokens += item.Tokens
+		// A symbol is only covered once one of its hits fits the budget
+		if symbolKey != "" {
+			seenSymbols[symbolKey] = true
+		}
+	}
+	return bundle, nil
+}
+
+// enclosingSymbol returns the symbol a hit belongs to: the last symbol
+// starting at or before the hit among those overlapping it, or the first
+// overlapping symbol.
+func enclosingSymbol(symbols []trace.Symbol, start, end int) (trace.Symbol, bool) {
+	overlapping := SymbolsInRange(symbols, start, end)
+	if len(overlapping) == 0 {
+		return trace.Symbol{}, false
+	}
+	best := overlapping[0]
+	for _, sym := range overlapping[1:] {
+		if sym.Line <= start {
+			best = sym
+		}
+	}
+	return best, true
+}
+
+// relatedSymbols returns up to limit distinct callers and callees of sym.
+func relatedSymbols(ctx context.Context, symbolStore trace.SymbolStore, sym trace.Symbol, limit int) (callers, callees []ContextRef) {
+	if refs, err := symbolStore.LookupCallers(ctx, sym.Name); err == nil {
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			if len(callers) == limit {
+				break
+			}
+			if key := ref.CallerName + ":" + ref.File; !seen[key] {
+				seen[key] = true
+				callers = append(callers, ContextRef{Name: ref.CallerName, File: ref.File, Line: ref.Line})
+			}
+		}
+	}
+	if refs, err := symbolStore.LookupCallees(ctx, sym.Name, sym.File); err == nil {
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			if len(callees) == limit {
+				break
+			}
+			if !seen[ref.SymbolName] {
+				seen[ref.SymbolName] = true
+				callees = append(callees, ContextRef{Name: ref.SymbolName, File: ref.File, Line: ref.Line})
+			}
+		}
+	}
+	return callers, callees
+}
+
+// overlapsItems reports whether item covers lines of a file already in items.
+func overlapsItems(items []ContextItem, item ContextItem) bool {
+	for _, other := range items {
+		if other.FilePath == item.FilePath && item.StartLine <= other.EndLine && other.StartLine <= item.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// itemTokens estimates the tokens item takes in the encoded bundle, with the
+// same estimate the token savings stats are recorded with.
+func itemTokens(item ContextItem) int {
+	item.Tokens = 0
+	data, err := json.Marshal(item)
+	if err != nil {
+		return embedder.EstimateTokens(item.Content)
+	}
+	return embedder.EstimateTokens(string(data))
+}
+
+// stripFileHeader removes the "File: <path>" header chunks are embedded with.
+func stripFileHeader(content string) string {
+	if !strings.HasPrefix(content, "File: ") {
+		return content
+	}
+	if _, rest, ok := strings.Cut(content, "\n\n"); ok {
+		return rest
+	}
+	return content
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/store"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+type fakeContextSearcher []store.SearchResult
+
+func (f fakeContextSearcher) Search(_ context.Context, _ string, limit int, _ string) ([]store.SearchResult, error) {
+	return f[:min(limit, len(f))], nil
+}
+
+func contextTestSymbols(t *testing.T) trace.SymbolStore {
+	t.Helper()
+	ctx := context.Background()
+	st := trace.NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	if err := st.SaveFile(ctx, "auth.go",
+		[]trace.Symbol{
+			{Name: "Login", Kind: trace.KindFunction, File: "auth.go", Line: 1, EndLine: 20, Signature: "func Login() error"},
+			{Name: "Logout", Kind: trace.KindFunction, File: "auth.go", Line: 30, EndLine: 40},
+		},
+		[]trace.Reference{
+			{SymbolName: "validate", File: "auth.go", Line: 5, CallerName: "Login", CallerFile: "auth.go", CallerLine: 1},
+			{SymbolName: "Login", File: "api.go", Line: 12, CallerName: "Handle", CallerFile: "api.go", CallerLine: 10},
+		},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestBuildContext_DeduplicatesAndEnriches(t *testing.T) {
+	searcher := fakeContextSearcher{
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 1, EndLine: 10, Content: "File: auth.go\n\nfunc Login() error {"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 11, EndLine: 20, Content: "\treturn validate()"}, Score: 0.8},
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 5, EndLine: 8, Content: "overlap"}, Score: 0.7},
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 30, EndLine: 40, Content: "func Logout() {}"}, Score: 0.6},
+	}
+	bundle, err := BuildContext(context.Background(), searcher, "login", ContextOptions{
+		Symbols:     contextTestSymbols(t),
+		FeaturePath: func(filePath string, _, _ int) string { return "auth/" + filePath },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundle.Items) != 2 {
+		t.Fatalf("expected one item per symbol, got %+v", bundle.Items)
+	}
+	login := bundle.Items[0]
+	if login.Symbol != "Login" || login.Signature != "func Login() error" || login.Content != "func Login() error {" || login.FeaturePath != "auth/auth.go" {
+		t.Fatalf("unexpected first item %+v", login)
+	}
+	if len(login.Callers) != 1 || login.Callers[0].Name != "Handle" || len(login.Callees) != 1 || login.Callees[0].Name != "validate" {
+		t.Fatalf("unexpected callers %+v and callees %+v", login.Callers, login.Callees)
+	}
+	if bundle.Items[1].Symbol != "Logout" {
+		t.Fatalf("expected Logout second, got %+v", bundle.Items[1])
+	}
+	if bundle.Tokens != login.Tokens+bundle.Items[1].Tokens || bundle.MaxTokens != DefaultContextTokens {
+		t.Fatalf("unexpected token accounting %+v", bundle)
+	}
+}
+
+func TestBuildContext_FitsBudget(t *testing.T) {
+	searcher := fakeContextSearcher{
+		{Chunk: store.Chunk{FilePath: "a.go", StartLine: 1, EndLine: 2, Content: "short"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "b.go", StartLine: 1, EndLine: 200, Content: strings.Repeat("long line\n", 200)}, Score: 0.8},
+		{Chunk: store.Chunk{FilePath: "c.go", StartLine: 1, EndLine: 200, Content: strings.Repeat("long line\n", 200)}, Score: 0.7},
+	}
+	bundle, err := BuildContext(context.Background(), searcher, "q", ContextOptions{MaxTokens: 70})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Tokens > 70 {
+		t.Fatalf("bundle exceeds its budget: %d tokens", bundle.Tokens)
+	}
+	if len(bundle.Items) < 2 || bundle.Items[0].Content != "short" || bundle.Items[1].Content != "" || bundle.Items[1].FilePath != "b.go" {
+		t.Fatalf("expected the long hit to be kept without content, got %+v", bundle.Items)
+	}
+	if bundle.Omitted+len(bundle.Items) != 3 {
+		t.Fatalf("expected every hit to be kept or counted as omitted, got %+v", bundle)
+	}
+}
+
+func TestBuildContext_KeepsSymbolOfHitOverBudget(t *testing.T) {
+	searcher := fakeContextSearcher{
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 1, EndLine: 10, Content: "func Login() error {"}, Score: 0.9},
+		{Chunk: store.Chunk{FilePath: "auth.go", StartLine: 11, EndLine: 20, Content: "\treturn validate()"}, Score: 0.8},
+	}
+	bundle, err := BuildContext(context.Background(), searcher, "login", ContextOptions{
+		Symbols:   contextTestSymbols(t),
+		MaxTokens: 80,
+		// The first hit's feature path alone exceeds the budget
+		FeaturePath: func(_ string, start, _ int) string {
+			if start == 1 {
+				return strings.Repeat("auth/", 100)
+			}
+			return "auth"
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Items) != 1 || bundle.Items[0].Symbol != "Login" || bundle.Items[0].StartLine != 11 {
+		t.Fatalf("expected the second hit to cover Login, got %+v", bundle.Items)
+	}
+	if bundle.Omitted != 1 {
+		t.Fatalf("expected the first hit to be omitted, got %d", bundle.Omitted)
+	}
+}
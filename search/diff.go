@@ -0,0 +1,308 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/yoanbernabeu/grepai/embedder"
+	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/indexer"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+const (
+	// diffChunkLines bounds the number of diff lines embedded per chunk.
+	diffChunkLines = 60
+	// diffEmbedBatch bounds the number of chunks sent per EmbedBatch call.
+	diffEmbedBatch = 64
+)
+
+// DiffChunk is a piece of a changed hunk scored against the query.
+type DiffChunk struct {
+	FilePath  string   `json:"file_path"`
+	Status    string   `json:"status"`
+	StartLine int      `json:"start_line"` // Head line range (base range for deleted files)
+	EndLine   int      `json:"end_line"`
+	Score     float32  `json:"score"`
+	Content   string   `json:"content,omitempty"` // Diff lines with +/- prefixes
+	Symbols   []string `json:"symbols,omitempty"` // Changed symbols overlapping the chunk
+}
+
+// DiffCaller is a call site of a changed symbol.
+type DiffCaller struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// ChangedSymbol is a symbol whose definition overlaps a matching chunk.
+type ChangedSymbol struct {
+	Name    string       `json:"name"`
+	File    string       `json:"file"`
+	Line    int          `json:"line"`
+	Score   float32      `json:"score"` // Best score of the chunks touching the symbol
+	Callers []DiffCaller `json:"callers,omitempty"`
+}
+
+// DiffSearchResult is the outcome of a DiffSearch.
+type DiffSearchResult struct {
+	Query          string          `json:"query"`
+	Base           string          `json:"base"`
+	Head           string          `json:"head"`
+	FilesChanged   int             `json:"files_changed"`
+	Chunks         []DiffChunk     `json:"chunks"`
+	ChangedSymbols []ChangedSymbol `json:"changed_symbols,omitempty"`
+}
+
+// DiffSearchOptions configures DiffSearch.
+type DiffSearchOptions struct {
+	Base  string
+	Head  string
+	Limit int
+	// Include reports whether changes to a file should be searched.
+	// A nil Include searches every text file.
+	Include func(filePath string) bool
+	// Symbols, when set, is used to find changed symbols and their callers.
+	Symbols trace.SymbolStore
+	// Secrets is the indexing.secrets policy applied to changed hunks before
+	// they are embedded and returned: redact (default), skip or index.
+	Secrets string
+}
+
+// DiffSearch scores the hunks changed between opts.Base and opts.Head against
+// query. Only the changed hunks are embedded, not whole files.
+func DiffSearch(ctx context.Context, emb embedder.Embedder, projectRoot, query string, opts DiffSearchOptions) (*DiffSearchResult, error) {
+	files, err := git.Diff(projectRoot, opts.Base, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiffSearchResult{Query: query, Base: opts.Base, Head: opts.Head, Chunks: []DiffChunk{}}
+	var chunks []DiffChunk
+	for _, file := range files {
+		if file.Binary || len(file.Hunks) == 0 {
+			continue
+		}
+		if opts.Include != nil && !opts.Include(file.Path) {
+			continue
+		}
+		fileChunks := ChunkDiff(file)
+		if !applyDiffSecretsPolicy(fileChunks, opts.Secrets) {
+			continue
+		}
+		result.FilesChanged++
+		chunks = append(chunks, fileChunks...)
+	}
+	if len(chunks) == 0 {
+		return result, nil
+	}
+
+	queryVector, err := emb.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	for start := 0; start < len(chunks); start += diffEmbedBatch {
+		end := min(start+diffEmbedBatch, len(chunks))
+		texts := make([]string, end-start)
+		for i, c := range chunks[start:end] {
+			texts[i] = fmt.Sprintf("File: %s (%s)\n\n%s", c.FilePath, c.Status, c.Content)
+		}
+		vectors, err := emb.EmbedBatch(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed diff hunks: %w", err)
+		}
+		for i, vec := range vectors {
+			chunks[start+i].Score = cosine(queryVector, vec)
+		}
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Score > chunks[j].Score
+	})
+	if opts.Limit > 0 && len(chunks) > opts.Limit {
+		chunks = chunks[:opts.Limit]
+	}
+	result.Chunks = chunks
+
+	if opts.Symbols != nil {
+		result.ChangedSymbols = changedSymbols(ctx, opts.Symbols, result.Chunks)
+	}
+	return result, nil
+}
+
+// ChunkDiff splits the hunks of a file into chunks of at most diffChunkLines
+// diff lines, tracking the head line range each chunk covers.
+func ChunkDiff(file git.FileDiff) []DiffChunk {
+	var chunks []DiffChunk
+	for _, hunk := range file.Hunks {
+		oldLine, newLine := hunk.OldStart, hunk.NewStart
+		for start := 0; start < len(hunk.Lines); start += diffChunkLines {
+			end := min(start+diffChunkLines, len(hunk.Lines))
+			lines := hunk.Lines[start:end]
+
+			first, last := 0, 0
+			for _, line := range lines {
+				// Removed lines are anchored to the next head line.
+				current := newLine
+				if file.Status == git.StatusDeleted {
+					current = oldLine
+				}
+				switch line[0] {
+				case '+':
+					newLine++
+				case '-':
+					oldLine++
+				default:
+					oldLine++
+					newLine++
+				}
+				if first == 0 {
+					first = current
+				}
+				last = current
+			}
+
+			content := strings.Join(lines, "\n")
+			if hunk.Section != "" && start == 0 {
+				content = "@@ " + hunk.Section + "\n" + content
+			}
+			chunks = append(chunks, DiffChunk{
+				FilePath:  file.Path,
+				Status:    file.Status,
+				StartLine: max(first, 1),
+				EndLine:   max(last, first, 1),
+				Content:   content,
+			})
+		}
+	}
+	return chunks
+}
+
+// applyDiffSecretsPolicy applies the secrets policy to the chunks of a file,
+// redacting their content in place. It returns false when the file must be
+// skipped because its changes contain credentials.
+func applyDiffSecretsPolicy(chunks []DiffChunk, policy string) bool {
+	for i := range chunks {
+		content, ok := applyHunkSecretsPolicy(chunks[i].Content, policy)
+		if !ok {
+			return false
+		}
+		chunks[i].Content = content
+	}
+	return true
+}
+
+// applyHunkSecretsPolicy applies the secrets policy to diff lines. The +/-
+// prefixes are removed while looking for credentials, so that rules anchored
+// at the start of a line, such as KEY=value settings, match as they do in
+// files.
+func applyHunkSecretsPolicy(content, policy string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	prefixes := make([]string, len(lines))
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "@@ ") {
+			continue // Section header
+		}
+		prefixes[i], lines[i] = line[:1], line[1:]
+	}
+	text, ok := indexer.ApplySecretsPolicy(strings.Join(lines, "\n"), policy)
+	if !ok {
+		return "", false
+	}
+	// Redaction keeps line breaks
+	lines = strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = prefixes[i] + lines[i]
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// changedSymbols maps chunks to the symbols they touch and looks up callers.
+func changedSymbols(ctx context.Context, symbolStore trace.SymbolStore, chunks []DiffChunk) []ChangedSymbol {
+	fileSymbols := make(map[string][]trace.Symbol)
+	index := make(map[string]int)
+	var out []ChangedSymbol
+
+	for i := range chunks {
+		chunk := &chunks[i]
+		if chunk.Status == git.StatusDeleted {
+			continue // Deleted code is no longer in the trace index
+		}
+		symbols, ok := fileSymbols[chunk.FilePath]
+		if !ok {
+			symbols, _ = symbolStore.GetSymbolsForFile(ctx, chunk.FilePath)
+			fileSymbols[chunk.FilePath] = symbols
+		}
+		for _, sym := range SymbolsInRange(symbols, chunk.StartLine, chunk.EndLine) {
+			chunk.Symbols = append(chunk.Symbols, sym.Name)
+			key := sym.File + ":" + sym.Name
+			if j, seen := index[key]; seen {
+				if chunk.Score > out[j].Score {
+					out[j].Score = chunk.Score
+				}
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, ChangedSymbol{Name: sym.Name, File: sym.File, Line: sym.Line, Score: chunk.Score})
+		}
+	}
+
+	for i := range out {
+		refs, err := symbolStore.LookupCallers(ctx, out[i].Name)
+		if err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			out[i].Callers = append(out[i].Callers, DiffCaller{Name: ref.CallerName, File: ref.File, Line: ref.Line})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score > out[j].Score
+	})
+	return out
+}
+
+// SymbolsInRange returns the symbols of a single file whose definition
+// overlaps lines start-end. Symbols without an end line are assumed to extend
+// to the line before the next symbol.
+func SymbolsInRange(symbols []trace.Symbol, start, end int) []trace.Symbol {
+	sorted := append([]trace.Symbol(nil), symbols...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	var out []trace.Symbol
+	for i, sym := range sorted {
+		symEnd := sym.EndLine
+		if symEnd < sym.Line {
+			symEnd = math.MaxInt
+			if i+1 < len(sorted) {
+				symEnd = sorted[i+1].Line - 1
+			}
+		}
+		if sym.Line <= end && start <= symEnd {
+			out = append(out, sym)
+		}
+	}
+	return out
+}
+
+func cosine(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoanbernabeu/grepai/git"
+	"github.com/yoanbernabeu/grepai/trace"
+)
+
+// keywordEmbedder embeds text as a one-hot vector of the keywords it contains.
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vec := make([]float32, len(e.keywords)+1)
+	vec[len(e.keywords)] = 0.1
+	for i, kw := range e.keywords {
+		if strings.Contains(text, kw) {
+			vec[i] = 1
+		}
+	}
+	return vec, nil
+}
+
+func (e keywordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i], _ = e.Embed(ctx, text)
+	}
+	return out, nil
+}
+
+func (e keywordEmbedder) Dimensions() int { return len(e.keywords) + 1 }
+func (e keywordEmbedder) Close() error    { return nil }
+
+func TestChunkDiff_LineRanges(t *testing.T) {
+	file := git.FileDiff{
+		Path:   "main.go",
+		Status: git.StatusModified,
+		Hunks: []git.Hunk{{
+			OldStart: 10, OldLines: 4, NewStart: 12, NewLines: 4,
+			Section: "func main() {",
+			Lines:   []string{" a", "-b", "+c", "+d", " e", "-f"},
+		}},
+	}
+	chunks := ChunkDiff(file)
+	if len(chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].StartLine != 12 || chunks[0].EndLine != 16 {
+		t.Errorf("range = %d-%d, want 12-16", chunks[0].StartLine, chunks[0].EndLine)
+	}
+	if !strings.HasPrefix(chunks[0].Content, "@@ func main() {\n a\n-b") {
+		t.Errorf("unexpected content %q", chunks[0].Content)
+	}
+
+	deleted := git.FileDiff{
+		Path:   "gone.go",
+		Status: git.StatusDeleted,
+		Hunks:  []git.Hunk{{OldStart: 1, OldLines: 2, Lines: []string{"-x", "-y"}}},
+	}
+	if c := ChunkDiff(deleted); len(c) != 1 || c[0].StartLine != 1 || c[0].EndLine != 2 {
+		t.Errorf("expected deleted file to use base lines, got %+v", c)
+	}
+
+	long := git.FileDiff{Path: "big.go", Status: git.StatusAdded}
+	hunk := git.Hunk{OldStart: 0, NewStart: 1, NewLines: diffChunkLines + 5}
+	for i := 0; i < diffChunkLines+5; i++ {
+		hunk.Lines = append(hunk.Lines, "+line")
+	}
+	long.Hunks = []git.Hunk{hunk}
+	c := ChunkDiff(long)
+	if len(c) != 2 || c[0].EndLine != diffChunkLines || c[1].StartLine != diffChunkLines+1 || c[1].EndLine != diffChunkLines+5 {
+		t.Errorf("expected long hunk to be split, got %+v", c)
+	}
+}
+
+func TestSymbolsInRange(t *testing.T) {
+	symbols := []trace.Symbol{
+		{Name: "C", Line: 40},
+		{Name: "A", Line: 1, EndLine: 10},
+		{Name: "B", Line: 20},
+	}
+
+	names := func(syms []trace.Symbol) string {
+		var out []string
+		for _, s := range syms {
+			out = append(out, s.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{5, 6, "A"},
+		{11, 15, ""},
+		{25, 39, "B"},
+		{9, 21, "A,B"},
+		{100, 120, "C"},
+	}
+	for _, tt := range tests {
+		if got := names(SymbolsInRange(symbols, tt.start, tt.end)); got != tt.want {
+			t.Errorf("SymbolsInRange(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestDiffSearch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	write("auth.go", "package app\n\nfunc Login() {\n\treturn\n}\n")
+	write("db.go", "package app\n\nfunc Query() {\n}\n")
+	write("notes.txt", "notes\n")
+	run("add", ".")
+	run("commit", "-m", "base")
+	run("tag", "base")
+
+	write("auth.go", "package app\n\nfunc Login() {\n\tcheckPassword()\n\treturn\n}\n")
+	write("db.go", "package app\n\nfunc Query() {\n\topenConnection()\n}\n")
+	write("notes.txt", "checkPassword notes\n")
+	run("commit", "-am", "change")
+
+	ctx := context.Background()
+	symbolStore := trace.NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	if err := symbolStore.SaveFile(ctx, "auth.go", []trace.Symbol{{Name: "Login", File: "auth.go", Line: 3, EndLine: 6}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := symbolStore.SaveFile(ctx, "handler.go", nil, []trace.Reference{{SymbolName: "Login", File: "handler.go", Line: 8, CallerName: "Handle"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	emb := keywordEmbedder{keywords: []string{"checkPassword", "openConnection"}}
+	result, err := DiffSearch(ctx, emb, dir, "checkPassword", DiffSearchOptions{
+		Base:    "base",
+		Head:    "HEAD",
+		Limit:   1,
+		Include: func(path string) bool { return strings.HasSuffix(path, ".go") },
+		Symbols: symbolStore,
+	})
+	if err != nil {
+		t.Fatalf("DiffSearch failed: %v", err)
+	}
+
+	if result.FilesChanged != 2 {
+		t.Errorf("FilesChanged = %d, want 2", result.FilesChanged)
+	}
+	if len(result.Chunks) != 1 || result.Chunks[0].FilePath != "auth.go" {
+		t.Fatalf("expected the auth.go hunk to rank first, got %+v", result.Chunks)
+	}
+	if syms := result.Chunks[0].Symbols; len(syms) != 1 || syms[0] != "Login" {
+		t.Errorf("expected chunk to reference Login, got %v", syms)
+	}
+	if len(result.ChangedSymbols) != 1 || len(result.ChangedSymbols[0].Callers) != 1 || result.ChangedSymbols[0].Callers[0].Name != "Handle" {
+		t.Errorf("expected Login with caller Handle, got %+v", result.ChangedSymbols)
+	}
+}
+
+func TestApplyDiffSecretsPolicy(t *testing.T) {
+	newChunks := func() []DiffChunk {
+		return []DiffChunk{
+			{FilePath: ".env", Content: "+DB_HOST=localhost"},
+			{FilePath: ".env", Content: "+DB_PASSWORD=hunter2hunter2"},
+		}
+	}
+
+	chunks := newChunks()
+	if !applyDiffSecretsPolicy(chunks, "") {
+		t.Fatal("expected the redact policy to keep the file")
+	}
+	if chunks[0].Content != "+DB_HOST=localhost" || chunks[1].Content != "+DB_PASSWORD=[REDACTED]" {
+		t.Errorf("expected the password to be redacted, got %+v", chunks)
+	}
+
+	if applyDiffSecretsPolicy(newChunks(), "skip") {
+		t.Error("expected the skip policy to skip the file")
+	}
+
+	chunks = newChunks()
+	if !applyDiffSecretsPolicy(chunks, "index") || chunks[1].Content != "+DB_PASSWORD=hunter2hunter2" {
+		t.Errorf("expected the index policy to keep the content, got %+v", chunks)
+	}
+}
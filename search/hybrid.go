@@ -8,9 +8,14 @@ import (
 	"github.com/yoanbernabeu/grepai/store"
 )
 
+// textSearchCancelInterval is the number of chunks TextSearch scores between
+// checks for cancellation.
+const textSearchCancelInterval = 1024
+
 // TextSearch performs a simple text-based search on chunks.
 // It scores chunks based on the number of query words they contain.
 // If pathPrefix is provided, only chunks from files starting with that prefix are included.
+// It returns nil when ctx is cancelled before all chunks are scored.
 func TextSearch(ctx context.Context, chunks []store.Chunk, query string, limit int, pathPrefix string) []store.SearchResult {
 	words := tokenize(query)
 	if len(words) == 0 {
@@ -19,7 +24,10 @@ func TextSearch(ctx context.Context, chunks []store.Chunk, query string, limit i
 
 	var results []store.SearchResult
 
-	for _, chunk := range chunks {
+	for i, chunk := range chunks {
+		if i%textSearchCancelInterval == 0 && ctx.Err() != nil {
+			return nil
+		}
 		// Filter by path prefix if provided
 		if pathPrefix != "" && !strings.HasPrefix(chunk.FilePath, pathPrefix) {
 			continue
@@ -14,6 +14,14 @@ type Searcher struct {
 	boostCfg  config.BoostConfig
 	hybridCfg config.HybridConfig
 	dedupCfg  config.DedupConfig
+
+	penalties []filePenalties
+}
+
+// filePenalties is a set of penalized files sharing one score factor.
+type filePenalties struct {
+	files  map[string]string
+	factor float32
 }
 
 func NewSearcher(st store.VectorStore, emb embedder.Embedder, searchCfg config.SearchConfig) *Searcher {
@@ -26,6 +34,19 @@ func NewSearcher(st store.VectorStore, emb embedder.Embedder, searchCfg config.S
 	}
 }
 
+// SetFilePenalties makes results from the given files (e.g. generated or
+// vendored code indexed with the "penalize" policy) score lower by factor.
+func (s *Searcher) SetFilePenalties(penalized map[string]string, factor float32) {
+	s.penalties = []filePenalties{{files: penalized, factor: factor}}
+}
+
+// AddFilePenalties penalizes the given files by factor on top of the
+// penalties already set. Workspace searches add one set per project, each with
+// its own project's penalty factor.
+func (s *Searcher) AddFilePenalties(penalized map[string]string, factor float32) {
+	s.penalties = append(s.penalties, filePenalties{files: penalized, factor: factor})
+}
+
 func (s *Searcher) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]store.SearchResult, error) {
 	queryVector, err := s.embedder.Embed(ctx, query)
 	if err != nil {
@@ -51,6 +72,9 @@ func (s *Searcher) Search(ctx context.Context, query string, limit int, pathPref
 	}
 
 	results = ApplyBoost(results, s.boostCfg)
+	for _, p := range s.penalties {
+		results = ApplyFilePenalties(results, p.files, p.factor)
+	}
 
 	if s.dedupCfg.Enabled {
 		results = DeduplicateByFile(results)
@@ -76,6 +100,9 @@ func (s *Searcher) hybridSearch(ctx context.Context, query string, queryVector [
 	}
 
 	textResults := TextSearch(ctx, allChunks, query, limit, pathPrefix)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	k := s.hybridCfg.K
 	if k <= 0 {
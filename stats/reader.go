@@ -55,6 +55,8 @@ func Summarize(entries []Entry, provider string) Summary {
 			TraceCallers: 0,
 			TraceCallees: 0,
 			TraceGraph:   0,
+			DiffSearch:   0,
+			Context:      0,
 		},
 		ByOutputMode: map[string]int{
 			Full:    0,
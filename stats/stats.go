@@ -10,6 +10,8 @@ const (
 	TraceCallers CommandType = "trace-callers"
 	TraceCallees CommandType = "trace-callees"
 	TraceGraph   CommandType = "trace-graph"
+	DiffSearch   CommandType = "diff-search"
+	Context      CommandType = "context"
 )
 
 // OutputMode represents the output format used for the command result.
@@ -68,7 +70,7 @@ func GrepEquivalentTokens(resultCount int) int {
 // Entry represents a single recorded command event.
 type Entry struct {
 	Timestamp    string `json:"timestamp"`    // RFC3339 UTC
-	CommandType  string `json:"command_type"` // search | trace-callers | trace-callees | trace-graph
+	CommandType  string `json:"command_type"` // search | trace-callers | trace-callees | trace-graph | diff-search | context
 	OutputMode   string `json:"output_mode"`  // full | compact | toon
 	ResultCount  int    `json:"result_count"`
 	OutputTokens int    `json:"output_tokens"` // estimated tokens in grepai output
@@ -14,6 +14,10 @@ import (
 	"github.com/yoanbernabeu/grepai/internal/fileutil"
 )
 
+// searchCancelInterval is the number of chunks Search scores between checks
+// for cancellation.
+const searchCancelInterval = 1024
+
 type GOBStore struct {
 	indexPath string
 	lockPath  string
@@ -63,13 +67,56 @@ func (s *GOBStore) DeleteByFile(ctx context.Context, filePath string) error {
 	return nil
 }
 
+// MoveFile re-keys the chunks and document of oldPath to newPath.
+func (s *GOBStore) MoveFile(ctx context.Context, oldPath, newPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[oldPath]
+	if !ok {
+		return nil
+	}
+	if existing, ok := s.documents[newPath]; ok {
+		for _, chunkID := range existing.ChunkIDs {
+			delete(s.chunks, chunkID)
+		}
+	}
+
+	chunkIDs := make([]string, 0, len(doc.ChunkIDs))
+	for _, chunkID := range doc.ChunkIDs {
+		chunk, ok := s.chunks[chunkID]
+		if !ok {
+			continue
+		}
+		delete(s.chunks, chunkID)
+		chunk = MoveChunk(chunk, oldPath, newPath)
+		s.chunks[chunk.ID] = chunk
+		chunkIDs = append(chunkIDs, chunk.ID)
+	}
+
+	delete(s.documents, oldPath)
+	doc.Path = newPath
+	doc.ChunkIDs = chunkIDs
+	s.documents[newPath] = doc
+
+	return nil
+}
+
 func (s *GOBStore) Search(ctx context.Context, queryVector []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	results := make([]SearchResult, 0, len(s.chunks))
 
+	scanned := 0
 	for _, chunk := range s.chunks {
+		if scanned%searchCancelInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		scanned++
+
 		// Filter by path prefix if provided
 		if opts.PathPrefix != "" && !strings.HasPrefix(chunk.FilePath, opts.PathPrefix) {
 			continue
@@ -277,6 +324,7 @@ func (s *GOBStore) ListFilesWithStats(ctx context.Context) ([]FileStats, error)
 	for _, doc := range s.documents {
 		stats = append(stats, FileStats{
 			Path:       doc.Path,
+			Hash:       doc.Hash,
 			ChunkCount: len(doc.ChunkIDs),
 			ModTime:    doc.ModTime,
 		})
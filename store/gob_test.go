@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -108,6 +109,54 @@ func TestGOBStore_DeleteByFile(t *testing.T) {
 	}
 }
 
+func TestGOBStore_MoveFile(t *testing.T) {
+	store := NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	ctx := context.Background()
+
+	save := func(path string, ids ...string) {
+		t.Helper()
+		if err := store.SaveDocument(ctx, Document{Path: path, Hash: "h-" + path, ChunkIDs: ids}); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range ids {
+			chunk := Chunk{ID: id, FilePath: path, Content: "File: " + path + "\n\nbody", Vector: []float32{1, 0}}
+			if err := store.SaveChunks(ctx, []Chunk{chunk}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	save("old/a.go", "old/a.go_0", "old/a.go_1_0")
+	save("new/a.go", "new/a.go_0", "new/a.go_1", "new/a.go_2")
+
+	if err := store.MoveFile(ctx, "old/a.go", "new/a.go"); err != nil {
+		t.Fatalf("MoveFile failed: %v", err)
+	}
+
+	if doc, _ := store.GetDocument(ctx, "old/a.go"); doc != nil {
+		t.Errorf("expected old document to be gone, got %+v", doc)
+	}
+	doc, _ := store.GetDocument(ctx, "new/a.go")
+	if doc == nil || doc.Hash != "h-old/a.go" || len(doc.ChunkIDs) != 2 || doc.ChunkIDs[1] != "new/a.go_1_0" {
+		t.Fatalf("unexpected moved document %+v", doc)
+	}
+	chunks, _ := store.GetChunksForFile(ctx, "new/a.go")
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 moved chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.FilePath != "new/a.go" || chunk.Content != "File: new/a.go\n\nbody" || len(chunk.Vector) != 2 {
+			t.Errorf("unexpected moved chunk %+v", chunk)
+		}
+	}
+	if _, numChunks := store.Stats(); numChunks != 2 {
+		t.Errorf("expected the replaced file's chunks to be dropped, got %d chunks", numChunks)
+	}
+
+	if err := store.MoveFile(ctx, "missing.go", "other.go"); err != nil {
+		t.Errorf("moving an unindexed file should be a no-op, got %v", err)
+	}
+}
+
 func TestGOBStore_PersistAndLoad(t *testing.T) {
 	tmpDir := t.TempDir()
 	indexPath := filepath.Join(tmpDir, "index.gob")
@@ -301,7 +350,7 @@ func TestGOBStore_ListFilesWithStats(t *testing.T) {
 	store := NewGOBStore(indexPath)
 	ctx := context.Background()
 
-	err := store.SaveDocument(ctx, Document{Path: "a.go", ChunkIDs: []string{"1", "2"}})
+	err := store.SaveDocument(ctx, Document{Path: "a.go", Hash: "hash-a", ChunkIDs: []string{"1", "2"}})
 	if err != nil {
 		t.Fatalf("failed to save document: %v", err)
 	}
@@ -324,6 +373,9 @@ func TestGOBStore_ListFilesWithStats(t *testing.T) {
 		if f.Path == "a.go" && f.ChunkCount != 2 {
 			t.Errorf("expected 2 chunks for a.go, got %d", f.ChunkCount)
 		}
+		if f.Path == "a.go" && f.Hash != "hash-a" {
+			t.Errorf("expected the hash of a.go, got %q", f.Hash)
+		}
 		if f.Path == "b.go" && f.ChunkCount != 1 {
 			t.Errorf("expected 1 chunk for b.go, got %d", f.ChunkCount)
 		}
@@ -444,3 +496,17 @@ func TestGOBStore_FileLocking(t *testing.T) {
 		t.Errorf("Expected chunk ID c1, got %s", chunks[0].ID)
 	}
 }
+
+func TestGOBStore_SearchCancelled(t *testing.T) {
+	store := NewGOBStore(filepath.Join(t.TempDir(), "index.gob"))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := store.SaveChunks(ctx, []Chunk{{ID: "chunk1", FilePath: "test.go", Vector: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("failed to save chunks: %v", err)
+	}
+	cancel()
+
+	if _, err := store.Search(ctx, []float32{1, 0, 0}, 10, SearchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
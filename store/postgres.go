@@ -138,6 +138,50 @@ func (s *PostgresStore) DeleteByFile(ctx context.Context, filePath string) error
 	return nil
 }
 
+// MoveFile re-keys the chunks and document of oldPath to newPath in a
+// single transaction.
+func (s *PostgresStore) MoveFile(ctx context.Context, oldPath, newPath string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin move: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	queries := []struct {
+		sql  string
+		args []any
+	}{
+		{`DELETE FROM chunks WHERE project_id = $1 AND file_path = $2`, []any{s.projectID, newPath}},
+		{`DELETE FROM documents WHERE project_id = $1 AND path = $2`, []any{s.projectID, newPath}},
+		{`UPDATE chunks SET
+			id = CASE WHEN left(id, char_length($2) + 1) = $2 || '_'
+				THEN $3 || substr(id, char_length($2) + 1) ELSE id END,
+			file_path = $3,
+			content = CASE WHEN left(content, char_length($4)) = $4
+				THEN $5 || substr(content, char_length($4) + 1) ELSE content END
+		WHERE project_id = $1 AND file_path = $2`,
+			[]any{s.projectID, oldPath, newPath, "File: " + oldPath + "\n\n", "File: " + newPath + "\n\n"}},
+		{`UPDATE documents SET
+			path = $3,
+			chunk_ids = ARRAY(
+				SELECT CASE WHEN left(c, char_length($2) + 1) = $2 || '_'
+					THEN $3 || substr(c, char_length($2) + 1) ELSE c END
+				FROM unnest(chunk_ids) WITH ORDINALITY AS ids(c, n) ORDER BY n)
+		WHERE project_id = $1 AND path = $2`,
+			[]any{s.projectID, oldPath, newPath}},
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(ctx, q.sql, q.args...); err != nil {
+			return fmt.Errorf("failed to move %s: %w", oldPath, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit move: %w", err)
+	}
+	return nil
+}
+
 func (s *PostgresStore) Search(ctx context.Context, queryVector []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
 	vec := pgvector.NewVector(queryVector)
 
@@ -302,7 +346,7 @@ func (s *PostgresStore) GetStats(ctx context.Context) (*IndexStats, error) {
 
 func (s *PostgresStore) ListFilesWithStats(ctx context.Context) ([]FileStats, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT path, mod_time, array_length(chunk_ids, 1) FROM documents WHERE project_id = $1`,
+		`SELECT path, hash, mod_time, array_length(chunk_ids, 1) FROM documents WHERE project_id = $1`,
 		s.projectID,
 	)
 	if err != nil {
@@ -314,7 +358,7 @@ func (s *PostgresStore) ListFilesWithStats(ctx context.Context) ([]FileStats, er
 	for rows.Next() {
 		var f FileStats
 		var chunkCount *int
-		if err := rows.Scan(&f.Path, &f.ModTime, &chunkCount); err != nil {
+		if err := rows.Scan(&f.Path, &f.Hash, &f.ModTime, &chunkCount); err != nil {
 			return nil, fmt.Errorf("failed to scan file: %w", err)
 		}
 		if chunkCount != nil {
@@ -315,7 +315,7 @@ func (s *QdrantStore) GetDocument(ctx context.Context, filePath string) (*Docume
 		CollectionName: s.collectionName,
 		Filter:         filter,
 		Limit:          qdrant.PtrOf(uint32(1)),
-		WithPayload:    qdrant.NewWithPayloadInclude("chunk_ids"),
+		WithPayload:    qdrant.NewWithPayloadInclude("chunk_ids", "file_hash"),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get document: %w", err)
@@ -329,14 +329,97 @@ func (s *QdrantStore) GetDocument(ctx context.Context, filePath string) (*Docume
 		Path:     filePath,
 		ChunkIDs: []string{},
 	}
+	if val, ok := scrollResult[0].Payload["file_hash"]; ok {
+		doc.Hash = val.GetStringValue()
+	}
 
 	return doc, nil
 }
 
+// SaveDocument records the file hash on the file's points; Qdrant has no
+// separate document records.
 func (s *QdrantStore) SaveDocument(ctx context.Context, doc Document) error {
+	if doc.Hash == "" {
+		return nil
+	}
+
+	_, err := s.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: s.collectionName,
+		Payload:        map[string]*qdrant.Value{"file_hash": qdrant.NewValueString(doc.Hash)},
+		PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatch("file_path", doc.Path),
+			},
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save document: %w", err)
+	}
 	return nil
 }
 
+// MoveFile re-keys the points of oldPath to newPath. Chunk IDs are not
+// stored in Qdrant, so moved points get IDs derived from their old ones.
+func (s *QdrantStore) MoveFile(ctx context.Context, oldPath, newPath string) error {
+	scrollResult, err := s.client.Scroll(ctx, &qdrant.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter: &qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatch("file_path", oldPath),
+			},
+		},
+		Limit:       qdrant.PtrOf(uint32(10000)),
+		WithPayload: qdrant.NewWithPayload(true),
+		WithVectors: qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get points for %s: %w", oldPath, err)
+	}
+	if len(scrollResult) == 0 {
+		return nil
+	}
+
+	points := make([]*qdrant.PointStruct, 0, len(scrollResult))
+	for _, point := range scrollResult {
+		if point.Vectors == nil || point.Vectors.GetVector() == nil || point.Vectors.GetVector().GetDense() == nil {
+			continue
+		}
+		points = append(points, &qdrant.PointStruct{
+			Id:      qdrant.NewID(s.getUUIDForChunk(newPath + "#" + point.Id.GetUuid()).String()),
+			Vectors: qdrant.NewVectors(point.Vectors.GetVector().GetDense().GetData()...),
+			Payload: movePointPayload(point.Payload, oldPath, newPath),
+		})
+	}
+
+	if err := s.DeleteByFile(ctx, newPath); err != nil {
+		return err
+	}
+	if len(points) > 0 {
+		if _, err := s.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: s.collectionName,
+			Points:         points,
+		}); err != nil {
+			return fmt.Errorf("failed to upsert moved points: %w", err)
+		}
+	}
+	return s.DeleteByFile(ctx, oldPath)
+}
+
+// movePointPayload returns a copy of a chunk payload with its file path and
+// "File:" context header rewritten from oldPath to newPath.
+func movePointPayload(payload map[string]*qdrant.Value, oldPath, newPath string) map[string]*qdrant.Value {
+	moved := make(map[string]*qdrant.Value, len(payload))
+	for key, val := range payload {
+		moved[key] = val
+	}
+	moved["file_path"] = qdrant.NewValueString(newPath)
+	if val, ok := payload["content"]; ok {
+		chunk := MoveChunk(Chunk{Content: val.GetStringValue()}, oldPath, newPath)
+		moved["content"] = qdrant.NewValueString(chunk.Content)
+	}
+	return moved
+}
+
 func (s *QdrantStore) DeleteDocument(ctx context.Context, filePath string) error {
 	return nil
 }
@@ -403,7 +486,7 @@ func (s *QdrantStore) ListFilesWithStats(ctx context.Context) ([]FileStats, erro
 	scrollResult, err := s.client.Scroll(ctx, &qdrant.ScrollPoints{
 		CollectionName: s.collectionName,
 		Limit:          qdrant.PtrOf(uint32(10000)),
-		WithPayload:    qdrant.NewWithPayloadInclude("file_path", "start_line", "end_line"),
+		WithPayload:    qdrant.NewWithPayloadInclude("file_path", "start_line", "end_line", "file_hash"),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list files: %w", err)
@@ -420,12 +503,12 @@ func (s *QdrantStore) ListFilesWithStats(ctx context.Context) ([]FileStats, erro
 			continue
 		}
 
+		// Qdrant keeps no modification times, as in GetDocument
 		if _, exists := fileStats[filePath]; !exists {
-			fileStats[filePath] = &FileStats{
-				Path:       filePath,
-				ChunkCount: 0,
-				ModTime:    time.Now(),
-			}
+			fileStats[filePath] = &FileStats{Path: filePath}
+		}
+		if val, ok := point.Payload["file_hash"]; ok {
+			fileStats[filePath].Hash = val.GetStringValue()
 		}
 		fileStats[filePath].ChunkCount++
 	}
@@ -447,3 +447,27 @@ func TestQdrantStore_ConfigurationVariants(t *testing.T) {
 		})
 	}
 }
+
+func TestMovePointPayload(t *testing.T) {
+	payload := map[string]*qdrant.Value{
+		"file_path":  mustCreateValue(t, "old/a.go"),
+		"content":    mustCreateValue(t, "File: old/a.go\n\nfunc A() {}"),
+		"start_line": mustCreateValue(t, int64(3)),
+		"file_hash":  mustCreateValue(t, "abc"),
+	}
+
+	moved := movePointPayload(payload, "old/a.go", "new/a.go")
+
+	if got := moved["file_path"].GetStringValue(); got != "new/a.go" {
+		t.Errorf("file_path = %q, want new/a.go", got)
+	}
+	if got := moved["content"].GetStringValue(); got != "File: new/a.go\n\nfunc A() {}" {
+		t.Errorf("content = %q", got)
+	}
+	if moved["start_line"].GetIntegerValue() != 3 || moved["file_hash"].GetStringValue() != "abc" {
+		t.Errorf("expected other payload fields to be kept, got %v", moved)
+	}
+	if payload["file_path"].GetStringValue() != "old/a.go" {
+		t.Error("original payload must not be modified")
+	}
+}
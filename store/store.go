@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -48,6 +49,7 @@ type IndexStats struct {
 // FileStats contains statistics for a single file
 type FileStats struct {
 	Path       string    `json:"path"`
+	Hash       string    `json:"hash,omitempty"`
 	ChunkCount int       `json:"chunk_count"`
 	ModTime    time.Time `json:"mod_time"`
 }
@@ -107,3 +109,57 @@ type EmbeddingCache interface {
 	// Returns (vector, true, nil) if found, (nil, false, nil) if not found.
 	LookupByContentHash(ctx context.Context, contentHash string) ([]float32, bool, error)
 }
+
+// FileMover is an optional interface that VectorStore implementations can
+// provide to move a file's chunks and document to a new path without
+// re-embedding them. When a store implements it, renamed files whose
+// content did not change are re-keyed in place.
+type FileMover interface {
+	// MoveFile re-keys the chunks and document of oldPath to newPath,
+	// replacing anything indexed at newPath. It is a no-op when oldPath is
+	// not indexed.
+	MoveFile(ctx context.Context, oldPath, newPath string) error
+}
+
+// MoveChunk returns the chunk re-keyed from oldPath to newPath: its ID, file
+// path and "File:" context header are rewritten, its vector is kept.
+func MoveChunk(chunk Chunk, oldPath, newPath string) Chunk {
+	chunk.ID = MovedChunkID(chunk.ID, oldPath, newPath)
+	chunk.FilePath = newPath
+	if rest, ok := strings.CutPrefix(chunk.Content, "File: "+oldPath+"\n\n"); ok {
+		chunk.Content = "File: " + newPath + "\n\n" + rest
+	}
+	return chunk
+}
+
+// MovedChunkID returns the ID a chunk of oldPath has once moved to newPath.
+// Chunk IDs are "<path>_<index>[_<sub-index>]".
+func MovedChunkID(id, oldPath, newPath string) string {
+	if rest, ok := strings.CutPrefix(id, oldPath+"_"); ok {
+		return newPath + "_" + rest
+	}
+	return id
+}
+
+// ChainEmbeddingCache consults each cache in order and returns the first hit.
+type ChainEmbeddingCache []EmbeddingCache
+
+// LookupByContentHash implements EmbeddingCache.
+func (c ChainEmbeddingCache) LookupByContentHash(ctx context.Context, contentHash string) ([]float32, bool, error) {
+	for _, cache := range c {
+		vec, found, err := cache.LookupByContentHash(ctx, contentHash)
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			return vec, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// SnapshotID derives the project ID (postgres) or collection name (qdrant) of
+// a named git ref snapshot from the live index's one, keeping both apart.
+func SnapshotID(base, name string) string {
+	return base + "__snapshot__" + name
+}
@@ -74,6 +74,31 @@ func (e *RegexExtractor) ExtractSymbols(ctx context.Context, filePath string, co
 	return symbols, nil
 }
 
+// ExtractFunctionSpans returns the functions and methods defined in a file
+// with the line their body ends on, found with the same brace, indentation
+// or "end" rules used to attribute references to callers.
+func (e *RegexExtractor) ExtractFunctionSpans(filePath string, content string) []Symbol {
+	patterns := e.patterns[strings.ToLower(filepath.Ext(filePath))]
+	if patterns == nil {
+		return nil
+	}
+
+	boundaries := e.buildFunctionBoundaries(content, patterns)
+	spans := make([]Symbol, 0, len(boundaries))
+	for _, b := range boundaries {
+		// EndPos is just past the closing brace, or at the start of the
+		// first line after an indented body
+		spans = append(spans, Symbol{
+			Name:     b.Name,
+			File:     filePath,
+			Line:     b.Line,
+			EndLine:  countLines(content[:b.EndPos-1]) + 1,
+			Language: patterns.Language,
+		})
+	}
+	return spans
+}
+
 // extractMatches extracts symbols from regex matches.
 func (e *RegexExtractor) extractMatches(re *regexp.Regexp, content string, filePath string, lang string, kind SymbolKind) []Symbol {
 	var symbols []Symbol
@@ -1469,3 +1469,35 @@ func TestRegexExtractor_ExtractReferences_FiltersBuiltinAndVueInternalNoise(t *t
 		t.Fatalf("expected builtins/vue internals filtered, got max=%v log=%v keys=%v $refs=%v", hasMax, hasLog, hasKeys, hasRefs)
 	}
 }
+
+func TestRegexExtractor_ExtractFunctionSpans(t *testing.T) {
+	extractor := NewRegexExtractor()
+
+	goSource := "package main\n\nfunc Add(a, b int) int {\n\tif a > b {\n\t\treturn a + b\n\t}\n\treturn b + a\n}\n\nfunc (s *Server) Run() {}\n"
+	spans := extractor.ExtractFunctionSpans("main.go", goSource)
+	want := map[string][2]int{"Add": {3, 8}, "Run": {10, 10}}
+	if len(spans) != len(want) {
+		t.Fatalf("expected %d spans, got %+v", len(want), spans)
+	}
+	for _, span := range spans {
+		if got := [2]int{span.Line, span.EndLine}; got != want[span.Name] {
+			t.Errorf("%s: expected lines %v, got %v", span.Name, want[span.Name], got)
+		}
+	}
+
+	pySource := "def first(x):\n    y = x\n    return y\n\ndef second():\n    pass\n"
+	spans = extractor.ExtractFunctionSpans("main.py", pySource)
+	want = map[string][2]int{"first": {1, 4}, "second": {5, 6}}
+	if len(spans) != len(want) {
+		t.Fatalf("expected %d spans, got %+v", len(want), spans)
+	}
+	for _, span := range spans {
+		if got := [2]int{span.Line, span.EndLine}; got != want[span.Name] {
+			t.Errorf("%s: expected lines %v, got %v", span.Name, want[span.Name], got)
+		}
+	}
+
+	if spans := extractor.ExtractFunctionSpans("notes.txt", "hello"); spans != nil {
+		t.Fatalf("expected no spans for an unsupported file, got %+v", spans)
+	}
+}
@@ -220,6 +220,49 @@ func (s *GOBSymbolStore) DeleteFile(ctx context.Context, filePath string) error
 	return nil
 }
 
+// MoveFile rewrites the paths of a file's symbols, references and call
+// edges from oldPath to newPath, replacing anything indexed at newPath.
+func (s *GOBSymbolStore) MoveFile(ctx context.Context, oldPath, newPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fileIndex[oldPath] {
+		return nil
+	}
+	s.deleteFileUnlocked(newPath)
+
+	for _, symbols := range s.index.Symbols {
+		for i := range symbols {
+			if symbols[i].File == oldPath {
+				symbols[i].File = newPath
+			}
+		}
+	}
+	for _, refs := range s.index.References {
+		for i := range refs {
+			if refs[i].File == oldPath {
+				refs[i].File = newPath
+			}
+			if refs[i].CallerFile == oldPath {
+				refs[i].CallerFile = newPath
+			}
+		}
+	}
+	for i := range s.index.CallGraph {
+		if s.index.CallGraph[i].File == oldPath {
+			s.index.CallGraph[i].File = newPath
+		}
+	}
+
+	s.fileIndex[newPath] = true
+	delete(s.fileIndex, oldPath)
+	if hash, ok := s.fileContentHashes[oldPath]; ok {
+		s.fileContentHashes[newPath] = hash
+		delete(s.fileContentHashes, oldPath)
+	}
+	return nil
+}
+
 func (s *GOBSymbolStore) deleteFileUnlocked(filePath string) {
 	// Remove symbols from this file
 	for name, symbols := range s.index.Symbols {
@@ -430,6 +473,9 @@ func (s *GOBSymbolStore) GetCallGraph(ctx context.Context, symbolName string, de
 	}
 
 	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		current := queue[0]
 		queue = queue[1:]
 
@@ -554,3 +600,14 @@ func (s *GOBSymbolStore) GetFileContentHash(filePath string) (string, bool) {
 	hash, ok := s.fileContentHashes[filePath]
 	return hash, ok
 }
+
+// ListFiles returns the paths of all indexed files.
+func (s *GOBSymbolStore) ListFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	files := make([]string, 0, len(s.fileIndex))
+	for path := range s.fileIndex {
+		files = append(files, path)
+	}
+	return files
+}
@@ -2,6 +2,7 @@ package trace
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
 	"testing"
 )
@@ -236,3 +237,20 @@ func TestGetCallGraph_DoesNotPullUnrelatedIncomingForIntermediateNode(t *testing
 		t.Fatalf("unexpected unrelated incoming edge X->B for intermediate node B")
 	}
 }
+
+func TestGetCallGraph_StopsWhenCancelled(t *testing.T) {
+	store := NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := store.SaveFile(ctx, "root.go", []Symbol{
+		{Name: "RootFn", Kind: KindFunction, File: "root.go", Line: 1, Language: "go"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveFile(root) failed: %v", err)
+	}
+	cancel()
+
+	if _, err := store.GetCallGraph(ctx, "RootFn", 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -194,6 +194,46 @@ func TestGOBSymbolStore_should_delete_file_symbols(t *testing.T) {
 	}
 }
 
+func TestGOBSymbolStore_should_move_file(t *testing.T) {
+	store := NewGOBSymbolStore(filepath.Join(t.TempDir(), "symbols.gob"))
+	ctx := context.Background()
+
+	symbols := []Symbol{{Name: "Login", Kind: KindFunction, File: "old/auth.go", Line: 3, Language: "go"}}
+	refs := []Reference{{SymbolName: "check", File: "old/auth.go", Line: 4, CallerName: "Login", CallerFile: "old/auth.go"}}
+	if err := store.SaveFileWithContentHash(ctx, "old/auth.go", "hash1", symbols, refs); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveFile(ctx, "new/auth.go", []Symbol{{Name: "Stale", File: "new/auth.go", Line: 1}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.MoveFile(ctx, "old/auth.go", "new/auth.go"); err != nil {
+		t.Fatalf("MoveFile failed: %v", err)
+	}
+
+	if store.IsFileIndexed("old/auth.go") || !store.IsFileIndexed("new/auth.go") {
+		t.Error("expected file index to follow the move")
+	}
+	if hash, ok := store.GetFileContentHash("new/auth.go"); !ok || hash != "hash1" {
+		t.Errorf("expected content hash to move, got %q, %v", hash, ok)
+	}
+	found, _ := store.LookupSymbol(ctx, "Login")
+	if len(found) != 1 || found[0].File != "new/auth.go" {
+		t.Errorf("expected Login in new/auth.go, got %+v", found)
+	}
+	if stale, _ := store.LookupSymbol(ctx, "Stale"); len(stale) != 0 {
+		t.Errorf("expected symbols previously at the destination to be replaced, got %+v", stale)
+	}
+	callers, _ := store.LookupCallers(ctx, "check")
+	if len(callers) != 1 || callers[0].File != "new/auth.go" || callers[0].CallerFile != "new/auth.go" {
+		t.Errorf("expected reference paths to move, got %+v", callers)
+	}
+	edges, _ := store.GetCallEdges(ctx)
+	if len(edges) != 1 || edges[0].File != "new/auth.go" {
+		t.Errorf("expected call edge to move, got %+v", edges)
+	}
+}
+
 func TestGOBSymbolStore_should_report_file_indexed(t *testing.T) {
 	tmpDir := t.TempDir()
 	indexPath := filepath.Join(tmpDir, "symbols.gob")
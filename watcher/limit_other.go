@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watcher
+
+// watchLimit returns the per-user inotify watch limit, which only exists
+// on Linux.
+func watchLimit() (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,220 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Mode selects how a Watcher detects changes.
+type Mode string
+
+const (
+	// ModeAuto uses fsnotify, polls on remote filesystems and polls the
+	// directories left unwatched when inotify limits are reached.
+	ModeAuto Mode = "auto"
+	// ModeFSNotify always uses kernel file notifications.
+	ModeFSNotify Mode = "fsnotify"
+	// ModePoll compares mtime/size snapshots of the tree at a fixed interval.
+	ModePoll Mode = "poll"
+)
+
+const (
+	// DefaultPollInterval is used in poll mode when no interval is configured.
+	DefaultPollInterval = 2 * time.Second
+	// DefaultReconcileInterval is used for directories outside the include
+	// paths when no interval is configured.
+	DefaultReconcileInterval = time.Minute
+)
+
+// SetMode selects the change detection mode and the interval between polls.
+// It must be called before Start.
+func (w *Watcher) SetMode(mode Mode, pollInterval time.Duration) {
+	if mode == "" {
+		mode = ModeAuto
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	w.mode = mode
+	w.pollInterval = pollInterval
+}
+
+// SetIncludePaths restricts live watching to the given sub-trees, relative
+// to the root. Everything else is reconciled every reconcileInterval. It
+// must be called before Start.
+func (w *Watcher) SetIncludePaths(paths []string, reconcileInterval time.Duration) {
+	w.includePaths = nil
+	for _, path := range paths {
+		path = filepath.Clean(filepath.FromSlash(strings.TrimSpace(path)))
+		if path == "." {
+			// The whole tree is included
+			w.includePaths = nil
+			break
+		}
+		w.includePaths = append(w.includePaths, path)
+	}
+	if reconcileInterval <= 0 {
+		reconcileInterval = DefaultReconcileInterval
+	}
+	w.reconcileInterval = reconcileInterval
+}
+
+// Mode returns the change detection mode in use: ModeFSNotify or ModePoll
+// once started, or the requested mode before Start.
+func (w *Watcher) Mode() Mode {
+	if active, ok := w.active.Load().(Mode); ok {
+		return active
+	}
+	return w.mode
+}
+
+// startPolling switches the whole tree to polling.
+func (w *Watcher) startPolling(ctx context.Context, reason string) {
+	if w.watcher != nil {
+		_ = w.watcher.Close()
+	}
+	w.snapshot(".")
+	w.active.Store(ModePoll)
+	log.Printf("Polling for changes every %s (%s)", w.pollInterval, reason)
+	go w.poll(ctx)
+}
+
+// poll diffs the polled and reconciled directories against the known files
+// at their respective intervals.
+func (w *Watcher) poll(ctx context.Context) {
+	pollTicker := time.NewTicker(w.pollInterval)
+	defer pollTicker.Stop()
+	reconcileTicker := time.NewTicker(w.reconcileInterval)
+	defer reconcileTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case <-pollTicker.C:
+			w.pollDirs()
+		case <-reconcileTicker.C:
+			w.reconcileDirs()
+		}
+	}
+}
+
+// pollDirs diffs the directories that are polled at the poll interval: the
+// include paths (or the whole tree) in poll mode, and the directories left
+// unwatched by inotify limits in auto mode.
+func (w *Watcher) pollDirs() {
+	var dirs []string
+	switch {
+	case w.Mode() == ModePoll && len(w.includePaths) > 0:
+		dirs = w.includePaths
+	case w.Mode() == ModePoll:
+		dirs = []string{"."}
+	case w.mode == ModeAuto:
+		dirs = w.dirSet(w.polled)
+	}
+	for _, dir := range dirs {
+		w.diff(dir)
+	}
+}
+
+// reconcileDirs diffs the directories outside the include paths.
+func (w *Watcher) reconcileDirs() {
+	var dirs []string
+	switch {
+	case w.Mode() == ModePoll && len(w.includePaths) > 0:
+		dirs = []string{"."}
+	case w.Mode() != ModePoll:
+		dirs = w.dirSet(w.reconciled)
+	}
+	for _, dir := range dirs {
+		w.diff(dir)
+	}
+}
+
+// dirSet returns the sorted directories of a set guarded by stateMu.
+func (w *Watcher) dirSet(set map[string]bool) []string {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	dirs := make([]string, 0, len(set))
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// snapshot records every file of a directory tree as known without
+// emitting events, and returns how many directories it contains.
+func (w *Watcher) snapshot(relDir string) int {
+	found, dirs := w.walkFiles(relDir)
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	for path, stamp := range found {
+		w.known[path] = stamp
+	}
+	return dirs
+}
+
+// liveDir reports whether a directory is watched live given the include
+// paths: it is inside an include path, or an ancestor of one.
+func (w *Watcher) liveDir(relDir string) bool {
+	if len(w.includePaths) == 0 || relDir == "." {
+		return true
+	}
+	for _, include := range w.includePaths {
+		if relDir == include || inDir(relDir, include) || inDir(include, relDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// pollReason reports why auto mode should poll rather than use fsnotify
+// before any directory is watched.
+func (w *Watcher) pollReason() (string, bool) {
+	if w.watcher == nil {
+		return fmt.Sprintf("file notifications unavailable: %v", w.watcherErr), true
+	}
+	if fsType, ok := remoteFilesystem(w.root); ok {
+		return fsType + " filesystem does not report changes", true
+	}
+	return "", false
+}
+
+// reportWatchLimit explains which directories are not watched because of
+// the inotify watch limit and what limit would cover the whole tree.
+// remaining is the number of directories in the unwatched sub-trees.
+func (w *Watcher) reportWatchLimit(remaining int) {
+	watched := int(w.watchedDirs.Load())
+	total := watched + remaining
+
+	limit := "fs.inotify.max_user_watches"
+	needed := ""
+	if current, ok := watchLimit(); ok {
+		limit = fmt.Sprintf("%s=%d", limit, current)
+		needed = fmt.Sprintf("; raise it to at least %d to watch everything: sudo sysctl fs.inotify.max_user_watches=%d", current+remaining, current+remaining)
+	}
+
+	if w.mode == ModeAuto {
+		log.Printf("Warning: inotify watch limit reached (%s): watching %d of %d directories, polling the other %d every %s%s",
+			limit, watched, total, remaining, w.pollInterval, needed)
+		return
+	}
+	log.Printf("Warning: inotify watch limit reached (%s): watching %d of %d directories, changes in the other %d are not detected (set watch.mode: auto to poll them)%s",
+		limit, watched, total, remaining, needed)
+}
+
+// isWatchLimit reports whether err means the inotify instance or watch
+// limits were reached.
+func isWatchLimit(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
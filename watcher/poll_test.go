@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatcher_PollModeDetectsChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) { writeTestFile(t, root, name, content) }
+	write(".gitignore", "node_modules/\n")
+	write("src/existing.go", "package src\n")
+	write("node_modules/dep/index.js", "module.exports = {}\n")
+
+	w := newTestWatcher(t, root)
+	w.SetMode(ModePoll, 20*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w.Mode() != ModePoll {
+		t.Fatalf("expected poll mode, got %s", w.Mode())
+	}
+
+	write("src/existing.go", "package src\n\nfunc Changed() {}\n")
+	write("src/added.go", "package src\n")
+	write("node_modules/dep/added.js", "module.exports = {}\n")
+
+	want := map[string]EventType{
+		"src/existing.go": EventModify,
+		"src/added.go":    EventCreate,
+	}
+	got := make(map[string]EventType)
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case event := <-w.Events():
+			got[filepath.ToSlash(event.Path)] = event.Type
+		case <-timeout:
+			t.Fatalf("received %v, want %v", got, want)
+		}
+	}
+	for path, typ := range want {
+		if got[path] != typ {
+			t.Errorf("event for %s = %s, want %s", path, got[path], typ)
+		}
+	}
+	if _, ok := got["node_modules/dep/added.js"]; ok {
+		t.Error("expected ignored directory not to be polled")
+	}
+}
+
+func TestWatcher_IncludePathsReconcileTheRest(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "src/app/app.go", "tools/gen/gen.go", "docs/guide.md"} {
+		writeTestFile(t, root, name, "package x\n")
+	}
+
+	w := newTestWatcher(t, root)
+	w.SetIncludePaths([]string{"src/app/"}, time.Hour)
+	if err := w.addRecursive(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range w.dirSet(w.reconciled) {
+		w.snapshot(dir)
+	}
+	if got := w.dirSet(w.reconciled); len(got) != 2 || got[0] != "docs" || got[1] != "tools" {
+		t.Fatalf("reconciled dirs = %v, want [docs tools]", got)
+	}
+	for _, dir := range []string{".", "src", "src/app"} {
+		if !w.liveDir(filepath.FromSlash(dir)) {
+			t.Errorf("expected %s to be watched live", dir)
+		}
+	}
+
+	writeTestFile(t, root, "tools/gen/gen.go", "package gen\n\nfunc Gen() {}\n")
+	w.reconcileDirs()
+	w.flush()
+	event, ok := w.queue.pop()
+	if !ok || event.Type != EventModify || filepath.ToSlash(event.Path) != "tools/gen/gen.go" {
+		t.Fatalf("expected modify of tools/gen/gen.go, got %+v (ok=%v)", event, ok)
+	}
+	if _, ok := w.queue.pop(); ok {
+		t.Error("expected a single event from reconciliation")
+	}
+}
+
+func TestWatcher_PollsDirectoriesPastWatchLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "src/app.go", "package src\n")
+
+	w := newTestWatcher(t, root)
+	if err := w.addRecursive(root); err != nil {
+		t.Fatal(err)
+	}
+	// A directory created once the watch limit has been reached
+	w.limitHit.Store(true)
+	writeTestFile(t, root, "gen/out.go", "package gen\n")
+	w.handleEvent(fsnotify.Event{Name: filepath.Join(root, "gen"), Op: fsnotify.Create})
+	w.flush()
+
+	if got := w.dirSet(w.polled); len(got) != 1 || got[0] != "gen" {
+		t.Fatalf("polled dirs = %v, want [gen]", got)
+	}
+	event, ok := w.queue.pop()
+	if !ok || event.Type != EventCreate || filepath.ToSlash(event.Path) != "gen/out.go" {
+		t.Fatalf("expected create of gen/out.go, got %+v (ok=%v)", event, ok)
+	}
+
+	writeTestFile(t, root, "gen/out.go", "package gen\n\nvar Out = 1\n")
+	w.pollDirs()
+	w.flush()
+	event, ok = w.queue.pop()
+	if !ok || event.Type != EventModify || filepath.ToSlash(event.Path) != "gen/out.go" {
+		t.Fatalf("expected modify of gen/out.go, got %+v (ok=%v)", event, ok)
+	}
+}
+
+func writeTestFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsWatchLimit(t *testing.T) {
+	if !isWatchLimit(fmt.Errorf("add watch: %w", syscall.ENOSPC)) {
+		t.Error("expected ENOSPC to be a watch limit")
+	}
+	if !isWatchLimit(syscall.EMFILE) {
+		t.Error("expected EMFILE to be a watch limit")
+	}
+	if isWatchLimit(syscall.ENOENT) {
+		t.Error("expected ENOENT not to be a watch limit")
+	}
+}
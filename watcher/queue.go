@@ -0,0 +1,86 @@
+package watcher
+
+import "sync"
+
+// eventQueue holds debounced events until the consumer takes them. Events are
+// coalesced by path, so the queue never holds more entries than there are
+// distinct files, however fast events arrive or slowly they are consumed.
+type eventQueue struct {
+	mu     sync.Mutex
+	order  []string
+	events map[string]FileEvent
+	ready  chan struct{} // Signalled when the queue becomes non-empty
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		events: make(map[string]FileEvent),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// push adds an event, merging it with a queued event for the same path.
+// It reports whether the event was coalesced.
+func (q *eventQueue) push(event FileEvent) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushLocked(event)
+}
+
+func (q *eventQueue) pushLocked(event FileEvent) bool {
+	existing, queued := q.events[event.Path]
+	if queued {
+		// Only the first move into a path is kept. The source of a later
+		// move is deleted, so that its entries do not stay in the index.
+		if existing.Type == EventMove && event.Type == EventMove && event.OldPath != existing.OldPath {
+			q.pushLocked(FileEvent{Type: EventDelete, Path: event.OldPath})
+		}
+		q.events[event.Path] = mergeEvents(existing, event)
+		return true
+	}
+	q.events[event.Path] = event
+	q.order = append(q.order, event.Path)
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return false
+}
+
+// pop removes the oldest event.
+func (q *eventQueue) pop() (FileEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return FileEvent{}, false
+	}
+	path := q.order[0]
+	q.order[0] = ""
+	q.order = q.order[1:]
+	event := q.events[path]
+	delete(q.events, path)
+	return event, true
+}
+
+func (q *eventQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
+
+// mergeEvents combines two events for the same path. The latest event
+// describes the final state of the file, except that a create followed by
+// modifications is still a create, and a move stays a move whatever happens
+// to its destination next, so that its source is still removed from the
+// index. Consumers re-check the destination when handling a move.
+func mergeEvents(existing, next FileEvent) FileEvent {
+	if existing.Type == EventCreate && next.Type == EventModify {
+		return existing
+	}
+	if existing.Type == EventMove {
+		return existing
+	}
+	return next
+}
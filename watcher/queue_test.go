@@ -0,0 +1,252 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/grepai/indexer"
+)
+
+func TestEventQueue_CoalescesByPath(t *testing.T) {
+	q := newEventQueue()
+	if q.push(FileEvent{Type: EventCreate, Path: "a.go"}) {
+		t.Error("first event should not coalesce")
+	}
+	q.push(FileEvent{Type: EventModify, Path: "b.go"})
+	if !q.push(FileEvent{Type: EventModify, Path: "a.go"}) {
+		t.Error("second event for a.go should coalesce")
+	}
+	q.push(FileEvent{Type: EventDelete, Path: "b.go"})
+
+	if q.len() != 2 {
+		t.Fatalf("expected 2 queued events, got %d", q.len())
+	}
+	first, _ := q.pop()
+	second, _ := q.pop()
+	if first.Path != "a.go" || first.Type != EventCreate {
+		t.Errorf("expected create of a.go first, got %+v", first)
+	}
+	if second.Path != "b.go" || second.Type != EventDelete {
+		t.Errorf("expected delete of b.go second, got %+v", second)
+	}
+	if _, ok := q.pop(); ok {
+		t.Error("expected empty queue")
+	}
+}
+
+func TestEventQueue_KeepsEverySourceMovedToTheSamePath(t *testing.T) {
+	q := newEventQueue()
+	q.push(FileEvent{Type: EventMove, Path: "p.go", OldPath: "a.go"})
+	if !q.push(FileEvent{Type: EventMove, Path: "p.go", OldPath: "b.go"}) {
+		t.Error("second move to p.go should coalesce")
+	}
+
+	var events []FileEvent
+	for {
+		event, ok := q.pop()
+		if !ok {
+			break
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 queued events, got %+v", events)
+	}
+	if events[0].Type != EventMove || events[0].Path != "p.go" || events[0].OldPath != "a.go" {
+		t.Errorf("expected the move from a.go kept, got %+v", events[0])
+	}
+	if events[1].Type != EventDelete || events[1].Path != "b.go" {
+		t.Errorf("expected b.go deleted, got %+v", events[1])
+	}
+}
+
+func TestMergeEvents(t *testing.T) {
+	tests := []struct {
+		existing, next, want EventType
+	}{
+		{EventCreate, EventModify, EventCreate},
+		{EventModify, EventDelete, EventDelete},
+		{EventDelete, EventCreate, EventCreate},
+		{EventCreate, EventDelete, EventDelete},
+	}
+	for _, tt := range tests {
+		got := mergeEvents(FileEvent{Type: tt.existing}, FileEvent{Type: tt.next})
+		if got.Type != tt.want {
+			t.Errorf("mergeEvents(%s, %s) = %s, want %s", tt.existing, tt.next, got.Type, tt.want)
+		}
+	}
+}
+
+func TestWatcher_DeliversAllEventsToSlowConsumer(t *testing.T) {
+	w := newTestWatcher(t, t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.deliverEvents(ctx)
+
+	const total = 500
+	for i := 0; i < total; i++ {
+		w.enqueue(FileEvent{Type: EventModify, Path: fmt.Sprintf("pkg/file%d.go", i)})
+	}
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < total {
+		select {
+		case event := <-w.Events():
+			seen[event.Path] = true
+		case <-timeout:
+			t.Fatalf("received %d of %d events", len(seen), total)
+		}
+	}
+}
+
+func TestWatcher_RescanFindsMissedChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/keep.go", "package src\n")
+	write("src/change.go", "package src\n")
+	write("src/gone.go", "package src\n")
+	write("other/untouched.go", "package other\n")
+
+	w := newTestWatcher(t, root)
+	if err := w.addRecursive(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes made while events were being lost.
+	write("src/change.go", "package src\n\nfunc Changed() {}\n")
+	write("src/new.go", "package src\n")
+	write("src/logo.png", "unsupported extension\n")
+	if err := os.Remove(filepath.Join(root, "src", "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	w.rescan("src")
+	w.flush()
+
+	var got []string
+	for {
+		event, ok := w.queue.pop()
+		if !ok {
+			break
+		}
+		got = append(got, event.Type.String()+" "+filepath.ToSlash(event.Path))
+	}
+	sort.Strings(got)
+	want := []string{"CREATE src/new.go", "DELETE src/gone.go", "MODIFY src/change.go"}
+	if len(got) != len(want) {
+		t.Fatalf("rescan events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rescan events = %v, want %v", got, want)
+			break
+		}
+	}
+	if stats := w.Stats(); stats.Rescans != 1 || stats.Queued != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// A second rescan finds nothing new.
+	w.rescan("src")
+	if n := w.Stats().Queued; n != 0 {
+		t.Errorf("expected no events from an unchanged tree, got %d", n)
+	}
+}
+
+func TestWatcher_PairMoves(t *testing.T) {
+	root := t.TempDir()
+	content := []byte("package b\n\nfunc X() {}\n")
+	for _, name := range []string{"b/x.go", "b/x.txt", "c.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := indexer.HashFile(filepath.Join(root, "b/x.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWatcher(t, root)
+	w.SetMoveDetection(func(path string) (string, bool) {
+		if path == "a/x.go" {
+			return hash, true
+		}
+		return "", false
+	})
+
+	got := w.pairMoves([]FileEvent{
+		{Type: EventCreate, Path: "b/x.txt"},
+		{Type: EventRename, Path: "a/x.go"},
+		{Type: EventCreate, Path: "b/x.go"},
+		{Type: EventDelete, Path: "gone.go"},
+	})
+
+	want := []FileEvent{
+		{Type: EventCreate, Path: "b/x.txt"},
+		{Type: EventMove, Path: "b/x.go", OldPath: "a/x.go"},
+		{Type: EventDelete, Path: "gone.go"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("pairMoves = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pairMoves = %+v, want %+v", got, want)
+			break
+		}
+	}
+
+	// A move stays a move when its destination changes again.
+	if merged := mergeEvents(want[1], FileEvent{Type: EventModify, Path: "b/x.go"}); merged.Type != EventMove {
+		t.Errorf("expected move to survive a later modify, got %+v", merged)
+	}
+}
+
+func TestOutermostDirs(t *testing.T) {
+	got := outermostDirs([]string{"a/b", "a", "c/d", "c/de"})
+	want := []string{"a", "c/d", "c/de"}
+	if len(got) != len(want) {
+		t.Fatalf("outermostDirs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != filepath.FromSlash(want[i]) {
+			t.Errorf("outermostDirs = %v, want %v", got, want)
+		}
+	}
+	if got := outermostDirs([]string{"x", "."}); len(got) != 1 || got[0] != "." {
+		t.Errorf("expected root to absorb everything, got %v", got)
+	}
+}
+
+func newTestWatcher(t *testing.T, root string) *Watcher {
+	t.Helper()
+	ignore, err := indexer.NewIgnoreMatcher(root, []string{}, "")
+	if err != nil {
+		t.Fatalf("failed to create ignore matcher: %v", err)
+	}
+	w, err := NewWatcher(root, ignore, 10)
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w
+}
@@ -0,0 +1,32 @@
+package watcher
+
+import "syscall"
+
+// Filesystem types whose changes are not reported by FSEvents/kqueue, or
+// only for changes made on this machine.
+var remoteFilesystems = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"cifs":    true,
+	"osxfuse": true,
+	"macfuse": true,
+}
+
+// remoteFilesystem reports the type of the filesystem holding path when
+// file notifications cannot be relied on there.
+func remoteFilesystem(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return string(name), remoteFilesystems[string(name)]
+}
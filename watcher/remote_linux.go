@@ -0,0 +1,28 @@
+package watcher
+
+import "syscall"
+
+// Filesystem magic numbers from statfs(2) whose changes are not reported
+// by inotify, or only for changes made on this machine.
+var remoteFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x01021997: "9p", // WSL2 Windows drives, some container bind mounts
+	0x65735546: "fuse",
+	0x73757245: "coda",
+	0x5346414f: "afs",
+	0x00c36400: "ceph",
+}
+
+// remoteFilesystem reports the type of the filesystem holding path when
+// file notifications cannot be relied on there.
+func remoteFilesystem(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name, ok := remoteFilesystems[uint32(st.Type)]
+	return name, ok
+}
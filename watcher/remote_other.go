@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package watcher
+
+// remoteFilesystem reports the type of the filesystem holding path when
+// file notifications cannot be relied on there. Detection is not supported
+// on this platform; set watch.mode to poll for network drives.
+func remoteFilesystem(path string) (string, bool) {
+	return "", false
+}
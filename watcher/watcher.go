@@ -2,11 +2,14 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -20,62 +23,200 @@ const (
 	EventModify
 	EventDelete
 	EventRename
+	EventMove // Path was moved from OldPath with its content unchanged
 )
 
 type FileEvent struct {
-	Type EventType
-	Path string
+	Type    EventType
+	Path    string
+	OldPath string // Set for EventMove
+}
+
+const (
+	// hotDirWindow is how long a directory counts as recently active when
+	// choosing what to rescan after an overflow.
+	hotDirWindow = 30 * time.Second
+	// maxHotDirs bounds targeted rescans; beyond it the whole tree is rescanned.
+	maxHotDirs = 64
+)
+
+// Stats are the event pipeline counters of a Watcher.
+type Stats struct {
+	Queued    int    // Events waiting for the consumer, including debounced ones
+	Coalesced uint64 // Events merged into a queued event for the same path
+	Overflows uint64 // Kernel queue overflows reported by fsnotify
+	Rescans   uint64 // Directory rescans run to recover from missed events
+}
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	modTime int64
+	size    int64
 }
 
 type Watcher struct {
 	root       string
 	watcher    *fsnotify.Watcher
+	watcherErr error // Why watcher is nil
 	ignore     *indexer.IgnoreMatcher
 	debounceMs int
+	extensions map[string]bool
 	events     chan FileEvent
 	done       chan struct{}
+	closeOnce  sync.Once
 
 	// Debouncing state
 	pending   map[string]FileEvent
 	pendingMu sync.Mutex
 	timer     *time.Timer
+
+	// Debounced events waiting for the consumer
+	queue *eventQueue
+
+	// Files known to exist and recently active directories, used by rescans
+	// to find changes whose events were lost
+	known   map[string]fileStamp
+	hotDirs map[string]time.Time
+	stateMu sync.Mutex
+
+	// Returns the content hash a path was last indexed with, for move detection
+	indexedHash func(relPath string) (string, bool)
+
+	// Change detection mode and the mode actually in use once started
+	mode         Mode
+	pollInterval time.Duration
+	active       atomic.Value
+
+	// Sub-trees watched live, and the interval at which the rest is reconciled
+	includePaths      []string
+	reconcileInterval time.Duration
+
+	// Sub-trees that are not watched, guarded by stateMu: polled ones were
+	// refused by the inotify watch limit, reconciled ones are outside the
+	// include paths
+	polled     map[string]bool
+	reconciled map[string]bool
+
+	watchedDirs   atomic.Int64
+	limitHit      atomic.Bool // The inotify watch limit refused a watch
+	limitReported atomic.Bool
+
+	coalesced atomic.Uint64
+	overflows atomic.Uint64
+	rescans   atomic.Uint64
 }
 
 func NewWatcher(root string, ignore *indexer.IgnoreMatcher, debounceMs int) (*Watcher, error) {
+	// Running out of inotify instances is not fatal: auto and poll modes
+	// work without them.
 	fsw, err := fsnotify.NewWatcher()
-	if err != nil {
+	if err != nil && !isWatchLimit(err) {
 		return nil, err
 	}
 
 	return &Watcher{
-		root:       root,
-		watcher:    fsw,
-		ignore:     ignore,
-		debounceMs: debounceMs,
-		events:     make(chan FileEvent, 100),
-		done:       make(chan struct{}),
-		pending:    make(map[string]FileEvent),
+		root:              root,
+		watcher:           fsw,
+		watcherErr:        err,
+		ignore:            ignore,
+		debounceMs:        debounceMs,
+		extensions:        indexer.SupportedExtensions,
+		events:            make(chan FileEvent, 100),
+		done:              make(chan struct{}),
+		pending:           make(map[string]FileEvent),
+		queue:             newEventQueue(),
+		known:             make(map[string]fileStamp),
+		hotDirs:           make(map[string]time.Time),
+		polled:            make(map[string]bool),
+		reconciled:        make(map[string]bool),
+		mode:              ModeAuto,
+		pollInterval:      DefaultPollInterval,
+		reconcileInterval: DefaultReconcileInterval,
 	}, nil
 }
 
+// SetExtensions overrides the set of file extensions that produce events.
+// It must be called before Start.
+func (w *Watcher) SetExtensions(extensions map[string]bool) {
+	w.extensions = extensions
+}
+
 func (w *Watcher) Start(ctx context.Context) error {
+	go w.deliverEvents(ctx)
+
+	switch w.mode {
+	case ModePoll:
+		w.startPolling(ctx, "watch.mode is poll")
+		return nil
+	case ModeAuto:
+		if reason, ok := w.pollReason(); ok {
+			w.startPolling(ctx, reason)
+			return nil
+		}
+	}
+	if w.watcher == nil {
+		return w.watcherErr
+	}
+
 	// Add root directory and all subdirectories
 	if err := w.addRecursive(w.root); err != nil {
 		return err
 	}
 
+	// Record the files of the sub-trees that are not watched so that the
+	// first poll only reports what changed
+	remaining := 0
+	for _, dir := range w.dirSet(w.polled) {
+		remaining += w.snapshot(dir)
+	}
+	for _, dir := range w.dirSet(w.reconciled) {
+		w.snapshot(dir)
+	}
+	if w.limitHit.Load() {
+		w.limitReported.Store(true)
+		w.reportWatchLimit(remaining)
+	}
+
 	// Start event processing
+	w.active.Store(ModeFSNotify)
 	go w.processEvents(ctx)
+	go w.poll(ctx)
 
 	return nil
 }
 
+// SetMoveDetection enables pairing of removed files with created files of the
+// same extension and content into EventMove. indexedHash returns the content
+// hash a path was last indexed with. It must be called before Start.
+func (w *Watcher) SetMoveDetection(indexedHash func(relPath string) (string, bool)) {
+	w.indexedHash = indexedHash
+}
+
+// Events returns debounced file events. Events are never dropped: when the
+// consumer falls behind they wait in a queue that coalesces them by path.
 func (w *Watcher) Events() <-chan FileEvent {
 	return w.events
 }
 
+// Stats returns the event pipeline counters.
+func (w *Watcher) Stats() Stats {
+	w.pendingMu.Lock()
+	pending := len(w.pending)
+	w.pendingMu.Unlock()
+
+	return Stats{
+		Queued:    pending + w.queue.len(),
+		Coalesced: w.coalesced.Load(),
+		Overflows: w.overflows.Load(),
+		Rescans:   w.rescans.Load(),
+	}
+}
+
 func (w *Watcher) Close() error {
-	close(w.done)
+	w.closeOnce.Do(func() { close(w.done) })
+	if w.watcher == nil {
+		return nil
+	}
 	return w.watcher.Close()
 }
 
@@ -95,12 +236,29 @@ func (w *Watcher) addRecursive(root string) error {
 			if w.ignore.ShouldSkipDir(relPath) {
 				return filepath.SkipDir
 			}
+			if !w.liveDir(relPath) {
+				w.addDir(w.reconciled, relPath)
+				return filepath.SkipDir
+			}
 			// Directory is not skipped; watch it if not individually ignored
-			if !w.ignore.ShouldIgnore(relPath) {
-				if err := w.watcher.Add(path); err != nil {
-					log.Printf("Failed to watch %s: %v", path, err)
+			if w.ignore.ShouldIgnore(relPath) {
+				return nil
+			}
+			// Past the watch limit, every further sub-tree is left to polling
+			if w.limitHit.Load() {
+				w.addDir(w.polled, relPath)
+				return filepath.SkipDir
+			}
+			if err := w.watcher.Add(path); err != nil {
+				if isWatchLimit(err) {
+					w.limitHit.Store(true)
+					w.addDir(w.polled, relPath)
+					return filepath.SkipDir
 				}
+				log.Printf("Failed to watch %s: %v", path, err)
+				return nil
 			}
+			w.watchedDirs.Add(1)
 			return nil
 		}
 
@@ -109,6 +267,11 @@ func (w *Watcher) addRecursive(root string) error {
 			return nil
 		}
 
+		if w.extensions[strings.ToLower(filepath.Ext(path))] && !strings.HasPrefix(info.Name(), ".") {
+			w.stateMu.Lock()
+			w.known[relPath] = fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+			w.stateMu.Unlock()
+		}
 		return nil
 	})
 }
@@ -125,15 +288,284 @@ func (w *Watcher) processEvents(ctx context.Context) {
 				return
 			}
 			w.handleEvent(event)
+			if w.limitHit.Load() && !w.limitReported.Swap(true) {
+				remaining := 0
+				for _, dir := range w.dirSet(w.polled) {
+					_, dirs := w.walkFiles(dir)
+					remaining += dirs
+				}
+				w.reportWatchLimit(remaining)
+			}
 		case err, ok := <-w.watcher.Errors:
 			if !ok {
 				return
 			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.recoverOverflow()
+				continue
+			}
 			log.Printf("Watcher error: %v", err)
 		}
 	}
 }
 
+// deliverEvents hands queued events to the consumer. Sends block while the
+// consumer is busy, so a slow consumer applies backpressure to the queue
+// instead of losing events.
+func (w *Watcher) deliverEvents(ctx context.Context) {
+	for {
+		event, ok := w.queue.pop()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-w.queue.ready:
+			}
+			continue
+		}
+
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// enqueue makes an event available to the consumer.
+func (w *Watcher) enqueue(event FileEvent) {
+	if w.queue.push(event) {
+		w.coalesced.Add(1)
+	}
+}
+
+// recoverOverflow rescans the directories active before the kernel dropped
+// events, or the whole tree when activity was too widespread to target.
+func (w *Watcher) recoverOverflow() {
+	w.overflows.Add(1)
+
+	cutoff := time.Now().Add(-hotDirWindow)
+	w.stateMu.Lock()
+	var dirs []string
+	for dir, at := range w.hotDirs {
+		if at.After(cutoff) {
+			dirs = append(dirs, dir)
+		}
+	}
+	w.stateMu.Unlock()
+
+	if len(dirs) == 0 || len(dirs) > maxHotDirs {
+		dirs = []string{"."}
+	}
+	dirs = outermostDirs(dirs)
+	log.Printf("Watcher event queue overflowed, rescanning %d director(ies)", len(dirs))
+	for _, dir := range dirs {
+		w.rescan(dir)
+	}
+}
+
+// rescan compares a directory tree with the files known to exist in it and
+// emits events for every difference: new or changed files and files that
+// disappeared.
+func (w *Watcher) rescan(relDir string) {
+	w.rescans.Add(1)
+	w.diff(relDir)
+}
+
+// diff emits events for the differences between a directory tree on disk
+// and the files known to exist in it.
+func (w *Watcher) diff(relDir string) {
+	found, _ := w.walkFiles(relDir)
+
+	var events []FileEvent
+	w.stateMu.Lock()
+	for path := range w.known {
+		if !inDir(path, relDir) {
+			continue
+		}
+		if _, ok := found[path]; !ok {
+			delete(w.known, path)
+			events = append(events, FileEvent{Type: EventDelete, Path: path})
+		}
+	}
+	for path, stamp := range found {
+		previous, ok := w.known[path]
+		switch {
+		case !ok:
+			events = append(events, FileEvent{Type: EventCreate, Path: path})
+		case previous != stamp:
+			events = append(events, FileEvent{Type: EventModify, Path: path})
+		default:
+			continue
+		}
+		w.known[path] = stamp
+	}
+	w.stateMu.Unlock()
+
+	// Debounce rather than enqueue, so that the deletes and creates of a
+	// directory moved within the tree are paired into moves on flush.
+	for _, event := range events {
+		w.debounceEvent(event)
+	}
+}
+
+// walkFiles stats the watched files of a directory tree, skipping ignored
+// directories without descending into them. It also returns the number of
+// directories walked.
+func (w *Watcher) walkFiles(relDir string) (map[string]fileStamp, int) {
+	found := make(map[string]fileStamp)
+	dirs := 0
+	absDir := filepath.Join(w.root, relDir)
+	_ = filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		relPath, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if relPath != "." && w.ignore.ShouldSkipDir(relPath) {
+				return filepath.SkipDir
+			}
+			dirs++
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") || w.ignore.ShouldIgnore(relPath) {
+			return nil
+		}
+		if !w.extensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		found[relPath] = fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		return nil
+	})
+	return found, dirs
+}
+
+// addDir records a sub-tree in a set of unwatched directories.
+func (w *Watcher) addDir(set map[string]bool, relDir string) {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	set[relDir] = true
+}
+
+// pairMoves replaces removed files that reappeared at another path with the
+// same extension and content by a single EventMove.
+func (w *Watcher) pairMoves(events []FileEvent) []FileEvent {
+	if w.indexedHash == nil {
+		return events
+	}
+
+	removed := make(map[string][]int)
+	for i, event := range events {
+		if event.Type != EventDelete && event.Type != EventRename {
+			continue
+		}
+		if hash, ok := w.indexedHash(event.Path); ok && hash != "" {
+			key := moveKey(event.Path, hash)
+			removed[key] = append(removed[key], i)
+		}
+	}
+	if len(removed) == 0 {
+		return events
+	}
+
+	paired := make(map[int]bool)
+	for i, event := range events {
+		if event.Type != EventCreate {
+			continue
+		}
+		hash, err := indexer.HashFile(filepath.Join(w.root, event.Path))
+		if err != nil {
+			continue
+		}
+		key := moveKey(event.Path, hash)
+		candidates := removed[key]
+		if len(candidates) == 0 {
+			continue
+		}
+		removed[key] = candidates[1:]
+		paired[candidates[0]] = true
+		events[i] = FileEvent{Type: EventMove, Path: event.Path, OldPath: events[candidates[0]].Path}
+	}
+
+	result := events[:0]
+	for i, event := range events {
+		if !paired[i] {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// moveKey identifies files that can be moved onto each other.
+func moveKey(path, hash string) string {
+	return strings.ToLower(filepath.Ext(path)) + ":" + hash
+}
+
+// track updates the known files and active directories for an event.
+func (w *Watcher) track(event FileEvent) {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+
+	w.hotDirs[filepath.Dir(event.Path)] = time.Now()
+	if len(w.hotDirs) > 4*maxHotDirs {
+		cutoff := time.Now().Add(-hotDirWindow)
+		for dir, at := range w.hotDirs {
+			if at.Before(cutoff) {
+				delete(w.hotDirs, dir)
+			}
+		}
+	}
+
+	switch event.Type {
+	case EventDelete, EventRename:
+		delete(w.known, event.Path)
+	default:
+		if info, err := os.Stat(filepath.Join(w.root, event.Path)); err == nil {
+			w.known[event.Path] = fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		}
+	}
+}
+
+// isKnownDir reports whether files are known to exist under relDir.
+func (w *Watcher) isKnownDir(relDir string) bool {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	for path := range w.known {
+		if inDir(path, relDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// inDir reports whether relPath is inside relDir ("." is the root).
+func inDir(relPath, relDir string) bool {
+	if relDir == "." {
+		return true
+	}
+	return strings.HasPrefix(relPath, relDir+string(filepath.Separator))
+}
+
+// outermostDirs drops directories nested in another directory of the list.
+func outermostDirs(dirs []string) []string {
+	sort.Strings(dirs)
+	var out []string
+	for _, dir := range dirs {
+		if len(out) > 0 && (out[len(out)-1] == "." || inDir(dir, out[len(out)-1])) {
+			continue
+		}
+		out = append(out, dir)
+	}
+	return out
+}
+
 func (w *Watcher) handleEvent(event fsnotify.Event) {
 	relPath, err := filepath.Rel(w.root, event.Name)
 	if err != nil {
@@ -150,18 +582,28 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 
 	// Check if it's a supported file
 	ext := strings.ToLower(filepath.Ext(event.Name))
-	if !indexer.SupportedExtensions[ext] {
+	if !w.extensions[ext] {
 		// Check if it's a directory (for watching new directories)
 		info, err := os.Stat(event.Name)
-		if err != nil || !info.IsDir() {
+		if err != nil {
+			// A directory moved or deleted as a whole only reports itself:
+			// rescan it to drop the files it contained.
+			if event.Has(fsnotify.Remove|fsnotify.Rename) && w.isKnownDir(relPath) {
+				w.rescan(relPath)
+			}
+			return
+		}
+		if !info.IsDir() {
 			return
 		}
 
-		// New directory created, add to watcher
+		// New directory created, add to watcher and pick up the files it
+		// already contains (e.g. a directory moved into the tree)
 		if event.Has(fsnotify.Create) {
 			if err := w.addRecursive(event.Name); err != nil {
 				log.Printf("Failed to add new directory %s: %v", event.Name, err)
 			}
+			w.rescan(relPath)
 		}
 		return
 	}
@@ -180,24 +622,23 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 		return
 	}
 
-	w.debounceEvent(FileEvent{
+	fileEvent := FileEvent{
 		Type: evType,
 		Path: relPath,
-	})
+	}
+	w.track(fileEvent)
+	w.debounceEvent(fileEvent)
 }
 
 func (w *Watcher) debounceEvent(event FileEvent) {
 	w.pendingMu.Lock()
 	defer w.pendingMu.Unlock()
 
-	// Merge events: delete > create/modify
-	existing, exists := w.pending[event.Path]
-	if exists && existing.Type == EventDelete && event.Type != EventDelete {
-		// Keep delete if file was deleted then recreated quickly
-		// This will be handled as delete + create
-	} else {
-		w.pending[event.Path] = event
+	if existing, exists := w.pending[event.Path]; exists {
+		event = mergeEvents(existing, event)
+		w.coalesced.Add(1)
 	}
+	w.pending[event.Path] = event
 
 	// Reset timer
 	if w.timer != nil {
@@ -215,12 +656,8 @@ func (w *Watcher) flush() {
 	w.pending = make(map[string]FileEvent)
 	w.pendingMu.Unlock()
 
-	for _, event := range events {
-		select {
-		case w.events <- event:
-		default:
-			log.Printf("Event channel full, dropping event for %s", event.Path)
-		}
+	for _, event := range w.pairMoves(events) {
+		w.enqueue(event)
 	}
 }
 
@@ -234,6 +671,8 @@ func (e EventType) String() string {
 		return "DELETE"
 	case EventRename:
 		return "RENAME"
+	case EventMove:
+		return "MOVE"
 	default:
 		return "UNKNOWN"
 	}